@@ -47,6 +47,7 @@ import (
 	"github.com/optimizely/agent/pkg/optimizely"
 	"github.com/optimizely/agent/pkg/routers"
 	"github.com/optimizely/agent/pkg/server"
+	"github.com/optimizely/agent/plugins/interceptors"
 	_ "github.com/optimizely/agent/plugins/interceptors/all"       // Initiate the loading of the userprofileservice plugins
 	_ "github.com/optimizely/agent/plugins/odpcache/all"           // Initiate the loading of the odpCache plugins
 	_ "github.com/optimizely/agent/plugins/userprofileservice/all" // Initiate the loading of the interceptor plugins
@@ -75,6 +76,15 @@ func initConfig(v *viper.Viper) error {
 }
 
 func loadConfig(v *viper.Viper) *config.AgentConfig {
+	conf, _ := readConfig(v)
+	return conf
+}
+
+// readConfig loads the configuration like loadConfig, and also returns the error of reading or
+// decoding the config file, which starting up only logs but a reload must not ignore
+func readConfig(v *viper.Viper) (*config.AgentConfig, error) {
+	var readErr error
+
 	// Configure environment variables
 	v.SetEnvPrefix("optimizely")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -85,11 +95,15 @@ func loadConfig(v *viper.Viper) *config.AgentConfig {
 	v.SetConfigFile(configFile)
 	if err := v.MergeInConfig(); err != nil {
 		log.Info().Err(err).Msg("Skip loading configuration from config file.")
+		readErr = err
 	}
 
 	conf := &config.AgentConfig{}
 	if err := v.Unmarshal(conf); err != nil {
 		log.Info().Err(err).Msg("Unable to marshal configuration.")
+		if readErr == nil {
+			readErr = err
+		}
 	}
 
 	// https://github.com/spf13/viper/issues/406
@@ -107,7 +121,7 @@ func loadConfig(v *viper.Viper) *config.AgentConfig {
 		conf.Client.ODP.SegmentsCache = odpSegmentsCache
 	}
 
-	return conf
+	return conf, readErr
 }
 
 func initLogging(conf config.LogConfig) {
@@ -260,6 +274,10 @@ func main() {
 	// Set metrics type to be used
 	agentMetricsRegistry := metrics.NewRegistry(conf.Admin.MetricsType)
 	sdkMetricsRegistry := optimizely.NewRegistry(agentMetricsRegistry)
+	interceptors.MetricsRegistry = agentMetricsRegistry
+	if err := interceptors.LoadPlugins(conf.Server.InterceptorPlugins); err != nil {
+		log.Error().Err(err).Msg("Skipped interceptor plugins")
+	}
 
 	ctx, cancel := context.WithCancel(context.Background()) // Create default service context
 	defer cancel()
@@ -273,6 +291,31 @@ func main() {
 	optlyCache := optimizely.NewCache(ctx, *conf, sdkMetricsRegistry, tracer)
 	optlyCache.Init(conf.SDKKeys)
 
+	// reload re-reads the configuration and applies the parts that can change at runtime
+	reload := func(ctx context.Context) error {
+		rv := viper.New()
+		if err := initConfig(rv); err != nil {
+			return err
+		}
+		reloaded, err := readConfig(rv)
+		if err != nil {
+			return err
+		}
+		return sg.ReloadInterceptors(ctx, reloaded.Server.Interceptors)
+	}
+
+	// goroutine to reload the configuration on SIGHUP
+	go func() {
+		reloadChannel := make(chan os.Signal, 1)
+		signal.Notify(reloadChannel, syscall.SIGHUP)
+		for range reloadChannel {
+			log.Info().Msg("Received SIGHUP, reloading configuration")
+			if err := reload(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to reload configuration")
+			}
+		}
+	}()
+
 	// goroutine to check for signals to gracefully shutdown listeners
 	go func() {
 		signalChannel := make(chan os.Signal, 1)
@@ -285,7 +328,7 @@ func main() {
 	}()
 
 	apiRouter := routers.NewDefaultAPIRouter(optlyCache, *conf, agentMetricsRegistry)
-	adminRouter := routers.NewAdminRouter(*conf)
+	adminRouter := routers.NewAdminRouter(*conf, reload)
 
 	log.Info().Str("version", conf.Version).Msg("Starting services.")
 	sg.GoListenAndServe("api", conf.API.Port, apiRouter)
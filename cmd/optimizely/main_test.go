@@ -18,6 +18,7 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -200,6 +201,29 @@ func TestViperYaml(t *testing.T) {
 	assertRuntime(t, actual.Runtime)
 }
 
+func TestReadConfigErrors(t *testing.T) {
+	v := viper.New()
+	v.Set("config.filename", "./testdata/default.yaml")
+	assert.NoError(t, initConfig(v))
+	_, err := readConfig(v)
+	assert.NoError(t, err)
+
+	invalid := filepath.Join(t.TempDir(), "invalid.yaml")
+	assert.NoError(t, os.WriteFile(invalid, []byte("server:\n  interceptors: [\n"), 0o600))
+	v = viper.New()
+	v.Set("config.filename", invalid)
+	assert.NoError(t, initConfig(v))
+	conf, err := readConfig(v)
+	assert.Error(t, err)
+	assert.NotNil(t, conf)
+
+	v = viper.New()
+	v.Set("config.filename", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.NoError(t, initConfig(v))
+	_, err = readConfig(v)
+	assert.Error(t, err)
+}
+
 func TestViperProps(t *testing.T) {
 	v := viper.New()
 
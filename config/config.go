@@ -279,16 +279,17 @@ type PluginConfigs map[string]interface{}
 
 // ServerConfig holds the global http server configs
 type ServerConfig struct {
-	AllowedHosts    []string            `json:"allowedHosts"`
-	ReadTimeout     time.Duration       `json:"readTimeout"`
-	WriteTimeout    time.Duration       `json:"writeTimeout"`
-	CertFile        string              `json:"certFile"`
-	KeyFile         string              `json:"keyFile"`
-	DisabledCiphers []string            `json:"disabledCiphers"`
-	HealthCheckPath string              `json:"healthCheckPath"`
-	Host            string              `json:"host"`
-	BatchRequests   BatchRequestsConfig `json:"batchRequests"`
-	Interceptors    PluginConfigs       `json:"interceptors"`
+	AllowedHosts       []string            `json:"allowedHosts"`
+	ReadTimeout        time.Duration       `json:"readTimeout"`
+	WriteTimeout       time.Duration       `json:"writeTimeout"`
+	CertFile           string              `json:"certFile"`
+	KeyFile            string              `json:"keyFile"`
+	DisabledCiphers    []string            `json:"disabledCiphers"`
+	HealthCheckPath    string              `json:"healthCheckPath"`
+	Host               string              `json:"host"`
+	BatchRequests      BatchRequestsConfig `json:"batchRequests"`
+	Interceptors       PluginConfigs       `json:"interceptors"`
+	InterceptorPlugins []string            `json:"interceptorPlugins"`
 }
 
 func (sc *ServerConfig) isHTTPSEnabled() bool {
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/render"
 
@@ -70,8 +71,10 @@ func Activate(w http.ResponseWriter, r *http.Request) {
 
 	parseFeatureKeys(query["featureKey"], oConf, kmap)
 
+	var decisionTime time.Duration
 	for key, value := range kmap {
 		var d *optimizely.Decision
+		start := time.Now()
 
 		switch value {
 		case "experiment":
@@ -100,12 +103,15 @@ func Activate(w http.ResponseWriter, r *http.Request) {
 			err = fmt.Errorf(`type %q not supported`, value)
 		}
 
+		decisionTime += time.Since(start)
+
 		if err != nil {
 			RenderError(err, http.StatusBadRequest, w, r)
 			return
 		}
 		decisions = append(decisions, d)
 	}
+	middleware.Annotate(r, middleware.AnnotationDecisionTime, decisionTime)
 
 	decisions = filterDecisions(r, decisions)
 	logger.Info().Msgf("Made activate decisions for user %s", uc.ID)
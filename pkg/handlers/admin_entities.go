@@ -18,6 +18,8 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"os"
 	"time"
@@ -25,6 +27,7 @@ import (
 	"github.com/go-chi/render"
 	"github.com/optimizely/agent/config"
 	"github.com/optimizely/agent/pkg/metrics"
+	"github.com/optimizely/agent/plugins/interceptors"
 )
 
 var startTime = time.Now()
@@ -41,10 +44,14 @@ type Info struct {
 	Host    string `json:"host,omitempty"`
 }
 
+// ReloadFunc reloads the parts of the agent configuration that can change at runtime
+type ReloadFunc func(ctx context.Context) error
+
 // Admin is holding info to pass to admin handlers
 type Admin struct {
 	Config config.AgentConfig
 	Info   Info
+	Reload ReloadFunc
 }
 
 // NewAdmin initializes admin
@@ -69,6 +76,26 @@ func (a Admin) AppConfig(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, a.Config)
 }
 
+// Interceptors describes the registered interceptors, the servers they are loaded on and their
+// configuration settings with the values in effect. Secrets are masked.
+func (a Admin) Interceptors(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, JSON{"interceptors": interceptors.Describe()})
+}
+
+// ReloadConfig reloads the runtime configuration, e.g. of interceptors, from the config file
+// and environment
+func (a Admin) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if a.Reload == nil {
+		RenderError(errors.New("config reloading is not supported"), http.StatusNotImplemented, w, r)
+		return
+	}
+	if err := a.Reload(r.Context()); err != nil {
+		RenderError(err, http.StatusInternalServerError, w, r)
+		return
+	}
+	render.JSON(w, r, JSON{"status": "reloaded"})
+}
+
 // AppInfoHeader adds custom app-info to the response header
 func (a Admin) AppInfoHeader(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
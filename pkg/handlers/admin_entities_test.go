@@ -18,7 +18,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -26,6 +28,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/optimizely/agent/config"
+	"github.com/optimizely/agent/plugins/interceptors"
 )
 
 var testConfig = config.AgentConfig{
@@ -71,6 +74,63 @@ func TestAppConfigHandler(t *testing.T) {
 	assert.Equal(t, &testConfig, actual)
 }
 
+type describedInterceptor struct {
+	APIKey string
+}
+
+func (d *describedInterceptor) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler { return next }
+}
+
+func TestInterceptorsHandler(t *testing.T) {
+	interceptors.Add("described", func() interceptors.Interceptor { return &describedInterceptor{} })
+	interceptors.AddLoaded("api", "described", "described", &describedInterceptor{APIKey: "key"})
+
+	req := httptest.NewRequest("GET", "/interceptors", nil)
+	rec := httptest.NewRecorder()
+
+	a := NewAdmin(testConfig)
+	a.Interceptors(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code, "Status code differs")
+
+	var actual struct {
+		Interceptors []interceptors.Description `json:"interceptors"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &actual)
+	assert.NoError(t, err)
+	assert.Contains(t, actual.Interceptors, interceptors.Description{
+		Name:    "described",
+		Servers: []string{"api"},
+		Fields:  []interceptors.Field{{Name: "apiKey", Type: "string", Default: "", Value: "****"}},
+	})
+}
+
+func TestReloadConfigHandler(t *testing.T) {
+	a := NewAdmin(testConfig)
+	rec := httptest.NewRecorder()
+	a.ReloadConfig(rec, httptest.NewRequest("POST", "/config/reload", nil))
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+
+	reloaded := false
+	a.Reload = func(ctx context.Context) error {
+		reloaded = true
+		return nil
+	}
+	rec = httptest.NewRecorder()
+	a.ReloadConfig(rec, httptest.NewRequest("POST", "/config/reload", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, reloaded)
+	assert.JSONEq(t, `{"status":"reloaded"}`, rec.Body.String())
+
+	a.Reload = func(ctx context.Context) error {
+		return errors.New("invalid config")
+	}
+	rec = httptest.NewRecorder()
+	a.ReloadConfig(rec, httptest.NewRequest("POST", "/config/reload", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
 func TestAppInfoHeaderHandler(t *testing.T) {
 	req := httptest.NewRequest("GET", "/info", nil)
 	rec := httptest.NewRecorder()
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/render"
 
@@ -109,6 +110,7 @@ func Decide(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var decides map[string]client.OptimizelyDecision
+	start := time.Now()
 	switch len(keys) {
 	case 0:
 		// Decide All
@@ -118,6 +120,7 @@ func Decide(w http.ResponseWriter, r *http.Request) {
 		key := keys[0]
 		logger.Debug().Str("featureKey", key).Msg("fetching feature decision")
 		d := optimizelyUserContext.Decide(key, decideOptions)
+		middleware.Annotate(r, middleware.AnnotationDecisionTime, time.Since(start))
 		decideOut := DecideOut{d, d.Variables.ToMap(), isEveryoneElseVariation(featureMap[d.FlagKey].DeliveryRules, d.RuleKey)}
 		render.JSON(w, r, decideOut)
 		return
@@ -125,6 +128,7 @@ func Decide(w http.ResponseWriter, r *http.Request) {
 		// Decide for Keys
 		decides = optimizelyUserContext.DecideForKeys(keys, decideOptions)
 	}
+	middleware.Annotate(r, middleware.AnnotationDecisionTime, time.Since(start))
 
 	decideOuts := []DecideOut{}
 	for _, d := range decides {
@@ -145,6 +145,18 @@ func (suite *DecideTestSuite) TestDecideWithFeatureTest() {
 	suite.Equal(expected, actual)
 }
 
+func (suite *DecideTestSuite) TestDecideAnnotatesDecisionTime() {
+	suite.tc.AddFeatureTest(entities.Feature{Key: "one"})
+
+	req, annotations := middleware.WithAnnotations(httptest.NewRequest("POST", "/decide?keys=one", bytes.NewBuffer(suite.body)))
+	rec := httptest.NewRecorder()
+	suite.mux.ServeHTTP(rec, req)
+
+	suite.Equal(http.StatusOK, rec.Code)
+	_, ok := annotations.Get(middleware.AnnotationDecisionTime)
+	suite.True(ok)
+}
+
 func (suite *DecideTestSuite) TestTrackWithFeatureRollout() {
 	feature := entities.Feature{Key: "one"}
 	suite.tc.AddFeatureRollout(feature)
@@ -36,7 +36,9 @@ func GetDatafile(w http.ResponseWriter, r *http.Request) {
 
 	logger := middleware.GetLogger(r)
 
-	datafile := optlyClient.WithTraceContext(r.Context()).GetOptimizelyConfig().GetDatafile()
+	optlyConfig := optlyClient.WithTraceContext(r.Context()).GetOptimizelyConfig()
+	middleware.Annotate(r, middleware.AnnotationRevision, optlyConfig.Revision)
+	datafile := optlyConfig.GetDatafile()
 	var raw map[string]interface{}
 	if err = json.Unmarshal([]byte(datafile), &raw); err != nil {
 		RenderError(err, http.StatusInternalServerError, w, r)
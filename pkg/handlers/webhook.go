@@ -35,6 +35,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/optimizely/agent/pkg/middleware"
 	"github.com/optimizely/agent/pkg/optimizely"
 	"github.com/optimizely/agent/pkg/syncer"
 )
@@ -146,6 +147,11 @@ func (h *OptlyWebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	middleware.Annotate(r, middleware.AnnotationProjectID, webhookMsg.ProjectID)
+	middleware.Annotate(r, middleware.AnnotationRevision, webhookMsg.Data.Revision)
+	middleware.Annotate(r, middleware.AnnotationEnvironment, webhookMsg.Data.Environment)
+	middleware.Annotate(r, middleware.AnnotationSDKKeys, webhookConfig.SDKKeys)
+
 	// Iterate through all SDK keys and update config
 	for _, sdkKey := range webhookConfig.SDKKeys {
 		if h.syncEnabled {
@@ -0,0 +1,82 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package middleware //
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Annotation keys set by the agent's middleware and handlers
+const (
+	// AnnotationClientCache is "hit" when the request's SDK client was already cached and "miss"
+	// when it was created for the request
+	AnnotationClientCache = "clientCache"
+	// AnnotationRevision is the revision of the datafile a request was served with or notified of
+	AnnotationRevision = "revision"
+	// AnnotationDecisionTime is the time.Duration the SDK client took to make the decisions of a request
+	AnnotationDecisionTime = "decisionTime"
+	// AnnotationProjectID is the Optimizely project of a datafile webhook
+	AnnotationProjectID = "projectId"
+	// AnnotationEnvironment is the environment of a datafile webhook
+	AnnotationEnvironment = "environment"
+	// AnnotationSDKKeys are the SDK keys whose datafiles a webhook updated
+	AnnotationSDKKeys = "sdkKeys"
+)
+
+// OptlyAnnotationsKey is the context key for the Annotations of a request
+const OptlyAnnotationsKey = contextKey("annotations")
+
+// Annotations collect details of how a request was served, such as whether its SDK client was
+// cached, for the interceptors wrapping the handlers. They are only recorded for requests an
+// interceptor added Annotations to with WithAnnotations.
+type Annotations struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+// WithAnnotations returns a shallow copy of r recording the annotations of its handlers
+func WithAnnotations(r *http.Request) (*http.Request, *Annotations) {
+	a := &Annotations{values: map[string]interface{}{}}
+	return r.WithContext(context.WithValue(r.Context(), OptlyAnnotationsKey, a)), a
+}
+
+// Annotated reports whether an interceptor requested the annotations of r, so that handlers can
+// skip working out details nobody reads
+func Annotated(r *http.Request) bool {
+	_, ok := r.Context().Value(OptlyAnnotationsKey).(*Annotations)
+	return ok
+}
+
+// Annotate records a detail of how r was served, if requested
+func Annotate(r *http.Request, key string, value interface{}) {
+	if a, ok := r.Context().Value(OptlyAnnotationsKey).(*Annotations); ok {
+		a.mu.Lock()
+		a.values[key] = value
+		a.mu.Unlock()
+	}
+}
+
+// Get returns the value of an annotation
+func (a *Annotations) Get(key string) (interface{}, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	v, ok := a.values[key]
+	return v, ok
+}
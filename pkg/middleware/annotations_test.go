@@ -0,0 +1,91 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package middleware //
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/agent/pkg/optimizely"
+)
+
+// lookupCache is a MockCache that knows which clients it holds
+type lookupCache struct {
+	MockCache
+	cached  map[string]bool
+	lookups int
+}
+
+func (c *lookupCache) HasClient(sdkKey string) bool {
+	c.lookups++
+	return c.cached[sdkKey]
+}
+
+func TestAnnotate(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+
+	// Annotations of requests that didn't ask for them are discarded
+	assert.False(t, Annotated(req))
+	Annotate(req, AnnotationRevision, "1")
+
+	req, annotations := WithAnnotations(req)
+	assert.True(t, Annotated(req))
+	Annotate(req, AnnotationRevision, "2")
+	v, ok := annotations.Get(AnnotationRevision)
+	assert.True(t, ok)
+	assert.Equal(t, "2", v)
+
+	_, ok = annotations.Get(AnnotationClientCache)
+	assert.False(t, ok)
+}
+
+func TestClientCtxAnnotatesCacheStatus(t *testing.T) {
+	cache := &lookupCache{cached: map[string]bool{"cached": true}}
+	cache.On("GetClient", "cached").Return(&expectedClient, nil)
+	cache.On("GetClient", "new").Return(&expectedClient, nil)
+	mw := &CachedOptlyMiddleware{Cache: cache}
+	handler := mw.ClientCtx(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for sdkKey, status := range map[string]string{"cached": "hit", "new": "miss"} {
+		req, annotations := WithAnnotations(httptest.NewRequest("GET", "/", nil))
+		req.Header.Set(OptlySDKHeader, sdkKey)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		v, _ := annotations.Get(AnnotationClientCache)
+		assert.Equal(t, status, v, sdkKey)
+	}
+	assert.Equal(t, 2, cache.lookups)
+
+	// Requests without annotations skip the lookup
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(OptlySDKHeader, "cached")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, 2, cache.lookups)
+
+	// Caches that can't tell don't annotate
+	plain := new(MockCache)
+	plain.On("GetClient", "key").Return(&optimizely.OptlyClient{}, nil)
+	req, annotations := WithAnnotations(httptest.NewRequest("GET", "/", nil))
+	req.Header.Set(OptlySDKHeader, "key")
+	(&CachedOptlyMiddleware{Cache: plain}).ClientCtx(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(httptest.NewRecorder(), req)
+	_, ok := annotations.Get(AnnotationClientCache)
+	assert.False(t, ok)
+}
@@ -50,6 +50,11 @@ const OptlyUPSHeader = "X-Optimizely-UPS-Name"
 // OptlyODPCacheHeader is the header key for an ad-hoc ODP Cache name
 const OptlyODPCacheHeader = "X-Optimizely-ODP-Cache-Name"
 
+// clientLookup is implemented by caches that can tell whether they hold the client of an SDK key
+type clientLookup interface {
+	HasClient(sdkKey string) bool
+}
+
 // CachedOptlyMiddleware implements OptlyMiddleware backed by a cache
 type CachedOptlyMiddleware struct {
 	Cache optimizely.Cache
@@ -80,6 +85,16 @@ func (mw *CachedOptlyMiddleware) ClientCtx(next http.Handler) http.Handler {
 			mw.Cache.SetODPCache(sdkKey, odpCacheKey)
 		}
 
+		// The cache status and revision are only worked out for interceptors asking for them
+		annotated := Annotated(r)
+		cacheStatus := ""
+		if lookup, ok := mw.Cache.(clientLookup); ok && annotated {
+			cacheStatus = "miss"
+			if lookup.HasClient(sdkKey) {
+				cacheStatus = "hit"
+			}
+		}
+
 		optlyClient, err := mw.Cache.GetClient(sdkKey)
 		if err != nil {
 			GetLogger(r).Error().Err(err).Msg("Initializing OptimizelyClient")
@@ -97,6 +112,15 @@ func (mw *CachedOptlyMiddleware) ClientCtx(next http.Handler) http.Handler {
 			return
 		}
 
+		if cacheStatus != "" {
+			Annotate(r, AnnotationClientCache, cacheStatus)
+		}
+		if annotated && optlyClient.ConfigManager != nil {
+			if projectConfig, err := optlyClient.ConfigManager.GetConfig(); err == nil {
+				Annotate(r, AnnotationRevision, projectConfig.GetRevision())
+			}
+		}
+
 		ctx := context.WithValue(r.Context(), OptlyClientKey, optlyClient)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
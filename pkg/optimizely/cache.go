@@ -130,6 +130,12 @@ func (c *OptlyCache) GetClient(sdkKey string) (*OptlyClient, error) {
 	return c.GetClient(sdkKey)
 }
 
+// HasClient reports whether the OptlyClient of an SDK key has already been created
+func (c *OptlyCache) HasClient(sdkKey string) bool {
+	_, ok := c.optlyMap.Get(sdkKey)
+	return ok
+}
+
 // UpdateConfigs is used to update config for all clients corresponding to a particular SDK key.
 func (c *OptlyCache) UpdateConfigs(sdkKey string) {
 	for clientInfo := range c.optlyMap.IterBuffered() {
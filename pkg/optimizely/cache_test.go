@@ -85,6 +85,13 @@ func (suite *CacheTestSuite) TestGetCacheMiss() {
 	suite.NotEqual(optltyClient1, optltyClient2)
 }
 
+func (suite *CacheTestSuite) TestHasClient() {
+	suite.False(suite.cache.HasClient("one"))
+	_, err := suite.cache.GetClient("one")
+	suite.NoError(err)
+	suite.True(suite.cache.HasClient("one"))
+}
+
 func (suite *CacheTestSuite) TestGetError() {
 	_, err1 := suite.cache.GetClient("ERROR")
 	suite.Error(err1)
@@ -24,14 +24,15 @@ import (
 	"github.com/optimizely/agent/config"
 	"github.com/optimizely/agent/pkg/handlers"
 	"github.com/optimizely/agent/pkg/middleware"
+	"github.com/optimizely/agent/plugins/interceptors"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/rs/zerolog/log"
 )
 
-// NewAdminRouter returns HTTP admin router
-func NewAdminRouter(conf config.AgentConfig) http.Handler {
+// NewAdminRouter returns HTTP admin router. reload, if not nil, serves config reload requests.
+func NewAdminRouter(conf config.AgentConfig, reload handlers.ReloadFunc) http.Handler {
 	r := chi.NewRouter()
 
 	authProvider := middleware.NewAuth(&conf.Admin.Auth)
@@ -48,11 +49,14 @@ func NewAdminRouter(conf config.AgentConfig) http.Handler {
 	}
 
 	optlyAdmin := handlers.NewAdmin(conf)
+	optlyAdmin.Reload = reload
 	r.Use(optlyAdmin.AppInfoHeader)
 	r.Use(render.SetContentType(render.ContentTypeJSON))
 
 	r.With(authProvider.AuthorizeAdmin).Get("/config", optlyAdmin.AppConfig)
+	r.With(authProvider.AuthorizeAdmin).Post("/config/reload", optlyAdmin.ReloadConfig)
 	r.With(authProvider.AuthorizeAdmin).Get("/info", optlyAdmin.AppInfo)
+	r.With(authProvider.AuthorizeAdmin).Get("/interceptors", optlyAdmin.Interceptors)
 	r.With(authProvider.AuthorizeAdmin).Get("/metrics", optlyAdmin.Metrics)
 
 	r.With(authProvider.AuthorizeAdmin).Get("/debug/pprof/*", pprof.Index)
@@ -61,6 +65,10 @@ func NewAdminRouter(conf config.AgentConfig) http.Handler {
 	r.With(authProvider.AuthorizeAdmin).Get("/debug/pprof/symbol", pprof.Symbol)
 	r.With(authProvider.AuthorizeAdmin).Get("/debug/pprof/trace", pprof.Trace)
 
+	for name, handler := range interceptors.AdminHandlers {
+		r.With(authProvider.AuthorizeAdmin).Mount("/"+name, handler)
+	}
+
 	r.Post("/oauth/token", tokenHandler.CreateAdminAccessToken)
 	return r
 }
@@ -24,13 +24,14 @@ import (
 	"testing"
 
 	"github.com/optimizely/agent/config"
+	"github.com/optimizely/agent/plugins/interceptors"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestAdminAllowedContentTypeMiddleware(t *testing.T) {
 
 	conf := config.NewDefaultConfig()
-	router := NewAdminRouter(*conf)
+	router := NewAdminRouter(*conf, nil)
 
 	// Testing unsupported content type
 	body := "<request> <parameters> <email>test@123.com</email> </parameters> </request>"
@@ -48,3 +49,14 @@ func TestAdminAllowedContentTypeMiddleware(t *testing.T) {
 	router.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
 }
+
+func TestAdminMountsInterceptorHandlers(t *testing.T) {
+	interceptors.AddAdminHandler("mounted", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	router := NewAdminRouter(*config.NewDefaultConfig(), nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/mounted/state", nil))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+}
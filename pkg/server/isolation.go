@@ -0,0 +1,109 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+
+	go_kit_metrics "github.com/go-kit/kit/metrics"
+	"github.com/rs/zerolog/log"
+
+	"github.com/optimizely/agent/plugins/interceptors"
+)
+
+// isolationKey is the context key of the isolationState of the named interceptor
+type isolationKey struct {
+	name string
+}
+
+// isolationState records how far a request got through an isolated interceptor
+type isolationState struct {
+	nextCalled bool // the interceptor passed the request on
+	nextPanic  bool // the rest of the chain panicked
+}
+
+// isolate wraps the named interceptor so that its panics don't fail requests. A request whose
+// interceptor panicked before passing it on continues with next, and a panic after next served it
+// is only logged. Panics of next itself are not recovered, so they reach the server as before.
+func isolate(name string, plugin interceptors.Interceptor, next http.Handler) http.Handler {
+	panics := recoveredPanics(name)
+	key := isolationKey{name: name}
+
+	handler, ok := interceptorHandler(name, plugin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, _ := r.Context().Value(key).(*isolationState)
+		if state == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		state.nextCalled = true
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				state.nextPanic = true
+				panic(recovered)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	}))
+	if !ok {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := &isolationState{}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if state.nextPanic || recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			if panics != nil {
+				panics.Add(1)
+			}
+			log.Error().Str("plugin", name).Interface("panic", recovered).Bytes("stack", debug.Stack()).
+				Bool("passedOn", state.nextCalled).Msg("Recovered from plugin panic.")
+			if !state.nextCalled {
+				next.ServeHTTP(w, r)
+			}
+		}()
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), key, state)))
+	})
+}
+
+// interceptorHandler returns the handler of plugin wrapping next, reporting false when
+// building it panicked
+func interceptorHandler(name string, plugin interceptors.Interceptor, next http.Handler) (handler http.Handler, ok bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Error().Str("plugin", name).Interface("panic", recovered).Msg("Skipping plugin whose handler panicked.")
+			handler, ok = nil, false
+		}
+	}()
+	return plugin.Handler()(next), true
+}
+
+// recoveredPanics returns the counter of the panics recovered from the named interceptor, or nil
+// without a metrics registry
+func recoveredPanics(name string) go_kit_metrics.Counter {
+	if interceptors.MetricsRegistry == nil {
+		return nil
+	}
+	return interceptors.MetricsRegistry.GetCounter("interceptors." + name + ".panics")
+}
@@ -0,0 +1,101 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/agent/pkg/metrics"
+	"github.com/optimizely/agent/plugins/interceptors"
+)
+
+// panickingInterceptor panics before or after passing requests on, or when building its handler
+type panickingInterceptor struct {
+	before, after, building bool
+	value                   interface{}
+}
+
+func (p *panickingInterceptor) Handler() func(http.Handler) http.Handler {
+	if p.building {
+		panic("building")
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p.before {
+				panic(p.value)
+			}
+			next.ServeHTTP(w, r)
+			if p.after {
+				panic(p.value)
+			}
+		})
+	}
+}
+
+func TestIsolateRecoversInterceptorPanics(t *testing.T) {
+	registry := interceptors.MetricsRegistry
+	interceptors.MetricsRegistry = metrics.NewRegistry("expvar")
+	defer func() { interceptors.MetricsRegistry = registry }()
+
+	var served int
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	for name, plugin := range map[string]*panickingInterceptor{
+		"before": {before: true, value: "bug"},
+		"after":  {after: true, value: "bug"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			served = 0
+			handler := isolate("panicking", plugin, api)
+			rec := httptest.NewRecorder()
+			assert.NotPanics(t, func() { handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil)) })
+			assert.Equal(t, 1, served, "the request is served once")
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		})
+	}
+	assert.Equal(t, "2", expvar.Get("counter.interceptors.panicking.panics").String())
+}
+
+func TestIsolateDoesNotRecoverOtherPanics(t *testing.T) {
+	// Panics of the rest of the chain aren't the interceptor's
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("handler") })
+	handler := isolate("passing", &panickingInterceptor{}, failing)
+	assert.PanicsWithValue(t, "handler", func() { handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)) })
+
+	// Nor are deliberate aborts
+	handler = isolate("aborting", &panickingInterceptor{before: true, value: http.ErrAbortHandler}, handler)
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)) })
+}
+
+func TestIsolateSkipsInterceptorFailingToBuild(t *testing.T) {
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusAccepted) })
+	handler := wrapWithInterceptors(api, []namedInterceptor{
+		{name: "building", Interceptor: &panickingInterceptor{building: true}},
+		{name: "after", Interceptor: &panickingInterceptor{after: true, value: "bug"}},
+	})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -38,13 +39,89 @@ import (
 
 // Server has generic functionality for service: it starts the service and performs basic checks
 type Server struct {
-	srv    *http.Server
-	logger zerolog.Logger
+	name         string
+	srv          *http.Server
+	logger       zerolog.Logger
+	interceptors []namedInterceptor
+}
+
+// namedInterceptor is an interceptor instance along with its configured name, type and order
+type namedInterceptor struct {
+	name  string
+	typ   string
+	order int
+	interceptors.Interceptor
+}
+
+// interceptorScope holds the settings of an interceptor's configuration that are applied by the
+// server rather than passed to the interceptor: the registered interceptor it creates (named
+// like the configuration when empty), the servers it runs on (all when empty) and its order,
+// lower orders running first.
+type interceptorScope struct {
+	Type    string   `json:"type"`
+	Servers []string `json:"servers"`
+	Order   int      `json:"order"`
+}
+
+// interceptorScopeKeys are the configuration keys of interceptorScope
+var interceptorScopeKeys = []string{"type", "servers", "order"}
+
+// typeOf returns the registered interceptor created by the configuration named name. Configuring
+// an interceptor's type allows several named instances of it, e.g. with different destinations.
+func (s interceptorScope) typeOf(name string) string {
+	if s.Type == "" {
+		return name
+	}
+	return s.Type
+}
+
+// includes reports whether the interceptor runs on the named server
+func (s interceptorScope) includes(server string) bool {
+	if len(s.Servers) == 0 {
+		return true
+	}
+	for _, name := range s.Servers {
+		if strings.EqualFold(name, server) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitInterceptorConfig separates the scope settings from the rest of an interceptor's
+// configuration, which is returned for the interceptor
+func splitInterceptorConfig(conf interface{}) (interceptorScope, interface{}, error) {
+	var scope interceptorScope
+	settings, ok := conf.(map[string]interface{})
+	if !ok {
+		return scope, conf, nil
+	}
+
+	scoped := map[string]interface{}{}
+	rest := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		rest[key] = value
+	}
+	for _, key := range interceptorScopeKeys {
+		if value, ok := rest[key]; ok {
+			scoped[key] = value
+			delete(rest, key)
+		}
+	}
+	if len(scoped) == 0 {
+		return scope, conf, nil
+	}
+	b, err := json.Marshal(scoped)
+	if err == nil {
+		err = json.Unmarshal(b, &scope)
+	}
+	return scope, rest, err
 }
 
 // HealthInfo is holding info about health checks
 type HealthInfo struct {
-	Status string `json:"status,omitempty"`
+	Status  string   `json:"status,omitempty"`
+	Reasons []string `json:"reasons,omitempty"`
 }
 
 // NewServer initializes new service.
@@ -56,8 +133,12 @@ func NewServer(name, port string, handler http.Handler, conf config.ServerConfig
 
 	handler = middleware.BatchRouter(conf.BatchRequests)(handler)
 	handler = middleware.AllowedHosts(conf.GetAllowedHosts())(handler)
-	handler = healthMW(handler, conf.HealthCheckPath)
-	handler = wrapWithInterceptors(handler, conf.Interceptors)
+	plugins, err := newInterceptors(name, conf.Interceptors)
+	if err != nil {
+		return Server{}, err
+	}
+	handler = healthMW(handler, conf.HealthCheckPath, plugins)
+	handler = wrapWithInterceptors(handler, plugins)
 
 	logger := log.With().Str("port", port).Str("name", name).Str("host", conf.Host).Logger()
 	srv := &http.Server{
@@ -75,7 +156,7 @@ func NewServer(name, port string, handler http.Handler, conf config.ServerConfig
 		srv.TLSConfig = cfg
 	}
 
-	return Server{srv: srv, logger: logger}, nil
+	return Server{name: name, srv: srv, logger: logger, interceptors: plugins}, nil
 }
 
 // ListenAndServe starts the server
@@ -97,6 +178,67 @@ func (s Server) ListenAndServe() (err error) {
 	return nil
 }
 
+// StartInterceptors starts the interceptors implementing interceptors.Starter. ctx should be
+// done when the server begins shutting down.
+func (s Server) StartInterceptors(ctx context.Context) error {
+	for _, plugin := range s.interceptors {
+		if starter, ok := plugin.Interceptor.(interceptors.Starter); ok {
+			if err := starter.Start(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReloadInterceptors applies conf to the running interceptors implementing interceptors.Reloader.
+// Adding or removing interceptors, or changing their servers or order, requires a restart.
+func (s Server) ReloadInterceptors(ctx context.Context, conf config.PluginConfigs) error {
+	running := map[string]bool{}
+	var errs []error
+	for _, plugin := range s.interceptors {
+		running[plugin.name] = true
+		pConf, ok := conf[plugin.name]
+		if !ok {
+			s.logger.Warn().Str("plugin", plugin.name).Msg("Removing a plugin requires a restart.")
+			continue
+		}
+		reloader, ok := plugin.Interceptor.(interceptors.Reloader)
+		if !ok {
+			s.logger.Warn().Str("plugin", plugin.name).Msg("Plugin does not support reloading.")
+			continue
+		}
+
+		scope, pConf, err := splitInterceptorConfig(pConf)
+		if err == nil && (!scope.includes(s.name) || scope.Order != plugin.order || scope.typeOf(plugin.name) != plugin.typ) {
+			s.logger.Warn().Str("plugin", plugin.name).Msg("Changing the type, servers or order of a plugin requires a restart.")
+		}
+		var pConfig []byte
+		if err == nil {
+			pConfig, err = json.Marshal(pConf)
+		}
+		if err == nil {
+			err = reloader.Reload(ctx, pConfig)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reloading plugin %q: %w", plugin.name, err))
+			continue
+		}
+		s.logger.Info().Str("plugin", plugin.name).Msg("Reloaded plugin.")
+	}
+
+	for name, pConf := range conf {
+		if running[name] {
+			continue
+		}
+		if scope, _, err := splitInterceptorConfig(pConf); err == nil && !scope.includes(s.name) {
+			continue
+		}
+		s.logger.Warn().Str("plugin", name).Msg("Adding a plugin requires a restart.")
+	}
+	return errors.Join(errs...)
+}
+
 // Shutdown server gracefully
 func (s Server) Shutdown() {
 	s.logger.Info().Msg("Shutting down server.")
@@ -105,17 +247,41 @@ func (s Server) Shutdown() {
 	if err := s.srv.Shutdown(ctx); err != nil {
 		s.logger.Error().Err(err).Msg("Failed shutdown.")
 	}
+
+	// Interceptors apply their own timeouts, e.g. for draining queued work
+	for _, plugin := range s.interceptors {
+		if stopper, ok := plugin.Interceptor.(interceptors.Stopper); ok {
+			if err := stopper.Stop(context.Background()); err != nil {
+				s.logger.Error().Err(err).Msg("Failed stopping interceptor.")
+			}
+		}
+	}
 }
 
-func wrapWithInterceptors(handler http.Handler, conf config.PluginConfigs) http.Handler {
+// newInterceptors creates the interceptors configured for the named server, sorted by their order
+// and then name. It skips unknown interceptors and ones whose config can't be decoded. Configs
+// rejected by interceptors implementing interceptors.Validator are returned as an error.
+func newInterceptors(server string, conf config.PluginConfigs) ([]namedInterceptor, error) {
+	var plugins []namedInterceptor
+	var errs []error
 	for name, conf := range conf {
-		creator, ok := interceptors.Interceptors[name]
+		scope, conf, err := splitInterceptorConfig(conf)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plugin %q: type, servers and order: %w", name, err))
+			continue
+		}
+		typ := scope.typeOf(name)
+		creator, ok := interceptors.Interceptors[typ]
 		if !ok {
-			log.Warn().Msgf("Plugin not found: %q", name)
+			log.Warn().Msgf("Plugin not found: %q", typ)
+			continue
+		}
+		if !scope.includes(server) {
+			log.Debug().Str("plugin", name).Str("server", server).Msg("Plugin not enabled for server.")
 			continue
 		}
 
-		log.Info().Str("plugin", name).Msg("Adding plugin.")
+		log.Info().Str("plugin", name).Str("type", typ).Msg("Adding plugin.")
 		pInstance := creator()
 		if pConfig, err := json.Marshal(conf); err != nil {
 			log.Warn().Err(err).Msg("Error marshaling plugin config")
@@ -124,7 +290,33 @@ func wrapWithInterceptors(handler http.Handler, conf config.PluginConfigs) http.
 			log.Warn().Err(err).Msg("Error unmarshalling plugin config")
 			continue
 		}
-		handler = pInstance.Handler()(handler)
+		if validator, ok := pInstance.(interceptors.Validator); ok {
+			if err := validator.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("plugin %q: %w", name, err))
+				continue
+			}
+		}
+		if namer, ok := pInstance.(interceptors.Namer); ok {
+			namer.SetName(name)
+		}
+		interceptors.AddLoaded(server, name, typ, pInstance)
+		plugins = append(plugins, namedInterceptor{name: name, typ: typ, order: scope.Order, Interceptor: pInstance})
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		if plugins[i].order != plugins[j].order {
+			return plugins[i].order < plugins[j].order
+		}
+		return plugins[i].name < plugins[j].name
+	})
+	return plugins, errors.Join(errs...)
+}
+
+// wrapWithInterceptors wraps handler so that the first of plugins is the outermost, seeing
+// requests first and responses last. Each interceptor is isolated from the others' panics.
+func wrapWithInterceptors(handler http.Handler, plugins []namedInterceptor) http.Handler {
+	for i := len(plugins) - 1; i >= 0; i-- {
+		handler = isolate(plugins[i].name, plugins[i].Interceptor, handler)
 	}
 
 	return handler
@@ -189,10 +381,24 @@ func blacklistCiphers(blacklist []string, defaultCiphers []uint16) []uint16 {
 	return modifiedCiphers
 }
 
-// healthMW intercepts requests for the given path to return a StatusOK.
-func healthMW(next http.Handler, path string) http.Handler {
+// healthMW intercepts requests for the given path to return a StatusOK, or a
+// StatusServiceUnavailable when an interceptor reports it isn't healthy.
+func healthMW(next http.Handler, path string, plugins []namedInterceptor) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "GET" && strings.HasSuffix(strings.ToLower(r.URL.Path), path) {
+			var reasons []string
+			for _, plugin := range plugins {
+				if checker, ok := plugin.Interceptor.(interceptors.HealthChecker); ok {
+					if err := checker.Health(); err != nil {
+						reasons = append(reasons, err.Error())
+					}
+				}
+			}
+			if len(reasons) > 0 {
+				render.Status(r, http.StatusServiceUnavailable)
+				render.JSON(w, r, HealthInfo{Status: "unhealthy", Reasons: reasons})
+				return
+			}
 			render.JSON(w, r, HealthInfo{Status: "ok"})
 			return
 		}
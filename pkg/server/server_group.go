@@ -19,6 +19,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -35,6 +36,9 @@ type Group struct {
 	eg   *errgroup.Group
 	ctx  context.Context
 	conf config.ServerConfig
+
+	mu      sync.Mutex
+	servers []Server
 }
 
 // NewGroup creares a new server group.
@@ -69,6 +73,16 @@ func (g *Group) GoListenAndServe(name, port string, handler http.Handler) {
 		return
 	}
 
+	if err := server.StartInterceptors(g.ctx); err != nil {
+		log.Error().Err(err).Msg("Failed starting interceptors")
+		g.stop()
+		return
+	}
+
+	g.mu.Lock()
+	g.servers = append(g.servers, server)
+	g.mu.Unlock()
+
 	wg := sync.WaitGroup{}
 	wg.Add(1)
 	g.eg.Go(func() error {
@@ -89,6 +103,21 @@ func (g *Group) GoListenAndServe(name, port string, handler http.Handler) {
 	wg.Wait()
 }
 
+// ReloadInterceptors applies the interceptor configuration to the interceptors of every server
+func (g *Group) ReloadInterceptors(ctx context.Context, conf config.PluginConfigs) error {
+	g.mu.Lock()
+	servers := append([]Server(nil), g.servers...)
+	g.mu.Unlock()
+
+	var errs []error
+	for _, server := range servers {
+		if err := server.ReloadInterceptors(ctx, conf); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Wait waits for all servers to complete before returning
 func (g *Group) Wait() error {
 	return g.eg.Wait()
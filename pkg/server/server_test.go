@@ -17,7 +17,9 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -140,7 +142,7 @@ func TestHealthMW(t *testing.T) {
 	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Fail(t, "health status api failed")
 	})
-	mw := healthMW(nextHandler, "/health")
+	mw := healthMW(nextHandler, "/health", nil)
 	req := httptest.NewRequest("GET", "/health", nil)
 	rec := httptest.NewRecorder()
 
@@ -236,10 +238,215 @@ func TestWrapWithInterceptors(t *testing.T) {
 	interceptors.Add("notJSON", creator)
 	conf["notJSON"] = make(chan struct{})
 
-	next := wrapWithInterceptors(http.HandlerFunc(handler), conf)
+	plugins, err := newInterceptors("api", conf)
+	assert.NoError(t, err)
+	assert.Len(t, plugins, 5)
+	next := wrapWithInterceptors(http.HandlerFunc(handler), plugins)
 
-	next.ServeHTTP(nil, nil)
+	next.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
 
 	// Ensure all VALID plugins were executed.
 	wg.Wait()
 }
+
+// orderedInterceptor records its name in calls when it handles a request
+type orderedInterceptor struct {
+	name  string
+	calls *[]string
+}
+
+func (o *orderedInterceptor) Handler() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*o.calls = append(*o.calls, o.name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestInterceptorOrderAndScope(t *testing.T) {
+	var calls []string
+	for _, name := range []string{"orderFirst", "orderSecond", "orderThird", "orderAdmin"} {
+		name := name
+		interceptors.Add(name, func() interceptors.Interceptor { return &orderedInterceptor{name: name, calls: &calls} })
+	}
+	conf := config.PluginConfigs{
+		"orderThird":  map[string]interface{}{"order": 10},
+		"orderSecond": map[string]interface{}{},
+		"orderFirst":  map[string]interface{}{"order": -5, "servers": []interface{}{"API", "webhook"}},
+		"orderAdmin":  map[string]interface{}{"servers": []interface{}{"admin"}},
+	}
+
+	plugins, err := newInterceptors("api", conf)
+	assert.NoError(t, err)
+	wrapWithInterceptors(handler, plugins).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []string{"orderFirst", "orderSecond", "orderThird"}, calls)
+
+	calls = nil
+	plugins, err = newInterceptors("admin", conf)
+	assert.NoError(t, err)
+	wrapWithInterceptors(handler, plugins).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []string{"orderAdmin", "orderSecond", "orderThird"}, calls)
+
+	_, err = newInterceptors("api", config.PluginConfigs{"orderFirst": map[string]interface{}{"order": "first"}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `plugin "orderFirst": type, servers and order`)
+	}
+}
+
+type namedInterceptorConfig struct {
+	mockInterceptor
+	Endpoint string `json:"endpoint"`
+	name     string
+}
+
+func (n *namedInterceptorConfig) SetName(name string) {
+	n.name = name
+}
+
+func TestNewInterceptorsNamedInstances(t *testing.T) {
+	interceptors.Add("named", func() interceptors.Interceptor { return &namedInterceptorConfig{} })
+
+	plugins, err := newInterceptors("api", config.PluginConfigs{
+		"named":      map[string]interface{}{"endpoint": "default"},
+		"named-eu":   map[string]interface{}{"type": "named", "endpoint": "eu", "order": -1},
+		"named-us":   map[string]interface{}{"type": "named", "endpoint": "us", "servers": []string{"admin"}},
+		"unknown-eu": map[string]interface{}{"type": "unknown"},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, plugins, 2) {
+		assert.Equal(t, "named-eu", plugins[0].name)
+		assert.Equal(t, "named", plugins[0].typ)
+		assert.Equal(t, &namedInterceptorConfig{Endpoint: "eu", name: "named-eu"}, plugins[0].Interceptor)
+		assert.Equal(t, "named", plugins[1].name)
+		assert.Equal(t, &namedInterceptorConfig{Endpoint: "default", name: "named"}, plugins[1].Interceptor)
+	}
+}
+
+func TestSplitInterceptorConfig(t *testing.T) {
+	settings := map[string]interface{}{"enabled": true, "type": "analytics", "servers": []interface{}{"api"}, "order": 2}
+	scope, rest, err := splitInterceptorConfig(settings)
+	assert.NoError(t, err)
+	assert.Equal(t, interceptorScope{Type: "analytics", Servers: []string{"api"}, Order: 2}, scope)
+	assert.Equal(t, "analytics", scope.typeOf("analytics-eu"))
+	assert.Equal(t, map[string]interface{}{"enabled": true}, rest)
+	assert.Len(t, settings, 4, "the configuration is not modified")
+
+	scope, rest, err = splitInterceptorConfig(false)
+	assert.NoError(t, err)
+	assert.Equal(t, interceptorScope{}, scope)
+	assert.Equal(t, false, rest)
+	assert.True(t, scope.includes("admin"))
+	assert.Equal(t, "httplog", scope.typeOf("httplog"))
+}
+
+type lifecycleInterceptor struct {
+	started  bool
+	stopped  bool
+	health   error
+	reloaded string
+}
+
+func (l *lifecycleInterceptor) Handler() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler { return next }
+}
+
+func (l *lifecycleInterceptor) Start(ctx context.Context) error {
+	l.started = true
+	return nil
+}
+
+func (l *lifecycleInterceptor) Stop(ctx context.Context) error {
+	l.stopped = true
+	return nil
+}
+
+func (l *lifecycleInterceptor) Health() error {
+	return l.health
+}
+
+func (l *lifecycleInterceptor) Reload(ctx context.Context, conf []byte) error {
+	l.reloaded = string(conf)
+	return nil
+}
+
+func TestReloadInterceptors(t *testing.T) {
+	reloadable := &lifecycleInterceptor{}
+	srv := Server{interceptors: []namedInterceptor{
+		{name: "reloadable", typ: "reloadable", Interceptor: reloadable},
+		{name: "static", typ: "static", Interceptor: &mockInterceptor{}},
+		{name: "removed", typ: "removed", Interceptor: &lifecycleInterceptor{}},
+	}}
+
+	err := srv.ReloadInterceptors(context.Background(), config.PluginConfigs{
+		"reloadable": map[string]interface{}{"enabled": true, "order": 0},
+		"static":     map[string]interface{}{},
+		"added":      map[string]interface{}{},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"enabled":true}`, reloadable.reloaded)
+
+	err = srv.ReloadInterceptors(context.Background(), config.PluginConfigs{
+		"reloadable": make(chan struct{}),
+	})
+	assert.Error(t, err)
+}
+
+func TestInterceptorLifecycle(t *testing.T) {
+	plugin := &lifecycleInterceptor{}
+	interceptors.Add("lifecycle", func() interceptors.Interceptor { return plugin })
+
+	srv, err := NewServer("valid", "6001", handler, config.ServerConfig{
+		Interceptors: config.PluginConfigs{"lifecycle": map[string]interface{}{}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, srv.StartInterceptors(context.Background()))
+	assert.True(t, plugin.started)
+
+	srv.Shutdown()
+	assert.True(t, plugin.stopped)
+}
+
+type validatingInterceptor struct {
+	mockInterceptor
+	Valid bool `json:"valid"`
+}
+
+func (v *validatingInterceptor) Validate() error {
+	if !v.Valid {
+		return errors.New("valid: must be true")
+	}
+	return nil
+}
+
+func TestNewServerRejectsInvalidInterceptorConfig(t *testing.T) {
+	interceptors.Add("validating", func() interceptors.Interceptor { return &validatingInterceptor{} })
+
+	_, err := NewServer("valid", "6002", handler, config.ServerConfig{
+		Interceptors: config.PluginConfigs{"validating": map[string]interface{}{"valid": true}},
+	})
+	assert.NoError(t, err)
+
+	_, err = NewServer("invalid", "6002", handler, config.ServerConfig{
+		Interceptors: config.PluginConfigs{"validating": map[string]interface{}{"valid": false}},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `plugin "validating": valid: must be true`)
+	}
+}
+
+func TestHealthMWUnhealthyInterceptor(t *testing.T) {
+	plugins := []namedInterceptor{
+		{name: "healthy", Interceptor: &lifecycleInterceptor{}},
+		{name: "unhealthy", Interceptor: &lifecycleInterceptor{health: errors.New("dispatcher not started")}},
+	}
+	mw := healthMW(http.NotFoundHandler(), "/health", plugins)
+	rec := httptest.NewRecorder()
+
+	mw.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status":"unhealthy","reasons":["dispatcher not started"]}`, rec.Body.String())
+}
@@ -22,4 +22,6 @@ import (
 	_ "github.com/optimizely/agent/plugins/interceptors/httplog"
 	// Register the analytics interceptor
 	_ "github.com/optimizely/agent/plugins/interceptors/analytics"
+	// Register the external process interceptor
+	_ "github.com/optimizely/agent/plugins/interceptors/external"
 )
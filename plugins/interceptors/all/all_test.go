@@ -26,7 +26,7 @@ import (
 )
 
 func TestAnonImports(t *testing.T) {
-	plugins := []string{"httplog"}
+	plugins := []string{"httplog", "analytics", "external"}
 
 	for _, plugin := range plugins {
 		actual := interceptors.Interceptors[plugin]
@@ -0,0 +1,107 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ActivationConfig limits tracking to the requests matching every configured condition, so
+// that one agent can serve tracked and untracked tenants. Other requests are passed through as
+// if analytics was disabled.
+type ActivationConfig struct {
+	Hosts   []string          `json:"hosts"`   // Host header patterns (path.Match syntax, e.g. *.example.com), any of which must match
+	SDKKeys []string          `json:"sdkKeys"` // SDK keys of the tracked projects and environments
+	Headers map[string]string `json:"headers"` // Values of headers requests must carry, or "*" for any value
+}
+
+// activation decides which requests are tracked. A nil activation tracks every request.
+type activation struct {
+	hosts   []string // nil when any host is tracked
+	sdkKeys map[string]bool
+	headers map[string]string
+}
+
+// newActivation returns the activation of conf, or nil when conf has no conditions, along with
+// errors for the invalid host patterns, which are skipped. Hosts stay restricted when all
+// patterns are invalid.
+func newActivation(conf ActivationConfig) (*activation, []error) {
+	if len(conf.Hosts) == 0 && len(conf.SDKKeys) == 0 && len(conf.Headers) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	a := &activation{}
+	if len(conf.Hosts) > 0 {
+		a.hosts = make([]string, 0, len(conf.Hosts))
+		for _, pattern := range conf.Hosts {
+			pattern = strings.ToLower(pattern)
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = append(errs, fmt.Errorf("invalid analytics host pattern %q: %w", pattern, err))
+				continue
+			}
+			a.hosts = append(a.hosts, pattern)
+		}
+	}
+	if len(conf.SDKKeys) > 0 {
+		a.sdkKeys = make(map[string]bool, len(conf.SDKKeys))
+		for _, sdkKey := range conf.SDKKeys {
+			a.sdkKeys[sdkKey] = true
+		}
+	}
+	if len(conf.Headers) > 0 {
+		a.headers = make(map[string]string, len(conf.Headers))
+		for name, value := range conf.Headers {
+			a.headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	return a, errs
+}
+
+// matches reports whether r is tracked
+func (a *activation) matches(r *http.Request) bool {
+	if a == nil {
+		return true
+	}
+	if a.hosts != nil && !a.matchesHost(r.Host) {
+		return false
+	}
+	if a.sdkKeys != nil && !a.sdkKeys[getSDKKey(r)] {
+		return false
+	}
+	for name, value := range a.headers {
+		actual := r.Header.Get(name)
+		if actual == "" || (value != "*" && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesHost reports whether host, without its port, matches one of the host patterns
+func (a *activation) matchesHost(host string) bool {
+	host = hostWithoutPort(host)
+	for _, pattern := range a.hosts {
+		if matched, _ := path.Match(pattern, host); matched {
+			return true
+		}
+	}
+	return false
+}
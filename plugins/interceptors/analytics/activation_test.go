@@ -0,0 +1,91 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivationMatches(t *testing.T) {
+	request := func(host, sdkKey, tier string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/v1/config", nil)
+		r.Host = host
+		if sdkKey != "" {
+			r.Header.Set("X-Optimizely-SDK-Key", sdkKey)
+		}
+		if tier != "" {
+			r.Header.Set("X-Tenant-Tier", tier)
+		}
+		return r
+	}
+	for name, tc := range map[string]struct {
+		conf     ActivationConfig
+		request  *http.Request
+		expected bool
+	}{
+		"no conditions":          {request: request("agent.example.com", "", ""), expected: true},
+		"host":                   {conf: ActivationConfig{Hosts: []string{"*.Tracked.example.com"}}, request: request("eu.tracked.example.com:8080", "", ""), expected: true},
+		"other host":             {conf: ActivationConfig{Hosts: []string{"*.tracked.example.com"}}, request: request("eu.untracked.example.com", "", ""), expected: false},
+		"sdk key":                {conf: ActivationConfig{SDKKeys: []string{"key1"}}, request: request("", "key1:token", ""), expected: true},
+		"other sdk key":          {conf: ActivationConfig{SDKKeys: []string{"key1"}}, request: request("", "key2", ""), expected: false},
+		"missing sdk key":        {conf: ActivationConfig{SDKKeys: []string{"key1"}}, request: request("", "", ""), expected: false},
+		"header value":           {conf: ActivationConfig{Headers: map[string]string{"x-tenant-tier": "premium"}}, request: request("", "", "premium"), expected: true},
+		"other header value":     {conf: ActivationConfig{Headers: map[string]string{"x-tenant-tier": "premium"}}, request: request("", "", "free"), expected: false},
+		"any header value":       {conf: ActivationConfig{Headers: map[string]string{"X-Tenant-Tier": "*"}}, request: request("", "", "free"), expected: true},
+		"missing header":         {conf: ActivationConfig{Headers: map[string]string{"X-Tenant-Tier": "*"}}, request: request("", "", ""), expected: false},
+		"every condition":        {conf: ActivationConfig{Hosts: []string{"a.example.com"}, SDKKeys: []string{"key1"}}, request: request("a.example.com", "key1", ""), expected: true},
+		"one condition failing":  {conf: ActivationConfig{Hosts: []string{"a.example.com"}, SDKKeys: []string{"key1"}}, request: request("b.example.com", "key1", ""), expected: false},
+		"only invalid hosts":     {conf: ActivationConfig{Hosts: []string{"[a"}}, request: request("a.example.com", "", ""), expected: false},
+		"invalid and valid host": {conf: ActivationConfig{Hosts: []string{"[a", "a.example.com"}}, request: request("a.example.com", "", ""), expected: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			activation, _ := newActivation(tc.conf)
+			assert.Equal(t, tc.expected, activation.matches(tc.request))
+		})
+	}
+}
+
+func TestNewActivationErrors(t *testing.T) {
+	_, errs := newActivation(ActivationConfig{Hosts: []string{"[a", "a.example.com"}})
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), `invalid analytics host pattern "[a"`)
+
+	a := &Analytics{Activation: ActivationConfig{Hosts: []string{"[a"}}}
+	assert.ErrorContains(t, a.Validate(), "activation.hosts")
+}
+
+func TestAnalyticsActivation(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Activation: ActivationConfig{SDKKeys: []string{"tracked"}}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	for _, sdkKey := range []string{"untracked", "tracked"} {
+		r := httptest.NewRequest(http.MethodGet, "/v1/config", nil)
+		r.Header.Set("X-Optimizely-SDK-Key", sdkKey)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	// Only the request of the tracked tenant is delivered
+	assert.Equal(t, "tracked", backend.next(t).Params[sdkKeyParam])
+	assert.Empty(t, backend.events)
+}
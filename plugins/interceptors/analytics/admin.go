@@ -0,0 +1,147 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/optimizely/agent/plugins/interceptors"
+)
+
+// trackingPaused is the runtime kill switch set through the admin API. It applies to every
+// interceptor instance and survives config reloads, but not restarts.
+var trackingPaused atomic.Bool
+
+// instances holds the interceptor instances reported by the admin API, in creation order
+var (
+	instances   []*Analytics
+	instancesMu sync.Mutex
+)
+
+// trackingState is the admin API representation of the analytics state
+type trackingState struct {
+	Enabled   bool            `json:"enabled"` // false while tracking is paused through the admin API
+	Instances []instanceState `json:"instances"`
+}
+
+// instanceState reports the configuration and dispatcher of one interceptor instance
+type instanceState struct {
+	Name          string             `json:"name"`       // name the instance is configured under
+	Configured    bool               `json:"configured"` // enabled in the configuration
+	Started       bool               `json:"started"`
+	Queued        int                `json:"queued"`
+	QueueCapacity int                `json:"queueCapacity"`
+	Destinations  []destinationState `json:"destinations"`
+}
+
+// destinationState reports the circuit breaker of a destination
+type destinationState struct {
+	Name    string `json:"name"`
+	Breaker string `json:"breaker"`
+}
+
+// stateRequest toggles tracking
+type stateRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// registerInstance adds the instance to the ones reported by the admin API
+func registerInstance(a *Analytics) {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	for _, instance := range instances {
+		if instance == a {
+			return
+		}
+	}
+	instances = append(instances, a)
+}
+
+// unregisterInstance removes a stopped instance
+func unregisterInstance(a *Analytics) {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	for i, instance := range instances {
+		if instance == a {
+			instances = append(instances[:i], instances[i+1:]...)
+			return
+		}
+	}
+}
+
+// currentState returns the tracking state of all instances
+func currentState() trackingState {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+
+	state := trackingState{Enabled: !trackingPaused.Load(), Instances: []instanceState{}}
+	for _, instance := range instances {
+		cur := instance.current()
+		is := instanceState{Name: instance.instanceName(), Configured: cur.Enabled, Destinations: []destinationState{}}
+		if d := cur.dispatcher; d != nil {
+			is.Started = !d.isClosed()
+			is.Queued = d.queued()
+			is.QueueCapacity = d.capacity()
+			for _, dest := range d.destinations {
+				breaker := breakerClosed
+				if dest.breaker != nil {
+					breaker = dest.breaker.currentState()
+				}
+				is.Destinations = append(is.Destinations, destinationState{Name: dest.name, Breaker: breaker.String()})
+			}
+		}
+		state.Instances = append(state.Instances, is)
+	}
+	return state
+}
+
+// getState reports whether tracking is enabled along with the dispatcher stats
+func getState(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, currentState())
+}
+
+// setState pauses or resumes tracking, responding with the resulting state
+func setState(w http.ResponseWriter, r *http.Request) {
+	var req stateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, map[string]string{"error": `expected {"enabled": true|false}`})
+		return
+	}
+
+	trackingPaused.Store(!*req.Enabled)
+	render.JSON(w, r, currentState())
+}
+
+// adminHandler serves the analytics admin API, mounted under /analytics on the admin listener
+func adminHandler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/state", getState)
+	r.Post("/state", setState)
+	r.Get("/stats", getStats)
+	return r
+}
+
+func init() {
+	interceptors.AddAdminHandler(defaultInstanceName, adminHandler())
+}
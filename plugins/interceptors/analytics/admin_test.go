@@ -0,0 +1,97 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/interceptors"
+)
+
+func adminRequest(t *testing.T, method, body string) (int, trackingState) {
+	rec := httptest.NewRecorder()
+	interceptors.AdminHandlers["analytics"].ServeHTTP(rec, httptest.NewRequest(method, "/state", strings.NewReader(body)))
+	var state trackingState
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	}
+	return rec.Code, state
+}
+
+func TestAdminState(t *testing.T) {
+	defer trackingPaused.Store(false)
+
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{queueSize: 10}, a.metrics)
+	defer unregisterInstance(a)
+
+	code, state := adminRequest(t, "GET", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, state.Enabled)
+	assert.Contains(t, state.Instances, instanceState{
+		Name:          "analytics",
+		Configured:    true,
+		Started:       true,
+		QueueCapacity: 10,
+		Destinations:  []destinationState{{Name: "mock", Breaker: "closed"}},
+	})
+
+	// Pausing skips tracking until resumed
+	code, state = adminRequest(t, "POST", `{"enabled": false}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, state.Enabled)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	assert.Empty(t, backend.events)
+
+	code, state = adminRequest(t, "POST", `{"enabled": true}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, state.Enabled)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	backend.next(t)
+
+	code, _ = adminRequest(t, "POST", `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestAdminStateUnregistersStoppedInstances(t *testing.T) {
+	a := &Analytics{}
+	a.Handler()
+	instancesMu.Lock()
+	assert.Contains(t, instances, a)
+	instancesMu.Unlock()
+
+	require.NoError(t, a.Stop(context.Background()))
+	instancesMu.Lock()
+	assert.NotContains(t, instances, a)
+	instancesMu.Unlock()
+}
+
+func TestBreakerStateString(t *testing.T) {
+	assert.Equal(t, "closed", breakerClosed.String())
+	assert.Equal(t, "open", breakerOpen.String())
+	assert.Equal(t, "half-open", breakerHalfOpen.String())
+}
@@ -0,0 +1,170 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	adobeInsertionPath = "/b/ss//6"
+	adobeCustomLink    = "o"
+	adobeMaxVariable   = 250 // highest eVar and prop number
+)
+
+// AdobeBackend sends events to Adobe Analytics with the Data Insertion API, one XML hit per event.
+// Events are custom link hits named after the event, or page views of their path with PageViews.
+type AdobeBackend struct {
+	TrackingServer string            `json:"trackingServer"` // Tracking server URL, e.g. https://example.sc.omtrdc.net
+	ReportSuiteID  string            `json:"reportSuiteID"`
+	PageViews      bool              `json:"pageViews"` // Send page views of the request paths instead of custom links
+	EVars          map[string]int    `json:"eVars"`     // eVar number of params, e.g. sdk_key: 1
+	Props          map[string]int    `json:"props"`     // prop number of params, e.g. path: 2
+	Events         map[string]string `json:"events"`    // Success events of event names, e.g. api_request: event1
+
+	client *http.Client
+}
+
+// adobeField is an element of a Data Insertion request
+type adobeField struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// adobeRequest is a Data Insertion request
+type adobeRequest struct {
+	XMLName xml.Name `xml:"request"`
+	Fields  []adobeField
+}
+
+// checkConfig checks that the tracking server and report suite are set
+func (a *AdobeBackend) checkConfig() error {
+	if a.TrackingServer == "" || a.ReportSuiteID == "" {
+		return errors.New("trackingServer and reportSuiteID are required")
+	}
+	for _, variables := range []map[string]int{a.EVars, a.Props} {
+		for name, n := range variables {
+			if n < 1 || n > adobeMaxVariable {
+				return fmt.Errorf("eVar or prop number of %q must be between 1 and %d, got %d", name, adobeMaxVariable, n)
+			}
+		}
+	}
+	return nil
+}
+
+// Send posts the events to the Data Insertion API, which accepts one hit per request
+func (a *AdobeBackend) Send(ctx context.Context, events []Event) error {
+	endpoint := strings.TrimSuffix(a.TrackingServer, "/") + adobeInsertionPath
+	for _, e := range events {
+		body, err := xml.Marshal(a.request(e))
+		if err != nil {
+			return err
+		}
+		resp, err := postResponse(ctx, a.client, endpoint, "application/xml", append([]byte(xml.Header), body...), nil)
+		if err != nil {
+			return err
+		}
+		if err := adobeStatus(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// request formats an event as a Data Insertion request
+func (a *AdobeBackend) request(e Event) adobeRequest {
+	var req adobeRequest
+	add := func(name, value string) {
+		req.Fields = append(req.Fields, adobeField{XMLName: xml.Name{Local: name}, Value: value})
+	}
+
+	add("reportSuiteID", a.ReportSuiteID)
+	add("visitorID", saltedHash("", e.ClientID))
+	if !e.Timestamp.IsZero() {
+		add("timestamp", strconv.FormatInt(e.Timestamp.Unix(), 10))
+	}
+	path, _ := e.Params[pathParam].(string)
+	if a.PageViews {
+		add("pageName", path)
+	} else {
+		add("linkType", adobeCustomLink)
+		add("linkName", e.Name)
+	}
+	if event, ok := a.Events[e.Name]; ok {
+		add("events", event)
+	}
+	for _, name := range sortedKeys(a.EVars) {
+		if v, ok := e.Params[name]; ok {
+			add("eVar"+strconv.Itoa(a.EVars[name]), fmt.Sprint(v))
+		}
+	}
+	for _, name := range sortedKeys(a.Props) {
+		if v, ok := e.Params[name]; ok {
+			add("prop"+strconv.Itoa(a.Props[name]), fmt.Sprint(v))
+		}
+	}
+	return req
+}
+
+// adobeStatus returns the failure reported in a Data Insertion response, which responds with
+// 200 OK either way. The response is a <status> element, followed by a <reason> element on
+// failure. Dry runs have no response.
+func adobeStatus(resp []byte) error {
+	if len(bytes.TrimSpace(resp)) == 0 {
+		return nil
+	}
+	fields := map[string]string{}
+	decoder := xml.NewDecoder(bytes.NewReader(resp))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid Adobe Analytics response: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			var value string
+			if err := decoder.DecodeElement(&value, &start); err != nil {
+				return fmt.Errorf("invalid Adobe Analytics response: %w", err)
+			}
+			fields[start.Name.Local] = value
+		}
+	}
+	if fields["status"] != "SUCCESS" {
+		return fmt.Errorf("adobe analytics rejected the hit: %s %s", fields["status"], fields["reason"])
+	}
+	return nil
+}
+
+func (a *AdobeBackend) setHTTPClient(client *http.Client) {
+	a.client = client
+}
+
+func init() {
+	AddBackend("adobe", func() Backend {
+		return &AdobeBackend{}
+	})
+}
@@ -0,0 +1,96 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdobeRequest(t *testing.T) {
+	backend := &AdobeBackend{
+		ReportSuiteID: "rsid",
+		EVars:         map[string]int{sdkKeyParam: 1},
+		Props:         map[string]int{pathParam: 2},
+		Events:        map[string]string{"api_request": "event1"},
+	}
+	e := Event{
+		Name:      "api_request",
+		ClientID:  "client-1",
+		Timestamp: time.Unix(1700000000, 0),
+		Params:    map[string]interface{}{pathParam: "/v1/decide", sdkKeyParam: "sdk-key"},
+	}
+
+	body, err := xml.Marshal(backend.request(e))
+	require.NoError(t, err)
+	assert.Equal(t, "<request>"+
+		"<reportSuiteID>rsid</reportSuiteID>"+
+		"<visitorID>"+saltedHash("", "client-1")+"</visitorID>"+
+		"<timestamp>1700000000</timestamp>"+
+		"<linkType>o</linkType>"+
+		"<linkName>api_request</linkName>"+
+		"<events>event1</events>"+
+		"<eVar1>sdk-key</eVar1>"+
+		"<prop2>/v1/decide</prop2>"+
+		"</request>", string(body))
+
+	backend.PageViews = true
+	body, err = xml.Marshal(backend.request(e))
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "<pageName>/v1/decide</pageName>")
+	assert.NotContains(t, string(body), "<linkType>")
+}
+
+func TestAdobeBackendSend(t *testing.T) {
+	status := "SUCCESS"
+	var hits []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, adobeInsertionPath, r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		hits = append(hits, string(body))
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><status>` + status + `</status><reason>bad visitor</reason>`))
+	}))
+	defer ts.Close()
+
+	backend := &AdobeBackend{TrackingServer: ts.URL, ReportSuiteID: "rsid"}
+	events := []Event{
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{}},
+		{Name: "api_request", ClientID: "b", Params: map[string]interface{}{}},
+	}
+	require.NoError(t, backend.Send(context.Background(), events))
+	require.Len(t, hits, 2)
+	assert.True(t, strings.HasPrefix(hits[0], xml.Header))
+
+	status = "FAILURE"
+	err := backend.Send(context.Background(), events[:1])
+	assert.ErrorContains(t, err, "FAILURE bad visitor")
+}
+
+func TestAdobeBackendCheckConfig(t *testing.T) {
+	assert.NoError(t, (&AdobeBackend{TrackingServer: "https://example.sc.omtrdc.net", ReportSuiteID: "rsid"}).checkConfig())
+	assert.Error(t, (&AdobeBackend{TrackingServer: "https://example.sc.omtrdc.net"}).checkConfig())
+	assert.Error(t, (&AdobeBackend{TrackingServer: "https://example.sc.omtrdc.net", ReportSuiteID: "rsid", EVars: map[string]int{"path": 251}}).checkConfig())
+}
@@ -0,0 +1,270 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	defaultAggregationWindow    = time.Minute
+	defaultAggregationEventName = "api_usage"
+	defaultAggregationMaxKeys   = 1000
+
+	requestCountParam  = "request_count"
+	errorCountParam    = "error_count"
+	errorRateParam     = "error_rate"
+	latencyP50Param    = "latency_p50_ms"
+	latencyP95Param    = "latency_p95_ms"
+	latencyMaxParam    = "latency_max_ms"
+	windowSecondsParam = "window_seconds"
+)
+
+// AggregationConfig replaces per-request events with one summary event per window for every
+// path, method and SDK key, for agents serving more requests than is practical to track one by one
+type AggregationConfig struct {
+	Enabled   bool           `json:"enabled"`
+	Window    utils.Duration `json:"window"`    // Length of a rollup window (defaults to 1m)
+	EventName string         `json:"eventName"` // Name of the summary events (defaults to api_usage)
+	MaxKeys   int            `json:"maxKeys"`   // Most path, method and SDK key combinations per window (defaults to 1000)
+}
+
+// agentClientID is the client ID of events describing the agent itself: its host name
+func agentClientID() string {
+	if hostname, _ := os.Hostname(); hostname != "" {
+		return hostname
+	}
+	return "optimizely-agent"
+}
+
+// rollupKey identifies the requests summarized together
+type rollupKey struct {
+	path, method, sdkKey string
+}
+
+// rollup summarizes the requests of a key in the current window
+type rollup struct {
+	count   int64
+	errors  int64
+	max     int64
+	latency [latencyBuckets]int64
+}
+
+// Latencies are counted in exponential buckets growing by latencyBucketGrowth from 1ms to about
+// a minute, so percentiles are estimated within 10% in constant memory per key
+const (
+	latencyBucketGrowth = 1.1
+	latencyBuckets      = 117
+)
+
+func latencyBucket(ms int64) int {
+	if ms <= 1 {
+		return 0
+	}
+	i := int(math.Ceil(math.Log(float64(ms)) / math.Log(latencyBucketGrowth)))
+	if i >= latencyBuckets {
+		return latencyBuckets - 1
+	}
+	return i
+}
+
+func (r *rollup) add(status int, durationMS int64) {
+	r.count++
+	if status >= 500 {
+		r.errors++
+	}
+	if durationMS > r.max {
+		r.max = durationMS
+	}
+	r.latency[latencyBucket(durationMS)]++
+}
+
+// percentile estimates the latency below which the fraction q of the requests fell, as the
+// upper bound of its bucket capped by the maximum. Latencies past the last bucket report the maximum.
+func (r *rollup) percentile(q float64) int64 {
+	rank := int64(math.Ceil(q * float64(r.count)))
+	var seen int64
+	for i, n := range r.latency {
+		seen += n
+		if seen >= rank && n > 0 {
+			if i == latencyBuckets-1 {
+				return r.max
+			}
+			upper := int64(math.Round(math.Pow(latencyBucketGrowth, float64(i))))
+			if upper > r.max {
+				return r.max
+			}
+			return upper
+		}
+	}
+	return r.max
+}
+
+// aggregator maintains the rollups of the current window and emits them as summary events
+// when the window ends
+type aggregator struct {
+	window    time.Duration
+	eventName string
+	maxKeys   int
+	clientID  string
+	emit      func(Event) bool
+
+	mu      sync.Mutex
+	start   time.Time
+	rollups map[rollupKey]*rollup
+
+	done      chan struct{}
+	stopped   sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// newAggregator starts emitting the rollups of every window to emit. Windows are aligned to the
+// clock, e.g. on the minute.
+func newAggregator(conf AggregationConfig, emit func(Event) bool) *aggregator {
+	g := &aggregator{
+		window:    conf.Window.Duration,
+		eventName: conf.EventName,
+		maxKeys:   conf.MaxKeys,
+		emit:      emit,
+		rollups:   map[rollupKey]*rollup{},
+		done:      make(chan struct{}),
+	}
+	if g.window <= 0 {
+		g.window = defaultAggregationWindow
+	}
+	if g.eventName == "" {
+		g.eventName = defaultAggregationEventName
+	}
+	if g.maxKeys <= 0 {
+		g.maxKeys = defaultAggregationMaxKeys
+	}
+	// Summaries describe the agent rather than a client
+	g.clientID = agentClientID()
+	g.start = time.Now().Truncate(g.window)
+
+	g.stopped.Add(1)
+	go g.run()
+	return g
+}
+
+func (g *aggregator) run() {
+	defer g.stopped.Done()
+	for {
+		g.mu.Lock()
+		next := g.start.Add(g.window)
+		g.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			g.flush(time.Now())
+		case <-g.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// record adds the request of event to the rollups of the current window
+func (g *aggregator) record(event Event) {
+	key := rollupKey{}
+	key.path, _ = event.Params[pathParam].(string)
+	key.method, _ = event.Params[methodParam].(string)
+	key.sdkKey, _ = event.Params[sdkKeyParam].(string)
+	status, _ := event.Params[statusCodeParam].(int)
+	duration, _ := event.Params[responseTimeParam].(int64)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r, ok := g.rollups[key]
+	if !ok {
+		if len(g.rollups) >= g.maxKeys {
+			key = rollupKey{path: otherPath}
+			r = g.rollups[key]
+		}
+		if r == nil {
+			r = &rollup{}
+			g.rollups[key] = r
+		}
+	}
+	r.add(status, duration)
+}
+
+// flush emits the summary events of the window ending at now and starts the next one
+func (g *aggregator) flush(now time.Time) {
+	g.mu.Lock()
+	start, rollups := g.start, g.rollups
+	g.start = now.Truncate(g.window)
+	g.rollups = map[rollupKey]*rollup{}
+	g.mu.Unlock()
+
+	keys := make([]rollupKey, 0, len(rollups))
+	for key := range rollups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.sdkKey < b.sdkKey
+	})
+
+	for _, key := range keys {
+		g.emit(g.summary(start, now, key, rollups[key]))
+	}
+}
+
+// summary creates the summary event of a key's rollup for the window from start to end
+func (g *aggregator) summary(start, end time.Time, key rollupKey, r *rollup) Event {
+	params := map[string]interface{}{
+		schemaVersionParam: eventSchemaVersion,
+		pathParam:          key.path,
+		requestCountParam:  r.count,
+		errorCountParam:    r.errors,
+		errorRateParam:     math.Round(float64(r.errors)/float64(r.count)*1e4) / 1e4,
+		latencyP50Param:    r.percentile(0.5),
+		latencyP95Param:    r.percentile(0.95),
+		latencyMaxParam:    r.max,
+		windowSecondsParam: math.Round(end.Sub(start).Seconds()),
+	}
+	if key.method != "" {
+		params[methodParam] = key.method
+	}
+	if key.sdkKey != "" {
+		params[sdkKeyParam] = key.sdkKey
+	}
+	return Event{Name: g.eventName, ClientID: g.clientID, Params: params, Timestamp: start}
+}
+
+// close stops the aggregator and emits the rollups of the partial window
+func (g *aggregator) close() {
+	g.closeOnce.Do(func() {
+		close(g.done)
+		g.stopped.Wait()
+		g.flush(time.Now())
+	})
+}
@@ -0,0 +1,157 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+// captureEvents returns an emit function collecting the events and a function returning them
+func captureEvents() (func(Event) bool, func() []Event) {
+	var mu sync.Mutex
+	var events []Event
+	return func(e Event) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+			return true
+		}, func() []Event {
+			mu.Lock()
+			defer mu.Unlock()
+			return events
+		}
+}
+
+func requestEvent(path, method string, status int, durationMS int64) Event {
+	return newEvent("api_request", time.Now(), ClientInfo{ID: "a"},
+		RequestInfo{Path: path, Method: method, SDKKey: "sdk"},
+		ResponseInfo{StatusCode: status, DurationMS: durationMS})
+}
+
+func TestRollupPercentile(t *testing.T) {
+	r := &rollup{}
+	for ms := int64(1); ms <= 100; ms++ {
+		r.add(http.StatusOK, ms)
+	}
+	assert.InDelta(t, 50, r.percentile(0.5), 5)
+	assert.InDelta(t, 95, r.percentile(0.95), 10)
+	assert.Equal(t, int64(100), r.percentile(1))
+
+	single := &rollup{}
+	single.add(http.StatusOK, 0)
+	assert.Equal(t, int64(0), single.percentile(0.95))
+
+	slow := &rollup{}
+	slow.add(http.StatusOK, 10*time.Minute.Milliseconds())
+	assert.Equal(t, 10*time.Minute.Milliseconds(), slow.percentile(0.5))
+}
+
+func TestAggregatorFlush(t *testing.T) {
+	emit, events := captureEvents()
+	g := newAggregator(AggregationConfig{Window: utils.Duration{Duration: time.Hour}}, emit)
+	defer g.close()
+
+	g.record(requestEvent("/v1/decide", "POST", http.StatusOK, 10))
+	g.record(requestEvent("/v1/decide", "POST", http.StatusServiceUnavailable, 30))
+	g.record(requestEvent("/v1/config", "GET", http.StatusOK, 2))
+	g.record(requestEvent("/v1/decide", "POST", http.StatusOK, 20))
+
+	start := g.start
+	g.flush(start.Add(time.Hour))
+	require.Len(t, events(), 2)
+
+	config, decide := events()[0], events()[1]
+	assert.Equal(t, "api_usage", decide.Name)
+	assert.Equal(t, start, decide.Timestamp)
+	assert.NotEmpty(t, decide.ClientID)
+	assert.Equal(t, map[string]interface{}{
+		schemaVersionParam: eventSchemaVersion,
+		pathParam:          "/v1/decide",
+		methodParam:        "POST",
+		sdkKeyParam:        "sdk",
+		requestCountParam:  int64(3),
+		errorCountParam:    int64(1),
+		errorRateParam:     0.3333,
+		latencyP50Param:    int64(21), // upper bound of the bucket of 20ms
+		latencyP95Param:    int64(30),
+		latencyMaxParam:    int64(30),
+		windowSecondsParam: float64(3600),
+	}, decide.Params)
+	assert.Equal(t, "/v1/config", config.Params[pathParam])
+	assert.Equal(t, int64(1), config.Params[requestCountParam])
+
+	// The next window starts empty
+	g.flush(start.Add(2 * time.Hour))
+	assert.Len(t, events(), 2)
+}
+
+func TestAggregatorMaxKeys(t *testing.T) {
+	emit, events := captureEvents()
+	g := newAggregator(AggregationConfig{Window: utils.Duration{Duration: time.Hour}, MaxKeys: 1}, emit)
+
+	g.record(requestEvent("/v1/decide", "POST", http.StatusOK, 1))
+	g.record(requestEvent("/v1/config", "GET", http.StatusOK, 1))
+	g.record(requestEvent("/v1/track", "POST", http.StatusOK, 1))
+	g.close()
+
+	require.Len(t, events(), 2)
+	assert.Equal(t, "/v1/decide", events()[0].Params[pathParam])
+	assert.Equal(t, otherPath, events()[1].Params[pathParam])
+	assert.Equal(t, int64(2), events()[1].Params[requestCountParam])
+}
+
+func TestAggregatorEmitsOnWindowEnd(t *testing.T) {
+	emit, events := captureEvents()
+	g := newAggregator(AggregationConfig{Window: utils.Duration{Duration: 20 * time.Millisecond}}, emit)
+	defer g.close()
+
+	g.record(requestEvent("/v1/decide", "POST", http.StatusOK, 1))
+	assert.Eventually(t, func() bool { return len(events()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestAnalyticsAggregation(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Aggregation: AggregationConfig{Enabled: true, Window: utils.Duration{Duration: time.Hour}}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.destinations = []destination{{name: "mock", backend: backend}}
+	a.startDispatcher()
+	require.NotNil(t, a.aggregator)
+
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	}
+	select {
+	case e := <-backend.events:
+		require.Failf(t, "unexpected per-request event", "%v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, a.drain(context.Background()))
+	e := backend.next(t)
+	assert.Equal(t, "api_usage", e.Name)
+	assert.Equal(t, int64(3), e.Params[requestCountParam])
+}
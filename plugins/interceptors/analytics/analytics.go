@@ -15,170 +15,645 @@
  ***************************************************************************/
 
 // Package analytics implements an interceptor for tracking API usage with Google Analytics
+// and other analytics backends
 package analytics
 
 import (
-	"bytes"
-	"encoding/json"
-	"io"
-	"io/ioutil"
+	"context"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 
 	"github.com/optimizely/agent/plugins/interceptors"
+	"github.com/optimizely/agent/plugins/utils"
 )
 
+// defaultInstanceName is the name the interceptor is registered under, and of instances configured
+// under it
+const defaultInstanceName = "analytics"
+
 // Analytics implements the Interceptor plugin interface for Google Analytics tracking
 type Analytics struct {
 	// Configuration fields
-	TrackingID string // Google Analytics tracking ID (e.g., UA-XXXXX-Y or G-XXXXXXX)
-	Enabled    bool   // Whether analytics tracking is enabled
-	EndpointURL string // Google Analytics endpoint URL (defaults to GA4 endpoint)
-}
+	TrackingID          string                 // Google Analytics tracking ID (e.g., UA-XXXXX-Y or G-XXXXXXX)
+	APISecret           string                 // Google Analytics Measurement Protocol API secret, or a secret reference
+	Enabled             bool                   // Whether analytics tracking is enabled
+	EndpointURL         string                 // Google Analytics endpoint URL (defaults to GA4 endpoint)
+	GA4Debug            bool                   // Send GA events to the GA4 validation endpoint and log the problems it finds
+	ValidateEvents      float64                // Fraction of GA payloads also sent to the GA4 validation endpoint, 0.0–1.0
+	ExperimentEvents    bool                   // Also send GA an experience_impression event per experiment decision of an event
+	GAProtocol          string                 // Protocol of the TrackingID destination: ga4 (default) or ua for Measurement Protocol v1
+	UA                  UAConfig               // Hit settings of the ua protocol
+	Properties          map[string]GA4Property // GA4 properties of the projects of SDK keys, by SDK key; others use TrackingID
+	Destinations        []BackendConfig        // Additional analytics backends (e.g. snowplow)
+	Secrets             SecretsConfig          // Resolution of secret references such as env://NAME
+	HTTPClient          HTTPClientConfig       // Timeouts, connection pooling, proxy and TLS of HTTP destinations
+	StatsD              StatsDConfig           // Optional StatsD/DogStatsD emitter for aggregate request metrics
+	QueueSize           int                    // Maximum number of events waiting for dispatch (defaults to 1000)
+	Workers             int                    // Number of concurrent dispatch workers (defaults to 2)
+	Batch               BatchConfig            // Size and time bounds of the batches sent to each destination
+	DrainTimeout        utils.Duration         // Time allowed for delivering queued events on shutdown (defaults to 5s)
+	Retry               RetryConfig            // Retry policy for failed deliveries
+	CircuitBreaker      CircuitBreakerConfig   // Short-circuits deliveries to destinations that keep failing
+	Spill               SpillConfig            // On-disk queue for events that cannot be delivered right away
+	DeadLetter          DeadLetterConfig       // Sink for events permanently rejected by a destination
+	RateLimit           RateLimitConfig        // Bounds the rate of dispatched events
+	Dedup               DedupConfig            // Drops repeated events of the same request ID
+	Priority            PriorityConfig         // Sheds low priority events first when the queue is under pressure
+	Aggregation         AggregationConfig      // Sends per-window usage rollups instead of per-request events
+	Notifications       NotificationConfig     // Forwards the decision, track and log event notifications of the SDK clients
+	MirrorEvents        bool                   // Mirror the impressions and conversions the SDK clients send to Optimizely
+	Quotas              QuotaConfig            // Per-tenant hourly and daily event budgets
+	Audit               AuditConfig            // Periodic counts of the events not dispatched, by reason
+	SampleRate          *float64               // Fraction of requests sent to the backends, 0.0–1.0 (defaults to 1, every request)
+	SampleByClientID    bool                   // Sample deterministically by client ID instead of per request
+	Activation          ActivationConfig       // Only track requests of these hosts, SDK keys or headers, e.g. of some tenants
+	EventName           string                 // Name, or template such as {method}_{route_template}, of request events (defaults to api_request)
+	Rules               []RouteRule            // Per-route tracking overrides, evaluated in order
+	IncludePaths        []string               // Glob patterns of the only paths to track (defaults to all paths)
+	ExcludePaths        []string               // Glob patterns of paths never tracked, e.g. health checks
+	PathLabels          PathLabelConfig        // Bounds the number of distinct paths sent to the backends
+	RawPaths            bool                   // Send request paths instead of the patterns of the routes serving them
+	Methods             []string               // HTTP methods that generate events (defaults to all methods)
+	StatusCodes         []string               // Status codes ("404") or classes ("4xx") that generate events (defaults to all)
+	Params              map[string]string      // Extra event params as "header:<name>", "query:<name>", "jwt:<claim>" or "static:<value>"
+	UserProperties      map[string]string      // User properties, declared like Params
+	HashSDKKey          bool                   // Send a digest of the SDK key instead of the key itself
+	EnrichDecisions     bool                   // Add flag, variation and rule details of /v1/decide and /v1/activate responses to events
+	DatafileEvents      bool                   // Track datafile fetches and webhooks as datafile_fetch and datafile_webhook events
+	AgentInternals      bool                   // Add the SDK client cache status, datafile revision and decision time of requests to events
+	Latency             LatencyConfig          // Latency buckets and Apdex classes of requests
+	ErrorDetails        bool                   // Add the error code and message of 4xx and 5xx responses to events
+	BodyParams          map[string]string      // Event params extracted from JSON request bodies, as gjson paths
+	GeoIP               GeoIPConfig            // Replaces the client IP address with its coarse location
+	ParseUserAgent      bool                   // Replace the raw user agent with device, browser and OS params
+	Privacy             PrivacyConfig          // Hashing and redaction of personal data
+	Consent             ConsentConfig          // Skips or anonymizes events of requests without consent
+	HonorDNT            bool                   // Skip events of requests sending DNT: 1 or Sec-GPC: 1
+	Anomalies           AnomalyConfig          // Flags the events of clients with unusual request rates or error ratios
+	Bots                BotConfig              // Tags or excludes the events of crawlers, which GA doesn't filter from Measurement Protocol events
+	GeoSuppression      GeoSuppressionConfig   // Anonymizes or skips events of requests from the listed countries
+	Residency           ResidencyConfig        // Routes events to destinations by client region
+	Routing             []RoutingRule          // Routes events to destinations by their name and params
+	Hooks               []Hook                 // Expressions filtering events and deriving params from the request, in order
+	Collect             CollectConfig          // First-party endpoint forwarding the events of browsers and apps
+	ClientIDSource      ClientIDSource         // Where client IDs come from (defaults to the _ga cookie)
+	Fingerprint         FingerprintConfig      // Anonymous client IDs for clients without a _ga cookie
+	Sessions            SessionConfig          // Server-side sessions for GA4 session reporting
+	UserIDClaim         string                 // JWT claim used as the user ID of authenticated requests, e.g. sub
+	CaptureRequestBody  bool                   // Buffer request bodies for bodyParams and body client IDs
+	CaptureResponseBody bool                   // Buffer /v1/decide and error response bodies for enrichDecisions and errorDetails
+	MaxCaptureBytes     int64                  // Bodies larger than this are only counted (defaults to 64KiB)
+	DryRun              bool                   // Log payloads instead of sending them
+	DryRunFile          string                 // Append dry-run payloads to this file instead of logging them
 
-// responseWriter is a wrapper for http.ResponseWriter that captures the status code and response size
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
-	body       *bytes.Buffer
+	name          string // name the instance is configured under, see SetName
+	activation    *activation
+	paths         pathFilter
+	pathLabels    *pathLabeler
+	rules         []routeRule
+	eventName     string
+	eventNames    eventNames
+	hooks         *hookChain
+	collect       *collector
+	bots          *botDetector
+	anomalies     *anomalyDetector
+	statusCodes   []string
+	dimensions    []dimension
+	maxCapture    int64
+	bodies        bodyNeeds
+	userProps     []dimension
+	geo           geoLocator
+	privacy       PrivacyConfig
+	clientID      ClientIDStrategy
+	fingerprint   *fingerprinter
+	sessions      *sessionStore
+	latency       *latencyClasses
+	destinations  []destination
+	httpClient    *http.Client
+	secrets       *secretResolver
+	stopSecrets   context.CancelFunc
+	stopBots      context.CancelFunc
+	dryRun        *dryRunSink
+	dispatcher    *dispatcher
+	aggregator    *aggregator
+	quotas        *quotas
+	notifications *notificationBridge
+	auditor       *auditor
+	active        atomic.Pointer[Analytics] // instance serving requests, see Reload
+	lifecycleMu   sync.Mutex
+	started       bool
+	statsd        *statsdEmitter
+	metrics       *analyticsMetrics
 }
 
-// WriteHeader captures the status code and calls the original WriteHeader
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
-}
-
-// Write captures the response body and calls the original Write
-func (rw *responseWriter) Write(b []byte) (int, error) {
-	rw.body.Write(b)
-	return rw.ResponseWriter.Write(b)
-}
-
-// Handler returns a middleware function that tracks API usage with Google Analytics
+// Handler returns a middleware function that tracks API usage with the configured analytics backends
 func (a *Analytics) Handler() func(http.Handler) http.Handler {
-	// Default endpoint for GA4
-	if a.EndpointURL == "" {
-		a.EndpointURL = "https://www.google-analytics.com/mp/collect"
-	}
+	a.init()
+	a.active.Store(a)
+	registerInstance(a)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Skip if analytics is disabled
-			if !a.Enabled || a.TrackingID == "" {
+			if cur := a.current(); cur.Enabled && cur.collect != nil && r.URL.Path == cur.collect.path {
+				cur.serveCollect(w, r)
+				return
+			}
+			if trackingPaused.Load() {
 				next.ServeHTTP(w, r)
 				return
 			}
+			a.current().serve(w, r, next)
+		})
+	}
+}
+
+// SetName sets the name the instance is configured under, which prefixes its metrics so that
+// several instances, e.g. with different destinations, can be told apart
+func (a *Analytics) SetName(name string) {
+	a.name = name
+}
+
+// instanceName returns the name the instance is configured under
+func (a *Analytics) instanceName() string {
+	if a.name == "" {
+		return defaultInstanceName
+	}
+	return a.name
+}
+
+// init validates the configuration and creates everything but the dispatcher, which is created by Start
+func (a *Analytics) init() {
+	a.metrics = newInstanceMetrics(a.instanceName())
+	a.initRules()
+	a.latency = newLatencyClasses(a.Latency)
+	a.initCapture()
+	a.secrets = newSecretResolver(a.Secrets)
+	a.initHTTPClient()
+	a.initDestinations()
+	a.initStatsD()
+	a.initGeoIP()
+	a.initClientID()
+	a.sessions = newSessionStore(a.Sessions)
+	a.quotas = newQuotas(a.Quotas, a.HashSDKKey)
+	a.initNotifications()
+}
+
+// current returns the instance holding the active configuration, which is replaced by Reload
+func (a *Analytics) current() *Analytics {
+	if active := a.active.Load(); active != nil {
+		return active
+	}
+	return a
+}
+
+// serve tracks the request and passes it to next
+func (a *Analytics) serve(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	// Skip if analytics is disabled, or not activated for the request's tenant
+	if !a.Enabled || (a.dispatcher == nil && a.statsd == nil) || !a.activation.matches(r) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	// Notifications of the SDK client are forwarded from the first request for its key on,
+	// including those of the request itself
+	a.notifications.subscribe(getSDKKey(r))
+
+	a.auditor.request()
+	route := a.routeSettings(r.URL.Path)
+	if !route.track || !a.paths.allows(r.URL.Path) {
+		a.auditor.count(auditFiltered)
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	startTime := time.Now()
+
+	ctx, span := startRequestSpan(r)
+	defer span.End()
+	r = r.WithContext(ctx)
+	reqID := requestID(r)
+	var rctx *chi.Context
+	if !a.RawPaths {
+		r, rctx = withRouteContext(r)
+	}
+
+	// Create a wrapper for the response writer to capture response details. The body is
+	// only counted, and buffered for responses an enrichment parses.
+	wrappedWriter := newResponseWriter(w, a.maxCapture, a.bodies.errors)
+	defer wrappedWriter.release()
+	if a.bodies.decisions && hasDecisions(r.URL.Path) {
+		wrappedWriter.captureBody()
+	}
+
+	r, annotations := a.annotate(r)
+
+	// Count the request body as the handlers read it, capturing it for analysis when enabled
+	var requestBody []byte
+	var requestBytes *countingReader
+	if r.Body != nil {
+		requestBytes = &countingReader{ReadCloser: r.Body}
+		r.Body = requestBytes
+		if a.bodies.request {
+			requestBody, r.Body = captureBody(r.Body, a.maxCapture)
+		}
+	}
 
-			startTime := time.Now()
+	// Continue with the normal request handling
+	next.ServeHTTP(wrappedWriter, r)
+
+	// Calculate request duration
+	elapsed := time.Since(startTime)
+	duration := elapsed.Milliseconds()
+
+	// Bodies the handlers didn't read in full are counted by their declared length
+	requestSize := r.ContentLength
+	if requestBytes != nil && requestBytes.n > requestSize {
+		requestSize = requestBytes.n
+	}
+	if requestSize < 0 {
+		requestSize = 0
+	}
 
-			// Create a wrapper for the response writer to capture response details
-			responseBuffer := &bytes.Buffer{}
-			wrappedWriter := &responseWriter{
-				ResponseWriter: w,
-				statusCode:     http.StatusOK, // Default status code
-				body:           responseBuffer,
+	a.metrics.requests.Add(1)
+	a.metrics.requestDuration.Observe(float64(duration))
+	a.metrics.responseSize.Observe(float64(wrappedWriter.size))
+
+	path := a.pathLabels.label(routePattern(rctx, r.URL.Path))
+	if a.statsd != nil {
+		a.statsd.emitRequest(r.Method, path, wrappedWriter.statusCode, elapsed)
+	}
+
+	// Prepare the analytics event to send to the backends
+	sdkKey := getSDKKey(r)
+	if sdkKey != "" && a.HashSDKKey {
+		sdkKey = hashSDKKey(sdkKey)
+	}
+	eventName := a.eventNames.render(route.eventName, eventNameVars{method: r.Method, route: path, status: wrappedWriter.statusCode})
+	event := newEvent(eventName, startTime,
+		ClientInfo{
+			ID:        a.getClientID(r, requestBody),
+			IPAddress: getIPAddress(r),
+			UserAgent: r.UserAgent(),
+		},
+		RequestInfo{
+			ID:     reqID,
+			Path:   path,
+			Method: r.Method,
+			SDKKey: sdkKey,
+			Bytes:  requestSize,
+		},
+		ResponseInfo{
+			StatusCode: wrappedWriter.statusCode,
+			Bytes:      wrappedWriter.size,
+			DurationMS: duration,
+		})
+	event.spanContext = span.SpanContext()
+	if wrappedWriter.body != nil && hasDecisions(r.URL.Path) && wrappedWriter.statusCode == http.StatusOK {
+		if decisions, ok := parseResponseDecisions(r.URL.Path, wrappedWriter.body.Bytes()); ok {
+			addDecisionParams(event.Params, decisions)
+		}
+	}
+	addAnnotationParams(&event, annotations, a.HashSDKKey)
+	if a.AgentInternals {
+		event.Params[handlerTimeParam] = elapsed.Microseconds()
+	}
+	a.latency.addParams(event.Params, elapsed, wrappedWriter.statusCode)
+	if a.ErrorDetails && wrappedWriter.statusCode >= http.StatusBadRequest {
+		var body []byte
+		if wrappedWriter.body != nil {
+			body = wrappedWriter.body.Bytes()
+		}
+		addErrorParams(event.Params, wrappedWriter.statusCode, body)
+	}
+	if a.UserIDClaim != "" {
+		event.UserID = authenticatedUserID(r, wrappedWriter.statusCode, a.UserIDClaim)
+	}
+	if a.Residency.enabled() {
+		event.Region = a.Residency.region(r, a.geo)
+	}
+	if a.GeoIP.DatabasePath != "" {
+		addGeoParams(event.Params, a.geo, getIPAddress(r), a.GeoIP.KeepIP)
+	}
+	if a.ParseUserAgent {
+		addUserAgentParams(event.Params, r.UserAgent())
+	}
+	addBodyParams(event.Params, a.BodyParams, requestBody)
+	addDimensions(event.Params, a.dimensions, r)
+	addUserProperties(&event, a.userProps, r)
+	anomalous := a.checkAnomaly(&event, r, wrappedWriter.statusCode)
+	hooked := a.hooks.apply(&event, r)
+	if !hooked {
+		a.metrics.hookFiltered.Add(1)
+	}
+	applyPrivacy(&event, a.privacy, r.URL.RawQuery)
+
+	dispatch := a.dispatcher != nil && hooked && route.generatesEvent(r.Method, wrappedWriter.statusCode)
+	if a.dispatcher != nil && !dispatch {
+		a.auditor.count(auditFiltered)
+	}
+	if dispatch {
+		dispatch = a.applyBots(&event, r) && a.consents(&event, r)
+	}
+	if dispatch {
+		a.sendAnomalyAlert(&event, anomalous)
+	}
+	span.SetAttributes(eventAttributes(event)...)
+
+	// Queue the event for the dispatch workers to not block the response. In aggregation mode
+	// only the rollups are dispatched, at the end of each window.
+	if dispatch && a.Aggregation.Enabled && a.aggregator != nil {
+		a.aggregator.record(event)
+	} else if dispatch {
+		if sampled(route.sampleRate, event.ClientID, a.SampleByClientID) {
+			applySampleRate(&event, route.sampleRate)
+			if a.withinQuota(&event) {
+				if a.sessions != nil {
+					addSessionParams(&event, a.sessions)
+				}
+				a.dispatcher.enqueue(event)
 			}
+		} else {
+			a.metrics.sampledOut.Add(1)
+			a.auditor.count(auditSampledOut)
+		}
+	}
+
+	log.Info().
+		Str("path", r.URL.Path).
+		Str("method", r.Method).
+		Int("status", wrappedWriter.statusCode).
+		Int64("duration_ms", duration).
+		Msg("Analytics tracking sent")
+}
 
-			// Create a copy of the request body for analysis
-			var requestBody []byte
-			if r.Body != nil {
-				requestBody, _ = ioutil.ReadAll(r.Body)
-				// Restore the request body for the next handlers
-				r.Body = ioutil.NopCloser(bytes.NewBuffer(requestBody))
+// consents reports whether the event of the request may be dispatched. Requests opting out are
+// only counted in the aggregate metrics, requests from suppressed countries or without consent
+// are either not dispatched or their event is anonymized.
+func (a *Analytics) consents(event *Event, r *http.Request) bool {
+	if a.HonorDNT && doNotTrack(r) {
+		a.metrics.dntSuppressed.Add(1)
+		a.auditor.count(auditDNTSuppressed)
+		return false
+	}
+	if len(a.GeoSuppression.Countries) > 0 &&
+		a.GeoSuppression.suppressed(clientCountry(r, a.GeoSuppression.CountryHeader, a.geo)) {
+		a.metrics.geoSuppressed.Add(1)
+		if a.GeoSuppression.Mode == suppressSkip {
+			a.auditor.count(auditGeoSuppressed)
+			return false
+		}
+		anonymizeLocation(event)
+	}
+	if a.Consent.enabled() && !a.Consent.consented(r) {
+		a.metrics.consentSuppressed.Add(1)
+		if a.Consent.Mode != suppressAnonymize {
+			a.auditor.count(auditConsentSuppressed)
+			return false
+		}
+		anonymize(event)
+	}
+	return true
+}
+
+// initNotifications creates the bridge forwarding SDK notifications and mirroring events, if enabled
+func (a *Analytics) initNotifications() {
+	var err error
+	if a.notifications, err = newNotificationBridge(a.Notifications, a.MirrorEvents, a.forwardNotification); err != nil {
+		log.Error().Err(err).Msg("Skipping analytics notifications")
+	}
+}
+
+// initRules validates the path filters, route rules, custom params and privacy settings,
+// skipping invalid ones
+func (a *Analytics) initRules() {
+	var errs []error
+	a.paths, errs = newPathFilter(a.IncludePaths, a.ExcludePaths)
+	for _, err := range errs {
+		log.Error().Err(err).Msg("Skipping analytics path filter")
+	}
+	a.activation, errs = newActivation(a.Activation)
+	for _, err := range errs {
+		log.Error().Err(err).Msg("Skipping analytics activation host")
+	}
+	a.statusCodes, errs = validateStatusCodes(a.StatusCodes)
+	for _, err := range errs {
+		log.Error().Err(err).Msg("Skipping analytics status code filter")
+	}
+	a.pathLabels, errs = newPathLabeler(a.PathLabels)
+	for _, err := range errs {
+		log.Error().Err(err).Msg("Skipping analytics path template")
+	}
+	a.dimensions, errs = newDimensions(a.Params)
+	for _, err := range errs {
+		log.Error().Err(err).Msg("Skipping analytics param")
+	}
+	a.userProps, errs = newDimensions(a.UserProperties)
+	for _, err := range errs {
+		log.Error().Err(err).Msg("Skipping analytics user property")
+	}
+
+	var err error
+	if a.privacy, err = validatePrivacy(a.Privacy); err != nil {
+		log.Error().Err(err).Msg("Invalid analytics privacy config")
+	}
+	if a.hooks, err = newHookChain(a.Hooks); err != nil {
+		log.Error().Err(err).Msg("Skipping analytics hooks")
+	}
+	if a.collect, err = newCollector(a.Collect); err != nil {
+		log.Error().Err(err).Msg("Disabling the analytics collect endpoint")
+	}
+	if a.bots, err = newBotDetector(a.Bots); err != nil {
+		log.Error().Err(err).Msg("Disabling analytics bot detection")
+	}
+	if a.anomalies, err = newAnomalyDetector(a.Anomalies); err != nil {
+		log.Error().Err(err).Msg("Disabling analytics anomaly detection")
+	}
+
+	a.rules = nil
+	names := []string{a.EventName}
+	for _, conf := range a.Rules {
+		rule, err := newRouteRule(conf)
+		if err != nil {
+			log.Error().Err(err).Msg("Skipping analytics route rule")
+			continue
+		}
+		a.rules = append(a.rules, rule)
+		names = append(names, rule.EventName)
+	}
+	a.eventNames, errs = newEventNames(names...)
+	a.eventName = a.EventName
+	for _, err := range errs {
+		log.Error().Err(err).Msg("Using the default analytics event name")
+		a.eventName = ""
+	}
+}
+
+// initDestinations builds the configured backends. A non-empty TrackingID or Properties
+// configures the Google Analytics backend in addition to any explicit Destinations.
+func (a *Analytics) initDestinations() {
+	a.destinations = nil
+	if a.TrackingID != "" || len(a.Properties) > 0 {
+		if ga4, err := a.newGA4Backend(); err != nil {
+			log.Error().Err(err).Msg("Failed to create analytics backend")
+		} else {
+			a.destinations = append(a.destinations, destination{name: "ga4", backend: ga4})
+		}
+	}
+
+	for _, conf := range a.Destinations {
+		conf, err := a.secrets.resolveConfig(conf)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create analytics backend")
+			continue
+		}
+		dest, err := newDestination(conf)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create analytics backend")
+			continue
+		}
+		a.destinations = append(a.destinations, dest)
+	}
+
+	for i, dest := range a.destinations {
+		if ga4, ok := dest.backend.(*GA4Backend); ok {
+			ga4.metrics = a.metrics
+			if a.HashSDKKey {
+				ga4.setHashedSDKKeys()
 			}
+		}
+		a.connectDestination(&a.destinations[i])
+	}
+	a.initDryRun()
+}
 
-			// Continue with the normal request handling
-			next.ServeHTTP(wrappedWriter, r)
-
-			// Calculate request duration
-			duration := time.Since(startTime).Milliseconds()
-
-			// Prepare analytics data to send to Google Analytics
-			// This is a simplified version - adjust to your needs
-			eventData := map[string]interface{}{
-				"client_id": getClientID(r),
-				"events": []map[string]interface{}{
-					{
-						"name": "api_request",
-						"params": map[string]interface{}{
-							"path":             r.URL.Path,
-							"method":           r.Method,
-							"status_code":      wrappedWriter.statusCode,
-							"response_time_ms": duration,
-							"user_agent":       r.UserAgent(),
-							"ip_address":       getIPAddress(r),
-						},
-					},
-				},
+// newGA4Backend creates the Google Analytics backend of TrackingID and Properties, resolving
+// their API secrets and checking the protocol settings
+func (a *Analytics) newGA4Backend() (*GA4Backend, error) {
+	apiSecret, err := a.secrets.resolve(a.APISecret)
+	if err != nil {
+		return nil, err
+	}
+	var properties map[string]GA4Property
+	if len(a.Properties) > 0 {
+		properties = make(map[string]GA4Property, len(a.Properties))
+		for sdkKey, p := range a.Properties {
+			if p.APISecret, err = a.secrets.resolve(p.APISecret); err != nil {
+				return nil, fmt.Errorf("GA4 property of SDK key %q: %w", sdkKey, err)
 			}
+			properties[sdkKey] = p
+		}
+	}
+	ga4 := &GA4Backend{
+		MeasurementID:    a.TrackingID,
+		APISecret:        apiSecret,
+		EndpointURL:      a.EndpointURL,
+		Debug:            a.GA4Debug,
+		ValidateEvents:   a.ValidateEvents,
+		ExperimentEvents: a.ExperimentEvents,
+		Protocol:         a.GAProtocol,
+		UA:               a.UA,
+		Properties:       properties,
+	}
+	if err := ga4.checkConfig(); err != nil {
+		return nil, err
+	}
+	return ga4, nil
+}
 
-			// Send data to Google Analytics in a separate goroutine to not block the response
-			go a.sendToGA(eventData)
+// initDryRun wraps the destinations so that their payloads are recorded instead of sent
+func (a *Analytics) initDryRun() {
+	a.dryRun = nil
+	if !a.DryRun {
+		return
+	}
 
-			log.Info().
-				Str("path", r.URL.Path).
-				Str("method", r.Method).
-				Int("status", wrappedWriter.statusCode).
-				Int64("duration_ms", duration).
-				Msg("Analytics tracking sent")
-		})
+	sink, err := newDryRunSink(a.DryRunFile)
+	if err != nil {
+		// Never fall back to sending payloads the configuration asked to hold back
+		log.Error().Err(err).Msg("Failed to open analytics dry-run file, logging payloads instead")
+		sink = &dryRunSink{}
 	}
+	a.dryRun = sink
+	for i, dest := range a.destinations {
+		a.destinations[i].backend = &dryRunBackend{
+			Backend: dest.backend,
+			target:  &dryRunTarget{sink: sink, destination: dest.name},
+		}
+	}
+	log.Info().Int("destinations", len(a.destinations)).Msg("Analytics dry run enabled, payloads will not be sent")
 }
 
-// sendToGA sends event data to Google Analytics
-func (a *Analytics) sendToGA(eventData map[string]interface{}) {
-	// Prepare the URL with the tracking ID
-	url := a.EndpointURL + "?measurement_id=" + a.TrackingID + "&api_secret=YOUR_API_SECRET" // You would need to set this in config
+// initStatsD connects the StatsD emitter when an address is configured
+func (a *Analytics) initStatsD() {
+	a.statsd = nil
+	if a.StatsD.Address == "" {
+		return
+	}
 
-	// Convert event data to JSON
-	jsonData, err := json.Marshal(eventData)
+	emitter, err := newStatsDEmitter(a.StatsD)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to marshal analytics data")
+		log.Error().Err(err).Msg("Failed to create StatsD emitter")
 		return
 	}
+	a.statsd = emitter
+}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create analytics request")
+// initGeoIP opens the GeoIP database when a path is configured
+func (a *Analytics) initGeoIP() {
+	a.geo = nil
+	if a.GeoIP.DatabasePath == "" {
 		return
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	// Send request
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
+	reader, err := newGeoIPReader(a.GeoIP.DatabasePath)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to send analytics data")
+		log.Error().Err(err).Str("path", a.GeoIP.DatabasePath).Msg("Failed to open GeoIP database")
+		return
+	}
+	a.geo = reader
+}
+
+// initClientID creates the configured client ID strategy
+func (a *Analytics) initClientID() {
+	a.fingerprint = newFingerprinter(a.Fingerprint)
+	a.clientID = nil
+	switch a.ClientIDSource.Type {
+	case "":
+		return
+	case fingerprintSource:
+		a.clientID = a.fingerprint
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		body, _ := io.ReadAll(resp.Body)
-		log.Error().
-			Int("status", resp.StatusCode).
-			Str("response", string(body)).
-			Msg("Analytics request failed")
+	strategy, err := newClientIDStrategy(a.ClientIDSource)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create analytics client ID strategy, using the default")
+		return
 	}
+	a.clientID = strategy
 }
 
-// getClientID extracts a client ID from the request
-// In a real implementation, you might use cookies or other identifiers
-func getClientID(r *http.Request) string {
-	// Use a cookie, header, or session ID as the client identifier
+// getClientID derives the client ID with the configured strategy, falling back to the default
+func (a *Analytics) getClientID(r *http.Request, body []byte) string {
+	if a.clientID != nil {
+		if clientID := a.clientID.ClientID(r, body); clientID != "" {
+			return clientID
+		}
+	}
+
+	// Use the GA cookie, falling back to an anonymous fingerprint if no cookie exists
 	cookie, err := r.Cookie("_ga")
 	if err == nil && cookie != nil {
 		return cookie.Value
 	}
-
-	// Fallback to IP + User-Agent hash if no cookie exists
-	// In a real implementation, you would generate a proper UUID
-	return getIPAddress(r) + r.UserAgent()
+	return a.fingerprint.ClientID(r, body)
 }
 
 // getIPAddress extracts the client IP address from the request
@@ -196,7 +671,7 @@ func getIPAddress(r *http.Request) string {
 
 // Register our interceptor as "analytics"
 func init() {
-	interceptors.Add("analytics", func() interceptors.Interceptor {
+	interceptors.Add(defaultInstanceName, func() interceptors.Interceptor {
 		return &Analytics{}
 	})
 }
@@ -17,13 +17,46 @@
 package analytics
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/optimizely/agent/plugins/interceptors"
 )
 
+// mockBackend records the events it receives
+type mockBackend struct {
+	events chan Event
+	err    error
+}
+
+func newMockBackend() *mockBackend {
+	return &mockBackend{events: make(chan Event, 100)}
+}
+
+func (m *mockBackend) Send(ctx context.Context, events []Event) error {
+	for _, e := range events {
+		m.events <- e
+	}
+	return m.err
+}
+
+func (m *mockBackend) next(t *testing.T) Event {
+	select {
+	case e := <-m.events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for analytics event")
+		return Event{}
+	}
+}
+
 func TestAnalyticsInterceptor(t *testing.T) {
 	// Test that our interceptor is properly registered
 	creator, exists := interceptors.Interceptors["analytics"]
@@ -58,7 +91,7 @@ func TestAnalyticsInterceptor(t *testing.T) {
 	// Create a test request
 	req := httptest.NewRequest("GET", "/test-path", nil)
 	req.Header.Set("User-Agent", "Test User Agent")
-	
+
 	// Add a test cookie
 	req.AddCookie(&http.Cookie{
 		Name:  "_ga",
@@ -83,3 +116,68 @@ func TestAnalyticsInterceptor(t *testing.T) {
 	// Note: We don't test the actual GA interaction since it's disabled in tests
 	// In a more comprehensive test setup, you would mock the HTTP client
 }
+
+func TestAnalyticsDispatchesToDestinations(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("POST", "/v1/track", nil)
+	req.AddCookie(&http.Cookie{Name: "_ga", Value: "test-client-id"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := backend.next(t)
+	assert.Equal(t, "api_request", event.Name)
+	assert.Equal(t, "test-client-id", event.ClientID)
+	assert.Equal(t, "/v1/track", event.Params["path"])
+	assert.Equal(t, "POST", event.Params["method"])
+	assert.Equal(t, http.StatusCreated, event.Params["status_code"])
+}
+
+func TestAnalyticsRecordsSizes(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello "))
+		w.Write([]byte("world"))
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/track", strings.NewReader(`{"a":1}`)))
+
+	event := backend.next(t)
+	assert.Equal(t, int64(7), event.Params["request_bytes"])
+	assert.Equal(t, int64(11), event.Params["response_bytes"])
+}
+
+func TestAnalyticsInitDestinations(t *testing.T) {
+	a := &Analytics{
+		TrackingID: "G-TEST123",
+		APISecret:  "secret",
+		Destinations: []BackendConfig{
+			{"type": "snowplow", "collectorURL": "http://collector"},
+			{"type": "unknown"},
+		},
+	}
+	a.initDestinations()
+
+	require.Len(t, a.destinations, 2)
+	assert.Equal(t, "ga4", a.destinations[0].name)
+	assert.Equal(t, &GA4Backend{MeasurementID: "G-TEST123", APISecret: "secret"}, a.destinations[0].backend)
+	assert.Equal(t, "snowplow", a.destinations[1].name)
+}
+
+func TestAnalyticsEmitsStatsDWithoutDestinations(t *testing.T) {
+	conn := listenStatsD(t)
+	a := &Analytics{Enabled: true, StatsD: StatsDConfig{Address: conn.LocalAddr().String()}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+
+	lines := readStatsD(t, conn)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "optimizely.agent.requests.GET.v1_config.200:1|c", lines[0])
+}
@@ -0,0 +1,275 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	anomalyParam           = "anomaly"
+	anomalyRequestsParam   = "requests_per_minute"
+	anomalyZScoreParam     = "z_score"
+	anomalyErrorRatioParam = "error_ratio"
+
+	anomalyRate   = "rate"
+	anomalyErrors = "errors"
+
+	defaultAnomalyZScore      = 3
+	defaultAnomalyErrorRatio  = 0.5
+	defaultAnomalyMinRequests = 30
+	defaultAnomalyMaxClients  = 100000
+)
+
+// AnomalyConfig flags the requests of clients whose traffic stands out, e.g. scraping or abusing
+// the decide API, with an anomaly param and optionally an alert event
+type AnomalyConfig struct {
+	Enabled     bool    `json:"enabled"`
+	ZScore      float64 `json:"zScore"`      // Standard deviations above the mean requests per minute of all clients that are anomalous (defaults to 3)
+	ErrorRatio  float64 `json:"errorRatio"`  // Share of 4xx and 5xx responses of a client that is anomalous (defaults to 0.5)
+	MinRequests int     `json:"minRequests"` // Requests per minute below which a client is never anomalous (defaults to 30)
+	ByIP        bool    `json:"byIP"`        // Tell clients apart by IP address instead of client ID
+	AlertEvent  string  `json:"alertEvent"`  // Name of an event sent when a client turns anomalous, at most once a minute (empty sends none)
+	MaxClients  int     `json:"maxClients"`  // Clients tracked per minute, further ones are not checked (defaults to 100000)
+}
+
+// clientTraffic is the traffic of a client in the current minute
+type clientTraffic struct {
+	requests int
+	errors   int
+	alerted  bool
+}
+
+// anomaly is the outcome of checking a request of a client
+type anomaly struct {
+	kinds      []string
+	requests   int
+	zScore     float64
+	errorRatio float64
+	alert      bool // first anomalous request of the client this minute
+}
+
+// anomalyDetector counts the requests of each client per minute, comparing them to the requests
+// per minute of all clients in the previous minute. A nil detector flags nothing.
+type anomalyDetector struct {
+	zScore      float64
+	errorRatio  float64
+	minRequests int
+	maxClients  int
+	byIP        bool
+	alertEvent  string
+	now         func() time.Time
+
+	mu       sync.Mutex
+	minute   time.Time
+	clients  map[string]*clientTraffic
+	baseline bool    // whether the previous minute had traffic to compare to
+	mean     float64 // requests per minute of the clients of the previous minute
+	stddev   float64
+}
+
+// newAnomalyDetector returns the detector of conf, nil if detection is disabled
+func newAnomalyDetector(conf AnomalyConfig) (*anomalyDetector, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+	switch {
+	case conf.ZScore < 0:
+		return nil, errors.New("zScore must not be negative")
+	case conf.ErrorRatio < 0 || conf.ErrorRatio > 1:
+		return nil, errors.New("errorRatio must be between 0 and 1")
+	case conf.MinRequests < 0 || conf.MaxClients < 0:
+		return nil, errors.New("minRequests and maxClients must not be negative")
+	case conf.AlertEvent != "" && !collectNamePattern.MatchString(conf.AlertEvent):
+		return nil, errors.New("alertEvent must be a valid event name")
+	}
+
+	d := &anomalyDetector{
+		zScore:      conf.ZScore,
+		errorRatio:  conf.ErrorRatio,
+		minRequests: conf.MinRequests,
+		maxClients:  conf.MaxClients,
+		byIP:        conf.ByIP,
+		alertEvent:  conf.AlertEvent,
+		now:         time.Now,
+		clients:     make(map[string]*clientTraffic),
+	}
+	if d.zScore == 0 {
+		d.zScore = defaultAnomalyZScore
+	}
+	if d.errorRatio == 0 {
+		d.errorRatio = defaultAnomalyErrorRatio
+	}
+	if d.minRequests == 0 {
+		d.minRequests = defaultAnomalyMinRequests
+	}
+	if d.maxClients == 0 {
+		d.maxClients = defaultAnomalyMaxClients
+	}
+	return d, nil
+}
+
+// observe counts a request of the client with the response status and checks the client's
+// traffic this minute
+func (d *anomalyDetector) observe(client string, status int) anomaly {
+	if d == nil {
+		return anomaly{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.roll(d.now().Truncate(time.Minute))
+
+	c := d.clients[client]
+	if c == nil {
+		if len(d.clients) >= d.maxClients {
+			return anomaly{}
+		}
+		c = &clientTraffic{}
+		d.clients[client] = c
+	}
+	c.requests++
+	if status >= http.StatusBadRequest {
+		c.errors++
+	}
+	if c.requests < d.minRequests {
+		return anomaly{}
+	}
+
+	result := anomaly{requests: c.requests, errorRatio: float64(c.errors) / float64(c.requests)}
+	if d.baseline {
+		// Requests per minute vary at least like a Poisson process, which keeps few or
+		// uniform clients from making any busier one stand out
+		stddev := math.Max(d.stddev, math.Sqrt(d.mean))
+		result.zScore = (float64(c.requests) - d.mean) / stddev
+		if result.zScore >= d.zScore {
+			result.kinds = append(result.kinds, anomalyRate)
+		}
+	}
+	if result.errorRatio >= d.errorRatio {
+		result.kinds = append(result.kinds, anomalyErrors)
+	}
+	if len(result.kinds) > 0 && !c.alerted {
+		c.alerted = true
+		result.alert = true
+	}
+	return result
+}
+
+// roll starts counting the minute, keeping the mean and standard deviation of the requests per
+// client of the previous minute as the baseline
+func (d *anomalyDetector) roll(minute time.Time) {
+	if minute.Equal(d.minute) {
+		return
+	}
+	d.baseline = minute.Sub(d.minute) == time.Minute && len(d.clients) > 0
+	if d.baseline {
+		var sum, squares float64
+		for _, c := range d.clients {
+			sum += float64(c.requests)
+			squares += float64(c.requests) * float64(c.requests)
+		}
+		n := float64(len(d.clients))
+		d.mean = sum / n
+		d.stddev = math.Sqrt(math.Max(squares/n-d.mean*d.mean, 0))
+	}
+	d.minute = minute
+	d.clients = make(map[string]*clientTraffic, len(d.clients))
+}
+
+// inherit carries the counts and baseline of prev over, so a reload doesn't reset detection
+func (d *anomalyDetector) inherit(prev *anomalyDetector) {
+	if d == nil || prev == nil {
+		return
+	}
+	prev.mu.Lock()
+	defer prev.mu.Unlock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.minute, d.baseline, d.mean, d.stddev = prev.minute, prev.baseline, prev.mean, prev.stddev
+	for client, c := range prev.clients {
+		copied := *c
+		d.clients[client] = &copied
+	}
+}
+
+// checkAnomaly flags the event of an anomalous client, returning the anomaly to alert of once a
+// minute per client
+func (a *Analytics) checkAnomaly(event *Event, r *http.Request, status int) anomaly {
+	if a.anomalies == nil {
+		return anomaly{}
+	}
+
+	client := event.ClientID
+	if a.anomalies.byIP {
+		client = getIPAddress(r)
+	}
+	result := a.anomalies.observe(client, status)
+	if len(result.kinds) == 0 {
+		return result
+	}
+	kinds := strings.Join(result.kinds, ",")
+	event.Params[anomalyParam] = kinds
+	a.metrics.anomalous.Add(1)
+	if result.alert {
+		// The IP address of clients told apart by it is not logged
+		log.Warn().Str("clientID", event.ClientID).Str("anomaly", kinds).Int("requestsPerMinute", result.requests).
+			Float64("errorRatio", result.errorRatio).Msg("Anomalous analytics client traffic")
+	}
+	return result
+}
+
+// sendAnomalyAlert queues the alert event of an anomaly. It is called with the event of the
+// request that raised it once that has passed hooks, privacy, bot and consent checks, so the
+// alert is only sent for requests that are tracked and carries no more than their event.
+func (a *Analytics) sendAnomalyAlert(event *Event, result anomaly) {
+	if !result.alert || a.anomalies.alertEvent == "" || a.dispatcher == nil {
+		return
+	}
+	alert := Event{
+		Name:      a.anomalies.alertEvent,
+		ClientID:  event.ClientID,
+		UserID:    event.UserID,
+		Region:    event.Region,
+		RequestID: event.RequestID,
+		Timestamp: event.Timestamp,
+		Params: map[string]interface{}{
+			schemaVersionParam:     eventSchemaVersion,
+			anomalyParam:           event.Params[anomalyParam],
+			anomalyRequestsParam:   result.requests,
+			anomalyZScoreParam:     math.Round(result.zScore*100) / 100,
+			anomalyErrorRatioParam: math.Round(result.errorRatio*100) / 100,
+			pathParam:              event.Params[pathParam],
+		},
+	}
+	// The client details are those left by the enrichment, privacy and consent, e.g. without the
+	// IP address with GeoIP or anonymization
+	for _, name := range []string{ipAddressParam, userAgentParam, sdkKeyParam} {
+		if v, ok := event.Params[name]; ok {
+			alert.Params[name] = v
+		}
+	}
+	if a.withinQuota(&alert) {
+		a.dispatcher.enqueue(alert)
+	}
+}
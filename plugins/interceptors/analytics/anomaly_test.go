@@ -0,0 +1,180 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAnomalyDetector returns a detector of conf on a clock set by the returned func
+func testAnomalyDetector(t *testing.T, conf AnomalyConfig) (*anomalyDetector, func(time.Time)) {
+	conf.Enabled = true
+	d, err := newAnomalyDetector(conf)
+	require.NoError(t, err)
+	now := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, func(t time.Time) { now = t }
+}
+
+func TestNewAnomalyDetector(t *testing.T) {
+	d, err := newAnomalyDetector(AnomalyConfig{ZScore: 5})
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+	assert.Equal(t, anomaly{}, d.observe("client-1", http.StatusOK))
+
+	d, err = newAnomalyDetector(AnomalyConfig{Enabled: true})
+	require.NoError(t, err)
+	assert.Equal(t, float64(defaultAnomalyZScore), d.zScore)
+	assert.Equal(t, defaultAnomalyErrorRatio, d.errorRatio)
+	assert.Equal(t, defaultAnomalyMinRequests, d.minRequests)
+	assert.Equal(t, defaultAnomalyMaxClients, d.maxClients)
+
+	for _, conf := range []AnomalyConfig{
+		{Enabled: true, ZScore: -1},
+		{Enabled: true, ErrorRatio: 1.5},
+		{Enabled: true, MinRequests: -1},
+		{Enabled: true, AlertEvent: "traffic anomaly"},
+	} {
+		_, err := newAnomalyDetector(conf)
+		assert.Error(t, err, conf)
+	}
+}
+
+func TestAnomalyDetectorRate(t *testing.T) {
+	d, setNow := testAnomalyDetector(t, AnomalyConfig{})
+	start := d.now()
+
+	// Without a previous minute to compare to, no rate is anomalous
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			assert.Empty(t, d.observe(fmt.Sprintf("client-%d", i), http.StatusOK).kinds)
+		}
+	}
+
+	setNow(start.Add(time.Minute + 5*time.Second))
+	for i := 1; i < defaultAnomalyMinRequests; i++ {
+		assert.Empty(t, d.observe("abuser", http.StatusOK).kinds)
+	}
+	result := d.observe("abuser", http.StatusOK)
+	assert.Equal(t, []string{anomalyRate}, result.kinds)
+	assert.Equal(t, defaultAnomalyMinRequests, result.requests)
+	assert.Greater(t, result.zScore, float64(defaultAnomalyZScore))
+	assert.True(t, result.alert)
+	result = d.observe("abuser", http.StatusOK)
+	assert.Equal(t, []string{anomalyRate}, result.kinds)
+	assert.False(t, result.alert)
+
+	// After a minute without traffic there is no baseline again
+	setNow(start.Add(3 * time.Minute))
+	for i := 0; i < 40; i++ {
+		assert.Empty(t, d.observe("abuser", http.StatusOK).kinds)
+	}
+}
+
+func TestAnomalyDetectorErrors(t *testing.T) {
+	d, _ := testAnomalyDetector(t, AnomalyConfig{MinRequests: 4, ErrorRatio: 0.75})
+	for _, status := range []int{http.StatusOK, http.StatusNotFound, http.StatusTooManyRequests} {
+		assert.Empty(t, d.observe("client-1", status).kinds)
+	}
+	result := d.observe("client-1", http.StatusUnauthorized)
+	assert.Equal(t, []string{anomalyErrors}, result.kinds)
+	assert.Equal(t, 0.75, result.errorRatio)
+	assert.True(t, result.alert)
+	assert.Empty(t, d.observe("client-1", http.StatusOK).kinds)
+}
+
+func TestAnomalyDetectorMaxClients(t *testing.T) {
+	d, _ := testAnomalyDetector(t, AnomalyConfig{MinRequests: 1, MaxClients: 1})
+	assert.NotEmpty(t, d.observe("client-1", http.StatusInternalServerError).kinds)
+	assert.Empty(t, d.observe("client-2", http.StatusInternalServerError).kinds)
+	assert.Len(t, d.clients, 1)
+}
+
+func TestAnomalyDetectorInherit(t *testing.T) {
+	prev, _ := testAnomalyDetector(t, AnomalyConfig{MinRequests: 2})
+	prev.observe("client-1", http.StatusInternalServerError)
+
+	next, _ := testAnomalyDetector(t, AnomalyConfig{MinRequests: 2})
+	next.inherit(prev)
+	assert.Equal(t, []string{anomalyErrors}, next.observe("client-1", http.StatusInternalServerError).kinds)
+	assert.Equal(t, 1, prev.clients["client-1"].requests)
+}
+
+func TestAnalyticsAnomalies(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Anomalies: AnomalyConfig{
+		Enabled: true, ByIP: true, MinRequests: 2, AlertEvent: "traffic_anomaly",
+	}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+	defer unregisterInstance(a)
+
+	before := expvarValue("counter.analytics.requests.anomalous")
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("POST", "/v1/decide", nil)
+		req.Header.Set("X-Optimizely-SDK-Key", "sdk-key")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.NotContains(t, backend.next(t).Params, anomalyParam)
+	alert := backend.next(t)
+	assert.Equal(t, "traffic_anomaly", alert.Name)
+	assert.Equal(t, anomalyErrors, alert.Params[anomalyParam])
+	assert.Equal(t, 2, alert.Params[anomalyRequestsParam])
+	assert.Equal(t, 1.0, alert.Params[anomalyErrorRatioParam])
+	assert.Equal(t, "sdk-key", alert.Params[sdkKeyParam])
+	assert.Equal(t, "/v1/decide", alert.Params[pathParam])
+	for i := 0; i < 2; i++ {
+		event := backend.next(t)
+		assert.Equal(t, "api_request", event.Name)
+		assert.Equal(t, anomalyErrors, event.Params[anomalyParam])
+	}
+	assert.Equal(t, before+2, expvarValue("counter.analytics.requests.anomalous"))
+}
+
+func TestAnalyticsAnomalyAlertNotTracked(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, HonorDNT: true, Anomalies: AnomalyConfig{
+		Enabled: true, ByIP: true, MinRequests: 2, AlertEvent: "traffic_anomaly",
+	}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+	defer unregisterInstance(a)
+
+	// The requests opting out of tracking are flagged, but raise no alert event
+	before := expvarValue("counter.analytics.requests.anomalous")
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("POST", "/v1/decide", nil)
+		req.Header.Set("DNT", "1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	require.NoError(t, a.dispatcher.close(context.Background()))
+	assert.Empty(t, backend.events)
+	assert.Equal(t, before+2, expvarValue("counter.analytics.requests.anomalous"))
+}
@@ -0,0 +1,192 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+// AuditConfig periodically records how many requests were tracked and how many events were not
+// dispatched and why, so data teams can quantify gaps in tracking coverage
+type AuditConfig struct {
+	Interval utils.Duration `json:"interval"` // Length of an audit period (0 disables the audit log)
+	File     string         `json:"file"`     // Append the records to this file as JSON lines instead of logging them
+}
+
+// auditReason is a reason for an event not being dispatched
+type auditReason int
+
+const (
+	auditFiltered          auditReason = iota // excluded by the path, method, status code or route filters
+	auditSampledOut                           // not selected by sampling
+	auditDNTSuppressed                        // skipped for DNT or Sec-GPC
+	auditConsentSuppressed                    // skipped for missing consent
+	auditGeoSuppressed                        // skipped for the client's country
+	auditBot                                  // excluded as a request of a bot
+	auditOverQuota                            // dropped over the budget of the tenant
+	auditRateLimited                          // dropped over the rate limit
+	auditDuplicate                            // dropped as a duplicate of a recent event
+	auditDropped                              // dropped because the queue was full or closed
+	numAuditReasons
+)
+
+// auditRecord counts the requests tracked in a period and the events not dispatched, by reason
+type auditRecord struct {
+	Start             time.Time `json:"start"`
+	End               time.Time `json:"end"`
+	Requests          int64     `json:"requests"`
+	Filtered          int64     `json:"filtered"`
+	SampledOut        int64     `json:"sampledOut"`
+	DNTSuppressed     int64     `json:"dntSuppressed"`
+	ConsentSuppressed int64     `json:"consentSuppressed"`
+	GeoSuppressed     int64     `json:"geoSuppressed"`
+	Bots              int64     `json:"bots"`
+	OverQuota         int64     `json:"overQuota"`
+	RateLimited       int64     `json:"rateLimited"`
+	Duplicates        int64     `json:"duplicates"`
+	Dropped           int64     `json:"dropped"`
+}
+
+// auditor counts the events that were not dispatched and writes a record of the counts every
+// interval, to the log or, with a file configured, as one JSON record per line. A nil auditor
+// counts nothing.
+type auditor struct {
+	interval time.Duration
+	file     *os.File
+
+	requests atomic.Int64
+	counts   [numAuditReasons]atomic.Int64
+
+	mu    sync.Mutex // serializes flushes
+	start time.Time
+
+	done      chan struct{}
+	stopped   sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// newAuditor starts writing the audit records of conf, or returns nil if the audit log is disabled
+func newAuditor(conf AuditConfig) *auditor {
+	if conf.Interval.Duration <= 0 {
+		return nil
+	}
+	u := &auditor{
+		interval: conf.Interval.Duration,
+		start:    time.Now(),
+		done:     make(chan struct{}),
+	}
+	if conf.File != "" {
+		file, err := os.OpenFile(conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to open analytics audit file, logging audit records")
+		} else {
+			u.file = file
+		}
+	}
+
+	u.stopped.Add(1)
+	go u.run()
+	return u
+}
+
+func (u *auditor) run() {
+	defer u.stopped.Done()
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			u.flush(now)
+		case <-u.done:
+			return
+		}
+	}
+}
+
+// request counts a request considered for tracking
+func (u *auditor) request() {
+	if u != nil {
+		u.requests.Add(1)
+	}
+}
+
+// count counts an event that was not dispatched for reason
+func (u *auditor) count(reason auditReason) {
+	if u != nil {
+		u.counts[reason].Add(1)
+	}
+}
+
+// flush writes the record of the period ending at now and starts the next one
+func (u *auditor) flush(now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	rec := auditRecord{
+		Start:             u.start.UTC(),
+		End:               now.UTC(),
+		Requests:          u.requests.Swap(0),
+		Filtered:          u.counts[auditFiltered].Swap(0),
+		SampledOut:        u.counts[auditSampledOut].Swap(0),
+		DNTSuppressed:     u.counts[auditDNTSuppressed].Swap(0),
+		ConsentSuppressed: u.counts[auditConsentSuppressed].Swap(0),
+		GeoSuppressed:     u.counts[auditGeoSuppressed].Swap(0),
+		Bots:              u.counts[auditBot].Swap(0),
+		OverQuota:         u.counts[auditOverQuota].Swap(0),
+		RateLimited:       u.counts[auditRateLimited].Swap(0),
+		Duplicates:        u.counts[auditDuplicate].Swap(0),
+		Dropped:           u.counts[auditDropped].Swap(0),
+	}
+	u.start = now
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode analytics audit record")
+		return
+	}
+	if u.file == nil {
+		log.Info().RawJSON("audit", line).Msg("Analytics audit")
+		return
+	}
+	if _, err := u.file.Write(append(line, '\n')); err != nil {
+		log.Error().Err(err).Msg("Failed to write analytics audit record")
+	}
+}
+
+// close stops the audit log, writing the record of the partial period
+func (u *auditor) close() {
+	if u == nil {
+		return
+	}
+	u.closeOnce.Do(func() {
+		close(u.done)
+		u.stopped.Wait()
+		u.flush(time.Now())
+		if u.file != nil {
+			if err := u.file.Close(); err != nil {
+				log.Warn().Err(err).Msg("Failed to close analytics audit file")
+			}
+		}
+	})
+}
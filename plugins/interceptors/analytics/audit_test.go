@@ -0,0 +1,150 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+// readAuditRecords returns the records of an audit file
+func readAuditRecords(t *testing.T, path string) []auditRecord {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []auditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec auditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestNewAuditorDisabled(t *testing.T) {
+	u := newAuditor(AuditConfig{})
+	assert.Nil(t, u)
+
+	// A nil auditor counts nothing
+	u.request()
+	u.count(auditDropped)
+	u.close()
+}
+
+func TestAuditorWritesRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	u := newAuditor(AuditConfig{Interval: utils.Duration{Duration: time.Hour}, File: path})
+	require.NotNil(t, u)
+
+	for i := 0; i < 5; i++ {
+		u.request()
+	}
+	u.count(auditFiltered)
+	u.count(auditSampledOut)
+	u.count(auditSampledOut)
+	u.count(auditDropped)
+	end := u.start.Add(time.Minute)
+	u.flush(end)
+
+	u.count(auditConsentSuppressed)
+	u.close()
+	u.close()
+
+	records := readAuditRecords(t, path)
+	require.Len(t, records, 2)
+	assert.Equal(t, end.UTC(), records[0].End)
+	assert.Equal(t, auditRecord{
+		Start:      records[0].Start,
+		End:        records[0].End,
+		Requests:   5,
+		Filtered:   1,
+		SampledOut: 2,
+		Dropped:    1,
+	}, records[0])
+
+	// The partial period is written on close
+	assert.Equal(t, records[0].End, records[1].Start)
+	assert.EqualValues(t, 1, records[1].ConsentSuppressed)
+	assert.Zero(t, records[1].Requests)
+}
+
+func TestAuditorFlushesEveryInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	u := newAuditor(AuditConfig{Interval: utils.Duration{Duration: 10 * time.Millisecond}, File: path})
+	u.count(auditRateLimited)
+
+	assert.Eventually(t, func() bool {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		data, err := os.ReadFile(path)
+		return err == nil && len(data) > 0
+	}, time.Second, 10*time.Millisecond)
+	u.close()
+
+	records := readAuditRecords(t, path)
+	require.NotEmpty(t, records)
+	assert.EqualValues(t, 1, records[0].RateLimited)
+}
+
+func TestAnalyticsAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	backend := newMockBackend()
+	a := &Analytics{
+		Enabled:      true,
+		HonorDNT:     true,
+		ExcludePaths: []string{"/health"},
+		Methods:      []string{"GET"},
+		Audit:        AuditConfig{Interval: utils.Duration{Duration: time.Hour}, File: path},
+	}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.destinations = []destination{{name: "mock", backend: backend}}
+	require.NoError(t, a.Start(context.Background()))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/tracked", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/decide", nil))
+	optedOut := httptest.NewRequest("GET", "/v1/dnt", nil)
+	optedOut.Header.Set("DNT", "1")
+	handler.ServeHTTP(httptest.NewRecorder(), optedOut)
+	backend.next(t)
+
+	require.NoError(t, a.Stop(context.Background()))
+	// Events are not counted after the audit log is closed
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/late", nil))
+
+	records := readAuditRecords(t, path)
+	require.Len(t, records, 1)
+	assert.EqualValues(t, 4, records[0].Requests)
+	assert.EqualValues(t, 2, records[0].Filtered)
+	assert.EqualValues(t, 1, records[0].DNTSuppressed)
+	assert.Zero(t, records[0].Dropped)
+}
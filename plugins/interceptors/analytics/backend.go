@@ -0,0 +1,224 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+// Event is a single analytics event produced from an intercepted request
+type Event struct {
+	Name           string
+	ClientID       string
+	Params         map[string]interface{}
+	UserID         string                 `json:",omitempty"` // authenticated user, for backends with user-level reporting
+	UserProperties map[string]interface{} `json:",omitempty"` // attributes of the user rather than the event, e.g. plan
+	Region         string                 `json:",omitempty"` // data residency region of the client, used for routing
+	RequestID      string                 `json:",omitempty"` // ID of the originating request, sent with its deliveries
+	Timestamp      time.Time              // start of the originating request
+	IdempotencyKey string                 `json:"-"` // identifies the delivery to a destination, set by sendWithRetry
+
+	spanContext trace.SpanContext // span of the originating request
+}
+
+// Backend delivers analytics events to a destination
+type Backend interface {
+	Send(ctx context.Context, events []Event) error
+}
+
+// BackendCreator defines a function for creating an instance of a Backend
+type BackendCreator func() Backend
+
+// Backends stores the mapping of backend type against BackendCreator
+var Backends = map[string]BackendCreator{}
+
+// AddBackend registers a BackendCreator against a backend type
+func AddBackend(backendType string, creator BackendCreator) {
+	if _, ok := Backends[backendType]; ok {
+		panic(fmt.Sprintf("Analytics backend with type %q already exists", backendType))
+	}
+	Backends[backendType] = creator
+}
+
+// BackendConfig holds the settings for a single destination. The "type" key selects
+// the registered backend, the optional "name" key labels it in logs, the optional
+// "transforms" key reshapes the events sent to it and the optional "tls" key replaces
+// the TLS settings of its connections.
+type BackendConfig map[string]interface{}
+
+// configChecker is implemented by backends that check their settings once populated
+type configChecker interface {
+	checkConfig() error
+}
+
+// destination is a configured backend along with its display name
+type destination struct {
+	name        string
+	backend     Backend
+	breaker     *circuitBreaker
+	stats       *destinationStats
+	transforms  transformChain
+	tlsConfig   *tls.Config  // TLS settings of the destination's own connections, if any
+	compression string       // Content-Encoding of the destination's HTTP payloads, if any
+	client      *http.Client // client of the destination's own, created for tlsConfig or compression
+}
+
+// newDestination creates the backend selected by conf and populates it from the remaining settings
+func newDestination(conf BackendConfig) (destination, error) {
+	backendType, _ := conf["type"].(string)
+	creator, ok := Backends[backendType]
+	if !ok {
+		return destination{}, fmt.Errorf("analytics backend not found: %q", backendType)
+	}
+
+	name, _ := conf["name"].(string)
+	if name == "" {
+		name = backendType
+	}
+
+	backend := creator()
+	settings, err := json.Marshal(conf)
+	if err != nil {
+		return destination{}, err
+	}
+	if err := json.Unmarshal(settings, backend); err != nil {
+		return destination{}, fmt.Errorf("invalid config for analytics backend %q: %w", name, err)
+	}
+
+	var common struct {
+		Transforms  []Transform `json:"transforms"`
+		TLS         *TLSConfig  `json:"tls"`
+		Compression string      `json:"compression"`
+	}
+	if err := json.Unmarshal(settings, &common); err != nil {
+		return destination{}, fmt.Errorf("invalid config for analytics backend %q: %w", name, err)
+	}
+	transforms, err := newTransformChain(common.Transforms)
+	if err != nil {
+		return destination{}, fmt.Errorf("invalid config for analytics backend %q: %w", name, err)
+	}
+
+	if err := checkCompression(common.Compression); err != nil {
+		return destination{}, fmt.Errorf("invalid config for analytics backend %q: %w", name, err)
+	}
+
+	if c, ok := backend.(configChecker); ok {
+		if err := c.checkConfig(); err != nil {
+			return destination{}, fmt.Errorf("invalid config for analytics backend %q: %w", name, err)
+		}
+	}
+
+	dest := destination{name: name, backend: backend, transforms: transforms}
+	if common.Compression != "none" {
+		dest.compression = common.Compression
+	}
+	if common.TLS != nil {
+		if dest.tlsConfig, err = common.TLS.config(); err != nil {
+			return destination{}, fmt.Errorf("invalid tls config for analytics backend %q: %w", name, err)
+		}
+		if dest.tlsConfig == nil {
+			dest.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+	}
+	return dest, nil
+}
+
+// StatusError is returned by HTTP backends when the destination responds with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("analytics request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// defaultHTTPClient is used by HTTP backends that were not given the configured client
+var defaultHTTPClient = &http.Client{Timeout: 5 * time.Second}
+
+// postJSON marshals payload and POSTs it to url, returning a StatusError for non-2xx responses.
+// A nil client uses defaultHTTPClient.
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}, headers map[string]string) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return post(ctx, client, url, "application/json", jsonData, headers)
+}
+
+// post sends body to url with the given content type, returning a StatusError for non-2xx responses.
+// A nil client uses defaultHTTPClient.
+func post(ctx context.Context, client *http.Client, url, contentType string, body []byte, headers map[string]string) error {
+	_, err := postResponse(ctx, client, url, contentType, body, headers)
+	return err
+}
+
+// postResponse is like post but also returns the body of successful responses.
+// In dry-run mode the payload is recorded instead of sent and the response body is nil.
+func postResponse(ctx context.Context, client *http.Client, url, contentType string, body []byte, headers map[string]string) ([]byte, error) {
+	return send(ctx, client, http.MethodPost, url, contentType, body, headers)
+}
+
+// send is like postResponse with the given request method
+func send(ctx context.Context, client *http.Client, method, url, contentType string, body []byte, headers map[string]string) ([]byte, error) {
+	if target := dryRunFrom(ctx); target != nil {
+		return nil, target.record(url, contentType, body)
+	}
+
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if id := requestIDFrom(ctx); id != "" {
+		req.Header.Set(middleware.OptlyRequestHeader, id)
+	}
+	injectTraceContext(ctx, req.Header)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return respBody, err
+}
@@ -0,0 +1,91 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddBackendPanicsOnDuplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		AddBackend("ga4", func() Backend { return &GA4Backend{} })
+	})
+}
+
+func TestNewDestination(t *testing.T) {
+	dest, err := newDestination(BackendConfig{
+		"type":         "snowplow",
+		"name":         "warehouse",
+		"collectorURL": "http://collector",
+		"appID":        "agent",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "warehouse", dest.name)
+
+	backend, ok := dest.backend.(*SnowplowBackend)
+	require.True(t, ok)
+	assert.Equal(t, "http://collector", backend.CollectorURL)
+	assert.Equal(t, "agent", backend.AppID)
+}
+
+func TestNewDestinationDefaultsNameToType(t *testing.T) {
+	dest, err := newDestination(BackendConfig{"type": "ga4"})
+	require.NoError(t, err)
+	assert.Equal(t, "ga4", dest.name)
+}
+
+func TestNewDestinationUnknownType(t *testing.T) {
+	_, err := newDestination(BackendConfig{"type": "unknown"})
+	assert.Error(t, err)
+}
+
+func TestNewDestinationTLS(t *testing.T) {
+	dest, err := newDestination(BackendConfig{"type": "webhook", "url": "https://collector", "tls": map[string]interface{}{"serverName": "collector.internal"}})
+	require.NoError(t, err)
+	require.NotNil(t, dest.tlsConfig)
+	assert.Equal(t, "collector.internal", dest.tlsConfig.ServerName)
+
+	// TLS settings that are present but empty still give the destination connections of its own
+	dest, err = newDestination(BackendConfig{"type": "webhook", "url": "https://collector", "tls": map[string]interface{}{}})
+	require.NoError(t, err)
+	assert.NotNil(t, dest.tlsConfig)
+
+	_, err = newDestination(BackendConfig{"type": "webhook", "url": "https://collector", "tls": map[string]interface{}{"certFile": "client.pem"}})
+	assert.Error(t, err)
+}
+
+func TestPostJSONStatusError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "value", r.Header.Get("X-Test"))
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("bad payload"))
+	}))
+	defer ts.Close()
+
+	err := postJSON(context.Background(), ts.Client(), ts.URL, map[string]string{}, map[string]string{"X-Test": "value"})
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	assert.Equal(t, "bad payload", statusErr.Body)
+}
@@ -0,0 +1,95 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+package analytics
+
+import (
+	"time"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+// defaultBatchMaxEvents is the most events sent to a destination at once by default
+const defaultBatchMaxEvents = 100
+
+// BatchConfig bounds the batches the dispatch workers send to each destination. A worker takes
+// the queued events up to maxEvents, waiting up to flushInterval for more, and sends each
+// destination the events of the batch routed to it in a single call.
+type BatchConfig struct {
+	MaxEvents     int            `json:"maxEvents"`     // Most events sent to a destination at once (defaults to 100, 1 disables batching)
+	FlushInterval utils.Duration `json:"flushInterval"` // Longest a batch waits to fill up (defaults to 0, sending the events already queued)
+}
+
+// maxEvents returns the size bound of the batches
+func (c BatchConfig) maxEvents() int {
+	if c.MaxEvents <= 0 {
+		return defaultBatchMaxEvents
+	}
+	return c.MaxEvents
+}
+
+// nextBatch waits for the next event to deliver and adds the events queued after it, waiting up
+// to the flush interval for them. It returns false once both lanes are closed and drained.
+func (d *dispatcher) nextBatch() ([]Event, bool) {
+	event, ok := d.next()
+	if !ok {
+		return nil, false
+	}
+	batch := []Event{event}
+
+	var timeout <-chan time.Time
+	if interval := d.batch.FlushInterval.Duration; interval > 0 {
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for len(batch) < d.batch.maxEvents() {
+		event, ok := d.poll(timeout)
+		if !ok {
+			break
+		}
+		batch = append(batch, event)
+	}
+	return batch, true
+}
+
+// poll takes a queued event, preferring the high priority lane, waiting until timeout fires for
+// one, or not at all when timeout is nil. It returns false on timeout or once a lane is closed,
+// so that a draining dispatcher doesn't wait.
+func (d *dispatcher) poll(timeout <-chan time.Time) (Event, bool) {
+	select {
+	case event, ok := <-d.queue:
+		return event, ok
+	default:
+	}
+	if d.lowQueue != nil {
+		select {
+		case event, ok := <-d.lowQueue:
+			return event, ok
+		default:
+		}
+	}
+	if timeout == nil {
+		return Event{}, false
+	}
+	select {
+	case event, ok := <-d.queue:
+		return event, ok
+	case event, ok := <-d.lowQueue:
+		return event, ok
+	case <-timeout:
+		return Event{}, false
+	}
+}
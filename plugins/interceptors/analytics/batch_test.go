@@ -0,0 +1,107 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func TestNextBatchTakesQueuedEvents(t *testing.T) {
+	d := &dispatcher{queue: make(chan Event, 10), batch: BatchConfig{MaxEvents: 3}}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		d.queue <- Event{Name: name}
+	}
+
+	batch, ok := d.nextBatch()
+	assert.True(t, ok)
+	assert.Equal(t, []Event{{Name: "a"}, {Name: "b"}, {Name: "c"}}, batch)
+
+	batch, ok = d.nextBatch()
+	assert.True(t, ok)
+	assert.Equal(t, []Event{{Name: "d"}}, batch)
+}
+
+func TestNextBatchWaitsForFlushInterval(t *testing.T) {
+	d := &dispatcher{queue: make(chan Event, 10), batch: BatchConfig{MaxEvents: 2, FlushInterval: utils.Duration{Duration: time.Second}}}
+	d.queue <- Event{Name: "a"}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		d.queue <- Event{Name: "b"}
+	}()
+
+	batch, ok := d.nextBatch()
+	assert.True(t, ok)
+	assert.Equal(t, []Event{{Name: "a"}, {Name: "b"}}, batch)
+}
+
+func TestNextBatchSendsPartialBatchAfterFlushInterval(t *testing.T) {
+	d := &dispatcher{queue: make(chan Event, 10), batch: BatchConfig{FlushInterval: utils.Duration{Duration: 20 * time.Millisecond}}}
+	d.queue <- Event{Name: "a"}
+
+	start := time.Now()
+	batch, ok := d.nextBatch()
+	assert.True(t, ok)
+	assert.Equal(t, []Event{{Name: "a"}}, batch)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestNextBatchStopsWhenQueueCloses(t *testing.T) {
+	d := &dispatcher{queue: make(chan Event, 10)}
+	d.queue <- Event{Name: "a"}
+	close(d.queue)
+
+	batch, ok := d.nextBatch()
+	assert.True(t, ok)
+	assert.Equal(t, []Event{{Name: "a"}}, batch)
+
+	_, ok = d.nextBatch()
+	assert.False(t, ok)
+}
+
+func TestDispatcherSendsBatches(t *testing.T) {
+	backend := &batchBackend{sizes: make(chan int, 10)}
+	d := newDispatcher([]destination{{name: "batch", backend: backend}}, dispatcherOptions{
+		workers: 1,
+		batch:   BatchConfig{MaxEvents: 3, FlushInterval: utils.Duration{Duration: time.Second}},
+	}, newAnalyticsMetrics())
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, d.enqueue(Event{Name: "api_request"}))
+	}
+	select {
+	case size := <-backend.sizes:
+		assert.Equal(t, 3, size)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the batch")
+	}
+}
+
+// batchBackend records the number of events of every call
+type batchBackend struct {
+	sizes chan int
+}
+
+func (b *batchBackend) Send(_ context.Context, events []Event) error {
+	b.sizes <- len(events)
+	return nil
+}
@@ -0,0 +1,128 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// maxAddedAllocs is the regression threshold of the allocations the interceptor adds to a
+// request with the default configuration. Raise it deliberately when a change needs more.
+const maxAddedAllocs = 100
+
+// discardBackend drops the events it receives
+type discardBackend struct{}
+
+func (discardBackend) Send(ctx context.Context, events []Event) error {
+	return nil
+}
+
+// benchmarkConfigs are the interceptor configurations benchmarked against a bare handler
+var benchmarkConfigs = map[string]func() *Analytics{
+	"disabled": func() *Analytics { return &Analytics{} },
+	"enabled":  func() *Analytics { return &Analytics{Enabled: true} },
+	"captured bodies": func() *Analytics {
+		return &Analytics{
+			Enabled:             true,
+			BodyParams:          map[string]string{"user": "userId"},
+			CaptureRequestBody:  true,
+			CaptureResponseBody: true,
+			EnrichDecisions:     true,
+			ErrorDetails:        true,
+		}
+	},
+}
+
+// benchmarkHandler returns the handler of a /v1/decide response wrapped by a, with its events
+// delivered to a discarding backend. Logging is disabled so that only the interceptor is measured.
+func benchmarkHandler(tb testing.TB, a *Analytics) http.Handler {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	tb.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"flagKey":"checkout","variationKey":"on","ruleKey":"rollout","enabled":true}]`))
+	})
+	if a == nil {
+		return api
+	}
+	handler := a.Handler()(api)
+	if a.Enabled {
+		a.dispatcher = newDispatcher([]destination{{name: "discard", backend: discardBackend{}}}, dispatcherOptions{queueSize: 10000}, a.metrics)
+		tb.Cleanup(func() { a.dispatcher.close(context.Background()) })
+	}
+	return handler
+}
+
+func benchmarkRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/decide", strings.NewReader(`{"userId":"user"}`))
+	r.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15")
+	r.Header.Set("X-Optimizely-SDK-Key", "sdk-key")
+	return r
+}
+
+func BenchmarkHandler(b *testing.B) {
+	b.Run("baseline", func(b *testing.B) {
+		benchmarkServe(b, benchmarkHandler(b, nil))
+	})
+	for name, config := range benchmarkConfigs {
+		config := config
+		b.Run(name, func(b *testing.B) {
+			benchmarkServe(b, benchmarkHandler(b, config()))
+		})
+	}
+}
+
+func BenchmarkHandlerParallel(b *testing.B) {
+	handler := benchmarkHandler(b, &Analytics{Enabled: true})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			handler.ServeHTTP(httptest.NewRecorder(), benchmarkRequest())
+		}
+	})
+}
+
+func benchmarkServe(b *testing.B, handler http.Handler) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), benchmarkRequest())
+	}
+}
+
+func TestHandlerAddedAllocations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping allocation threshold in short mode")
+	}
+	allocs := func(handler http.Handler) float64 {
+		return testing.AllocsPerRun(200, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), benchmarkRequest())
+		})
+	}
+	baseline := allocs(benchmarkHandler(t, nil))
+	enabled := allocs(benchmarkHandler(t, &Analytics{Enabled: true}))
+	assert.LessOrEqual(t, enabled-baseline, float64(maxAddedAllocs), "allocations added per request")
+}
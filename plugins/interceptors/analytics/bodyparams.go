@@ -0,0 +1,42 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"github.com/tidwall/gjson"
+)
+
+// addBodyParams extracts event params from a JSON request body. paths maps each param name to
+// a gjson path (e.g. "userContext.attributes.plan"); objects and arrays are added as raw JSON
+// and params the body doesn't carry are omitted.
+func addBodyParams(params map[string]interface{}, paths map[string]string, body []byte) {
+	if len(paths) == 0 || !gjson.ValidBytes(body) {
+		return
+	}
+
+	for param, path := range paths {
+		result := gjson.GetBytes(body, path)
+		switch result.Type {
+		case gjson.Null:
+			continue
+		case gjson.JSON:
+			params[param] = result.Raw
+		default:
+			params[param] = result.Value()
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const decideRequestBody = `{"userId":"user-1","userAttributes":{"plan":"pro","seats":5,"beta":true},"decideOptions":["ENABLED_FLAGS_ONLY"]}`
+
+func TestAddBodyParams(t *testing.T) {
+	params := map[string]interface{}{}
+	addBodyParams(params, map[string]string{
+		"user_id": "userId",
+		"plan":    "userAttributes.plan",
+		"seats":   "userAttributes.seats",
+		"beta":    "userAttributes.beta",
+		"options": "decideOptions",
+		"missing": "userAttributes.region",
+	}, []byte(decideRequestBody))
+
+	assert.Equal(t, map[string]interface{}{
+		"user_id": "user-1",
+		"plan":    "pro",
+		"seats":   5.0,
+		"beta":    true,
+		"options": `["ENABLED_FLAGS_ONLY"]`,
+	}, params)
+}
+
+func TestAddBodyParamsIgnoresInvalidJSON(t *testing.T) {
+	params := map[string]interface{}{}
+	addBodyParams(params, map[string]string{"user_id": "userId"}, []byte("userId=user-1"))
+	assert.Empty(t, params)
+}
+
+func TestAnalyticsBodyParams(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, CaptureRequestBody: true, BodyParams: map[string]string{"plan": "userAttributes.plan"}}
+	var forwarded string
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := &bytes.Buffer{}
+		_, _ = buf.ReadFrom(r.Body)
+		forwarded = buf.String()
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/decide", bytes.NewBufferString(decideRequestBody)))
+
+	assert.Equal(t, "pro", backend.next(t).Params["plan"])
+	assert.Equal(t, decideRequestBody, forwarded)
+}
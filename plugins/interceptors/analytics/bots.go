@@ -0,0 +1,263 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	botTag     = "tag"
+	botExclude = "exclude"
+
+	isBotParam = "is_bot"
+
+	defaultBotListRefresh = 24 * time.Hour
+	maxBotListBytes       = 4 << 20
+)
+
+// defaultBotUserAgents matches the user agents of common crawlers, link previewers, headless
+// browsers and uptime monitors. HTTP libraries such as curl or Go-http-client are not matched,
+// since the SDKs calling the agent use them too.
+var defaultBotUserAgents = regexp.MustCompile(`(?i)bot\b|crawl|spider|slurp|facebookexternalhit|` +
+	`headlesschrome|phantomjs|lighthouse|pingdom|uptimerobot|statuscake|site24x7`)
+
+// BotConfig detects the requests of crawlers and other bots, whose events GA does not filter out
+// when they are sent through the Measurement Protocol
+type BotConfig struct {
+	Mode            string         `json:"mode"`            // "tag" adds is_bot to the events of bots, "exclude" drops them (empty disables detection)
+	UserAgents      []string       `json:"userAgents"`      // Extra case-insensitive patterns of bot user agents
+	IPRanges        []string       `json:"ipRanges"`        // Addresses or CIDR ranges of known crawlers
+	ListURL         string         `json:"listURL"`         // URL of a list of ranges and user agent patterns, one per line
+	RefreshInterval utils.Duration `json:"refreshInterval"` // How often the list is fetched again (defaults to 24h)
+}
+
+// botList is a set of user agent patterns and address ranges of bots
+type botList struct {
+	userAgents []*regexp.Regexp
+	ranges     []*net.IPNet
+}
+
+// add adds an entry of a bot list, an address or CIDR range, or else a user agent pattern
+func (l *botList) add(entry string) error {
+	if ipNet, err := parseIPRange(entry); err == nil {
+		l.ranges = append(l.ranges, ipNet)
+		return nil
+	}
+	re, err := regexp.Compile("(?i)" + entry)
+	if err != nil {
+		return fmt.Errorf("invalid user agent pattern %q: %w", entry, err)
+	}
+	l.userAgents = append(l.userAgents, re)
+	return nil
+}
+
+// matches reports whether the user agent or IP address are those of a bot
+func (l *botList) matches(userAgent string, ip net.IP) bool {
+	for _, re := range l.userAgents {
+		if re.MatchString(userAgent) {
+			return true
+		}
+	}
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range l.ranges {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// botDetector tells the requests of bots apart by the configured and fetched lists. A nil
+// detector detects no bots.
+type botDetector struct {
+	mode     string
+	static   botList
+	listURL  string
+	interval time.Duration
+	remote   atomic.Pointer[botList]
+}
+
+// newBotDetector returns the detector of conf, nil if detection is disabled
+func newBotDetector(conf BotConfig) (*botDetector, error) {
+	switch conf.Mode {
+	case "":
+		return nil, nil
+	case botTag, botExclude:
+	default:
+		return nil, fmt.Errorf("invalid mode %q, expected %q or %q", conf.Mode, botTag, botExclude)
+	}
+
+	d := &botDetector{
+		mode:     conf.Mode,
+		static:   botList{userAgents: []*regexp.Regexp{defaultBotUserAgents}},
+		listURL:  conf.ListURL,
+		interval: conf.RefreshInterval.Duration,
+	}
+	for _, pattern := range conf.UserAgents {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user agent pattern %q: %w", pattern, err)
+		}
+		d.static.userAgents = append(d.static.userAgents, re)
+	}
+	for _, r := range conf.IPRanges {
+		ipNet, err := parseIPRange(r)
+		if err != nil {
+			return nil, err
+		}
+		d.static.ranges = append(d.static.ranges, ipNet)
+	}
+	if d.listURL != "" {
+		if u, err := url.Parse(d.listURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid list URL %q", d.listURL)
+		}
+	}
+	if d.interval <= 0 {
+		d.interval = defaultBotListRefresh
+	}
+	return d, nil
+}
+
+// parseIPRange parses an address or CIDR range, an address matching only itself
+func parseIPRange(s string) (*net.IPNet, error) {
+	if _, ipNet, err := net.ParseCIDR(s); err == nil {
+		return ipNet, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP range %q", s)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// isBot reports whether r is a request of a bot
+func (d *botDetector) isBot(r *http.Request) bool {
+	if d == nil {
+		return false
+	}
+	ua := r.UserAgent()
+	ip := net.ParseIP(strings.TrimSpace(getIPAddress(r)))
+	if d.static.matches(ua, ip) {
+		return true
+	}
+	remote := d.remote.Load()
+	return remote != nil && remote.matches(ua, ip)
+}
+
+// inherit keeps the list prev fetched from the same URL until the list is fetched again
+func (d *botDetector) inherit(prev *botDetector) {
+	if d == nil || prev == nil || d.listURL == "" || d.listURL != prev.listURL {
+		return
+	}
+	d.remote.Store(prev.remote.Load())
+}
+
+// fetch fetches the list of the detector, skipping the entries that can't be parsed
+func (d *botDetector) fetch(ctx context.Context, client *http.Client) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.listURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d fetching the bot list", resp.StatusCode)
+	}
+
+	list := &botList{}
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxBotListBytes))
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if err := list.add(entry); err != nil {
+			log.Warn().Err(err).Str("url", d.listURL).Msg("Skipping bot list entry")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	d.remote.Store(list)
+	return nil
+}
+
+// applyBots tags the event of a bot request, returning false if it is to be excluded instead
+func (a *Analytics) applyBots(event *Event, r *http.Request) bool {
+	if !a.bots.isBot(r) {
+		return true
+	}
+	if a.bots.mode == botExclude {
+		a.metrics.botExcluded.Add(1)
+		a.auditor.count(auditBot)
+		return false
+	}
+	event.Params[isBotParam] = true
+	return true
+}
+
+// refresh returns the list URL and refresh interval of the detector, empty if it has no list
+func (d *botDetector) refresh() (string, time.Duration) {
+	if d == nil || d.listURL == "" {
+		return "", 0
+	}
+	return d.listURL, d.interval
+}
+
+// refreshBots fetches the bot list of the active config right away and then every interval
+// until ctx is done. A failed fetch keeps the previous list.
+func (a *Analytics) refreshBots(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		cur := a.current()
+		if cur.bots != nil && cur.bots.listURL != "" {
+			if err := cur.bots.fetch(ctx, cur.httpClient); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Str("url", cur.bots.listURL).Msg("Failed to fetch the bot list, keeping the previous one")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,187 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chromeUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36"
+
+func botRequest(userAgent, ip string) *http.Request {
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Forwarded-For", ip)
+	return req
+}
+
+func TestNewBotDetector(t *testing.T) {
+	d, err := newBotDetector(BotConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, d)
+	assert.False(t, d.isBot(botRequest("Googlebot/2.1", "203.0.113.7")))
+
+	d, err = newBotDetector(BotConfig{Mode: botTag})
+	require.NoError(t, err)
+	assert.Equal(t, defaultBotListRefresh, d.interval)
+
+	for _, conf := range []BotConfig{
+		{Mode: "drop"},
+		{Mode: botTag, UserAgents: []string{"("}},
+		{Mode: botTag, IPRanges: []string{"203.0.113.0/33"}},
+		{Mode: botTag, ListURL: "file:///etc/bots"},
+	} {
+		_, err := newBotDetector(conf)
+		assert.Error(t, err, conf)
+	}
+}
+
+func TestBotDetectorIsBot(t *testing.T) {
+	d, err := newBotDetector(BotConfig{
+		Mode:       botExclude,
+		UserAgents: []string{"acme-scanner"},
+		IPRanges:   []string{"66.249.64.0/19", "198.51.100.9"},
+	})
+	require.NoError(t, err)
+
+	for ua, bot := range map[string]bool{
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)": true,
+		"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)":  true,
+		"Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)":       true,
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)":               true,
+		"facebookexternalhit/1.1":          true,
+		"Mozilla/5.0 HeadlessChrome/124.0": true,
+		"ACME-Scanner/3.1":                 true,
+		chromeUserAgent:                    false,
+		"curl/8.4.0":                       false,
+		"Go-http-client/1.1":               false,
+		"Optimizely/Python 5.0":            false,
+	} {
+		assert.Equal(t, bot, d.isBot(botRequest(ua, "203.0.113.7")), ua)
+	}
+	assert.True(t, d.isBot(botRequest(chromeUserAgent, "66.249.66.1")))
+	assert.True(t, d.isBot(botRequest(chromeUserAgent, "198.51.100.9")))
+	assert.False(t, d.isBot(botRequest(chromeUserAgent, "198.51.100.10")))
+}
+
+func TestBotDetectorFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "# known crawlers\n\n192.0.2.0/24\n2001:db8::1\nExampleCrawler\n(\n")
+	}))
+	defer server.Close()
+
+	d, err := newBotDetector(BotConfig{Mode: botTag, ListURL: server.URL})
+	require.NoError(t, err)
+	assert.False(t, d.isBot(botRequest(chromeUserAgent, "192.0.2.10")))
+
+	require.NoError(t, d.fetch(context.Background(), server.Client()))
+	assert.True(t, d.isBot(botRequest(chromeUserAgent, "192.0.2.10")))
+	assert.True(t, d.isBot(botRequest(chromeUserAgent, "2001:db8::1")))
+	assert.True(t, d.isBot(botRequest("examplecrawler/1.0", "203.0.113.7")))
+	assert.False(t, d.isBot(botRequest(chromeUserAgent, "203.0.113.7")))
+
+	next, err := newBotDetector(BotConfig{Mode: botExclude, ListURL: server.URL})
+	require.NoError(t, err)
+	next.inherit(d)
+	assert.True(t, next.isBot(botRequest(chromeUserAgent, "192.0.2.10")))
+
+	other, err := newBotDetector(BotConfig{Mode: botTag, ListURL: server.URL + "/other"})
+	require.NoError(t, err)
+	other.inherit(d)
+	assert.False(t, other.isBot(botRequest(chromeUserAgent, "192.0.2.10")))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+	d.listURL = failing.URL
+	assert.Error(t, d.fetch(context.Background(), failing.Client()))
+	assert.True(t, d.isBot(botRequest(chromeUserAgent, "192.0.2.10")))
+}
+
+func TestAnalyticsBots(t *testing.T) {
+	for _, mode := range []string{botTag, botExclude} {
+		backend := newMockBackend()
+		a := &Analytics{Enabled: true, Bots: BotConfig{Mode: mode}}
+		handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+		before := expvarValue("counter.analytics.requests.botExcluded")
+		handler.ServeHTTP(httptest.NewRecorder(), botRequest("Googlebot/2.1", "203.0.113.7"))
+		handler.ServeHTTP(httptest.NewRecorder(), botRequest(chromeUserAgent, "203.0.113.7"))
+
+		event := backend.next(t)
+		if mode == botTag {
+			assert.Equal(t, true, event.Params[isBotParam])
+			assert.Equal(t, before, expvarValue("counter.analytics.requests.botExcluded"))
+			event = backend.next(t)
+		} else {
+			assert.Equal(t, before+1, expvarValue("counter.analytics.requests.botExcluded"))
+		}
+		assert.NotContains(t, event.Params, isBotParam)
+		unregisterInstance(a)
+	}
+}
+
+func TestAnalyticsRefreshBots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "192.0.2.0/24\n")
+	}))
+	defer server.Close()
+
+	a := &Analytics{Enabled: true, Bots: BotConfig{Mode: botExclude, ListURL: server.URL}}
+	a.Handler()
+	require.NoError(t, a.Start(context.Background()))
+	assert.Eventually(t, func() bool {
+		return a.current().bots.isBot(botRequest(chromeUserAgent, "192.0.2.10"))
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, a.Stop(context.Background()))
+	assert.Nil(t, a.stopBots)
+}
+
+func TestAnalyticsRefreshBotsReloaded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "192.0.2.0/24\n")
+	}))
+	defer server.Close()
+
+	a := &Analytics{Enabled: true, Bots: BotConfig{Mode: botExclude}}
+	a.Handler()
+	require.NoError(t, a.Start(context.Background()))
+	defer a.Stop(context.Background())
+	assert.Nil(t, a.stopBots)
+
+	// A list URL added by a reload is fetched right away
+	require.NoError(t, a.Reload(context.Background(), []byte(`{"enabled": true, "bots": {"mode": "exclude", "listURL": "`+server.URL+`"}}`)))
+	assert.Eventually(t, func() bool {
+		return a.current().bots.isBot(botRequest(chromeUserAgent, "192.0.2.10"))
+	}, time.Second, 10*time.Millisecond)
+
+	// and removing it stops the refresher
+	require.NoError(t, a.Reload(context.Background(), []byte(`{"enabled": true, "bots": {"mode": "exclude"}}`)))
+	a.lifecycleMu.Lock()
+	assert.Nil(t, a.stopBots)
+	a.lifecycleMu.Unlock()
+}
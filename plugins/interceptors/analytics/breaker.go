@@ -0,0 +1,141 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const defaultBreakerCooldown = 30 * time.Second
+
+// CircuitBreakerConfig configures the per destination circuit breaker
+type CircuitBreakerConfig struct {
+	FailureThreshold int            `json:"failureThreshold"` // Consecutive failed deliveries that open the breaker (0 disables it)
+	Cooldown         utils.Duration `json:"cooldown"`         // Time the breaker stays open before a trial delivery (defaults to 30s)
+}
+
+// breakerState is the state of a circuit breaker, exposed as a gauge value
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// String returns the state name reported by the admin API
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker short-circuits deliveries to a destination that keeps failing. After the
+// cooldown a single trial delivery is let through: success closes the breaker, failure reopens it.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(breakerState)
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+// newCircuitBreaker returns nil when the breaker is disabled by the config
+func newCircuitBreaker(conf CircuitBreakerConfig, onChange func(breakerState)) *circuitBreaker {
+	if conf.FailureThreshold <= 0 {
+		return nil
+	}
+
+	cooldown := conf.Cooldown.Duration
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+
+	return &circuitBreaker{
+		threshold: conf.FailureThreshold,
+		cooldown:  cooldown,
+		onChange:  onChange,
+		now:       time.Now,
+	}
+}
+
+// allow reports whether a delivery may be attempted
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(breakerHalfOpen)
+		return true
+	case breakerHalfOpen:
+		// Only the trial delivery is allowed until its outcome is recorded
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of a delivery
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.failures = 0
+		b.setState(breakerClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.setState(breakerOpen)
+	}
+}
+
+// currentState returns the state of the breaker
+func (b *circuitBreaker) currentState() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *circuitBreaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(state)
+	}
+}
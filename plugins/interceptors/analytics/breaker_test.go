@@ -0,0 +1,96 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	assert.Nil(t, newCircuitBreaker(CircuitBreakerConfig{}, nil))
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	var states []breakerState
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		Cooldown:         utils.Duration{Duration: time.Minute},
+	}, func(state breakerState) { states = append(states, state) })
+	require.NotNil(t, b)
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	assert.True(t, b.allow())
+	b.record(false)
+	assert.Equal(t, breakerClosed, b.currentState())
+	b.record(false)
+	assert.Equal(t, breakerOpen, b.currentState())
+	assert.False(t, b.allow())
+
+	// After the cooldown a single trial delivery is allowed
+	now = now.Add(time.Minute)
+	assert.True(t, b.allow())
+	assert.Equal(t, breakerHalfOpen, b.currentState())
+	assert.False(t, b.allow())
+
+	// A failed trial reopens the breaker
+	b.record(false)
+	assert.Equal(t, breakerOpen, b.currentState())
+	assert.False(t, b.allow())
+
+	// A successful trial closes it
+	now = now.Add(time.Minute)
+	assert.True(t, b.allow())
+	b.record(true)
+	assert.Equal(t, breakerClosed, b.currentState())
+	assert.True(t, b.allow())
+
+	assert.Equal(t, []breakerState{breakerOpen, breakerHalfOpen, breakerOpen, breakerHalfOpen, breakerClosed}, states)
+}
+
+func TestCircuitBreakerResetsFailuresOnSuccess(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2}, nil)
+	b.record(false)
+	b.record(true)
+	b.record(false)
+	assert.Equal(t, breakerClosed, b.currentState())
+}
+
+func TestDispatcherShortCircuitsOpenBreaker(t *testing.T) {
+	backend := newMockBackend()
+	backend.err = errors.New("unavailable")
+	d := newDispatcher([]destination{{name: "breaker-test", backend: backend}}, dispatcherOptions{
+		breaker: CircuitBreakerConfig{FailureThreshold: 1, Cooldown: utils.Duration{Duration: time.Hour}},
+	}, newAnalyticsMetrics())
+
+	before := expvarValue("counter.analytics.dispatch.shortCircuited")
+	d.deliver([]Event{{Name: "api_request"}})
+	backend.next(t)
+	assert.Equal(t, float64(breakerOpen), expvarValue("gauge.analytics.breaker.breaker_test"))
+
+	d.deliver([]Event{{Name: "api_request"}})
+	assert.Empty(t, backend.events)
+	assert.Equal(t, before+1, expvarValue("counter.analytics.dispatch.shortCircuited"))
+}
@@ -0,0 +1,97 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/rs/zerolog/log"
+)
+
+// defaultMaxCaptureBytes is the largest body buffered for analysis by default
+const defaultMaxCaptureBytes = 64 << 10
+
+// bodyNeeds records which enrichments read request and response bodies. It's decided once when
+// the interceptor is configured, so that requests only count body bytes unless an enabled
+// enrichment reads the content.
+type bodyNeeds struct {
+	request   bool // bodyParams and body client IDs read the request body
+	decisions bool // enrichDecisions reads /v1/decide and /v1/activate response bodies
+	errors    bool // errorDetails reads the body of error responses
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read reads from the original body, counting the bytes read
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// captureBody reads up to max bytes of body for analysis. It returns the captured bytes, or nil
+// when the body is larger than max, along with a body that replays them followed by the rest,
+// so that the body is never held in memory beyond max.
+func captureBody(body io.ReadCloser, max int64) ([]byte, io.ReadCloser) {
+	captured, err := io.ReadAll(io.LimitReader(body, max+1))
+	restored := struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), body), body}
+	if err != nil || int64(len(captured)) > max {
+		return nil, restored
+	}
+	return captured, restored
+}
+
+// initCapture applies the body capture limit, decides which bodies are captured and warns about
+// settings that need a captured body
+func (a *Analytics) initCapture() {
+	a.maxCapture = a.MaxCaptureBytes
+	if a.maxCapture <= 0 {
+		a.maxCapture = defaultMaxCaptureBytes
+	}
+	a.bodies = bodyNeeds{
+		request:   a.CaptureRequestBody && (len(a.BodyParams) > 0 || a.ClientIDSource.Type == "body"),
+		decisions: a.CaptureResponseBody && a.EnrichDecisions,
+		errors:    a.CaptureResponseBody && a.ErrorDetails,
+	}
+
+	if !a.CaptureRequestBody && len(a.BodyParams) > 0 {
+		log.Warn().Msg("Analytics bodyParams require captureRequestBody and are ignored")
+	}
+	if !a.CaptureRequestBody && a.ClientIDSource.Type == "body" {
+		log.Warn().Msg("Analytics body client IDs require captureRequestBody and are ignored")
+	}
+	if !a.CaptureResponseBody && a.EnrichDecisions {
+		log.Warn().Msg("Analytics enrichDecisions requires captureResponseBody and is ignored")
+	}
+	if !a.CaptureResponseBody && a.ErrorDetails {
+		log.Warn().Msg("Analytics errorDetails without captureResponseBody only records error codes")
+	}
+	if a.CaptureRequestBody && !a.bodies.request {
+		log.Info().Msg("Analytics captureRequestBody is unused without bodyParams or body client IDs; request bodies are only counted")
+	}
+	if a.CaptureResponseBody && !a.bodies.decisions && !a.bodies.errors {
+		log.Info().Msg("Analytics captureResponseBody is unused without enrichDecisions or errorDetails; response bodies are only counted")
+	}
+}
@@ -0,0 +1,128 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureBody(t *testing.T) {
+	captured, body := captureBody(io.NopCloser(strings.NewReader("hello")), 5)
+	assert.Equal(t, "hello", string(captured))
+	rest, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(rest))
+
+	// Bodies over the limit aren't captured but still reach the handlers in full
+	captured, body = captureBody(io.NopCloser(strings.NewReader("hello world")), 5)
+	assert.Nil(t, captured)
+	rest, err = io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(rest))
+}
+
+func TestInitCaptureBodyNeeds(t *testing.T) {
+	for name, tc := range map[string]struct {
+		analytics *Analytics
+		expected  bodyNeeds
+	}{
+		"disabled":                    {analytics: &Analytics{}},
+		"capture without enrichments": {analytics: &Analytics{CaptureRequestBody: true, CaptureResponseBody: true}},
+		"enrichments without capture": {analytics: &Analytics{BodyParams: map[string]string{"plan": "plan"}, EnrichDecisions: true, ErrorDetails: true}},
+		"body params":                 {analytics: &Analytics{CaptureRequestBody: true, BodyParams: map[string]string{"plan": "plan"}}, expected: bodyNeeds{request: true}},
+		"body client IDs":             {analytics: &Analytics{CaptureRequestBody: true, ClientIDSource: ClientIDSource{Type: "body"}}, expected: bodyNeeds{request: true}},
+		"decisions":                   {analytics: &Analytics{CaptureResponseBody: true, EnrichDecisions: true}, expected: bodyNeeds{decisions: true}},
+		"error details":               {analytics: &Analytics{CaptureResponseBody: true, ErrorDetails: true}, expected: bodyNeeds{errors: true}},
+		"decisions and error details": {analytics: &Analytics{CaptureResponseBody: true, EnrichDecisions: true, ErrorDetails: true}, expected: bodyNeeds{decisions: true, errors: true}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.analytics.initCapture()
+			assert.Equal(t, tc.expected, tc.analytics.bodies)
+		})
+	}
+}
+
+func TestAnalyticsCountsUnusedCapturedBodies(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, CaptureRequestBody: true, CaptureResponseBody: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Request bodies nobody reads aren't buffered ahead of the handler
+		assert.IsType(t, &countingReader{}, r.Body)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+		assert.Nil(t, w.(*responseWriter).body)
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/decide", strings.NewReader(`{"userId":"u"}`)))
+
+	event := backend.next(t)
+	assert.Equal(t, int64(21), event.Params[responseBytesParam])
+}
+
+func TestResponseWriterDropsOversizedBody(t *testing.T) {
+	rw := &responseWriter{ResponseWriter: httptest.NewRecorder(), body: &bytes.Buffer{}, maxBody: 8}
+	rw.Write([]byte("hello"))
+	assert.Equal(t, "hello", rw.body.String())
+	rw.Write([]byte(" world"))
+	assert.Nil(t, rw.body)
+	assert.Equal(t, int64(11), rw.size)
+}
+
+func TestAnalyticsCaptureRequestBody(t *testing.T) {
+	body := `{"userAttributes":{"plan":"pro"}}`
+	for name, tc := range map[string]struct {
+		capture  bool
+		maxBytes int64
+		plan     interface{}
+	}{
+		"disabled":       {plan: nil},
+		"enabled":        {capture: true, plan: "pro"},
+		"over the limit": {capture: true, maxBytes: 10, plan: nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			backend := newMockBackend()
+			a := &Analytics{
+				Enabled:            true,
+				BodyParams:         map[string]string{"plan": "userAttributes.plan"},
+				CaptureRequestBody: tc.capture,
+				MaxCaptureBytes:    tc.maxBytes,
+			}
+			var received string
+			handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				received = string(b)
+			}))
+			a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/decide", strings.NewReader(body)))
+
+			event := backend.next(t)
+			assert.Equal(t, body, received)
+			assert.Equal(t, tc.plan, event.Params["plan"])
+			assert.Equal(t, int64(len(body)), event.Params["request_bytes"])
+		})
+	}
+}
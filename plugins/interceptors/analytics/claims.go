@@ -0,0 +1,64 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// bearerClaims returns the claims of the request's bearer token without verifying it. Tokens
+// of requests to authenticated routes are verified by the agent's auth middleware; analytics
+// only uses the claims for reporting.
+func bearerClaims(r *http.Request) jwt.MapClaims {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return nil
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(strings.TrimSpace(auth[7:]), claims); err != nil {
+		return nil
+	}
+	return claims
+}
+
+// authenticatedUserID returns the claim of the request's bearer token as the user ID. Requests
+// the auth middleware rejected carry no user ID.
+func authenticatedUserID(r *http.Request, status int, claim string) string {
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return ""
+	}
+	return claimString(bearerClaims(r), claim)
+}
+
+// claimString returns a claim as a string, formatting non-string scalar values
+func claimString(claims jwt.MapClaims, name string) string {
+	switch v := claims[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64, bool:
+		return fmt.Sprint(v)
+	default:
+		return ""
+	}
+}
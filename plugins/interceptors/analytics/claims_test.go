@@ -0,0 +1,83 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withBearerToken adds a signed bearer token with the claims to the request
+func withBearerToken(t *testing.T, r *http.Request, claims jwt.MapClaims) *http.Request {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestBearerClaims(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	assert.Nil(t, bearerClaims(req))
+
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	assert.Nil(t, bearerClaims(req))
+
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	assert.Nil(t, bearerClaims(req))
+
+	withBearerToken(t, req, jwt.MapClaims{"sub": "user-1"})
+	assert.Equal(t, "user-1", bearerClaims(req)["sub"])
+}
+
+func TestClaimString(t *testing.T) {
+	claims := jwt.MapClaims{"sub": "user-1", "id": 42.0, "admin": true, "roles": []interface{}{"a"}}
+	assert.Equal(t, "user-1", claimString(claims, "sub"))
+	assert.Equal(t, "42", claimString(claims, "id"))
+	assert.Equal(t, "true", claimString(claims, "admin"))
+	assert.Equal(t, "", claimString(claims, "roles"))
+	assert.Equal(t, "", claimString(claims, "missing"))
+	assert.Equal(t, "", claimString(nil, "sub"))
+}
+
+func TestAuthenticatedUserID(t *testing.T) {
+	req := withBearerToken(t, httptest.NewRequest("GET", "/v1/config", nil), jwt.MapClaims{"sub": "user-1"})
+	assert.Equal(t, "user-1", authenticatedUserID(req, http.StatusOK, "sub"))
+	assert.Equal(t, "user-1", authenticatedUserID(req, http.StatusBadRequest, "sub"))
+	assert.Equal(t, "", authenticatedUserID(req, http.StatusUnauthorized, "sub"))
+	assert.Equal(t, "", authenticatedUserID(req, http.StatusForbidden, "sub"))
+	assert.Equal(t, "", authenticatedUserID(req, http.StatusOK, "email"))
+}
+
+func TestAnalyticsUserIDClaim(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, UserIDClaim: "sub"}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := withBearerToken(t, httptest.NewRequest("GET", "/v1/config", nil), jwt.MapClaims{"sub": "user-1"})
+	req.AddCookie(&http.Cookie{Name: "_ga", Value: "client-1"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := backend.next(t)
+	assert.Equal(t, "user-1", event.UserID)
+	assert.Equal(t, "client-1", event.ClientID)
+}
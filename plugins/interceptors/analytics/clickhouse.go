@@ -0,0 +1,181 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultClickHouseTable = "optimizely_agent_events"
+	clickHouseTimeFormat   = "2006-01-02 15:04:05.000"
+)
+
+// clickHouseIdentifier matches the table names accepted by the clickhouse backend, optionally
+// qualified by their database
+var clickHouseIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// ClickHouseBackend inserts events into a ClickHouse table through its HTTP interface, one
+// INSERT per batch in the JSONEachRow format. Inserts are asynchronous by default, so that
+// ClickHouse buffers the small batches of the agent into larger parts.
+type ClickHouseBackend struct {
+	URL        string `json:"url"`        // Base URL of the HTTP interface, e.g. http://clickhouse:8123
+	Database   string `json:"database"`   // Optional: defaults to the default database of the user
+	Table      string `json:"table"`      // Optional: defaults to optimizely_agent_events
+	Username   string `json:"username"`   // Optional
+	Password   string `json:"password"`   // Optional
+	SyncInsert bool   `json:"syncInsert"` // Insert synchronously instead of with async inserts
+
+	client *http.Client
+}
+
+// clickHouseRow is a row of the events table
+type clickHouseRow struct {
+	Timestamp      string `json:"timestamp"`
+	Name           string `json:"name"`
+	SchemaVersion  int    `json:"schema_version"`
+	ClientID       string `json:"client_id"`
+	UserID         string `json:"user_id"`
+	Region         string `json:"region"`
+	RequestID      string `json:"request_id"`
+	Path           string `json:"path"`
+	Method         string `json:"method"`
+	SDKKey         string `json:"sdk_key"`
+	StatusCode     int    `json:"status_code"`
+	DurationMS     int64  `json:"duration_ms"`
+	RequestBytes   int64  `json:"request_bytes"`
+	ResponseBytes  int64  `json:"response_bytes"`
+	Params         string `json:"params"`          // all params, as a JSON object
+	UserProperties string `json:"user_properties"` // as a JSON object
+}
+
+func (c *ClickHouseBackend) checkConfig() error {
+	if c.URL == "" {
+		return errors.New("url is required")
+	}
+	if c.Table != "" && !clickHouseIdentifier.MatchString(c.Table) {
+		return fmt.Errorf("invalid table name: %q", c.Table)
+	}
+	return nil
+}
+
+// Send inserts the events with a single INSERT query
+func (c *ClickHouseBackend) Send(ctx context.Context, events []Event) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, e := range events {
+		row, err := clickHouseRowOf(e)
+		if err != nil {
+			return err
+		}
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return post(ctx, c.client, c.insertURL(), "application/x-ndjson", body.Bytes(), c.headers())
+}
+
+// insertURL returns the URL of the INSERT query, with its settings
+func (c *ClickHouseBackend) insertURL() string {
+	table := c.Table
+	if table == "" {
+		table = defaultClickHouseTable
+	}
+	quoted := "`" + strings.ReplaceAll(table, ".", "`.`") + "`"
+
+	query := url.Values{}
+	query.Set("query", "INSERT INTO "+quoted+" FORMAT JSONEachRow")
+	if c.Database != "" {
+		query.Set("database", c.Database)
+	}
+	// Columns the table does not have are skipped, so it may keep only some of them
+	query.Set("input_format_skip_unknown_fields", "1")
+	if !c.SyncInsert {
+		// Waiting for the buffer to be flushed reports failed inserts, so that they are retried
+		query.Set("async_insert", "1")
+		query.Set("wait_for_async_insert", "1")
+	}
+	return strings.TrimSuffix(c.URL, "/") + "/?" + query.Encode()
+}
+
+func (c *ClickHouseBackend) headers() map[string]string {
+	if c.Username == "" {
+		return nil
+	}
+	return map[string]string{
+		"X-ClickHouse-User": c.Username,
+		"X-ClickHouse-Key":  c.Password,
+	}
+}
+
+func clickHouseRowOf(e Event) (clickHouseRow, error) {
+	event := canonicalEvent(e)
+	row := clickHouseRow{
+		Timestamp:      event.Timestamp.Format(clickHouseTimeFormat),
+		Name:           event.Name,
+		SchemaVersion:  event.SchemaVersion,
+		ClientID:       event.Client.ID,
+		UserID:         event.Client.UserID,
+		Region:         event.Client.Region,
+		RequestID:      event.Request.ID,
+		Path:           event.Request.Path,
+		Method:         event.Request.Method,
+		SDKKey:         event.Request.SDKKey,
+		StatusCode:     event.Response.StatusCode,
+		DurationMS:     event.Response.DurationMS,
+		RequestBytes:   event.Request.Bytes,
+		ResponseBytes:  event.Response.Bytes,
+		Params:         "{}",
+		UserProperties: "{}",
+	}
+	if row.RequestID == "" {
+		row.RequestID = e.RequestID
+	}
+	if len(e.Params) > 0 {
+		params, err := json.Marshal(e.Params)
+		if err != nil {
+			return clickHouseRow{}, err
+		}
+		row.Params = string(params)
+	}
+	if len(e.UserProperties) > 0 {
+		props, err := json.Marshal(e.UserProperties)
+		if err != nil {
+			return clickHouseRow{}, err
+		}
+		row.UserProperties = string(props)
+	}
+	return row, nil
+}
+
+func (c *ClickHouseBackend) setHTTPClient(client *http.Client) {
+	c.client = client
+}
+
+func init() {
+	AddBackend("clickhouse", func() Backend {
+		return &ClickHouseBackend{}
+	})
+}
@@ -0,0 +1,109 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickHouseBackendSend(t *testing.T) {
+	var rows []clickHouseRow
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "INSERT INTO `analytics`.`events` FORMAT JSONEachRow", query.Get("query"))
+		assert.Equal(t, "1", query.Get("async_insert"))
+		assert.Equal(t, "1", query.Get("wait_for_async_insert"))
+		assert.Equal(t, "1", query.Get("input_format_skip_unknown_fields"))
+		assert.Equal(t, "agent", r.Header.Get("X-ClickHouse-User"))
+		assert.Equal(t, "secret", r.Header.Get("X-ClickHouse-Key"))
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row clickHouseRow
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+			rows = append(rows, row)
+		}
+	}))
+	defer ts.Close()
+
+	backend := &ClickHouseBackend{URL: ts.URL, Table: "analytics.events", Username: "agent", Password: "secret"}
+	require.NoError(t, backend.checkConfig())
+	timestamp := time.Date(2025, 6, 1, 12, 30, 15, 250*int(time.Millisecond), time.UTC)
+	event := newEvent("api_request", timestamp, ClientInfo{ID: "client-1", UserID: "user-1"},
+		RequestInfo{ID: "req-1", Path: "/v1/decide", Method: http.MethodPost, SDKKey: "sdk-key", Bytes: 10},
+		ResponseInfo{StatusCode: http.StatusOK, Bytes: 20, DurationMS: 5})
+	event.UserProperties = map[string]interface{}{"plan": "pro"}
+	require.NoError(t, backend.Send(context.Background(), []Event{event, {Name: "custom", ClientID: "client-2"}}))
+
+	require.Len(t, rows, 2)
+	row := rows[0]
+	assert.Equal(t, "2025-06-01 12:30:15.250", row.Timestamp)
+	assert.Equal(t, "api_request", row.Name)
+	assert.Equal(t, "client-1", row.ClientID)
+	assert.Equal(t, "user-1", row.UserID)
+	assert.Equal(t, "req-1", row.RequestID)
+	assert.Equal(t, "/v1/decide", row.Path)
+	assert.Equal(t, http.MethodPost, row.Method)
+	assert.Equal(t, "sdk-key", row.SDKKey)
+	assert.Equal(t, http.StatusOK, row.StatusCode)
+	assert.Equal(t, int64(5), row.DurationMS)
+	assert.Equal(t, int64(10), row.RequestBytes)
+	assert.Equal(t, int64(20), row.ResponseBytes)
+	assert.Equal(t, `{"plan":"pro"}`, row.UserProperties)
+
+	var params map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(row.Params), &params))
+	assert.Equal(t, "/v1/decide", params[pathParam])
+
+	assert.Equal(t, "custom", rows[1].Name)
+	assert.Equal(t, "{}", rows[1].Params)
+	assert.Equal(t, "{}", rows[1].UserProperties)
+}
+
+func TestClickHouseBackendSyncInsert(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "INSERT INTO `optimizely_agent_events` FORMAT JSONEachRow", query.Get("query"))
+		assert.Equal(t, "agent", query.Get("database"))
+		assert.Empty(t, query.Get("async_insert"))
+		assert.Empty(t, r.Header.Get("X-ClickHouse-User"))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	backend := &ClickHouseBackend{URL: ts.URL + "/", Database: "agent", SyncInsert: true}
+	err := backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "client"}})
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+}
+
+func TestClickHouseBackendCheckConfig(t *testing.T) {
+	assert.EqualError(t, (&ClickHouseBackend{}).checkConfig(), "url is required")
+	assert.EqualError(t, (&ClickHouseBackend{URL: "http://clickhouse:8123", Table: "events; DROP TABLE x"}).checkConfig(),
+		`invalid table name: "events; DROP TABLE x"`)
+	assert.NoError(t, (&ClickHouseBackend{URL: "http://clickhouse:8123", Table: "events"}).checkConfig())
+}
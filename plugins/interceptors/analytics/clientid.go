@@ -0,0 +1,100 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tidwall/gjson"
+)
+
+// ClientIDStrategy derives the analytics client ID of a request. An empty ID falls back to the
+// default of the _ga cookie or a fingerprint of the client's IP address and user agent.
+type ClientIDStrategy interface {
+	ClientID(r *http.Request, body []byte) string
+}
+
+// ClientIDStrategyCreator creates a ClientIDStrategy for the configured name (a cookie, header,
+// claim or body field depending on the strategy)
+type ClientIDStrategyCreator func(name string) ClientIDStrategy
+
+// ClientIDStrategies stores the mapping of strategy type against ClientIDStrategyCreator
+var ClientIDStrategies = map[string]ClientIDStrategyCreator{}
+
+// AddClientIDStrategy registers a ClientIDStrategyCreator against a strategy type
+func AddClientIDStrategy(strategyType string, creator ClientIDStrategyCreator) {
+	if _, ok := ClientIDStrategies[strategyType]; ok {
+		panic(fmt.Sprintf("Client ID strategy with type %q already exists", strategyType))
+	}
+	ClientIDStrategies[strategyType] = creator
+}
+
+// ClientIDSource selects the strategy used to derive client IDs
+type ClientIDSource struct {
+	Type string `json:"type"` // "cookie", "header", "jwt", "body", "fingerprint" or a registered custom type
+	Name string `json:"name"` // Cookie name, header name, JWT claim or gjson path of the request body field
+}
+
+// fingerprintSource selects the fingerprinter, which is configured separately from the strategies
+const fingerprintSource = "fingerprint"
+
+// newClientIDStrategy creates the strategy selected by the source
+func newClientIDStrategy(source ClientIDSource) (ClientIDStrategy, error) {
+	creator, ok := ClientIDStrategies[source.Type]
+	if !ok {
+		return nil, fmt.Errorf("client ID strategy not found: %q", source.Type)
+	}
+	return creator(source.Name), nil
+}
+
+// ClientIDFunc adapts a function to the ClientIDStrategy interface
+type ClientIDFunc func(r *http.Request, body []byte) string
+
+// ClientID calls f(r, body)
+func (f ClientIDFunc) ClientID(r *http.Request, body []byte) string {
+	return f(r, body)
+}
+
+func init() {
+	AddClientIDStrategy("cookie", func(name string) ClientIDStrategy {
+		return ClientIDFunc(func(r *http.Request, body []byte) string {
+			if cookie, err := r.Cookie(name); err == nil {
+				return cookie.Value
+			}
+			return ""
+		})
+	})
+	AddClientIDStrategy("header", func(name string) ClientIDStrategy {
+		return ClientIDFunc(func(r *http.Request, body []byte) string {
+			return r.Header.Get(name)
+		})
+	})
+	AddClientIDStrategy("jwt", func(name string) ClientIDStrategy {
+		return ClientIDFunc(func(r *http.Request, body []byte) string {
+			return claimString(bearerClaims(r), name)
+		})
+	})
+	AddClientIDStrategy("body", func(name string) ClientIDStrategy {
+		return ClientIDFunc(func(r *http.Request, body []byte) string {
+			if !gjson.ValidBytes(body) {
+				return ""
+			}
+			return gjson.GetBytes(body, name).String()
+		})
+	})
+}
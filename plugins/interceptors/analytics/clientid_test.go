@@ -0,0 +1,82 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIDStrategies(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v1/decide", nil)
+	req.AddCookie(&http.Cookie{Name: "uid", Value: "cookie-id"})
+	req.Header.Set("X-Client-ID", "header-id")
+	withBearerToken(t, req, jwt.MapClaims{"sub": "claim-id"})
+	body := []byte(`{"userId":"body-id"}`)
+
+	for source, expected := range map[ClientIDSource]string{
+		{Type: "cookie", Name: "uid"}:            "cookie-id",
+		{Type: "header", Name: "X-Client-ID"}:    "header-id",
+		{Type: "jwt", Name: "sub"}:               "claim-id",
+		{Type: "body", Name: "userId"}:           "body-id",
+		{Type: "cookie", Name: "missing"}:        "",
+		{Type: "body", Name: "userAttributes.x"}: "",
+	} {
+		strategy, err := newClientIDStrategy(source)
+		require.NoError(t, err)
+		assert.Equal(t, expected, strategy.ClientID(req, body), source)
+	}
+
+	_, err := newClientIDStrategy(ClientIDSource{Type: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestAddClientIDStrategyPanicsOnDuplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		AddClientIDStrategy("cookie", func(string) ClientIDStrategy { return nil })
+	})
+}
+
+func TestAnalyticsClientIDSource(t *testing.T) {
+	AddClientIDStrategy("test-upper", func(name string) ClientIDStrategy {
+		return ClientIDFunc(func(r *http.Request, body []byte) string {
+			return strings.ToUpper(r.Header.Get(name))
+		})
+	})
+
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, ClientIDSource: ClientIDSource{Type: "test-upper", Name: "X-Client-ID"}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("X-Client-ID", "abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "ABC", backend.next(t).ClientID)
+
+	// An empty ID falls back to the default
+	req = httptest.NewRequest("GET", "/v1/config", nil)
+	req.AddCookie(&http.Cookie{Name: "_ga", Value: "ga-id"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "ga-id", backend.next(t).ClientID)
+}
@@ -0,0 +1,319 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultCollectPath      = "/collect"
+	defaultCollectBodyBytes = 16 << 10
+
+	// Limits of GA4 Measurement Protocol requests
+	maxCollectEvents      = 25
+	maxCollectParams      = 25
+	maxCollectParamLength = 100
+	maxCollectUserID      = 256
+)
+
+// CollectConfig configures the first-party measurement endpoint, through which browsers and apps
+// send their own events to the destinations via the agent rather than to GA directly
+type CollectConfig struct {
+	Enabled        bool     `json:"enabled"`
+	Path           string   `json:"path"`           // Path of the endpoint (defaults to /collect)
+	AllowedOrigins []string `json:"allowedOrigins"` // Origins of the web pages allowed to send events (path.Match syntax, e.g. https://*.example.com)
+	Events         []string `json:"events"`         // Names of the events accepted (defaults to every valid name)
+	MaxBodyBytes   int64    `json:"maxBodyBytes"`   // Largest request accepted (defaults to 16KiB)
+}
+
+// collectRequest is the body of collect requests, shaped like a GA4 Measurement Protocol request
+// without the credentials, which stay with the agent
+type collectRequest struct {
+	ClientID string         `json:"client_id"`
+	UserID   string         `json:"user_id"`
+	Events   []collectEvent `json:"events"`
+}
+
+type collectEvent struct {
+	Name   string                 `json:"name"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// collectNamePattern matches valid GA4 event and param names
+var collectNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,39}$`)
+
+// reservedCollectPrefixes and reservedCollectEvents are names GA4 reserves for its own use
+var (
+	reservedCollectPrefixes = []string{"_", "firebase_", "ga_", "google_", "gtag."}
+	reservedCollectEvents   = map[string]bool{
+		"ad_activeview": true, "ad_click": true, "ad_exposure": true, "ad_query": true, "ad_reward": true,
+		"adunit_exposure": true, "app_background": true, "app_clear_data": true, "app_exception": true,
+		"app_remove": true, "app_store_refund": true, "app_store_subscription_cancel": true,
+		"app_store_subscription_convert": true, "app_store_subscription_renew": true, "app_update": true,
+		"app_upgrade": true, "dynamic_link_app_open": true, "dynamic_link_app_update": true,
+		"dynamic_link_first_open": true, "error": true, "first_open": true, "first_visit": true,
+		"in_app_purchase": true, "notification_dismiss": true, "notification_foreground": true,
+		"notification_open": true, "notification_receive": true, "os_update": true, "session_start": true,
+		"session_start_with_rollout": true, "user_engagement": true,
+	}
+)
+
+// reservedCollectParams are the params the agent sets itself or derives from API requests, which
+// collected events can't send: downstream quotas, sampling weights and reports trust them.
+// Session params are not reserved, as clients send their own when sessions aren't tracked.
+var reservedCollectParams = map[string]bool{
+	schemaVersionParam: true, methodParam: true, statusCodeParam: true, responseTimeParam: true,
+	requestBytesParam: true, responseBytesParam: true, pathParam: true, sdkKeyParam: true,
+	requestIDParam: true, sampleRateParam: true, ipAddressParam: true, userAgentParam: true,
+	geoCountryParam: true, geoRegionParam: true, geoCityParam: true, deviceCategoryParam: true,
+	browserParam: true, browserVersionParam: true, osParam: true, osVersionParam: true,
+	isBotParam: true, errorCodeParam: true, errorMessageParam: true, latencyBucketParam: true,
+	apdexParam: true, clientCacheParam: true, decisionTimeParam: true, handlerTimeParam: true,
+}
+
+// collector serves the first-party measurement endpoint
+type collector struct {
+	path    string
+	origins []string
+	events  map[string]bool
+	maxBody int64
+}
+
+// newCollector validates the config, returning nil when the endpoint is disabled
+func newCollector(conf CollectConfig) (*collector, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	c := &collector{path: conf.Path, origins: conf.AllowedOrigins, maxBody: conf.MaxBodyBytes}
+	if c.path == "" {
+		c.path = defaultCollectPath
+	}
+	if !strings.HasPrefix(c.path, "/") {
+		return nil, fmt.Errorf("analytics collect path %q must start with /", c.path)
+	}
+	if c.maxBody <= 0 {
+		c.maxBody = defaultCollectBodyBytes
+	}
+	for _, pattern := range c.origins {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid analytics collect origin %q: %w", pattern, err)
+		}
+	}
+	if len(conf.Events) > 0 {
+		c.events = make(map[string]bool, len(conf.Events))
+		for _, name := range conf.Events {
+			if err := validateCollectEventName(name); err != nil {
+				return nil, err
+			}
+			c.events[name] = true
+		}
+	}
+	return c, nil
+}
+
+// allowsOrigin reports whether a page of origin may send events. Requests without an Origin
+// header, e.g. of apps, are not restricted.
+func (c *collector) allowsOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, pattern := range c.origins {
+		if matched, _ := path.Match(pattern, origin); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// serveCollect accepts the events of a collect request and dispatches them enriched like the
+// events of API requests. Accepted requests are answered 204 whether or not their events are
+// dispatched, e.g. when sampled or suppressed for lack of consent.
+func (a *Analytics) serveCollect(w http.ResponseWriter, r *http.Request) {
+	c := a.collect
+	origin := r.Header.Get("Origin")
+	if !c.allowsOrigin(origin) {
+		a.rejectCollect(w, r, http.StatusForbidden, fmt.Errorf("origin %q is not allowed", origin))
+		return
+	}
+	if origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Methods", http.MethodPost)
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "POST, OPTIONS")
+		a.rejectCollect(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+
+	var req collectRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, c.maxBody)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			a.rejectCollect(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("body exceeds %d bytes", c.maxBody))
+		} else {
+			a.rejectCollect(w, r, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		}
+		return
+	}
+	if err := c.validate(req); err != nil {
+		a.rejectCollect(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if a.dispatcher == nil {
+		a.rejectCollect(w, r, http.StatusServiceUnavailable, errors.New("analytics is not started"))
+		return
+	}
+
+	if !trackingPaused.Load() {
+		a.dispatchCollected(r, req)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// rejectCollect answers a collect request that isn't accepted
+func (a *Analytics) rejectCollect(w http.ResponseWriter, r *http.Request, status int, err error) {
+	a.metrics.collectRejected.Add(1)
+	render.Status(r, status)
+	render.JSON(w, r, map[string]string{"error": err.Error()})
+}
+
+// dispatchCollected enriches the events of an accepted collect request and queues them
+func (a *Analytics) dispatchCollected(r *http.Request, req collectRequest) {
+	clientID := req.ClientID
+	if clientID == "" {
+		clientID = a.getClientID(r, nil)
+	}
+	now := time.Now()
+	for _, e := range req.Events {
+		a.auditor.request()
+		event := Event{
+			Name:      e.Name,
+			ClientID:  clientID,
+			UserID:    req.UserID,
+			RequestID: requestID(r),
+			Timestamp: now,
+			Params:    make(map[string]interface{}, len(e.Params)+eventParamsCapacity),
+		}
+		for name, v := range e.Params {
+			event.Params[name] = v
+		}
+		event.Params[userAgentParam] = r.UserAgent()
+		event.Params[ipAddressParam] = getIPAddress(r)
+		if a.Residency.enabled() {
+			event.Region = a.Residency.region(r, a.geo)
+		}
+		if a.GeoIP.DatabasePath != "" {
+			addGeoParams(event.Params, a.geo, getIPAddress(r), a.GeoIP.KeepIP)
+		}
+		if a.ParseUserAgent {
+			addUserAgentParams(event.Params, r.UserAgent())
+		}
+		addDimensions(event.Params, a.dimensions, r)
+		applyPrivacy(&event, a.privacy, r.URL.RawQuery)
+
+		if !a.applyBots(&event, r) || !a.consents(&event, r) || !a.withinQuota(&event) {
+			continue
+		}
+		if a.sessions != nil {
+			addSessionParams(&event, a.sessions)
+		}
+		a.metrics.collectAccepted.Add(1)
+		a.dispatcher.enqueue(event)
+	}
+}
+
+// validate checks a collect request against the limits of GA4 and the accepted events
+func (c *collector) validate(req collectRequest) error {
+	switch {
+	case len(req.Events) == 0:
+		return errors.New("events: required")
+	case len(req.Events) > maxCollectEvents:
+		return fmt.Errorf("events: at most %d events are accepted per request", maxCollectEvents)
+	case len(req.UserID) > maxCollectUserID:
+		return fmt.Errorf("user_id: longer than %d characters", maxCollectUserID)
+	}
+	for i, e := range req.Events {
+		field := fmt.Sprintf("events[%d]", i)
+		if err := validateCollectEventName(e.Name); err != nil {
+			return fmt.Errorf("%s.name: %w", field, err)
+		}
+		if c.events != nil && !c.events[e.Name] {
+			return fmt.Errorf("%s.name: event %q is not accepted", field, e.Name)
+		}
+		if len(e.Params) > maxCollectParams {
+			return fmt.Errorf("%s.params: at most %d params are accepted per event", field, maxCollectParams)
+		}
+		for name, v := range e.Params {
+			if !collectNamePattern.MatchString(name) || hasReservedCollectPrefix(name) {
+				return fmt.Errorf("%s.params: invalid param name %q", field, name)
+			}
+			if reservedCollectParams[name] {
+				return fmt.Errorf("%s.params: param name %q is reserved", field, name)
+			}
+			switch v := v.(type) {
+			case string:
+				if len(v) > maxCollectParamLength {
+					return fmt.Errorf("%s.params.%s: longer than %d characters", field, name, maxCollectParamLength)
+				}
+			case float64, bool:
+			default:
+				return fmt.Errorf("%s.params.%s: must be a string, number or boolean", field, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCollectEventName returns an error for names GA4 doesn't accept or reserves
+func validateCollectEventName(name string) error {
+	switch {
+	case !collectNamePattern.MatchString(name):
+		return fmt.Errorf("invalid event name %q: must start with a letter and have at most 40 letters, digits and underscores", name)
+	case reservedCollectEvents[name] || hasReservedCollectPrefix(name):
+		return fmt.Errorf("event name %q is reserved", name)
+	}
+	return nil
+}
+
+func hasReservedCollectPrefix(name string) bool {
+	for _, prefix := range reservedCollectPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
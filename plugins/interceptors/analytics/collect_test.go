@@ -0,0 +1,183 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCollectHandler(t *testing.T, conf CollectConfig) (*Analytics, http.Handler, *mockBackend) {
+	backend := newMockBackend()
+	conf.Enabled = true
+	a := &Analytics{Enabled: true, Collect: conf, ParseUserAgent: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+	t.Cleanup(func() { unregisterInstance(a) })
+	return a, handler, backend
+}
+
+func collect(handler http.Handler, method, path, origin, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1")
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestCollectForwardsEnrichedEvents(t *testing.T) {
+	_, handler, backend := newCollectHandler(t, CollectConfig{AllowedOrigins: []string{"https://*.example.com"}})
+
+	rec := collect(handler, http.MethodPost, "/collect", "https://www.example.com", `{
+		"client_id": "123.456",
+		"user_id": "user-1",
+		"events": [
+			{"name": "page_view", "params": {"page_location": "https://www.example.com/", "engaged": true}},
+			{"name": "add_to_cart", "params": {"value": 9.99}}
+		]
+	}`)
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	assert.Equal(t, "https://www.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	event := backend.next(t)
+	assert.Equal(t, "page_view", event.Name)
+	assert.Equal(t, "123.456", event.ClientID)
+	assert.Equal(t, "user-1", event.UserID)
+	assert.Equal(t, "https://www.example.com/", event.Params["page_location"])
+	assert.Equal(t, true, event.Params["engaged"])
+	assert.Equal(t, "mobile", event.Params[deviceCategoryParam], "the user agent is parsed server side")
+	assert.Equal(t, "192.0.2.1", event.Params[ipAddressParam])
+
+	event = backend.next(t)
+	assert.Equal(t, "add_to_cart", event.Name)
+	assert.Equal(t, 9.99, event.Params["value"])
+}
+
+func TestCollectRejectsInvalidRequests(t *testing.T) {
+	_, handler, backend := newCollectHandler(t, CollectConfig{
+		Path:           "/t/collect",
+		AllowedOrigins: []string{"https://example.com"},
+		Events:         []string{"page_view", "sign_up"},
+		MaxBodyBytes:   256,
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		origin string
+		body   string
+		status int
+		err    string
+	}{
+		{"origin", http.MethodPost, "https://evil.test", `{"events": [{"name": "page_view"}]}`, http.StatusForbidden, `origin "https://evil.test" is not allowed`},
+		{"method", http.MethodGet, "", "", http.StatusMethodNotAllowed, "method GET is not allowed"},
+		{"json", http.MethodPost, "", `{"events": `, http.StatusBadRequest, "invalid body"},
+		{"size", http.MethodPost, "", `{"events": [{"name": "page_view", "params": {"p": "` + strings.Repeat("x", 300) + `"}}]}`, http.StatusRequestEntityTooLarge, "body exceeds 256 bytes"},
+		{"no events", http.MethodPost, "", `{"client_id": "1.2"}`, http.StatusBadRequest, "events: required"},
+		{"event name", http.MethodPost, "", `{"events": [{"name": "1st"}]}`, http.StatusBadRequest, `events[0].name: invalid event name "1st"`},
+		{"reserved", http.MethodPost, "", `{"events": [{"name": "first_visit"}]}`, http.StatusBadRequest, `event name "first_visit" is reserved`},
+		{"not accepted", http.MethodPost, "", `{"events": [{"name": "purchase"}]}`, http.StatusBadRequest, `event "purchase" is not accepted`},
+		{"param name", http.MethodPost, "", `{"events": [{"name": "page_view", "params": {"ga_x": 1}}]}`, http.StatusBadRequest, `invalid param name "ga_x"`},
+		{"reserved param", http.MethodPost, "", `{"events": [{"name": "page_view", "params": {"sdk_key": "k"}}]}`, http.StatusBadRequest, `param name "sdk_key" is reserved`},
+		{"reserved status", http.MethodPost, "", `{"events": [{"name": "page_view", "params": {"status_code": 500}}]}`, http.StatusBadRequest, `param name "status_code" is reserved`},
+		{"reserved sample rate", http.MethodPost, "", `{"events": [{"name": "page_view", "params": {"sample_rate": 100}}]}`, http.StatusBadRequest, `param name "sample_rate" is reserved`},
+		{"param value", http.MethodPost, "", `{"events": [{"name": "page_view", "params": {"items": [1]}}]}`, http.StatusBadRequest, "events[0].params.items: must be a string, number or boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := collect(handler, tt.method, "/t/collect", tt.origin, tt.body)
+			assert.Equal(t, tt.status, rec.Code)
+			var resp map[string]string
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Contains(t, resp["error"], tt.err)
+		})
+	}
+	assert.Empty(t, backend.events)
+
+	// Other paths are passed on
+	assert.Equal(t, http.StatusNotFound, collect(handler, http.MethodPost, "/collect", "", "{}").Code)
+}
+
+func TestCollectPreflight(t *testing.T) {
+	_, handler, _ := newCollectHandler(t, CollectConfig{AllowedOrigins: []string{"https://example.com"}})
+
+	rec := collect(handler, http.MethodOptions, "/collect", "https://example.com", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "POST", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type", rec.Header().Get("Access-Control-Allow-Headers"))
+
+	assert.Equal(t, http.StatusForbidden, collect(handler, http.MethodOptions, "/collect", "https://other.test", "").Code)
+}
+
+func TestCollectHonorsConsentAndKillSwitch(t *testing.T) {
+	defer trackingPaused.Store(false)
+	a, handler, backend := newCollectHandler(t, CollectConfig{})
+	a.HonorDNT = true
+
+	body := `{"events": [{"name": "page_view"}]}`
+	r := httptest.NewRequest(http.MethodPost, "/collect", strings.NewReader(body))
+	r.Header.Set("DNT", "1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	trackingPaused.Store(true)
+	assert.Equal(t, http.StatusNoContent, collect(handler, http.MethodPost, "/collect", "", body).Code)
+	assert.Empty(t, backend.events)
+
+	trackingPaused.Store(false)
+	assert.Equal(t, http.StatusNoContent, collect(handler, http.MethodPost, "/collect", "", body).Code)
+	assert.NotEmpty(t, backend.next(t).ClientID, "clients without an ID get one like API clients")
+}
+
+func TestNewCollector(t *testing.T) {
+	c, err := newCollector(CollectConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = newCollector(CollectConfig{Enabled: true})
+	require.NoError(t, err)
+	assert.Equal(t, defaultCollectPath, c.path)
+	assert.EqualValues(t, defaultCollectBodyBytes, c.maxBody)
+	assert.True(t, c.allowsOrigin(""))
+	assert.False(t, c.allowsOrigin("https://example.com"))
+
+	for _, conf := range []CollectConfig{
+		{Enabled: true, Path: "collect"},
+		{Enabled: true, AllowedOrigins: []string{"https://["}},
+		{Enabled: true, Events: []string{"session_start"}},
+	} {
+		_, err := newCollector(conf)
+		assert.Error(t, err, "%+v", conf)
+	}
+
+	a := &Analytics{Collect: CollectConfig{Enabled: true, Path: "collect"}}
+	assert.ErrorContains(t, a.Validate(), "collect: analytics collect path")
+}
@@ -0,0 +1,97 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	compressionGzip = "gzip"
+
+	// minCompressedBytes is the size below which payloads are sent as is, as compressing them
+	// saves too little to be worth it
+	minCompressedBytes = 1024
+)
+
+// checkCompression returns an error for encodings payloads can't be compressed with
+func checkCompression(encoding string) error {
+	switch encoding {
+	case "", "none", compressionGzip:
+		return nil
+	}
+	return fmt.Errorf("unsupported compression %q, must be %q or \"none\"", encoding, compressionGzip)
+}
+
+// compressingTransport compresses the request bodies of a destination with Content-Encoding.
+// Destinations that reject the encoding with 415 Unsupported Media Type are sent the payload
+// as is, and every later payload too.
+type compressingTransport struct {
+	next        http.RoundTripper
+	encoding    string
+	destination string
+	disabled    atomic.Bool
+}
+
+func (t *compressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.disabled.Load() || req.GetBody == nil || req.ContentLength < minCompressedBytes || req.Header.Get("Content-Encoding") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = io.Copy(zw, body)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	compressed := req.Clone(req.Context())
+	payload := buf.Bytes()
+	compressed.Body = io.NopCloser(bytes.NewReader(payload))
+	compressed.ContentLength = int64(len(payload))
+	compressed.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(payload)), nil
+	}
+	compressed.Header.Set("Content-Encoding", t.encoding)
+
+	resp, err := t.next.RoundTrip(compressed)
+	if err != nil || resp.StatusCode != http.StatusUnsupportedMediaType {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return resp, err
+	}
+
+	resp.Body.Close()
+	t.disabled.Store(true)
+	log.Warn().Str("destination", t.destination).Str("encoding", t.encoding).Msg("Analytics destination doesn't accept compressed payloads, sending them uncompressed")
+	return t.next.RoundTrip(req)
+}
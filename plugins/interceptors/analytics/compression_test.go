@@ -0,0 +1,111 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressingTransport(t *testing.T) {
+	var mu sync.Mutex
+	var encodings []string
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		mu.Lock()
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		bodies = append(bodies, string(data))
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &compressingTransport{next: http.DefaultTransport, encoding: compressionGzip}}
+	large := `{"events":"` + strings.Repeat("a", minCompressedBytes) + `"}`
+	_, err := send(context.Background(), client, http.MethodPost, ts.URL, "application/json", []byte(large), nil)
+	require.NoError(t, err)
+	_, err = send(context.Background(), client, http.MethodPost, ts.URL, "application/json", []byte(`{}`), nil)
+	require.NoError(t, err)
+
+	// Small payloads are sent as is
+	assert.Equal(t, []string{"gzip", ""}, encodings)
+	assert.Equal(t, []string{large, `{}`}, bodies)
+}
+
+func TestCompressingTransportUnsupported(t *testing.T) {
+	var mu sync.Mutex
+	var encodings []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		mu.Unlock()
+		if r.Header.Get("Content-Encoding") != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Len(t, data, minCompressedBytes)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &compressingTransport{next: http.DefaultTransport, encoding: compressionGzip}}
+	body := []byte(strings.Repeat("a", minCompressedBytes))
+	for i := 0; i < 2; i++ {
+		_, err := send(context.Background(), client, http.MethodPost, ts.URL, "text/plain", body, nil)
+		require.NoError(t, err)
+	}
+
+	// The rejected encoding isn't tried again
+	assert.Equal(t, []string{"gzip", "", ""}, encodings)
+}
+
+func TestDestinationCompression(t *testing.T) {
+	a := &Analytics{Destinations: []BackendConfig{
+		{"type": "webhook", "name": "gzip", "url": "http://collector", "compression": "gzip"},
+		{"type": "webhook", "name": "none", "url": "http://collector", "compression": "none"},
+		{"type": "webhook", "name": "zip", "url": "http://collector", "compression": "zip"},
+	}}
+	a.init()
+	defer a.closeDestinations()
+	require.Len(t, a.destinations, 2)
+
+	compressed, uncompressed := a.destinations[0], a.destinations[1]
+	require.NotNil(t, compressed.client)
+	assert.Same(t, compressed.client, compressed.backend.(*WebhookBackend).client)
+	transport, ok := compressed.client.Transport.(*compressingTransport)
+	require.True(t, ok)
+	assert.Same(t, a.httpClient.Transport, transport.next)
+	assert.Nil(t, uncompressed.client)
+	assert.Same(t, a.httpClient, uncompressed.backend.(*WebhookBackend).client)
+}
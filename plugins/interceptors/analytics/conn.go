@@ -0,0 +1,118 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultWriteTimeout bounds writes to stream destinations when the delivery has no deadline
+const defaultWriteTimeout = 5 * time.Second
+
+// connWriter writes messages to a lazily dialed connection, dialing again after a failure
+type connWriter struct {
+	network   string // "tcp", "udp", "unix" or "tls" (TCP with TLS)
+	address   string
+	tlsConfig *tls.Config // settings of "tls" connections (defaults to the system roots)
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// write sends each message with a single write, e.g. one datagram per message over UDP
+func (c *connWriter) write(ctx context.Context, msgs ...[]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.New("connection is closed")
+	}
+	if c.conn == nil {
+		conn, err := c.dial(ctx)
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultWriteTimeout)
+	}
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		return c.reset(err)
+	}
+	for _, msg := range msgs {
+		if _, err := c.conn.Write(msg); err != nil {
+			return c.reset(err)
+		}
+	}
+	return nil
+}
+
+func (c *connWriter) dial(ctx context.Context) (net.Conn, error) {
+	switch c.network {
+	case "tls":
+		conf := c.tlsConfig
+		if conf == nil {
+			conf = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		dialer := &tls.Dialer{Config: conf}
+		return dialer.DialContext(ctx, "tcp", c.address)
+	case "tcp", "udp", "unix":
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, c.network, c.address)
+	default:
+		return nil, fmt.Errorf("unknown network: %q", c.network)
+	}
+}
+
+// reset closes the connection after a failed write, so that the next write dials again
+func (c *connWriter) reset(err error) error {
+	_ = c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// close closes the connection, if any; later writes fail
+func (c *connWriter) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// setConnDeadline bounds reads and writes to the deadline of ctx, or to the default timeout
+func setConnDeadline(ctx context.Context, conn net.Conn) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultWriteTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+}
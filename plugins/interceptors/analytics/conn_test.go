@@ -0,0 +1,72 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnWriterRedialsAfterFailure(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	writer := &connWriter{network: "udp", address: conn.LocalAddr().String()}
+	require.NoError(t, writer.write(context.Background(), []byte("a")))
+
+	// A failed write drops the connection and the next write dials again
+	_ = writer.conn.Close()
+	assert.Error(t, writer.write(context.Background(), []byte("b")))
+	assert.Nil(t, writer.conn)
+	require.NoError(t, writer.write(context.Background(), []byte("c")))
+
+	buf := make([]byte, 8)
+	for _, expected := range []string{"a", "c"} {
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(buf[:n]))
+	}
+
+	require.NoError(t, writer.close())
+	assert.Error(t, writer.write(context.Background(), []byte("d")))
+}
+
+func TestConnWriterTLSConfig(t *testing.T) {
+	clientCert, certFile, keyFile := newClientCertificate(t)
+	server, caFile := newMutualTLSServer(t, clientCert)
+	address := server.Listener.Addr().String()
+
+	// The server certificate is not trusted by the system roots
+	writer := &connWriter{network: "tls", address: address}
+	assert.Error(t, writer.write(context.Background(), []byte("a")))
+
+	conf, err := TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}.config()
+	require.NoError(t, err)
+	writer = &connWriter{network: "tls", address: address, tlsConfig: conf}
+	require.NoError(t, writer.write(context.Background(), []byte("a")))
+	assert.NoError(t, writer.close())
+}
+
+func TestConnWriterUnknownNetwork(t *testing.T) {
+	writer := &connWriter{network: "ipx", address: "127.0.0.1:1"}
+	assert.Error(t, writer.write(context.Background(), []byte("a")))
+}
@@ -0,0 +1,94 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	suppressSkip      = "skip"
+	suppressAnonymize = "anonymize"
+)
+
+var defaultGrantedValues = []string{"granted", "true", "yes", "1"}
+
+// ConsentConfig reads a consent signal from a cookie or header. Tracking is enabled when either
+// is set.
+type ConsentConfig struct {
+	Cookie        string   `json:"cookie"`        // Cookie carrying the consent signal
+	Header        string   `json:"header"`        // Header carrying the consent signal, e.g. X-Consent
+	GrantedValues []string `json:"grantedValues"` // Signal values that grant consent (defaults to granted, true, yes, 1)
+	Required      bool     `json:"required"`      // Treat requests without a signal as non-consenting
+	Mode          string   `json:"mode"`          // "skip" (default) or "anonymize" events without consent
+}
+
+func (c ConsentConfig) enabled() bool {
+	return c.Cookie != "" || c.Header != ""
+}
+
+// consented reports whether the request grants consent. The header takes precedence over the cookie.
+func (c ConsentConfig) consented(r *http.Request) bool {
+	var signal string
+	var found bool
+	if c.Header != "" {
+		if values := r.Header.Values(c.Header); len(values) > 0 {
+			signal, found = values[0], true
+		}
+	}
+	if !found && c.Cookie != "" {
+		if cookie, err := r.Cookie(c.Cookie); err == nil {
+			signal, found = cookie.Value, true
+		}
+	}
+	if !found {
+		return !c.Required
+	}
+
+	granted := c.GrantedValues
+	if len(granted) == 0 {
+		granted = defaultGrantedValues
+	}
+	signal = strings.TrimSpace(signal)
+	for _, v := range granted {
+		if strings.EqualFold(signal, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// doNotTrack reports whether the request opts out of tracking with Do Not Track (DNT: 1) or
+// Global Privacy Control (Sec-GPC: 1)
+func doNotTrack(r *http.Request) bool {
+	return strings.TrimSpace(r.Header.Get("DNT")) == "1" || strings.TrimSpace(r.Header.Get("Sec-GPC")) == "1"
+}
+
+// anonymize removes everything that identifies the client from the event. The client ID is
+// replaced with a random one so that events can't be linked to each other, and the user ID and
+// user properties removed.
+func anonymize(event *Event) {
+	event.ClientID = uuid.NewString()
+	event.UserID = ""
+	event.UserProperties = nil
+	for _, param := range []string{ipAddressParam, userAgentParam, sdkKeyParam} {
+		delete(event.Params, param)
+	}
+}
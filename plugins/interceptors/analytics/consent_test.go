@@ -0,0 +1,124 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsentEnabled(t *testing.T) {
+	assert.False(t, ConsentConfig{}.enabled())
+	assert.True(t, ConsentConfig{Cookie: "consent"}.enabled())
+	assert.True(t, ConsentConfig{Header: "X-Consent"}.enabled())
+}
+
+func TestConsented(t *testing.T) {
+	conf := ConsentConfig{Cookie: "consent", Header: "X-Consent"}
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	assert.True(t, conf.consented(req), "no signal is consent unless required")
+	conf.Required = true
+	assert.False(t, conf.consented(req))
+
+	req.AddCookie(&http.Cookie{Name: "consent", Value: "Granted"})
+	assert.True(t, conf.consented(req))
+
+	// The header takes precedence over the cookie
+	req.Header.Set("X-Consent", "denied")
+	assert.False(t, conf.consented(req))
+
+	conf.GrantedValues = []string{"analytics"}
+	req.Header.Set("X-Consent", "analytics")
+	assert.True(t, conf.consented(req))
+}
+
+func TestAnonymize(t *testing.T) {
+	event := Event{
+		ClientID: "client-1",
+		UserID:   "user-1",
+		Params: map[string]interface{}{
+			pathParam: "/v1/decide", ipAddressParam: "203.0.113.7", userAgentParam: "curl/8.0", sdkKeyParam: "sdk-key",
+		},
+	}
+	anonymize(&event)
+	assert.NotEqual(t, "client-1", event.ClientID)
+	assert.Empty(t, event.UserID)
+	assert.Equal(t, map[string]interface{}{pathParam: "/v1/decide"}, event.Params)
+}
+
+func TestAnalyticsConsent(t *testing.T) {
+	for _, mode := range []string{suppressSkip, suppressAnonymize} {
+		backend := newMockBackend()
+		a := &Analytics{Enabled: true, Consent: ConsentConfig{Header: "X-Consent", Mode: mode}}
+		handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+		before := expvarValue("counter.analytics.requests.consentSuppressed")
+		denied := httptest.NewRequest("GET", "/v1/denied", nil)
+		denied.Header.Set("X-Consent", "denied")
+		denied.AddCookie(&http.Cookie{Name: "_ga", Value: "client-1"})
+		handler.ServeHTTP(httptest.NewRecorder(), denied)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/granted", nil))
+
+		event := backend.next(t)
+		if mode == suppressAnonymize {
+			assert.Equal(t, "/v1/denied", event.Params[pathParam])
+			assert.NotEqual(t, "client-1", event.ClientID)
+			assert.NotContains(t, event.Params, ipAddressParam)
+			event = backend.next(t)
+		}
+		assert.Equal(t, "/v1/granted", event.Params[pathParam])
+		assert.Equal(t, before+1, expvarValue("counter.analytics.requests.consentSuppressed"))
+	}
+}
+
+func TestDoNotTrack(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	assert.False(t, doNotTrack(req))
+
+	req.Header.Set("DNT", "0")
+	assert.False(t, doNotTrack(req))
+	req.Header.Set("DNT", "1")
+	assert.True(t, doNotTrack(req))
+
+	req = httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("Sec-GPC", "1")
+	assert.True(t, doNotTrack(req))
+}
+
+func TestAnalyticsHonorDNT(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, HonorDNT: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	before := expvarValue("counter.analytics.requests.dntSuppressed")
+	requests := expvarValue("counter.analytics.requests")
+	optedOut := httptest.NewRequest("GET", "/v1/dnt", nil)
+	optedOut.Header.Set("DNT", "1")
+	handler.ServeHTTP(httptest.NewRecorder(), optedOut)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/tracked", nil))
+
+	assert.Equal(t, "/v1/tracked", backend.next(t).Params[pathParam])
+	assert.Equal(t, before+1, expvarValue("counter.analytics.requests.dntSuppressed"))
+	// Opted out requests are still counted in the aggregate metrics
+	assert.Equal(t, requests+2, expvarValue("counter.analytics.requests"))
+}
@@ -0,0 +1,39 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+const (
+	datafilePath = "/v1/datafile"
+	webhookPath  = "/webhooks/optimizely"
+
+	datafileFetchEvent   = "datafile_fetch"
+	datafileWebhookEvent = "datafile_webhook"
+
+	environmentParam = "environment"
+)
+
+// datafileEventName returns the event name of datafile fetches and webhooks, and "" for other paths
+func datafileEventName(path string) string {
+	switch path {
+	case datafilePath:
+		return datafileFetchEvent
+	case webhookPath:
+		return datafileWebhookEvent
+	default:
+		return ""
+	}
+}
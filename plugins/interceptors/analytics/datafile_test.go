@@ -0,0 +1,64 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+func TestDatafileEventName(t *testing.T) {
+	assert.Equal(t, datafileFetchEvent, datafileEventName("/v1/datafile"))
+	assert.Equal(t, datafileWebhookEvent, datafileEventName("/webhooks/optimizely"))
+	assert.Empty(t, datafileEventName("/v1/decide"))
+}
+
+func TestAnalyticsDatafileEvents(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, DatafileEvents: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.Annotate(r, middleware.AnnotationClientCache, "hit")
+		middleware.Annotate(r, middleware.AnnotationRevision, "42")
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/datafile", nil)
+	req.Header.Set(middleware.OptlySDKHeader, "sdk-key")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	e := backend.next(t)
+	assert.Equal(t, datafileFetchEvent, e.Name)
+	assert.Equal(t, "sdk-key", e.Params[sdkKeyParam])
+	assert.Equal(t, "42", e.Params[revisionParam])
+	assert.Equal(t, "hit", e.Params[clientCacheParam])
+
+	// Other requests are neither renamed nor annotated
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	e = backend.next(t)
+	assert.Equal(t, defaultEventName, e.Name)
+	assert.NotContains(t, e.Params, revisionParam)
+
+	// Route rules still rename the events
+	a.Rules = []RouteRule{{Path: webhookPath, EventName: "config_webhook"}}
+	a.initRules()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", webhookPath, nil))
+	assert.Equal(t, "config_webhook", backend.next(t).Name)
+}
@@ -0,0 +1,161 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	deadLetterDiscard = "discard"
+	deadLetterFile    = "file"
+	deadLetterKafka   = "kafka"
+
+	kafkaRESTJSONMediaType = "application/vnd.kafka.json.v2+json"
+)
+
+// DeadLetterConfig configures where events permanently rejected by a destination are sent
+type DeadLetterConfig struct {
+	Type         string `json:"type"`         // "discard" (default), "file" or "kafka"
+	Path         string `json:"path"`         // file: NDJSON file the dead letters are appended to
+	RESTProxyURL string `json:"restProxyURL"` // kafka: base URL of the Kafka REST Proxy
+	Topic        string `json:"topic"`        // kafka: topic the dead letters are produced to
+}
+
+// deadLetter is a rejected event along with the reason it was rejected
+type deadLetter struct {
+	Destination string    `json:"destination"`
+	Reason      string    `json:"reason"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	RejectedAt  time.Time `json:"rejectedAt"`
+	Event       Event     `json:"event"`
+}
+
+// deadLetterSink stores dead letters for later diagnosis
+type deadLetterSink interface {
+	write(ctx context.Context, letter deadLetter) error
+}
+
+// newDeadLetterSink creates the sink selected by the config. HTTP sinks use client, or
+// defaultHTTPClient when nil.
+func newDeadLetterSink(conf DeadLetterConfig, client *http.Client) (deadLetterSink, error) {
+	switch conf.Type {
+	case deadLetterDiscard, "":
+		return discardSink{}, nil
+	case deadLetterFile:
+		if conf.Path == "" {
+			return nil, errors.New("dead letter file sink requires a path")
+		}
+		f, err := os.OpenFile(conf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return nil, err
+		}
+		return &fileSink{file: f}, nil
+	case deadLetterKafka:
+		if conf.RESTProxyURL == "" || conf.Topic == "" {
+			return nil, errors.New("dead letter kafka sink requires restProxyURL and topic")
+		}
+		return &kafkaRESTSink{url: strings.TrimSuffix(conf.RESTProxyURL, "/") + "/topics/" + conf.Topic, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown dead letter type: %q", conf.Type)
+	}
+}
+
+// isRejected reports whether the destination permanently rejected the events,
+// i.e. responded with a client error other than throttling
+func isRejected(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) &&
+		statusErr.StatusCode >= http.StatusBadRequest &&
+		statusErr.StatusCode < http.StatusInternalServerError &&
+		statusErr.StatusCode != http.StatusTooManyRequests
+}
+
+// newDeadLetter describes the rejection of an event by a destination
+func newDeadLetter(destinationName string, event Event, err error) deadLetter {
+	letter := deadLetter{
+		Destination: destinationName,
+		Reason:      err.Error(),
+		RejectedAt:  time.Now(),
+		Event:       event,
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		letter.StatusCode = statusErr.StatusCode
+	}
+	return letter
+}
+
+// discardSink drops dead letters; they are only counted
+type discardSink struct{}
+
+func (discardSink) write(context.Context, deadLetter) error {
+	return nil
+}
+
+// fileSink appends dead letters to a file as newline delimited JSON
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (f *fileSink) write(_ context.Context, letter deadLetter) error {
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the file; dead letters written afterwards fail
+func (f *fileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// kafkaRESTSink produces dead letters to a Kafka topic through the Kafka REST Proxy,
+// keyed by destination name
+type kafkaRESTSink struct {
+	url    string
+	client *http.Client
+}
+
+func (k *kafkaRESTSink) write(ctx context.Context, letter deadLetter) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{{
+			"key":   letter.Destination,
+			"value": letter,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	return post(ctx, k.client, k.url, kafkaRESTJSONMediaType, body, nil)
+}
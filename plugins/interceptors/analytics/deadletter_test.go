@@ -0,0 +1,138 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRejected(t *testing.T) {
+	assert.True(t, isRejected(&StatusError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, isRejected(&StatusError{StatusCode: http.StatusUnprocessableEntity}))
+	assert.False(t, isRejected(&StatusError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, isRejected(&StatusError{StatusCode: http.StatusBadGateway}))
+	assert.False(t, isRejected(errors.New("connection reset")))
+}
+
+func TestNewDeadLetterSinkValidation(t *testing.T) {
+	sink, err := newDeadLetterSink(DeadLetterConfig{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, discardSink{}, sink)
+
+	_, err = newDeadLetterSink(DeadLetterConfig{Type: deadLetterFile}, nil)
+	assert.Error(t, err)
+	_, err = newDeadLetterSink(DeadLetterConfig{Type: deadLetterKafka, Topic: "dlq"}, nil)
+	assert.Error(t, err)
+	_, err = newDeadLetterSink(DeadLetterConfig{Type: "s3"}, nil)
+	assert.Error(t, err)
+}
+
+func TestFileDeadLetterSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.ndjson")
+	sink, err := newDeadLetterSink(DeadLetterConfig{Type: deadLetterFile, Path: path}, nil)
+	require.NoError(t, err)
+
+	rejection := &StatusError{StatusCode: http.StatusBadRequest, Body: "invalid event name"}
+	require.NoError(t, sink.write(context.Background(), newDeadLetter("ga4", Event{Name: "api_request"}, rejection)))
+	require.NoError(t, sink.write(context.Background(), newDeadLetter("ga4", Event{Name: "other"}, rejection)))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	var letter deadLetter
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &letter))
+	assert.Equal(t, "ga4", letter.Destination)
+	assert.Equal(t, http.StatusBadRequest, letter.StatusCode)
+	assert.Contains(t, letter.Reason, "invalid event name")
+	assert.Equal(t, "api_request", letter.Event.Name)
+}
+
+func TestKafkaRESTDeadLetterSink(t *testing.T) {
+	var payload struct {
+		Records []struct {
+			Key   string     `json:"key"`
+			Value deadLetter `json:"value"`
+		} `json:"records"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/analytics-dlq", r.URL.Path)
+		assert.Equal(t, kafkaRESTJSONMediaType, r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	sink, err := newDeadLetterSink(DeadLetterConfig{Type: deadLetterKafka, RESTProxyURL: ts.URL + "/", Topic: "analytics-dlq"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, sink.write(context.Background(), newDeadLetter("posthog", Event{Name: "api_request"}, &StatusError{StatusCode: 401})))
+
+	require.Len(t, payload.Records, 1)
+	assert.Equal(t, "posthog", payload.Records[0].Key)
+	assert.Equal(t, 401, payload.Records[0].Value.StatusCode)
+}
+
+type recordingSink struct {
+	letters []deadLetter
+}
+
+func (r *recordingSink) write(_ context.Context, letter deadLetter) error {
+	r.letters = append(r.letters, letter)
+	return nil
+}
+
+func TestDispatcherRoutesRejectedEventsToDeadLetters(t *testing.T) {
+	backend := newMockBackend()
+	backend.err = &StatusError{StatusCode: http.StatusBadRequest, Body: "bad"}
+	sink := &recordingSink{}
+	d := &dispatcher{
+		destinations: []destination{{name: "mock", backend: backend}},
+		deadLetters:  sink,
+		metrics:      newAnalyticsMetrics(),
+		ctx:          context.Background(),
+	}
+
+	before := expvarValue("counter.analytics.dispatch.deadLetters")
+	d.deliver([]Event{{Name: "api_request"}})
+	backend.next(t)
+
+	require.Len(t, sink.letters, 1)
+	assert.Equal(t, "mock", sink.letters[0].Destination)
+	assert.Equal(t, before+1, expvarValue("counter.analytics.dispatch.deadLetters"))
+}
+
+func TestDispatcherClosesDeadLetterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letters.ndjson")
+	d := newDispatcher([]destination{{name: "mock", backend: newMockBackend()}}, dispatcherOptions{
+		deadLetter: DeadLetterConfig{Type: deadLetterFile, Path: path},
+	}, newAnalyticsMetrics())
+	sink := d.deadLetters.(*fileSink)
+
+	require.NoError(t, d.close(context.Background()))
+	assert.ErrorIs(t, sink.file.Close(), os.ErrClosed)
+}
@@ -0,0 +1,148 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+const (
+	decidePath   = "/v1/decide"
+	activatePath = "/v1/activate"
+)
+
+// decision holds the fields of a /v1/decide response used for enrichment
+type decision struct {
+	FlagKey                 string `json:"flagKey"`
+	VariationKey            string `json:"variationKey"`
+	RuleKey                 string `json:"ruleKey"`
+	Enabled                 bool   `json:"enabled"`
+	IsEveryoneElseVariation bool   `json:"isEveryoneElseVariation"`
+}
+
+// ruleType classifies how the decision was made: by the "Everyone Else" rollout rule, by any
+// other experiment or delivery rule, or by no rule at all (the flag's default)
+func (d decision) ruleType() string {
+	switch {
+	case d.IsEveryoneElseVariation:
+		return "everyone_else"
+	case d.RuleKey != "":
+		return "rule"
+	default:
+		return "none"
+	}
+}
+
+// activateDecision holds the fields of a /v1/activate response decision used for enrichment
+type activateDecision struct {
+	ExperimentKey string `json:"experimentKey"`
+	FeatureKey    string `json:"featureKey"`
+	VariationKey  string `json:"variationKey"`
+	Enabled       bool   `json:"enabled"`
+	Error         string `json:"error"`
+}
+
+// hasDecisions reports whether the responses of path carry decisions
+func hasDecisions(path string) bool {
+	return path == decidePath || path == activatePath
+}
+
+// parseResponseDecisions reads the decisions of a /v1/decide or /v1/activate response body
+func parseResponseDecisions(path string, body []byte) ([]decision, bool) {
+	if path == activatePath {
+		return parseActivateDecisions(body)
+	}
+	return parseDecisions(body)
+}
+
+// parseActivateDecisions reads a /v1/activate response body, an array of experiment and feature
+// decisions. The experiment of a decision is its rule and the feature its flag; failed decisions
+// are skipped.
+func parseActivateDecisions(body []byte) ([]decision, bool) {
+	var activated []activateDecision
+	if err := json.Unmarshal(body, &activated); err != nil {
+		return nil, false
+	}
+	decisions := make([]decision, 0, len(activated))
+	for _, d := range activated {
+		if d.Error != "" {
+			continue
+		}
+		decisions = append(decisions, decision{
+			FlagKey:      d.FeatureKey,
+			VariationKey: d.VariationKey,
+			RuleKey:      d.ExperimentKey,
+			Enabled:      d.Enabled,
+		})
+	}
+	return decisions, true
+}
+
+// parseDecisions reads a /v1/decide response body, which is a single decision when one flag
+// key was requested and an array of decisions otherwise
+func parseDecisions(body []byte) ([]decision, bool) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, false
+	}
+
+	var decisions []decision
+	if body[0] == '[' {
+		if err := json.Unmarshal(body, &decisions); err != nil {
+			return nil, false
+		}
+		return decisions, true
+	}
+
+	var d decision
+	if err := json.Unmarshal(body, &d); err != nil {
+		return nil, false
+	}
+	return []decision{d}, true
+}
+
+// addDecisionParams attaches the flag, variation and rule details of the decisions to the
+// event params. Several decisions are joined with commas, in response order.
+func addDecisionParams(params map[string]interface{}, decisions []decision) {
+	if len(decisions) == 0 {
+		return
+	}
+
+	flagKeys := make([]string, 0, len(decisions))
+	variationKeys := make([]string, 0, len(decisions))
+	ruleKeys := make([]string, 0, len(decisions))
+	ruleTypes := make([]string, 0, len(decisions))
+	enabled := 0
+	for _, d := range decisions {
+		flagKeys = append(flagKeys, d.FlagKey)
+		variationKeys = append(variationKeys, d.VariationKey)
+		ruleKeys = append(ruleKeys, d.RuleKey)
+		ruleTypes = append(ruleTypes, d.ruleType())
+		if d.Enabled {
+			enabled++
+		}
+	}
+
+	params[flagKeysParam] = strings.Join(flagKeys, ",")
+	params[variationKeysParam] = strings.Join(variationKeys, ",")
+	params[ruleKeysParam] = strings.Join(ruleKeys, ",")
+	params[ruleTypesParam] = strings.Join(ruleTypes, ",")
+	params[decisionCountParam] = len(decisions)
+	params[enabledCountParam] = enabled
+}
@@ -0,0 +1,107 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecisions(t *testing.T) {
+	decisions, ok := parseDecisions([]byte(`{"flagKey":"checkout","variationKey":"on","ruleKey":"ab_test","enabled":true}`))
+	require.True(t, ok)
+	assert.Equal(t, []decision{{FlagKey: "checkout", VariationKey: "on", RuleKey: "ab_test", Enabled: true}}, decisions)
+
+	decisions, ok = parseDecisions([]byte(` [{"flagKey":"a"},{"flagKey":"b"}]`))
+	require.True(t, ok)
+	assert.Len(t, decisions, 2)
+
+	for _, body := range []string{"", "not json", `{"error":"bad"`} {
+		_, ok := parseDecisions([]byte(body))
+		assert.False(t, ok, body)
+	}
+}
+
+func TestParseActivateDecisions(t *testing.T) {
+	body := []byte(`[
+		{"userId":"user-1","experimentKey":"checkout_test","variationKey":"treatment","type":"experiment","enabled":true},
+		{"userId":"user-1","experimentKey":"banner_rollout","featureKey":"banner","variationKey":"on","type":"feature","enabled":true},
+		{"userId":"user-1","experimentKey":"missing","type":"experiment","error":"experiment not found"}
+	]`)
+	decisions, ok := parseResponseDecisions(activatePath, body)
+	require.True(t, ok)
+	assert.Equal(t, []decision{
+		{VariationKey: "treatment", RuleKey: "checkout_test", Enabled: true},
+		{FlagKey: "banner", VariationKey: "on", RuleKey: "banner_rollout", Enabled: true},
+	}, decisions)
+
+	_, ok = parseResponseDecisions(activatePath, []byte(`{"flagKey":"checkout"}`))
+	assert.False(t, ok)
+}
+
+func TestDecisionRuleType(t *testing.T) {
+	assert.Equal(t, "everyone_else", decision{RuleKey: "rollout", IsEveryoneElseVariation: true}.ruleType())
+	assert.Equal(t, "rule", decision{RuleKey: "ab_test"}.ruleType())
+	assert.Equal(t, "none", decision{}.ruleType())
+}
+
+func TestAddDecisionParams(t *testing.T) {
+	params := map[string]interface{}{}
+	addDecisionParams(params, []decision{
+		{FlagKey: "checkout", VariationKey: "on", RuleKey: "ab_test", Enabled: true},
+		{FlagKey: "banner", VariationKey: "off"},
+	})
+	assert.Equal(t, map[string]interface{}{
+		"flag_keys":      "checkout,banner",
+		"variation_keys": "on,off",
+		"rule_keys":      "ab_test,",
+		"rule_types":     "rule,none",
+		"decision_count": 2,
+		"enabled_count":  1,
+	}, params)
+}
+
+func TestAnalyticsEnrichDecisions(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, CaptureResponseBody: true, EnrichDecisions: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"flagKey":"checkout","variationKey":"on","ruleKey":"ab_test","enabled":true}`))
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/decide?keys=checkout", nil))
+	event := backend.next(t)
+	assert.Equal(t, "checkout", event.Params["flag_keys"])
+	assert.Equal(t, "on", event.Params["variation_keys"])
+
+	// Activate responses are enriched as well
+	handler = a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"experimentKey":"ab_test","variationKey":"on","type":"experiment"}]`))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/activate?experimentKey=ab_test", nil))
+	event = backend.next(t)
+	assert.Equal(t, "ab_test", event.Params["rule_keys"])
+	assert.Equal(t, "on", event.Params["variation_keys"])
+
+	// Other routes are not enriched
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	assert.NotContains(t, backend.next(t).Params, "flag_keys")
+}
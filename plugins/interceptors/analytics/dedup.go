@@ -0,0 +1,107 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	defaultDedupWindow     = 5 * time.Minute
+	defaultDedupMaxEntries = 100000
+)
+
+// DedupConfig drops events repeating one of the same request ID, so retried client requests
+// reusing their X-Request-Id and replays within the agent aren't counted twice downstream
+type DedupConfig struct {
+	Enabled    bool           `json:"enabled"`
+	Window     utils.Duration `json:"window"`     // Time an event is remembered for (defaults to 5m)
+	MaxEntries int            `json:"maxEntries"` // Events remembered in memory; the least recently seen are evicted (defaults to 100000)
+}
+
+// dedupEntry is an event remembered by dedupWindow
+type dedupEntry struct {
+	key      string
+	lastSeen time.Time
+}
+
+// dedupWindow remembers the events of recent request IDs. It is an LRU of event keys with a TTL,
+// like sessionStore. A nil window remembers nothing.
+type dedupWindow struct {
+	window     time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently seen first
+}
+
+func newDedupWindow(conf DedupConfig) *dedupWindow {
+	if !conf.Enabled {
+		return nil
+	}
+
+	w := &dedupWindow{
+		window:     conf.Window.Duration,
+		maxEntries: conf.MaxEntries,
+		now:        time.Now,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+	if w.window <= 0 {
+		w.window = defaultDedupWindow
+	}
+	if w.maxEntries <= 0 {
+		w.maxEntries = defaultDedupMaxEntries
+	}
+	return w
+}
+
+// duplicate records the event and reports whether an event of the same name and request ID was
+// seen within the window. Events without a request ID are never duplicates.
+func (w *dedupWindow) duplicate(event Event) bool {
+	if w == nil || event.RequestID == "" {
+		return false
+	}
+	key := event.RequestID + "\x00" + event.Name
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	if el, ok := w.entries[key]; ok {
+		entry := el.Value.(*dedupEntry)
+		w.order.MoveToFront(el)
+		// Repeats keep the entry alive, so a request retried in a loop is counted once
+		seen := now.Sub(entry.lastSeen) < w.window
+		entry.lastSeen = now
+		return seen
+	}
+
+	w.entries[key] = w.order.PushFront(&dedupEntry{key: key, lastSeen: now})
+	if w.order.Len() > w.maxEntries {
+		oldest := w.order.Back()
+		w.order.Remove(oldest)
+		delete(w.entries, oldest.Value.(*dedupEntry).key)
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func TestDedupWindowDisabled(t *testing.T) {
+	w := newDedupWindow(DedupConfig{})
+	assert.Nil(t, w)
+
+	// A nil window remembers nothing
+	assert.False(t, w.duplicate(Event{Name: "api_request", RequestID: "request"}))
+}
+
+func TestDedupWindowDuplicate(t *testing.T) {
+	w := newDedupWindow(DedupConfig{Enabled: true, Window: utils.Duration{Duration: time.Minute}})
+	require.NotNil(t, w)
+	now := time.Unix(1700000000, 0)
+	w.now = func() time.Time { return now }
+
+	event := Event{Name: "api_request", RequestID: "request"}
+	assert.False(t, w.duplicate(event))
+	assert.True(t, w.duplicate(event))
+
+	// Other events of the request and events without a request ID aren't duplicates
+	assert.False(t, w.duplicate(Event{Name: "decision", RequestID: "request"}))
+	assert.False(t, w.duplicate(Event{Name: "api_request"}))
+	assert.False(t, w.duplicate(Event{Name: "api_request"}))
+
+	// Repeats within the window extend it
+	now = now.Add(50 * time.Second)
+	assert.True(t, w.duplicate(event))
+	now = now.Add(50 * time.Second)
+	assert.True(t, w.duplicate(event))
+
+	now = now.Add(time.Minute)
+	assert.False(t, w.duplicate(event))
+}
+
+func TestDedupWindowEvictsLeastRecentlySeen(t *testing.T) {
+	w := newDedupWindow(DedupConfig{Enabled: true, MaxEntries: 2})
+	w.duplicate(Event{Name: "api_request", RequestID: "a"})
+	w.duplicate(Event{Name: "api_request", RequestID: "b"})
+	w.duplicate(Event{Name: "api_request", RequestID: "a"})
+	w.duplicate(Event{Name: "api_request", RequestID: "c"})
+
+	assert.Len(t, w.entries, 2)
+	assert.False(t, w.duplicate(Event{Name: "api_request", RequestID: "b"}))
+}
@@ -0,0 +1,78 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// dimension is an extra event param declared in config as "<source>:<key>", where the
+// source is "header", "query", "jwt" (a claim of the bearer token) or "static"
+type dimension struct {
+	param  string
+	source string
+	key    string
+}
+
+// newDimensions parses the declared params, returning errors for invalid declarations
+func newDimensions(params map[string]string) ([]dimension, []error) {
+	var dims []dimension
+	var errs []error
+	for param, spec := range params {
+		source, key, ok := strings.Cut(spec, ":")
+		if !ok || key == "" {
+			errs = append(errs, fmt.Errorf("invalid analytics param %q: expected <source>:<key>, got %q", param, spec))
+			continue
+		}
+		switch source {
+		case "header", "query", "jwt", "static":
+			dims = append(dims, dimension{param: param, source: source, key: key})
+		default:
+			errs = append(errs, fmt.Errorf("invalid analytics param %q: unknown source %q", param, source))
+		}
+	}
+	sort.Slice(dims, func(i, j int) bool { return dims[i].param < dims[j].param })
+	return dims, errs
+}
+
+// value returns the dimension's value for the request, or false when the request doesn't carry it
+func (d dimension) value(r *http.Request) (string, bool) {
+	var v string
+	switch d.source {
+	case "header":
+		v = r.Header.Get(d.key)
+	case "query":
+		v = r.URL.Query().Get(d.key)
+	case "jwt":
+		v = claimString(bearerClaims(r), d.key)
+	case "static":
+		v = d.key
+	}
+	return v, v != ""
+}
+
+// addDimensions sets the declared params that are present on the request
+func addDimensions(params map[string]interface{}, dims []dimension, r *http.Request) {
+	for _, d := range dims {
+		if v, ok := d.value(r); ok {
+			params[d.param] = v
+		}
+	}
+}
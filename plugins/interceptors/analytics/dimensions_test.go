@@ -0,0 +1,75 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDimensions(t *testing.T) {
+	dims, errs := newDimensions(map[string]string{
+		"app_version": "header:X-App-Version",
+		"env":         "static:prod",
+		"campaign":    "query:utm_campaign",
+		"plan":        "jwt:plan",
+		"missing":     "header",
+		"unknown":     "cookie:session",
+	})
+	assert.Len(t, errs, 2)
+	assert.Equal(t, []dimension{
+		{param: "app_version", source: "header", key: "X-App-Version"},
+		{param: "campaign", source: "query", key: "utm_campaign"},
+		{param: "env", source: "static", key: "prod"},
+		{param: "plan", source: "jwt", key: "plan"},
+	}, dims)
+}
+
+func TestAddDimensions(t *testing.T) {
+	dims, _ := newDimensions(map[string]string{
+		"app_version": "header:X-App-Version",
+		"env":         "static:prod",
+		"campaign":    "query:utm_campaign",
+	})
+
+	req := httptest.NewRequest("GET", "/v1/config?utm_campaign=spring", nil)
+	req.Header.Set("X-App-Version", "2.1.0")
+	params := map[string]interface{}{}
+	addDimensions(params, dims, req)
+	assert.Equal(t, map[string]interface{}{"app_version": "2.1.0", "env": "prod", "campaign": "spring"}, params)
+
+	// Params missing from the request are omitted
+	params = map[string]interface{}{}
+	addDimensions(params, dims, httptest.NewRequest("GET", "/v1/config", nil))
+	assert.Equal(t, map[string]interface{}{"env": "prod"}, params)
+}
+
+func TestAnalyticsCustomParams(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Params: map[string]string{"app_version": "header:X-App-Version"}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("X-App-Version", "2.1.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "2.1.0", backend.next(t).Params["app_version"])
+}
@@ -0,0 +1,524 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultQueueSize = 1000
+	defaultWorkers   = 2
+)
+
+// dispatcherOptions holds the settings of a dispatcher; zero values select the defaults
+type dispatcherOptions struct {
+	queueSize  int
+	workers    int
+	batch      BatchConfig
+	retry      RetryConfig
+	breaker    CircuitBreakerConfig
+	spill      SpillConfig
+	deadLetter DeadLetterConfig
+	httpClient *http.Client
+	rateLimit  RateLimitConfig
+	dedup      DedupConfig
+	priority   PriorityConfig
+	residency  ResidencyConfig
+	routing    []RoutingRule
+	// sampleByClientID samples routed events deterministically by client ID
+	sampleByClientID bool
+}
+
+// dispatcher delivers events to the destinations from a bounded in-memory queue. With priority
+// lanes, low priority events are queued separately and only delivered when no other events are.
+type dispatcher struct {
+	queue        chan Event
+	lowQueue     chan Event // nil without priority lanes
+	lanes        *priorityLanes
+	destinations []destination
+	batch        BatchConfig
+	retry        RetryConfig
+	spill        *spillQueue
+	deadLetters  deadLetterSink
+	limiter      *rateLimiter
+	dedup        *dedupWindow
+	routes       *residencyRoutes
+	routing      *eventRoutes
+	metrics      *analyticsMetrics
+	stats        dispatchStats
+	audit        *auditor
+
+	// ctx is cancelled when the dispatcher is closed and its drain timeout expires,
+	// aborting outstanding deliveries
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+	mu      sync.RWMutex // guards closed against enqueuing on the closed queue
+	closed  bool
+}
+
+// newDispatcher creates a dispatcher and starts its workers
+func newDispatcher(destinations []destination, opts dispatcherOptions, m *analyticsMetrics) *dispatcher {
+	if opts.queueSize <= 0 {
+		opts.queueSize = defaultQueueSize
+	}
+	if opts.workers <= 0 {
+		opts.workers = defaultWorkers
+	}
+
+	// Every destination gets its own breaker so one failing backend doesn't affect the others
+	dests := make([]destination, 0, len(destinations))
+	for _, dest := range destinations {
+		gauge := m.breakerState(dest.name)
+		gauge.Set(float64(breakerClosed))
+		dest.breaker = newCircuitBreaker(opts.breaker, func(state breakerState) {
+			gauge.Set(float64(state))
+		})
+		dest.stats = &destinationStats{}
+		dests = append(dests, dest)
+	}
+
+	lanes, errs := newPriorityLanes(opts.priority)
+	for _, err := range errs {
+		log.Error().Err(err).Msg("Skipping analytics low priority path")
+	}
+
+	d := &dispatcher{
+		queue:        make(chan Event, opts.queueSize),
+		lanes:        lanes,
+		destinations: dests,
+		batch:        opts.batch,
+		retry:        opts.retry,
+		limiter:      newRateLimiter(opts.rateLimit),
+		dedup:        newDedupWindow(opts.dedup),
+		routes:       newResidencyRoutes(opts.residency),
+		routing:      newEventRoutes(opts.routing, opts.sampleByClientID),
+		metrics:      m,
+	}
+	if lanes != nil {
+		d.lowQueue = make(chan Event, opts.priority.lowQueueSize(opts.queueSize))
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+	sink, err := newDeadLetterSink(opts.deadLetter, opts.httpClient)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create analytics dead letter sink, discarding dead letters")
+		sink = discardSink{}
+	}
+	d.deadLetters = sink
+
+	if opts.spill.Directory != "" {
+		spill, err := openSpillQueue(opts.spill, m)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to open analytics spill queue")
+		} else {
+			d.spill = spill
+			spill.attach(d.replay)
+		}
+	}
+
+	d.workers.Add(opts.workers)
+	for i := 0; i < opts.workers; i++ {
+		go d.run()
+	}
+	return d
+}
+
+// close stops accepting events and waits for the workers to deliver the queued ones. When ctx
+// expires first, outstanding deliveries are cancelled and the remaining events are spilled to
+// disk if enabled, otherwise dropped.
+func (d *dispatcher) close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	close(d.queue)
+	if d.lowQueue != nil {
+		close(d.lowQueue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+
+	defer func() {
+		d.cancel()
+		if d.spill != nil {
+			d.spill.detach()
+		}
+		if closer, ok := d.deadLetters.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Warn().Err(err).Msg("Failed to close the analytics dead letter sink")
+			}
+		}
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// isClosed reports whether the dispatcher was closed
+func (d *dispatcher) isClosed() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.closed
+}
+
+// enqueue adds the event to the queue without blocking. Duplicates of recent events are
+// dropped and events over the rate limit are handled according to the rate limit policy.
+// Low priority events are shed once the high priority lane fills up. When the queue is full
+// the event is spilled to disk if enabled, otherwise it is dropped and false is returned.
+func (d *dispatcher) enqueue(event Event) bool {
+	if d.dedup.duplicate(event) {
+		d.metrics.duplicates.Add(1)
+		d.stats.duplicates.Add(1)
+		d.audit.count(auditDuplicate)
+		return false
+	}
+
+	if d.limiter != nil {
+		admitted, sampleRate := d.limiter.admit()
+		if !admitted {
+			return d.overLimit(event)
+		}
+		applySampleRate(&event, sampleRate)
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		d.metrics.dispatchDropped.Add(1)
+		d.stats.dropped.Add(1)
+		d.audit.count(auditDropped)
+		return false
+	}
+
+	if d.lanes.lowPriority(event) {
+		return d.enqueueLow(event)
+	}
+	if d.tryEnqueue(event) {
+		d.stats.accepted.Add(1)
+		return true
+	}
+
+	if d.spill != nil {
+		if err := d.spill.write(spillRecord{SpilledAt: time.Now(), Event: event}); err == nil {
+			d.stats.accepted.Add(1)
+			return true
+		}
+	}
+	d.metrics.dispatchDropped.Add(1)
+	d.stats.dropped.Add(1)
+	d.audit.count(auditDropped)
+	log.Warn().Msg("Analytics queue is full, dropping event")
+	return false
+}
+
+// overLimit applies the rate limit policy to an event that exceeded the rate limit
+func (d *dispatcher) overLimit(event Event) bool {
+	d.metrics.rateLimited.Add(1)
+	d.stats.rateLimited.Add(1)
+
+	if d.limiter.policy == rateLimitSpill && d.spill != nil {
+		if d.spill.write(spillRecord{SpilledAt: time.Now(), Event: event}) == nil {
+			return true
+		}
+	}
+	d.audit.count(auditRateLimited)
+	return false
+}
+
+// enqueueLow adds a low priority event to its lane, shedding it when the high priority lane is
+// under pressure or the low priority lane is full. Callers hold d.mu.
+func (d *dispatcher) enqueueLow(event Event) bool {
+	if !d.lanes.shed(len(d.queue), cap(d.queue)) {
+		select {
+		case d.lowQueue <- event:
+			d.metrics.queueDepth.Set(float64(d.queued()))
+			d.stats.accepted.Add(1)
+			return true
+		default:
+		}
+	}
+	d.metrics.shed.Add(1)
+	d.stats.shed.Add(1)
+	d.audit.count(auditDropped)
+	return false
+}
+
+// tryEnqueue adds the event to the queue if there is room. Callers hold d.mu.
+func (d *dispatcher) tryEnqueue(event Event) bool {
+	select {
+	case d.queue <- event:
+		d.metrics.queueDepth.Set(float64(d.queued()))
+		return true
+	default:
+		return false
+	}
+}
+
+// queued returns the number of events waiting in both lanes
+func (d *dispatcher) queued() int {
+	return len(d.queue) + len(d.lowQueue)
+}
+
+// capacity returns the number of events both lanes can hold
+func (d *dispatcher) capacity() int {
+	return cap(d.queue) + cap(d.lowQueue)
+}
+
+func (d *dispatcher) run() {
+	defer d.workers.Done()
+	for {
+		batch, ok := d.nextBatch()
+		if !ok {
+			return
+		}
+		d.metrics.queueDepth.Set(float64(d.queued()))
+		if d.ctx.Err() != nil {
+			// The drain timeout expired, keep the events for the next start if possible
+			for _, event := range batch {
+				d.spillEvent(event)
+			}
+			continue
+		}
+		d.deliver(batch)
+	}
+}
+
+// next waits for the next event to deliver, taking low priority events only when there are no
+// others. It returns false once both lanes are closed and drained.
+func (d *dispatcher) next() (Event, bool) {
+	if d.lowQueue == nil {
+		event, ok := <-d.queue
+		return event, ok
+	}
+
+	select {
+	case event, ok := <-d.queue:
+		if ok {
+			return event, true
+		}
+		event, ok = <-d.lowQueue
+		return event, ok
+	default:
+	}
+	select {
+	case event, ok := <-d.queue:
+		if ok {
+			return event, true
+		}
+		event, ok = <-d.lowQueue
+		return event, ok
+	case event, ok := <-d.lowQueue:
+		if ok {
+			return event, true
+		}
+		event, ok = <-d.queue
+		return event, ok
+	}
+}
+
+// spillEvent keeps an event that was never delivered for a later replay, dropping it when
+// spilling is disabled
+func (d *dispatcher) spillEvent(event Event) {
+	if d.spill != nil && d.spill.write(spillRecord{SpilledAt: time.Now(), Event: event}) == nil {
+		return
+	}
+	d.metrics.dispatchDropped.Add(1)
+	d.stats.dropped.Add(1)
+	d.audit.count(auditDropped)
+}
+
+// deliver sends every destination the events of the batch routed to it, in a single call
+func (d *dispatcher) deliver(batch []Event) {
+	for _, dest := range d.destinations {
+		events := make([]Event, 0, len(batch))
+		for _, original := range batch {
+			if !d.routes.allows(dest.name, original.Region) {
+				continue
+			}
+			if event, ok := d.routing.route(dest.name, original); ok {
+				events = append(events, event)
+			}
+		}
+		if len(events) == 0 {
+			continue
+		}
+		dest.stats.addN(statAccepted, len(events))
+		if dest.breaker != nil && !dest.breaker.allow() {
+			d.metrics.shortCircuited.Add(float64(len(events)))
+			dest.stats.addN(statShortCircuited, len(events))
+			for _, event := range events {
+				d.spillFor(dest, event)
+			}
+			continue
+		}
+
+		ctx, span := startDispatchSpan(d.ctx, events, dest.name)
+		err := sendWithRetry(ctx, dest, events, d.retry, func(err error) {
+			d.metrics.dispatchRetries.Add(1)
+			dest.stats.add(statRetried)
+			log.Debug().Err(err).Str("backend", dest.name).Msg("Retrying analytics delivery")
+		})
+		endDispatchSpan(span, err)
+		if dest.breaker != nil {
+			dest.breaker.record(err == nil)
+		}
+		dest.stats.recordResult(err, len(events))
+		if err != nil {
+			d.metrics.dispatchFailures.Add(1)
+			log.Error().Err(err).Str("backend", dest.name).Int("events", len(events)).Msg("Failed to send analytics data")
+			for _, event := range events {
+				switch {
+				case isRetryable(err), d.ctx.Err() != nil:
+					d.spillFor(dest, event)
+				case isRejected(err):
+					d.deadLetter(dest, event, err)
+				default:
+					dest.stats.add(statDropped)
+				}
+			}
+		}
+	}
+}
+
+// deadLetter routes an event the destination permanently rejected to the dead letter sink
+func (d *dispatcher) deadLetter(dest destination, event Event, err error) {
+	d.metrics.deadLetters.Add(1)
+	dest.stats.add(statDeadLettered)
+	if d.deadLetters == nil {
+		return
+	}
+	if sinkErr := d.deadLetters.write(context.Background(), newDeadLetter(dest.name, event, err)); sinkErr != nil {
+		log.Error().Err(sinkErr).Str("backend", dest.name).Msg("Failed to write analytics dead letter")
+	}
+}
+
+// spillFor keeps an event that could not be delivered to dest for a later replay, dropping it
+// when spilling is disabled
+func (d *dispatcher) spillFor(dest destination, event Event) {
+	if d.spill == nil {
+		dest.stats.add(statDropped)
+		return
+	}
+	rec := spillRecord{Destination: dest.name, SpilledAt: time.Now(), Event: event}
+	if err := d.spill.write(rec); err != nil {
+		log.Error().Err(err).Str("backend", dest.name).Msg("Failed to spill analytics event")
+		dest.stats.add(statDropped)
+		return
+	}
+	dest.stats.add(statSpilled)
+}
+
+// replay delivers spilled records, a batch of records of the same destination at a time, and
+// returns how many from the start of recs were handled and can be removed from the spill queue
+func (d *dispatcher) replay(recs []spillRecord) int {
+	handled := 0
+	for handled < len(recs) {
+		n := d.replayBatch(recs[handled:])
+		if n == 0 {
+			break
+		}
+		handled += n
+	}
+	return handled
+}
+
+// replayBatch replays the leading records of recs bound for the same destination, up to the
+// batch size, returning how many were handled
+func (d *dispatcher) replayBatch(recs []spillRecord) int {
+	name := recs[0].Destination
+	n := 1
+	for n < len(recs) && n < d.batch.maxEvents() && recs[n].Destination == name {
+		n++
+	}
+	recs = recs[:n]
+
+	if name == "" {
+		// Events spilled by the rate limiter are queued again within the same limit
+		for i, rec := range recs {
+			if !d.requeue(rec.Event) {
+				return i
+			}
+		}
+		return n
+	}
+
+	for _, dest := range d.destinations {
+		if dest.name != name {
+			continue
+		}
+		if dest.breaker != nil && !dest.breaker.allow() {
+			return 0
+		}
+
+		events := make([]Event, len(recs))
+		for i, rec := range recs {
+			events[i] = rec.Event
+		}
+		err := sendWithRetry(d.ctx, dest, events, d.retry, func(error) {
+			d.metrics.dispatchRetries.Add(1)
+			dest.stats.add(statRetried)
+		})
+		if dest.breaker != nil {
+			dest.breaker.record(err == nil)
+		}
+		dest.stats.recordResult(err, len(events))
+		if err != nil && (isRetryable(err) || d.ctx.Err() != nil) {
+			return 0
+		}
+		if err != nil {
+			log.Error().Err(err).Str("backend", dest.name).Int("events", len(events)).Msg("Failed to replay analytics events")
+			if isRejected(err) {
+				for _, event := range events {
+					d.deadLetter(dest, event, err)
+				}
+			}
+		}
+		return n
+	}
+
+	// The destination is no longer configured
+	return n
+}
+
+// requeue queues a spilled event again, within the rate limit when it was spilled by it
+func (d *dispatcher) requeue(event Event) bool {
+	if d.limiter != nil && d.limiter.policy == rateLimitSpill && !d.limiter.allow() {
+		return false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return !d.closed && d.tryEnqueue(event)
+}
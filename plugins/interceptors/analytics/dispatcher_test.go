@@ -0,0 +1,91 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// expvarValue returns the current value of an expvar metric from the fallback registry
+func expvarValue(name string) float64 {
+	v := expvar.Get(name)
+	if v == nil {
+		return 0
+	}
+	f, _ := strconv.ParseFloat(v.String(), 64)
+	return f
+}
+
+func TestDispatcherDeliversToAllDestinations(t *testing.T) {
+	first, second := newMockBackend(), newMockBackend()
+	d := newDispatcher([]destination{
+		{name: "first", backend: first},
+		{name: "second", backend: second},
+	}, dispatcherOptions{}, newAnalyticsMetrics())
+
+	assert.True(t, d.enqueue(Event{Name: "api_request"}))
+	assert.Equal(t, "api_request", first.next(t).Name)
+	assert.Equal(t, "api_request", second.next(t).Name)
+}
+
+func TestDispatcherCountsFailures(t *testing.T) {
+	backend := newMockBackend()
+	backend.err = errors.New("unavailable")
+	d := newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{workers: 1}, newAnalyticsMetrics())
+
+	before := expvarValue("counter.analytics.dispatch.failures")
+	d.enqueue(Event{Name: "api_request"})
+	backend.next(t)
+
+	assert.Eventually(t, func() bool {
+		return expvarValue("counter.analytics.dispatch.failures") == before+1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDispatcherDropsWhenQueueIsFull(t *testing.T) {
+	m := newAnalyticsMetrics()
+	// No workers are started so the queue is never drained
+	d := &dispatcher{queue: make(chan Event, 1), metrics: m, ctx: context.Background()}
+
+	before := expvarValue("counter.analytics.dispatch.dropped")
+	assert.True(t, d.enqueue(Event{}))
+	assert.False(t, d.enqueue(Event{}))
+	assert.Equal(t, before+1, expvarValue("counter.analytics.dispatch.dropped"))
+	assert.Equal(t, float64(1), expvarValue("gauge.analytics.queue.depth"))
+}
+
+func TestDispatcherDropsDuplicates(t *testing.T) {
+	backend := newMockBackend()
+	d := newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{
+		dedup: DedupConfig{Enabled: true},
+	}, newAnalyticsMetrics())
+
+	before := expvarValue("counter.analytics.dispatch.duplicates")
+	assert.True(t, d.enqueue(Event{Name: "api_request", RequestID: "request"}))
+	assert.False(t, d.enqueue(Event{Name: "api_request", RequestID: "request"}))
+	assert.Equal(t, "request", backend.next(t).RequestID)
+	assert.Equal(t, before+1, expvarValue("counter.analytics.dispatch.duplicates"))
+	assert.Equal(t, int64(1), d.stats.duplicates.Load())
+	assert.Equal(t, int64(1), d.stats.accepted.Load())
+}
@@ -0,0 +1,175 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// dryRunKey is the context key of the dryRunTarget of a delivery
+type dryRunKey struct{}
+
+// dryRunTarget identifies the destination whose payloads are recorded by sink
+type dryRunTarget struct {
+	sink        *dryRunSink
+	destination string
+}
+
+// dryRunFrom returns the dry-run target of ctx, or nil when payloads should be sent
+func dryRunFrom(ctx context.Context) *dryRunTarget {
+	target, _ := ctx.Value(dryRunKey{}).(*dryRunTarget)
+	return target
+}
+
+// dryRunRecord is a payload that would have been sent, as written to the dry-run file
+type dryRunRecord struct {
+	Time        time.Time       `json:"time"`
+	Destination string          `json:"destination"`
+	URL         string          `json:"url"`
+	ContentType string          `json:"contentType"`
+	Body        json.RawMessage `json:"body,omitempty"`      // JSON payloads
+	BodyText    string          `json:"bodyText,omitempty"`  // text payloads
+	BodyBytes   []byte          `json:"bodyBytes,omitempty"` // other payloads, base64 encoded
+}
+
+// dryRunSink records payloads instead of sending them, to the log or, with a file
+// configured, as one JSON record per line
+type dryRunSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// newDryRunSink opens the file payloads are appended to; an empty path logs them instead
+func newDryRunSink(path string) (*dryRunSink, error) {
+	if path == "" {
+		return &dryRunSink{}, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &dryRunSink{file: file}, nil
+}
+
+// record writes a payload that would have been sent to rawURL. API secrets in the URL are redacted.
+func (t *dryRunTarget) record(rawURL, contentType string, body []byte) error {
+	rec := dryRunRecord{
+		Time:        time.Now().UTC(),
+		Destination: t.destination,
+		URL:         redactURL(rawURL),
+		ContentType: contentType,
+	}
+	switch {
+	case json.Valid(body):
+		rec.Body = body
+	case strings.HasPrefix(contentType, "text/"):
+		rec.BodyText = string(body)
+	default:
+		rec.BodyBytes = body
+	}
+
+	s := t.sink
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		log.Info().Str("destination", rec.Destination).Str("url", rec.URL).
+			Str("contentType", rec.ContentType).RawJSON("payload", payloadJSON(rec)).
+			Msg("Analytics dry run, payload not sent")
+		return nil
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// payloadJSON returns the body of rec as JSON for logging
+func payloadJSON(rec dryRunRecord) []byte {
+	if rec.Body != nil {
+		return rec.Body
+	}
+	if rec.BodyText != "" {
+		encoded, _ := json.Marshal(rec.BodyText)
+		return encoded
+	}
+	encoded, _ := json.Marshal(rec.BodyBytes)
+	return encoded
+}
+
+// close closes the dry-run file, if any. Payloads of deliveries still outstanding are logged instead.
+func (s *dryRunSink) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return
+	}
+	if err := s.file.Close(); err != nil {
+		log.Warn().Err(err).Msg("Failed to close analytics dry-run file")
+	}
+	s.file = nil
+}
+
+// redactURL replaces the values of secret query parameters and the password of the user, e.g.
+// of amqp URLs, so recorded URLs can be shared
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	_, hasPassword := u.User.Password()
+	query := u.Query()
+	if query.Get("api_secret") == "" && !hasPassword {
+		return rawURL
+	}
+	if query.Get("api_secret") != "" {
+		query.Set("api_secret", "REDACTED")
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
+
+// dryRunBackend marks deliveries to the wrapped backend as dry runs, so that its payloads
+// are serialized as usual and then recorded instead of sent
+type dryRunBackend struct {
+	Backend
+	target *dryRunTarget
+}
+
+// Send serializes the events with the wrapped backend without sending them
+func (d *dryRunBackend) Send(ctx context.Context, events []Event) error {
+	return d.Backend.Send(context.WithValue(ctx, dryRunKey{}, d.target), events)
+}
+
+// Close closes the wrapped backend, if it holds resources
+func (d *dryRunBackend) Close() error {
+	if closer, ok := d.Backend.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
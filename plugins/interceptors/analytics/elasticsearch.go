@@ -0,0 +1,187 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultElasticsearchIndex = "optimizely-agent"
+	elasticsearchDateFormat   = "2006.01.02"
+
+	elasticsearchRolloverDaily = "daily"
+	elasticsearchRolloverNone  = "none"
+)
+
+// ElasticsearchBackend bulk-indexes events into Elasticsearch or OpenSearch, by default into a
+// daily index named after the date of each event
+type ElasticsearchBackend struct {
+	URL      string            `json:"url"`      // Base URL of the cluster
+	Index    string            `json:"index"`    // Index, or prefix of the daily indices (defaults to optimizely-agent)
+	Rollover string            `json:"rollover"` // "daily" (default) or "none"
+	Username string            `json:"username"` // Optional: basic authentication
+	Password string            `json:"password"`
+	APIKey   string            `json:"apiKey"`  // Optional: base64 encoded API key, instead of basic authentication
+	Headers  map[string]string `json:"headers"` // Optional: extra request headers
+
+	client *http.Client
+}
+
+// elasticsearchDocument is an indexed event, the canonical event with the @timestamp field
+// dashboards expect
+type elasticsearchDocument struct {
+	CanonicalEvent
+	Timestamp string `json:"@timestamp"`
+}
+
+// elasticsearchBulkResponse is the response of the bulk API, which reports failures per item
+type elasticsearchBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+func (e *ElasticsearchBackend) checkConfig() error {
+	if e.URL == "" {
+		return errors.New("url is required")
+	}
+	switch e.Rollover {
+	case "", elasticsearchRolloverDaily, elasticsearchRolloverNone:
+	default:
+		return fmt.Errorf("unknown rollover: %q", e.Rollover)
+	}
+	if e.APIKey != "" && e.Username != "" {
+		return errors.New("apiKey and username are mutually exclusive")
+	}
+	return nil
+}
+
+// Send indexes the events with a single bulk request. Documents are identified by a digest of
+// their content, so a retried batch overwrites the documents indexed by the failed attempt.
+func (e *ElasticsearchBackend) Send(ctx context.Context, events []Event) error {
+	var body bytes.Buffer
+	for _, event := range events {
+		canonical := canonicalEvent(event)
+		doc, err := json.Marshal(elasticsearchDocument{
+			CanonicalEvent: canonical,
+			Timestamp:      canonical.Timestamp.Format("2006-01-02T15:04:05.000Z07:00"),
+		})
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256(doc)
+		action, err := json.Marshal(map[string]interface{}{
+			"index": map[string]string{
+				"_index": e.indexName(canonical),
+				"_id":    hex.EncodeToString(digest[:16]),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+
+	resp, err := postResponse(ctx, e.client, strings.TrimSuffix(e.URL, "/")+"/_bulk", "application/x-ndjson", body.Bytes(), e.headers())
+	if err != nil || len(resp) == 0 {
+		return err
+	}
+	return bulkError(resp)
+}
+
+// indexName returns the index of an event
+func (e *ElasticsearchBackend) indexName(event CanonicalEvent) string {
+	index := e.Index
+	if index == "" {
+		index = defaultElasticsearchIndex
+	}
+	if e.Rollover == elasticsearchRolloverNone {
+		return index
+	}
+	return index + "-" + event.Timestamp.Format(elasticsearchDateFormat)
+}
+
+func (e *ElasticsearchBackend) headers() map[string]string {
+	headers := make(map[string]string, len(e.Headers)+1)
+	for k, v := range e.Headers {
+		headers[k] = v
+	}
+	switch {
+	case e.APIKey != "":
+		headers["Authorization"] = "ApiKey " + e.APIKey
+	case e.Username != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(e.Username+":"+e.Password))
+	}
+	return headers
+}
+
+// bulkError returns the error of the first failed item of a bulk response. Items rejected because
+// the cluster is overloaded or failing are reported as a StatusError, so the batch is retried;
+// other failures, e.g. mapping conflicts, are not.
+func bulkError(resp []byte) error {
+	var result elasticsearchBulkResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("invalid bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	var failed error
+	for _, item := range result.Items {
+		for _, status := range item {
+			if status.Error == nil {
+				continue
+			}
+			if status.Status == http.StatusTooManyRequests || status.Status >= 500 {
+				return &StatusError{StatusCode: status.Status, Body: status.Error.Reason}
+			}
+			if failed == nil {
+				failed = fmt.Errorf("bulk indexing failed with status %d: %s: %s", status.Status, status.Error.Type, status.Error.Reason)
+			}
+		}
+	}
+	return failed
+}
+
+func (e *ElasticsearchBackend) setHTTPClient(client *http.Client) {
+	e.client = client
+}
+
+func init() {
+	AddBackend("elasticsearch", func() Backend {
+		return &ElasticsearchBackend{}
+	})
+}
@@ -0,0 +1,115 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElasticsearchBackendSend(t *testing.T) {
+	var lines []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "elastic", user)
+		assert.Equal(t, "secret", password)
+		assert.Equal(t, "value", r.Header.Get("X-Extra"))
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+		_, _ = w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}},{"index":{"status":201}}]}`))
+	}))
+	defer ts.Close()
+
+	backend := &ElasticsearchBackend{URL: ts.URL, Username: "elastic", Password: "secret", Headers: map[string]string{"X-Extra": "value"}}
+	require.NoError(t, backend.checkConfig())
+	first := time.Date(2025, 6, 1, 23, 59, 0, 0, time.UTC)
+	events := []Event{
+		newEvent("api_request", first, ClientInfo{ID: "client"}, RequestInfo{Path: "/v1/decide"}, ResponseInfo{StatusCode: http.StatusOK}),
+		newEvent("api_request", first.Add(2*time.Minute), ClientInfo{ID: "client"}, RequestInfo{Path: "/v1/track"}, ResponseInfo{StatusCode: http.StatusOK}),
+	}
+	require.NoError(t, backend.Send(context.Background(), events))
+
+	require.Len(t, lines, 4)
+	action := lines[0]["index"].(map[string]interface{})
+	assert.Equal(t, "optimizely-agent-2025.06.01", action["_index"])
+	assert.Len(t, action["_id"], 32)
+	assert.Equal(t, "2025-06-01T23:59:00.000Z", lines[1]["@timestamp"])
+	assert.Equal(t, "/v1/decide", lines[1]["request"].(map[string]interface{})["path"])
+	assert.Equal(t, "optimizely-agent-2025.06.02", lines[2]["index"].(map[string]interface{})["_index"])
+	assert.NotEqual(t, action["_id"], lines[2]["index"].(map[string]interface{})["_id"])
+
+	// Documents of a retried batch keep their IDs
+	retried := lines
+	lines = nil
+	require.NoError(t, backend.Send(context.Background(), events))
+	assert.Equal(t, retried, lines)
+}
+
+func TestElasticsearchBackendIndex(t *testing.T) {
+	event := canonicalEvent(Event{Timestamp: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)})
+	assert.Equal(t, "api-2025.06.01", (&ElasticsearchBackend{Index: "api"}).indexName(event))
+	assert.Equal(t, "api", (&ElasticsearchBackend{Index: "api", Rollover: elasticsearchRolloverNone}).indexName(event))
+}
+
+func TestElasticsearchBackendAPIKey(t *testing.T) {
+	headers := (&ElasticsearchBackend{APIKey: "a2V5"}).headers()
+	assert.Equal(t, "ApiKey a2V5", headers["Authorization"])
+}
+
+func TestElasticsearchBackendItemErrors(t *testing.T) {
+	response := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(response))
+	}))
+	defer ts.Close()
+
+	backend := &ElasticsearchBackend{URL: ts.URL}
+	events := []Event{{Name: "api_request", ClientID: "client"}, {Name: "api_request", ClientID: "client"}}
+
+	response = `{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}},{"index":{"status":201}}]}`
+	err := backend.Send(context.Background(), events)
+	assert.EqualError(t, err, "bulk indexing failed with status 400: mapper_parsing_exception: failed to parse")
+
+	response = `{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}},{"index":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"rejected"}}}]}`
+	err = backend.Send(context.Background(), events)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+}
+
+func TestElasticsearchBackendCheckConfig(t *testing.T) {
+	assert.EqualError(t, (&ElasticsearchBackend{}).checkConfig(), "url is required")
+	assert.EqualError(t, (&ElasticsearchBackend{URL: "http://es:9200", Rollover: "weekly"}).checkConfig(), `unknown rollover: "weekly"`)
+	assert.EqualError(t, (&ElasticsearchBackend{URL: "http://es:9200", APIKey: "key", Username: "elastic"}).checkConfig(),
+		"apiKey and username are mutually exclusive")
+}
@@ -0,0 +1,102 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	errorCodeParam    = "error_code"
+	errorMessageParam = "error_message"
+
+	// maxErrorMessageLength fits the 100 character limit of GA4 param values
+	maxErrorMessageLength = 100
+)
+
+// errorMessageRedactions replace personal data and IDs in error messages
+var errorMessageRedactions = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`), "[email]"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`), "[id]"},
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}\b`), "[ip]"},
+}
+
+// tokenPattern matches words long enough to be keys or tokens, e.g. the SDK key of a datafile
+// error, which are redacted when they contain digits
+var tokenPattern = regexp.MustCompile(`[\w-]{16,}`)
+
+// addErrorParams adds the error_code and error_message params of an error response, parsed
+// from the agent's error JSON ({"error": "..."}) or the OAuth error JSON ({"error": "invalid_client",
+// "error_description": "..."}). Other bodies are used as the message as they are, and the code
+// defaults to the status text, e.g. not_found.
+func addErrorParams(params map[string]interface{}, status int, body []byte) {
+	code := strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	message := strings.TrimSpace(string(body))
+
+	var errorJSON struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+		Code             string `json:"code"`
+		Message          string `json:"message"`
+	}
+	if json.Unmarshal(body, &errorJSON) == nil {
+		message = ""
+		switch {
+		case errorJSON.ErrorDescription != "":
+			code, message = errorJSON.Error, errorJSON.ErrorDescription
+		case errorJSON.Error != "":
+			message = errorJSON.Error
+		case errorJSON.Message != "":
+			message = errorJSON.Message
+		}
+		if errorJSON.Code != "" {
+			code = errorJSON.Code
+		}
+	}
+
+	if code != "" {
+		params[errorCodeParam] = code
+	}
+	if message = redactErrorMessage(message); message != "" {
+		params[errorMessageParam] = message
+	}
+}
+
+// redactErrorMessage replaces personal data, IDs and anything that looks like a key or token
+// in message and truncates it
+func redactErrorMessage(message string) string {
+	for _, r := range errorMessageRedactions {
+		message = r.re.ReplaceAllString(message, r.replacement)
+	}
+	message = tokenPattern.ReplaceAllStringFunc(message, func(word string) string {
+		if strings.ContainsAny(word, "0123456789") {
+			return "[redacted]"
+		}
+		return word
+	})
+	if utf8.RuneCountInString(message) > maxErrorMessageLength {
+		message = string([]rune(message)[:maxErrorMessageLength-1]) + "…"
+	}
+	return message
+}
@@ -0,0 +1,90 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddErrorParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected map[string]interface{}
+	}{
+		{"agent error", http.StatusBadRequest, `{"error":"missing required field"}`,
+			map[string]interface{}{errorCodeParam: "bad_request", errorMessageParam: "missing required field"}},
+		{"oauth error", http.StatusUnauthorized, `{"error":"invalid_client","error_description":"Invalid client credentials"}`,
+			map[string]interface{}{errorCodeParam: "invalid_client", errorMessageParam: "Invalid client credentials"}},
+		{"code and message", http.StatusTooManyRequests, `{"code":"rate_limited","message":"slow down"}`,
+			map[string]interface{}{errorCodeParam: "rate_limited", errorMessageParam: "slow down"}},
+		{"text body", http.StatusForbidden, "Forbidden\n",
+			map[string]interface{}{errorCodeParam: "forbidden", errorMessageParam: "Forbidden"}},
+		{"no body", http.StatusInternalServerError, "",
+			map[string]interface{}{errorCodeParam: "internal_server_error"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]interface{}{}
+			addErrorParams(params, tt.status, []byte(tt.body))
+			assert.Equal(t, tt.expected, params)
+		})
+	}
+}
+
+func TestRedactErrorMessage(t *testing.T) {
+	assert.Equal(t, "datafile not found for sdk key [redacted]",
+		redactErrorMessage("datafile not found for sdk key TbrfRLeKvLyWGusqANoeR9"))
+	assert.Equal(t, "user [email] from [ip] with id [id]",
+		redactErrorMessage("user jane.doe@example.com from 192.0.2.10 with id 123e4567-e89b-12d3-a456-426614174000"))
+	assert.Equal(t, "flag checkout_2 and experimentation_key are unknown",
+		redactErrorMessage("flag checkout_2 and experimentation_key are unknown"))
+
+	long := redactErrorMessage(strings.Repeat("é", 150))
+	assert.Equal(t, maxErrorMessageLength, len([]rune(long)))
+	assert.True(t, strings.HasSuffix(long, "…"))
+}
+
+func TestAnalyticsErrorDetails(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, ErrorDetails: true, CaptureResponseBody: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/ok" {
+			w.Write([]byte(`{"error":"not an error"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"flag not found"}`))
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/missing", nil))
+	event := backend.next(t)
+	assert.Equal(t, "not_found", event.Params[errorCodeParam])
+	assert.Equal(t, "flag not found", event.Params[errorMessageParam])
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/ok", nil))
+	event = backend.next(t)
+	assert.NotContains(t, event.Params, errorCodeParam)
+	assert.NotContains(t, event.Params, errorMessageParam)
+}
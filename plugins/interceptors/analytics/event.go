@@ -0,0 +1,371 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Params of the canonical event model. Params not listed here, e.g. custom params, body params
+// and dimensions, are carried as custom params.
+const (
+	schemaVersionParam      = "schema_version"
+	methodParam             = "method"
+	statusCodeParam         = "status_code"
+	responseTimeParam       = "response_time_ms"
+	requestBytesParam       = "request_bytes"
+	responseBytesParam      = "response_bytes"
+	geoCountryParam         = "geo_country"
+	geoRegionParam          = "geo_region"
+	geoCityParam            = "geo_city"
+	deviceCategoryParam     = "device_category"
+	browserParam            = "browser"
+	browserVersionParam     = "browser_version"
+	osParam                 = "os"
+	osVersionParam          = "os_version"
+	flagKeysParam           = "flag_keys"
+	variationKeysParam      = "variation_keys"
+	ruleKeysParam           = "rule_keys"
+	ruleTypesParam          = "rule_types"
+	decisionCountParam      = "decision_count"
+	enabledCountParam       = "enabled_count"
+	sessionIDParam          = "session_id"
+	sessionNumberParam      = "session_number"
+	engagementTimeMsecParam = "engagement_time_msec"
+)
+
+// eventParamsCapacity is the initial capacity of the params of request events
+const eventParamsCapacity = 24
+
+// CanonicalEvent is the typed, versioned form of an event, serialized by the destinations that
+// deliver whole events (file, syslog, fluent, webhook and kafka). Events travel through the
+// interceptor with flat params, which destinations such as GA4 send as is; the canonical form
+// groups the known params and keeps the others as custom params.
+type CanonicalEvent struct {
+	SchemaVersion  int                    `json:"schemaVersion"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Name           string                 `json:"name"`
+	Client         ClientInfo             `json:"client"`
+	Request        RequestInfo            `json:"request"`
+	Response       ResponseInfo           `json:"response"`
+	Enrichment     Enrichment             `json:"enrichment"`
+	Custom         map[string]interface{} `json:"custom,omitempty"`
+	UserProperties map[string]interface{} `json:"userProperties,omitempty"`
+}
+
+// ClientInfo identifies the client of a request. The IP address and user agent are omitted
+// when replaced by their geo and device enrichment or removed for privacy.
+type ClientInfo struct {
+	ID        string `json:"id"`
+	UserID    string `json:"userId,omitempty"`
+	IPAddress string `json:"ipAddress,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
+	Region    string `json:"region,omitempty"` // data residency region
+}
+
+// RequestInfo describes the API request
+type RequestInfo struct {
+	ID     string `json:"id,omitempty"` // the X-Request-Id of the agent's logs
+	Path   string `json:"path"`
+	Method string `json:"method"`
+	SDKKey string `json:"sdkKey,omitempty"` // the key or its digest with hashSDKKey
+	Bytes  int64  `json:"bytes"`
+}
+
+// ResponseInfo describes the API response
+type ResponseInfo struct {
+	StatusCode int   `json:"statusCode"`
+	Bytes      int64 `json:"bytes"`
+	DurationMS int64 `json:"durationMs"`
+}
+
+// Enrichment holds the details derived from the request and response, each present only when
+// the corresponding feature is enabled
+type Enrichment struct {
+	Geo        *GeoInfo      `json:"geo,omitempty"`
+	Device     *DeviceInfo   `json:"device,omitempty"`
+	Decisions  *DecisionInfo `json:"decisions,omitempty"`
+	Session    *SessionInfo  `json:"session,omitempty"`
+	SampleRate float64       `json:"sampleRate,omitempty"`
+}
+
+// GeoInfo is the coarse location of the client, see GeoIPConfig
+type GeoInfo struct {
+	Country string `json:"country,omitempty"`
+	Region  string `json:"region,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+// DeviceInfo is the device parsed from the user agent, see Analytics.ParseUserAgent
+type DeviceInfo struct {
+	Category       string `json:"category,omitempty"`
+	Browser        string `json:"browser,omitempty"`
+	BrowserVersion string `json:"browserVersion,omitempty"`
+	OS             string `json:"os,omitempty"`
+	OSVersion      string `json:"osVersion,omitempty"`
+}
+
+// DecisionInfo summarizes the decisions of /v1/decide responses, see Analytics.EnrichDecisions
+type DecisionInfo struct {
+	FlagKeys      []string `json:"flagKeys,omitempty"`
+	VariationKeys []string `json:"variationKeys,omitempty"`
+	RuleKeys      []string `json:"ruleKeys,omitempty"`
+	RuleTypes     []string `json:"ruleTypes,omitempty"`
+	Count         int      `json:"count"`
+	EnabledCount  int      `json:"enabledCount"`
+}
+
+// SessionInfo is the server-side session of the client, see SessionConfig
+type SessionInfo struct {
+	ID                 string `json:"id"`
+	Number             int64  `json:"number"`
+	EngagementTimeMsec int64  `json:"engagementTimeMsec"`
+}
+
+// newEvent creates the event of a request with the params of its client, request and response
+func newEvent(name string, timestamp time.Time, client ClientInfo, req RequestInfo, resp ResponseInfo) Event {
+	// The params are sized for the enrichment added to most events, so they aren't grown repeatedly
+	params := make(map[string]interface{}, eventParamsCapacity)
+	params[schemaVersionParam] = eventSchemaVersion
+	params[pathParam] = req.Path
+	params[methodParam] = req.Method
+	params[statusCodeParam] = resp.StatusCode
+	params[responseTimeParam] = resp.DurationMS
+	params[requestBytesParam] = req.Bytes
+	params[responseBytesParam] = resp.Bytes
+	params[userAgentParam] = client.UserAgent
+	params[ipAddressParam] = client.IPAddress
+	if req.SDKKey != "" {
+		params[sdkKeyParam] = req.SDKKey
+	}
+	if req.ID != "" {
+		params[requestIDParam] = req.ID
+	}
+
+	return Event{
+		Name:      name,
+		ClientID:  client.ID,
+		UserID:    client.UserID,
+		Region:    client.Region,
+		RequestID: req.ID,
+		Timestamp: timestamp,
+		Params:    params,
+	}
+}
+
+// eventTime returns the time the event was captured, for destinations that date events
+// explicitly rather than by when they receive them. Events without a time, or dated after now
+// by a skewed clock, e.g. of an SDK whose events are mirrored, are dated now.
+func eventTime(e Event, now time.Time) time.Time {
+	if e.Timestamp.IsZero() || e.Timestamp.After(now) {
+		return now
+	}
+	return e.Timestamp
+}
+
+// canonicalEvent returns the canonical form of the event. Known params of an unexpected type,
+// e.g. redacted by privacy settings, are kept as custom params.
+func canonicalEvent(e Event) CanonicalEvent {
+	timestamp := e.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	c := CanonicalEvent{
+		SchemaVersion:  eventSchemaVersion,
+		Timestamp:      timestamp.UTC(),
+		Name:           e.Name,
+		Client:         ClientInfo{ID: e.ClientID, UserID: e.UserID, Region: e.Region},
+		UserProperties: e.UserProperties,
+	}
+
+	for param, value := range e.Params {
+		if !c.setParam(param, value) {
+			if c.Custom == nil {
+				c.Custom = map[string]interface{}{}
+			}
+			c.Custom[param] = value
+		}
+	}
+	return c
+}
+
+// setParam sets the field of a known param, reporting whether it did
+func (c *CanonicalEvent) setParam(param string, value interface{}) bool {
+	switch param {
+	case schemaVersionParam:
+		return setInt(&c.SchemaVersion, value)
+	case pathParam:
+		return setString(&c.Request.Path, value)
+	case methodParam:
+		return setString(&c.Request.Method, value)
+	case sdkKeyParam:
+		return setString(&c.Request.SDKKey, value)
+	case requestIDParam:
+		return setString(&c.Request.ID, value)
+	case requestBytesParam:
+		return setInt64(&c.Request.Bytes, value)
+	case statusCodeParam:
+		return setInt(&c.Response.StatusCode, value)
+	case responseBytesParam:
+		return setInt64(&c.Response.Bytes, value)
+	case responseTimeParam:
+		return setInt64(&c.Response.DurationMS, value)
+	case ipAddressParam:
+		return setString(&c.Client.IPAddress, value)
+	case userAgentParam:
+		return setString(&c.Client.UserAgent, value)
+	case sampleRateParam:
+		rate, ok := value.(float64)
+		c.Enrichment.SampleRate = rate
+		return ok
+	}
+	return c.setEnrichmentParam(param, value)
+}
+
+func (c *CanonicalEvent) setEnrichmentParam(param string, value interface{}) bool {
+	en := &c.Enrichment
+	switch param {
+	case decisionCountParam, enabledCountParam, sessionNumberParam, engagementTimeMsecParam:
+		var n int64
+		if !setInt64(&n, value) {
+			return false
+		}
+		switch param {
+		case decisionCountParam:
+			en.decisions().Count = int(n)
+		case enabledCountParam:
+			en.decisions().EnabledCount = int(n)
+		case sessionNumberParam:
+			en.session().Number = n
+		default:
+			en.session().EngagementTimeMsec = n
+		}
+		return true
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	switch param {
+	case geoCountryParam:
+		en.geo().Country = s
+	case geoRegionParam:
+		en.geo().Region = s
+	case geoCityParam:
+		en.geo().City = s
+	case deviceCategoryParam:
+		en.device().Category = s
+	case browserParam:
+		en.device().Browser = s
+	case browserVersionParam:
+		en.device().BrowserVersion = s
+	case osParam:
+		en.device().OS = s
+	case osVersionParam:
+		en.device().OSVersion = s
+	case flagKeysParam:
+		en.decisions().FlagKeys = splitKeys(s)
+	case variationKeysParam:
+		en.decisions().VariationKeys = splitKeys(s)
+	case ruleKeysParam:
+		en.decisions().RuleKeys = splitKeys(s)
+	case ruleTypesParam:
+		en.decisions().RuleTypes = splitKeys(s)
+	case sessionIDParam:
+		en.session().ID = s
+	default:
+		return false
+	}
+	return true
+}
+
+func (en *Enrichment) geo() *GeoInfo {
+	if en.Geo == nil {
+		en.Geo = &GeoInfo{}
+	}
+	return en.Geo
+}
+
+func (en *Enrichment) device() *DeviceInfo {
+	if en.Device == nil {
+		en.Device = &DeviceInfo{}
+	}
+	return en.Device
+}
+
+func (en *Enrichment) decisions() *DecisionInfo {
+	if en.Decisions == nil {
+		en.Decisions = &DecisionInfo{}
+	}
+	return en.Decisions
+}
+
+func (en *Enrichment) session() *SessionInfo {
+	if en.Session == nil {
+		en.Session = &SessionInfo{}
+	}
+	return en.Session
+}
+
+// splitKeys splits the comma separated keys of decision params
+func splitKeys(keys string) []string {
+	if keys == "" {
+		return nil
+	}
+	return strings.Split(keys, ",")
+}
+
+func setString(field *string, value interface{}) bool {
+	s, ok := value.(string)
+	if ok {
+		*field = s
+	}
+	return ok
+}
+
+func setInt(field *int, value interface{}) bool {
+	var i int64
+	if !setInt64(&i, value) {
+		return false
+	}
+	*field = int(i)
+	return true
+}
+
+// setInt64 accepts any integer, and floats without a fraction as produced by decoding events
+// from JSON, e.g. when replayed from the spill queue
+func setInt64(field *int64, value interface{}) bool {
+	switch v := value.(type) {
+	case int:
+		*field = int64(v)
+	case int32:
+		*field = int64(v)
+	case int64:
+		*field = v
+	case float64:
+		if v != math.Trunc(v) {
+			return false
+		}
+		*field = int64(v)
+	default:
+		return false
+	}
+	return true
+}
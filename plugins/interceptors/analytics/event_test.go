@@ -0,0 +1,115 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEvent(t *testing.T) {
+	timestamp := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	event := newEvent("api_request", timestamp,
+		ClientInfo{ID: "a", UserID: "user-1", IPAddress: "192.0.2.1", UserAgent: "curl/8.0", Region: "eu"},
+		RequestInfo{Path: "/v1/decide", Method: "POST", SDKKey: "sdk", Bytes: 10},
+		ResponseInfo{StatusCode: 200, Bytes: 20, DurationMS: 5})
+
+	assert.Equal(t, "a", event.ClientID)
+	assert.Equal(t, "user-1", event.UserID)
+	assert.Equal(t, "eu", event.Region)
+	assert.Equal(t, timestamp, event.Timestamp)
+	assert.Equal(t, map[string]interface{}{
+		"schema_version":   eventSchemaVersion,
+		"path":             "/v1/decide",
+		"method":           "POST",
+		"status_code":      200,
+		"response_time_ms": int64(5),
+		"request_bytes":    int64(10),
+		"response_bytes":   int64(20),
+		"user_agent":       "curl/8.0",
+		"ip_address":       "192.0.2.1",
+		"sdk_key":          "sdk",
+	}, event.Params)
+}
+
+func TestCanonicalEvent(t *testing.T) {
+	timestamp := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	event := newEvent("api_request", timestamp,
+		ClientInfo{ID: "a", UserAgent: "curl/8.0"},
+		RequestInfo{Path: "/v1/decide", Method: "POST", Bytes: 10},
+		ResponseInfo{StatusCode: 200, Bytes: 20, DurationMS: 5})
+	addDecisionParams(event.Params, []decision{
+		{FlagKey: "checkout", VariationKey: "on", RuleKey: "rollout", Enabled: true},
+		{FlagKey: "banner", VariationKey: "off", RuleKey: "default"},
+	})
+	event.Params[geoCountryParam] = "DE"
+	event.Params[sessionIDParam] = "s1"
+	event.Params[sessionNumberParam] = 2
+	event.Params[sampleRateParam] = 0.5
+	event.Params["plan"] = "pro"
+	event.UserProperties = map[string]interface{}{"tier": "gold"}
+
+	c := canonicalEvent(event)
+	assert.Equal(t, eventSchemaVersion, c.SchemaVersion)
+	assert.Equal(t, timestamp, c.Timestamp)
+	assert.Equal(t, ClientInfo{ID: "a", UserAgent: "curl/8.0"}, c.Client)
+	assert.Equal(t, RequestInfo{Path: "/v1/decide", Method: "POST", Bytes: 10}, c.Request)
+	assert.Equal(t, ResponseInfo{StatusCode: 200, Bytes: 20, DurationMS: 5}, c.Response)
+	assert.Equal(t, &GeoInfo{Country: "DE"}, c.Enrichment.Geo)
+	assert.Nil(t, c.Enrichment.Device)
+	require.NotNil(t, c.Enrichment.Decisions)
+	assert.Equal(t, []string{"checkout", "banner"}, c.Enrichment.Decisions.FlagKeys)
+	assert.Equal(t, 2, c.Enrichment.Decisions.Count)
+	assert.Equal(t, 1, c.Enrichment.Decisions.EnabledCount)
+	assert.Equal(t, &SessionInfo{ID: "s1", Number: 2}, c.Enrichment.Session)
+	assert.Equal(t, 0.5, c.Enrichment.SampleRate)
+	assert.Equal(t, map[string]interface{}{"plan": "pro"}, c.Custom)
+	assert.Equal(t, map[string]interface{}{"tier": "gold"}, c.UserProperties)
+}
+
+func TestEventTime(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	captured := now.Add(-time.Minute)
+	assert.Equal(t, captured, eventTime(Event{Timestamp: captured}, now))
+	assert.Equal(t, now, eventTime(Event{}, now))
+
+	// Events dated in the future by a skewed clock are dated now
+	assert.Equal(t, now, eventTime(Event{Timestamp: now.Add(time.Hour)}, now))
+}
+
+func TestCanonicalEventFromJSON(t *testing.T) {
+	// Events replayed from the spill queue carry their numbers as floats
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"Name": "api_request",
+		"ClientID": "a",
+		"Params": {"schema_version": 1, "status_code": 404, "response_time_ms": 12, "request_bytes": 1.5}
+	}`), &event))
+
+	c := canonicalEvent(event)
+	assert.Equal(t, 1, c.SchemaVersion)
+	assert.Equal(t, 404, c.Response.StatusCode)
+	assert.Equal(t, int64(12), c.Response.DurationMS)
+	assert.False(t, c.Timestamp.IsZero())
+
+	// Known params of an unexpected type are kept as custom params
+	assert.Equal(t, map[string]interface{}{"request_bytes": 1.5}, c.Custom)
+}
@@ -0,0 +1,217 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	eventHubsBatchMediaType = "application/vnd.microsoft.servicebus.json"
+	eventHubsAPIVersion     = "2014-01"
+	eventHubsAADScope       = "https://eventhubs.azure.net/.default"
+	defaultAzureAuthority   = "https://login.microsoftonline.com"
+
+	// eventHubsSASLifetime is the validity of the SAS tokens signed for each request
+	eventHubsSASLifetime = time.Hour
+	// eventHubsTokenMargin renews AAD tokens this long before they expire
+	eventHubsTokenMargin = time.Minute
+)
+
+// EventHubsBackend sends events to an Azure Event Hub with its REST API, authenticated with the
+// shared access key of a connection string or with an Azure AD (Entra ID) application. Events are
+// sent as canonical events in JSON with their client ID as partition key, one batch per client.
+type EventHubsBackend struct {
+	ConnectionString string `json:"connectionString"` // Connection string of a shared access policy, optionally with EntityPath
+	Namespace        string `json:"namespace"`        // Fully qualified namespace with AAD auth, e.g. example.servicebus.windows.net
+	EventHub         string `json:"eventHub"`         // Name of the event hub (defaults to the EntityPath of the connection string)
+	TenantID         string `json:"tenantID"`         // AAD auth: tenant, client ID and secret of the application
+	ClientID         string `json:"clientID"`
+	ClientSecret     string `json:"clientSecret"`
+	AuthorityURL     string `json:"authorityURL"` // Optional: defaults to https://login.microsoftonline.com
+
+	endpoint string // base URL of the namespace, e.g. https://example.servicebus.windows.net
+	keyName  string
+	key      string
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	client      *http.Client
+}
+
+// eventHubsMessage is a message of a batch sent to the REST API
+type eventHubsMessage struct {
+	Body             string            `json:"Body"`
+	BrokerProperties map[string]string `json:"BrokerProperties,omitempty"`
+	UserProperties   map[string]string `json:"UserProperties,omitempty"`
+}
+
+func (h *EventHubsBackend) checkConfig() error {
+	switch {
+	case h.ConnectionString != "":
+		settings := map[string]string{}
+		for _, part := range strings.Split(h.ConnectionString, ";") {
+			if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+				settings[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+			}
+		}
+		endpoint, err := url.Parse(settings["endpoint"])
+		if err != nil || endpoint.Host == "" || settings["sharedaccesskeyname"] == "" || settings["sharedaccesskey"] == "" {
+			return errors.New("connectionString requires Endpoint, SharedAccessKeyName and SharedAccessKey")
+		}
+		h.endpoint = "https://" + endpoint.Host
+		h.keyName, h.key = settings["sharedaccesskeyname"], settings["sharedaccesskey"]
+		if h.EventHub == "" {
+			h.EventHub = settings["entitypath"]
+		}
+	case h.Namespace != "":
+		if h.TenantID == "" || h.ClientID == "" || h.ClientSecret == "" {
+			return errors.New("namespace requires tenantID, clientID and clientSecret")
+		}
+		h.endpoint = "https://" + strings.TrimSuffix(strings.TrimPrefix(h.Namespace, "https://"), "/")
+	default:
+		return errors.New("connectionString or namespace is required")
+	}
+	if h.EventHub == "" {
+		return errors.New("eventHub is required")
+	}
+	return nil
+}
+
+// Send posts the events of each client as a batch partitioned by its client ID
+func (h *EventHubsBackend) Send(ctx context.Context, events []Event) error {
+	resource := h.endpoint + "/" + h.EventHub
+	for _, clientEvents := range groupByClient(events) {
+		messages := make([]eventHubsMessage, 0, len(clientEvents))
+		for _, e := range clientEvents {
+			body, err := json.Marshal(canonicalEvent(e))
+			if err != nil {
+				return err
+			}
+			msg := eventHubsMessage{
+				Body:           string(body),
+				UserProperties: map[string]string{"name": e.Name},
+			}
+			if e.ClientID != "" {
+				msg.BrokerProperties = map[string]string{"PartitionKey": e.ClientID}
+			}
+			if e.RequestID != "" {
+				msg.UserProperties["requestId"] = e.RequestID
+			}
+			messages = append(messages, msg)
+		}
+
+		authorization, err := h.authorization(ctx, resource)
+		if err != nil {
+			return err
+		}
+		body, err := json.Marshal(messages)
+		if err != nil {
+			return err
+		}
+		endpoint := resource + "/messages?api-version=" + eventHubsAPIVersion
+		if err := post(ctx, h.client, endpoint, eventHubsBatchMediaType, body, map[string]string{"Authorization": authorization}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorization returns the Authorization header of requests to resource
+func (h *EventHubsBackend) authorization(ctx context.Context, resource string) (string, error) {
+	if h.key != "" {
+		return sharedAccessSignature(resource, h.keyName, h.key, time.Now().Add(eventHubsSASLifetime)), nil
+	}
+	token, err := h.aadToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
+// sharedAccessSignature signs a SAS token for resource valid until expiry
+func sharedAccessSignature(resource, keyName, key string, expiry time.Time) string {
+	encoded := url.QueryEscape(strings.ToLower(resource))
+	se := strconv.FormatInt(expiry.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(encoded + "\n" + se))
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return fmt.Sprintf("SharedAccessSignature sr=%s&sig=%s&se=%s&skn=%s", encoded, url.QueryEscape(sig), se, url.QueryEscape(keyName))
+}
+
+// aadToken returns the access token of the application, requesting a new one with the client
+// credentials grant when the last has expired
+func (h *EventHubsBackend) aadToken(ctx context.Context) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.token != "" && time.Now().Before(h.tokenExpiry) {
+		return h.token, nil
+	}
+
+	authority := h.AuthorityURL
+	if authority == "" {
+		authority = defaultAzureAuthority
+	}
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", h.ClientID)
+	form.Set("client_secret", h.ClientSecret)
+	form.Set("scope", eventHubsAADScope)
+	tokenURL := strings.TrimSuffix(authority, "/") + "/" + url.PathEscape(h.TenantID) + "/oauth2/v2.0/token"
+	resp, err := postResponse(ctx, h.client, tokenURL, "application/x-www-form-urlencoded", []byte(form.Encode()), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Azure AD token: %w", err)
+	}
+	if resp == nil {
+		// Dry runs record the token request without sending it
+		return "", nil
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil || result.AccessToken == "" {
+		return "", errors.New("invalid Azure AD token response")
+	}
+	h.token = result.AccessToken
+	h.tokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - eventHubsTokenMargin)
+	return h.token, nil
+}
+
+func (h *EventHubsBackend) setHTTPClient(client *http.Client) {
+	h.client = client
+}
+
+func init() {
+	AddBackend("eventhubs", func() Backend {
+		return &EventHubsBackend{}
+	})
+}
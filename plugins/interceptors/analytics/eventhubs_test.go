@@ -0,0 +1,134 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHubsBackendConnectionString(t *testing.T) {
+	var batches [][]eventHubsMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/events/messages", r.URL.Path)
+		assert.Equal(t, eventHubsAPIVersion, r.URL.Query().Get("api-version"))
+		assert.Equal(t, eventHubsBatchMediaType, r.Header.Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "SharedAccessSignature sr="))
+
+		var batch []eventHubsMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		batches = append(batches, batch)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	backend := &EventHubsBackend{
+		ConnectionString: "Endpoint=sb://example.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=a2V5=;EntityPath=events",
+	}
+	require.NoError(t, backend.checkConfig())
+	assert.Equal(t, "https://example.servicebus.windows.net", backend.endpoint)
+	assert.Equal(t, "events", backend.EventHub)
+	assert.Equal(t, "a2V5=", backend.key)
+	backend.endpoint = ts.URL
+
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", RequestID: "req-1", Params: map[string]interface{}{pathParam: "/v1/decide"}},
+		{Name: "api_request", ClientID: "b"},
+		{Name: "api_request", ClientID: "a"},
+	}))
+
+	require.Len(t, batches, 2)
+	require.Len(t, batches[0], 2)
+	msg := batches[0][0]
+	assert.Equal(t, map[string]string{"PartitionKey": "a"}, msg.BrokerProperties)
+	assert.Equal(t, map[string]string{"name": "api_request", "requestId": "req-1"}, msg.UserProperties)
+	var event CanonicalEvent
+	require.NoError(t, json.Unmarshal([]byte(msg.Body), &event))
+	assert.Equal(t, "/v1/decide", event.Request.Path)
+	assert.Equal(t, "b", batches[1][0].BrokerProperties["PartitionKey"])
+}
+
+func TestSharedAccessSignature(t *testing.T) {
+	expiry := time.Unix(1750000000, 0)
+	token := sharedAccessSignature("https://example.servicebus.windows.net/Events", "send", "key", expiry)
+
+	encoded := url.QueryEscape("https://example.servicebus.windows.net/events")
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte(encoded + "\n1750000000"))
+	sig := url.QueryEscape(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	assert.Equal(t, "SharedAccessSignature sr="+encoded+"&sig="+sig+"&se=1750000000&skn=send", token)
+}
+
+func TestEventHubsBackendAAD(t *testing.T) {
+	tokenRequests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tenant/oauth2/v2.0/token" {
+			tokenRequests++
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "client", r.PostForm.Get("client_id"))
+			assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+			assert.Equal(t, eventHubsAADScope, r.PostForm.Get("scope"))
+			_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		}
+		assert.Equal(t, "/events/messages", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	backend := &EventHubsBackend{
+		Namespace: "example.servicebus.windows.net", EventHub: "events",
+		TenantID: "tenant", ClientID: "client", ClientSecret: "secret", AuthorityURL: ts.URL,
+	}
+	require.NoError(t, backend.checkConfig())
+	assert.Equal(t, "https://example.servicebus.windows.net", backend.endpoint)
+	backend.endpoint = ts.URL
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a"}}))
+	}
+	// The token is reused until it expires
+	assert.Equal(t, 1, tokenRequests)
+
+	backend.tokenExpiry = time.Now().Add(-time.Second)
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a"}}))
+	assert.Equal(t, 2, tokenRequests)
+}
+
+func TestEventHubsBackendCheckConfig(t *testing.T) {
+	assert.EqualError(t, (&EventHubsBackend{}).checkConfig(), "connectionString or namespace is required")
+	assert.EqualError(t, (&EventHubsBackend{ConnectionString: "Endpoint=sb://example.servicebus.windows.net/"}).checkConfig(),
+		"connectionString requires Endpoint, SharedAccessKeyName and SharedAccessKey")
+	assert.EqualError(t, (&EventHubsBackend{ConnectionString: "Endpoint=sb://example.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=key"}).checkConfig(),
+		"eventHub is required")
+	assert.EqualError(t, (&EventHubsBackend{Namespace: "example.servicebus.windows.net", EventHub: "events"}).checkConfig(),
+		"namespace requires tenantID, clientID and clientSecret")
+}
@@ -0,0 +1,145 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxEventNameLength is the longest event name GA4 accepts
+const maxEventNameLength = 40
+
+// eventNameVars are the values of the placeholders of event name templates
+type eventNameVars struct {
+	method string // HTTP method of the request
+	route  string // path sent in the event, e.g. the route pattern /v1/decide
+	status int    // status code of the response
+}
+
+// eventNamePlaceholders render the values of the placeholders of event name templates
+var eventNamePlaceholders = map[string]func(v eventNameVars) string{
+	"method":         func(v eventNameVars) string { return v.method },
+	"route_template": func(v eventNameVars) string { return v.route },
+	"status_class":   func(v eventNameVars) string { return strconv.Itoa(v.status/100) + "xx" },
+}
+
+// eventNameTemplate is a compiled event name template such as "{method}_{route_template}".
+// Segments are literals, except those naming a placeholder.
+type eventNameTemplate []eventNameSegment
+
+type eventNameSegment struct {
+	text        string
+	placeholder bool
+}
+
+// compileEventName parses an event name template, returning nil for names without placeholders
+func compileEventName(src string) (eventNameTemplate, error) {
+	if !strings.ContainsAny(src, "{}") {
+		return nil, nil
+	}
+
+	var t eventNameTemplate
+	for rest := src; rest != ""; {
+		open := strings.IndexByte(rest, '{')
+		if close := strings.IndexByte(rest, '}'); close >= 0 && (open < 0 || close < open) {
+			return nil, fmt.Errorf("invalid analytics event name %q: unexpected }", src)
+		}
+		if open < 0 {
+			t = append(t, eventNameSegment{text: rest})
+			break
+		}
+		if open > 0 {
+			t = append(t, eventNameSegment{text: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid analytics event name %q: unterminated {", src)
+		}
+		name := rest[open+1 : open+end]
+		if _, ok := eventNamePlaceholders[name]; !ok {
+			return nil, fmt.Errorf("invalid analytics event name %q: unknown placeholder {%s}", src, name)
+		}
+		t = append(t, eventNameSegment{text: name, placeholder: true})
+		rest = rest[open+end+1:]
+	}
+	return t, nil
+}
+
+// render returns the event name for the request. Placeholder values are reduced to lower case
+// letters, digits and underscores, and the name is cut to the length GA4 accepts.
+func (t eventNameTemplate) render(v eventNameVars) string {
+	var sb strings.Builder
+	for _, s := range t {
+		if s.placeholder {
+			sb.WriteString(eventNameSegmentValue(eventNamePlaceholders[s.text](v)))
+		} else {
+			sb.WriteString(s.text)
+		}
+	}
+	name := strings.Trim(sb.String(), "_")
+	if len(name) > maxEventNameLength {
+		name = strings.TrimRight(name[:maxEventNameLength], "_")
+	}
+	return name
+}
+
+// eventNameSegmentValue lower-cases value, replacing runs of other characters than letters and
+// digits with an underscore and trimming them at the ends, e.g. /v1/decide becomes v1_decide
+func eventNameSegmentValue(value string) string {
+	var sb strings.Builder
+	pending := false
+	for _, c := range strings.ToLower(value) {
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+			if pending && sb.Len() > 0 {
+				sb.WriteByte('_')
+			}
+			pending = false
+			sb.WriteRune(c)
+		} else {
+			pending = true
+		}
+	}
+	return sb.String()
+}
+
+// eventNames holds the compiled event name templates, by source
+type eventNames map[string]eventNameTemplate
+
+// newEventNames compiles the templates among names, returning errors for the invalid ones
+func newEventNames(names ...string) (eventNames, []error) {
+	var errs []error
+	compiled := eventNames{}
+	for _, name := range names {
+		t, err := compileEventName(name)
+		if err != nil {
+			errs = append(errs, err)
+		} else if t != nil {
+			compiled[name] = t
+		}
+	}
+	return compiled, errs
+}
+
+// render returns the event name for name, rendered when it is a template
+func (n eventNames) render(name string, v eventNameVars) string {
+	if t, ok := n[name]; ok {
+		return t.render(v)
+	}
+	return name
+}
@@ -0,0 +1,90 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventNameTemplateRender(t *testing.T) {
+	vars := eventNameVars{method: "POST", route: "/v1/decide", status: http.StatusNotFound}
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"{method}_{route_template}", "post_v1_decide"},
+		{"api_{route_template}_{status_class}", "api_v1_decide_4xx"},
+		{"{route_template}", "v1_decide"},
+		{"agent_{method}", "agent_post"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			tmpl, err := compileEventName(tt.src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tmpl.render(vars))
+		})
+	}
+
+	tmpl, err := compileEventName("{method}_{route_template}")
+	require.NoError(t, err)
+	assert.Equal(t, "get_v1_datafiles_sdkkey", tmpl.render(eventNameVars{method: "GET", route: "/v1/datafiles/{sdkKey}"}))
+	name := tmpl.render(eventNameVars{method: "GET", route: "/a-very/long/route/that/goes/on/and/on/forever"})
+	assert.Equal(t, "get_a_very_long_route_that_goes_on_and_o", name)
+	assert.Len(t, name, maxEventNameLength)
+}
+
+func TestCompileEventName(t *testing.T) {
+	tmpl, err := compileEventName("api_request")
+	assert.NoError(t, err)
+	assert.Nil(t, tmpl, "names without placeholders aren't templates")
+
+	for _, src := range []string{"{method", "method}", "{path}", "{}_request"} {
+		_, err := compileEventName(src)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestAnalyticsEventNameTemplates(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{
+		Enabled:   true,
+		EventName: "{method}_{route_template}",
+		Rules:     []RouteRule{{Path: "/v1/track", EventName: "track_{status_class}"}},
+	}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/decide", nil))
+	assert.Equal(t, "post_v1_decide", backend.next(t).Name)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/track", nil))
+	assert.Equal(t, "track_2xx", backend.next(t).Name)
+}
+
+func TestAnalyticsInvalidEventName(t *testing.T) {
+	a := &Analytics{Enabled: true, EventName: "{path}"}
+	a.Handler()
+	assert.Equal(t, defaultEventName, a.routeSettings("/v1/decide").eventName)
+
+	err := a.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `eventName: invalid analytics event name "{path}"`)
+}
@@ -0,0 +1,313 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// eventSchemaVersion is the version of the canonical event model, see CanonicalEvent. Bump it,
+// and the schemas below, with every change to its fields.
+const eventSchemaVersion = 3
+
+const avroNamespace = "com.optimizely.agent.analytics"
+
+// eventAvroSchema is the canonical event schema for Avro serialization. Custom param values are
+// typed where possible; objects and arrays are serialized as JSON strings.
+const eventAvroSchema = `{
+  "type": "record",
+  "name": "ApiEvent",
+  "namespace": "com.optimizely.agent.analytics",
+  "doc": "API request tracked by the Optimizely Agent analytics interceptor",
+  "fields": [
+    {"name": "schemaVersion", "type": "int", "default": 3},
+    {"name": "timestamp", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+    {"name": "name", "type": "string"},
+    {"name": "client", "type": {"type": "record", "name": "Client", "fields": [
+      {"name": "id", "type": "string"},
+      {"name": "userId", "type": ["null", "string"], "default": null},
+      {"name": "ipAddress", "type": ["null", "string"], "default": null},
+      {"name": "userAgent", "type": ["null", "string"], "default": null},
+      {"name": "region", "type": ["null", "string"], "default": null}
+    ]}},
+    {"name": "request", "type": {"type": "record", "name": "Request", "fields": [
+      {"name": "path", "type": "string"},
+      {"name": "method", "type": "string"},
+      {"name": "sdkKey", "type": ["null", "string"], "default": null},
+      {"name": "bytes", "type": "long"},
+      {"name": "id", "type": ["null", "string"], "default": null}
+    ]}},
+    {"name": "response", "type": {"type": "record", "name": "Response", "fields": [
+      {"name": "statusCode", "type": "int"},
+      {"name": "bytes", "type": "long"},
+      {"name": "durationMs", "type": "long"}
+    ]}},
+    {"name": "enrichment", "type": {"type": "record", "name": "Enrichment", "fields": [
+      {"name": "geo", "default": null, "type": ["null", {"type": "record", "name": "Geo", "fields": [
+        {"name": "country", "type": ["null", "string"], "default": null},
+        {"name": "region", "type": ["null", "string"], "default": null},
+        {"name": "city", "type": ["null", "string"], "default": null}
+      ]}]},
+      {"name": "device", "default": null, "type": ["null", {"type": "record", "name": "Device", "fields": [
+        {"name": "category", "type": ["null", "string"], "default": null},
+        {"name": "browser", "type": ["null", "string"], "default": null},
+        {"name": "browserVersion", "type": ["null", "string"], "default": null},
+        {"name": "os", "type": ["null", "string"], "default": null},
+        {"name": "osVersion", "type": ["null", "string"], "default": null}
+      ]}]},
+      {"name": "decisions", "default": null, "type": ["null", {"type": "record", "name": "Decisions", "fields": [
+        {"name": "flagKeys", "type": {"type": "array", "items": "string"}, "default": []},
+        {"name": "variationKeys", "type": {"type": "array", "items": "string"}, "default": []},
+        {"name": "ruleKeys", "type": {"type": "array", "items": "string"}, "default": []},
+        {"name": "ruleTypes", "type": {"type": "array", "items": "string"}, "default": []},
+        {"name": "count", "type": "int"},
+        {"name": "enabledCount", "type": "int"}
+      ]}]},
+      {"name": "session", "default": null, "type": ["null", {"type": "record", "name": "Session", "fields": [
+        {"name": "id", "type": "string"},
+        {"name": "number", "type": "long"},
+        {"name": "engagementTimeMsec", "type": "long"}
+      ]}]},
+      {"name": "sampleRate", "type": ["null", "double"], "default": null}
+    ]}},
+    {"name": "custom", "type": {"type": "map", "values": ["null", "boolean", "long", "double", "string"]}, "default": {}},
+    {"name": "userProperties", "type": {"type": "map", "values": ["null", "boolean", "long", "double", "string"]}, "default": {}}
+  ]
+}`
+
+// eventProtobufSchema is the canonical event schema for Protobuf serialization. Its JSON field
+// names match those of CanonicalEvent.
+const eventProtobufSchema = `syntax = "proto3";
+
+package com.optimizely.agent.analytics;
+
+import "google/protobuf/struct.proto";
+import "google/protobuf/timestamp.proto";
+
+// API request tracked by the Optimizely Agent analytics interceptor
+message ApiEvent {
+  int32 schema_version = 1;
+  google.protobuf.Timestamp timestamp = 2;
+  string name = 3;
+  Client client = 4;
+  Request request = 5;
+  Response response = 6;
+  Enrichment enrichment = 7;
+  map<string, google.protobuf.Value> custom = 8;
+  map<string, google.protobuf.Value> user_properties = 9;
+}
+
+message Client {
+  string id = 1;
+  string user_id = 2;
+  string ip_address = 3;
+  string user_agent = 4;
+  string region = 5;
+}
+
+message Request {
+  string path = 1;
+  string method = 2;
+  string sdk_key = 3;
+  int64 bytes = 4;
+  string id = 5;
+}
+
+message Response {
+  int32 status_code = 1;
+  int64 bytes = 2;
+  int64 duration_ms = 3;
+}
+
+message Enrichment {
+  Geo geo = 1;
+  Device device = 2;
+  Decisions decisions = 3;
+  Session session = 4;
+  double sample_rate = 5;
+}
+
+message Geo {
+  string country = 1;
+  string region = 2;
+  string city = 3;
+}
+
+message Device {
+  string category = 1;
+  string browser = 2;
+  string browser_version = 3;
+  string os = 4;
+  string os_version = 5;
+}
+
+message Decisions {
+  repeated string flag_keys = 1;
+  repeated string variation_keys = 2;
+  repeated string rule_keys = 3;
+  repeated string rule_types = 4;
+  int32 count = 5;
+  int32 enabled_count = 6;
+}
+
+message Session {
+  string id = 1;
+  int64 number = 2;
+  int64 engagement_time_msec = 3;
+}
+`
+
+// eventProtobufKeySchema is the Protobuf schema of record keys, which hold the client ID
+const eventProtobufKeySchema = `syntax = "proto3";
+
+package com.optimizely.agent.analytics;
+
+message ApiEventKey {
+  string client_id = 1;
+}
+`
+
+// avroEvent returns the Avro JSON encoding of the canonical event
+func avroEvent(c CanonicalEvent) map[string]interface{} {
+	enrichment := map[string]interface{}{
+		"geo":        nil,
+		"device":     nil,
+		"decisions":  nil,
+		"session":    nil,
+		"sampleRate": nil,
+	}
+	if geo := c.Enrichment.Geo; geo != nil {
+		enrichment["geo"] = avroRecord("Geo", map[string]interface{}{
+			"country": avroOptionalString(geo.Country),
+			"region":  avroOptionalString(geo.Region),
+			"city":    avroOptionalString(geo.City),
+		})
+	}
+	if device := c.Enrichment.Device; device != nil {
+		enrichment["device"] = avroRecord("Device", map[string]interface{}{
+			"category":       avroOptionalString(device.Category),
+			"browser":        avroOptionalString(device.Browser),
+			"browserVersion": avroOptionalString(device.BrowserVersion),
+			"os":             avroOptionalString(device.OS),
+			"osVersion":      avroOptionalString(device.OSVersion),
+		})
+	}
+	if decisions := c.Enrichment.Decisions; decisions != nil {
+		enrichment["decisions"] = avroRecord("Decisions", map[string]interface{}{
+			"flagKeys":      avroStrings(decisions.FlagKeys),
+			"variationKeys": avroStrings(decisions.VariationKeys),
+			"ruleKeys":      avroStrings(decisions.RuleKeys),
+			"ruleTypes":     avroStrings(decisions.RuleTypes),
+			"count":         decisions.Count,
+			"enabledCount":  decisions.EnabledCount,
+		})
+	}
+	if session := c.Enrichment.Session; session != nil {
+		enrichment["session"] = avroRecord("Session", map[string]interface{}{
+			"id":                 session.ID,
+			"number":             session.Number,
+			"engagementTimeMsec": session.EngagementTimeMsec,
+		})
+	}
+	if c.Enrichment.SampleRate != 0 {
+		enrichment["sampleRate"] = map[string]interface{}{"double": c.Enrichment.SampleRate}
+	}
+
+	return map[string]interface{}{
+		"schemaVersion": c.SchemaVersion,
+		"timestamp":     c.Timestamp.UnixMilli(),
+		"name":          c.Name,
+		"client": map[string]interface{}{
+			"id":        c.Client.ID,
+			"userId":    avroOptionalString(c.Client.UserID),
+			"ipAddress": avroOptionalString(c.Client.IPAddress),
+			"userAgent": avroOptionalString(c.Client.UserAgent),
+			"region":    avroOptionalString(c.Client.Region),
+		},
+		"request": map[string]interface{}{
+			"path":   c.Request.Path,
+			"method": c.Request.Method,
+			"sdkKey": avroOptionalString(c.Request.SDKKey),
+			"bytes":  c.Request.Bytes,
+			"id":     avroOptionalString(c.Request.ID),
+		},
+		"response": map[string]interface{}{
+			"statusCode": c.Response.StatusCode,
+			"bytes":      c.Response.Bytes,
+			"durationMs": c.Response.DurationMS,
+		},
+		"enrichment":     enrichment,
+		"custom":         avroValues(c.Custom),
+		"userProperties": avroValues(c.UserProperties),
+	}
+}
+
+// avroRecord encodes a record as the non-null member of a ["null", record] union
+func avroRecord(name string, fields map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{avroNamespace + "." + name: fields}
+}
+
+// avroOptionalString encodes s as a member of the ["null", "string"] union, empty strings as null
+func avroOptionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return map[string]interface{}{"string": s}
+}
+
+// avroStrings encodes an array of strings, which must not be null
+func avroStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// avroValues encodes values as members of the ["null", "boolean", "long", "double", "string"] union
+func avroValues(values map[string]interface{}) map[string]interface{} {
+	encoded := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		encoded[k] = avroUnionValue(v)
+	}
+	return encoded
+}
+
+func avroUnionValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch value := reflect.ValueOf(v); value.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"boolean": value.Bool()}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"long": value.Int()}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"long": int64(value.Uint())}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"double": value.Float()}
+	case reflect.String:
+		return map[string]interface{}{"string": value.String()}
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		encoded = []byte(fmt.Sprint(v))
+	}
+	return map[string]interface{}{"string": string(encoded)}
+}
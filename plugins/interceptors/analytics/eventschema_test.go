@@ -0,0 +1,130 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventAvroSchemaIsValidJSON(t *testing.T) {
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(eventAvroSchema), &schema))
+	assert.Equal(t, "ApiEvent", schema["name"])
+}
+
+func TestAvroUnionValue(t *testing.T) {
+	assert.Nil(t, avroUnionValue(nil))
+	assert.Equal(t, map[string]interface{}{"boolean": true}, avroUnionValue(true))
+	assert.Equal(t, map[string]interface{}{"long": int64(200)}, avroUnionValue(200))
+	assert.Equal(t, map[string]interface{}{"long": int64(7)}, avroUnionValue(uint8(7)))
+	assert.Equal(t, map[string]interface{}{"double": 1.5}, avroUnionValue(1.5))
+	assert.Equal(t, map[string]interface{}{"string": "/v1/decide"}, avroUnionValue("/v1/decide"))
+	assert.Equal(t, map[string]interface{}{"string": `["a","b"]`}, avroUnionValue([]string{"a", "b"}))
+}
+
+func TestAvroEvent(t *testing.T) {
+	event := avroEvent(CanonicalEvent{
+		SchemaVersion: eventSchemaVersion,
+		Timestamp:     time.UnixMilli(1700000000000),
+		Name:          "api_request",
+		Client:        ClientInfo{ID: "a"},
+		Request:       RequestInfo{ID: "req-1", Path: "/v1/decide", Method: "POST", Bytes: 10},
+		Enrichment: Enrichment{
+			Geo:        &GeoInfo{Country: "DE"},
+			Decisions:  &DecisionInfo{FlagKeys: []string{"checkout"}, Count: 1},
+			SampleRate: 0.5,
+		},
+	})
+	assert.Equal(t, int64(1700000000000), event["timestamp"])
+	assert.Nil(t, event["client"].(map[string]interface{})["userId"])
+	assert.Equal(t, map[string]interface{}{"string": "req-1"}, event["request"].(map[string]interface{})["id"])
+	assert.Equal(t, map[string]interface{}{}, event["custom"])
+
+	enrichment := event["enrichment"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"com.optimizely.agent.analytics.Geo": map[string]interface{}{
+			"country": map[string]interface{}{"string": "DE"},
+			"region":  nil,
+			"city":    nil,
+		},
+	}, enrichment["geo"])
+	assert.Nil(t, enrichment["device"])
+	assert.Equal(t, []string{}, enrichment["decisions"].(map[string]interface{})["com.optimizely.agent.analytics.Decisions"].(map[string]interface{})["ruleKeys"])
+	assert.Equal(t, map[string]interface{}{"double": 0.5}, enrichment["sampleRate"])
+}
+
+// The JSON form of canonical events is the Protobuf JSON form of the schema, so field names must match
+func TestEventProtobufSchemaMatchesJSON(t *testing.T) {
+	data, err := json.Marshal(CanonicalEvent{
+		Enrichment: Enrichment{
+			Geo:       &GeoInfo{Country: "DE", Region: "BE", City: "Berlin"},
+			Device:    &DeviceInfo{Category: "a", Browser: "b", BrowserVersion: "c", OS: "d", OSVersion: "e"},
+			Decisions: &DecisionInfo{FlagKeys: []string{"a"}, VariationKeys: []string{"b"}, RuleKeys: []string{"c"}, RuleTypes: []string{"d"}},
+			Session:   &SessionInfo{ID: "a"},
+		},
+		Client:         ClientInfo{ID: "a", UserID: "b", IPAddress: "c", UserAgent: "d", Region: "e"},
+		Request:        RequestInfo{ID: "a", SDKKey: "a"},
+		Custom:         map[string]interface{}{"a": 1},
+		UserProperties: map[string]interface{}{"a": 1},
+	})
+	require.NoError(t, err)
+
+	var fields func(prefix string, v interface{})
+	names := map[string]bool{}
+	fields = func(prefix string, v interface{}) {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for name, value := range object {
+			names[name] = true
+			if prefix != "custom" && prefix != "userProperties" {
+				fields(name, value)
+			}
+		}
+	}
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	fields("", decoded)
+
+	for name := range names {
+		if name == "a" {
+			continue
+		}
+		assert.Contains(t, eventProtobufSchema, protoFieldName(name)+" = ", name)
+	}
+}
+
+// protoFieldName converts a lowerCamelCase JSON name to its snake_case Protobuf field name
+func protoFieldName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
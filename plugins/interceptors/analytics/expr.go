@@ -0,0 +1,682 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// expr is a compiled expression of the small language used to compute event params in config.
+// Expressions combine params (referenced by name, missing params are null) and literals
+// ('text', 42, 1.5, true, false, null) with the operators ?:, ||, &&, ==, !=, <, <=, >, >=,
+// +, -, *, /, %, ! and unary minus, parentheses and the functions in exprFuncs, e.g.
+//
+//	status_code >= 500 ? 'error' : lower(method) + ' ' + path
+//
+// Functions can also be called on a param, passing it as the first argument: path.startsWith('/v1/')
+// is startsWith(path, '/v1/').
+type expr struct {
+	src  string
+	root exprNode
+}
+
+// exprNode is a node of a compiled expression
+type exprNode interface {
+	eval(env map[string]interface{}) (interface{}, error)
+}
+
+// compileExpr parses src, returning an error for invalid syntax or unknown functions
+func compileExpr(src string) (*expr, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.ternary()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+	return &expr{src: src, root: root}, nil
+}
+
+// eval evaluates the expression with the params in env
+func (e *expr) eval(env map[string]interface{}) (interface{}, error) {
+	return e.root.eval(env)
+}
+
+// idents calls fn with the name of each param the expression references
+func (e *expr) idents(fn func(name string)) {
+	var walk func(n exprNode)
+	walk = func(n exprNode) {
+		switch n := n.(type) {
+		case identNode:
+			fn(n.name)
+		case ternaryNode:
+			walk(n.cond)
+			walk(n.then)
+			walk(n.otherwise)
+		case unaryNode:
+			walk(n.operand)
+		case binaryNode:
+			walk(n.left)
+			walk(n.right)
+		case callNode:
+			for _, arg := range n.args {
+				walk(arg)
+			}
+		}
+	}
+	walk(e.root)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	val  interface{} // value of number and string literals
+}
+
+// exprOperators lists the operators and punctuation, longest first
+var exprOperators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":", "(", ")", ","}
+
+func lexExpr(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			text := src[i:j]
+			if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+				tokens = append(tokens, token{kind: tokenNumber, text: text, val: n})
+			} else if f, err := strconv.ParseFloat(text, 64); err == nil {
+				tokens = append(tokens, token{kind: tokenNumber, text: text, val: f})
+			} else {
+				return nil, fmt.Errorf("invalid number %q", text)
+			}
+			i = j
+		case c == '\'' || c == '"':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				sb.WriteByte(src[j])
+			}
+			if j == len(src) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[i : j+1], val: sb.String()})
+			i = j + 1
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i
+			for j < len(src) && (src[j] == '_' || src[j] == '.' || src[j] >= 'a' && src[j] <= 'z' || src[j] >= 'A' && src[j] <= 'Z' || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range exprOperators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, text: "end of expression"}), nil
+}
+
+// exprParser is a recursive descent parser with one function per precedence level
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// accept consumes the next token if it is one of the operators ops
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return fmt.Errorf("expected %q, got %q", op, p.peek().text)
+	}
+	return nil
+}
+
+func (p *exprParser) ternary() (exprNode, error) {
+	cond, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+	then, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return ternaryNode{cond, then, otherwise}, nil
+}
+
+// binaryPrecedence lists the binary operators from the lowest precedence to the highest
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) binary(level int) (exprNode, error) {
+	if level == len(binaryPrecedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(binaryPrecedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op, left, right}
+	}
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op, operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (exprNode, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber, tokenString:
+		p.pos++
+		return literalNode{t.val}, nil
+	case tokenIdent:
+		p.pos++
+		switch t.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		case "null":
+			return literalNode{nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.call(t.text)
+		}
+		return identNode{t.text}, nil
+	case tokenOp:
+		if _, ok := p.accept("("); ok {
+			node, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+// call parses the arguments of a call to the function name, whose opening parenthesis was consumed
+func (p *exprParser) call(name string) (exprNode, error) {
+	var args []exprNode
+	fn, ok := exprFuncs[name]
+	if i := strings.LastIndexByte(name, '.'); !ok && i > 0 {
+		// param.fn(args) calls fn with the param as its first argument
+		if fn, ok = exprFuncs[name[i+1:]]; ok {
+			args = append(args, identNode{name[:i]})
+			name = name[i+1:]
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("wrong number of arguments for %s: %d", name, len(args))
+	}
+	return callNode{name, fn, args}, nil
+}
+
+type literalNode struct{ val interface{} }
+
+func (n literalNode) eval(map[string]interface{}) (interface{}, error) {
+	return n.val, nil
+}
+
+type identNode struct{ name string }
+
+func (n identNode) eval(env map[string]interface{}) (interface{}, error) {
+	return env[n.name], nil
+}
+
+type ternaryNode struct{ cond, then, otherwise exprNode }
+
+func (n ternaryNode) eval(env map[string]interface{}) (interface{}, error) {
+	cond, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(cond) {
+		return n.then.eval(env)
+	}
+	return n.otherwise.eval(env)
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n unaryNode) eval(env map[string]interface{}) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !truthy(v), nil
+	}
+	i, f, isInt, ok := toNumber(v)
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("cannot negate %T", v)
+	case isInt:
+		return -i, nil
+	default:
+		return -f, nil
+	}
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n binaryNode) eval(env map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// Logical operators short-circuit
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "&&", "||":
+		return truthy(right), nil
+	case "==":
+		return exprEqual(left, right), nil
+	case "!=":
+		return !exprEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compareValues(n.op, left, right)
+	default:
+		return arithmetic(n.op, left, right)
+	}
+}
+
+type callNode struct {
+	name string
+	fn   exprFunc
+	args []exprNode
+}
+
+func (n callNode) eval(env map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+// exprFunc is a function callable from expressions. maxArgs is -1 for variadic functions.
+type exprFunc struct {
+	minArgs, maxArgs int
+	call             func(args []interface{}) (interface{}, error)
+}
+
+// stringFunc adapts a function of strings, converting the arguments with toString
+func stringFunc(n int, fn func(args []string) interface{}) exprFunc {
+	return exprFunc{minArgs: n, maxArgs: n, call: func(args []interface{}) (interface{}, error) {
+		s := make([]string, len(args))
+		for i, arg := range args {
+			s[i] = toString(arg)
+		}
+		return fn(s), nil
+	}}
+}
+
+// exprFuncs are the functions available to expressions
+var exprFuncs = map[string]exprFunc{
+	"lower":      stringFunc(1, func(s []string) interface{} { return strings.ToLower(s[0]) }),
+	"upper":      stringFunc(1, func(s []string) interface{} { return strings.ToUpper(s[0]) }),
+	"trim":       stringFunc(1, func(s []string) interface{} { return strings.TrimSpace(s[0]) }),
+	"contains":   stringFunc(2, func(s []string) interface{} { return strings.Contains(s[0], s[1]) }),
+	"startsWith": stringFunc(2, func(s []string) interface{} { return strings.HasPrefix(s[0], s[1]) }),
+	"endsWith":   stringFunc(2, func(s []string) interface{} { return strings.HasSuffix(s[0], s[1]) }),
+	"replace":    stringFunc(3, func(s []string) interface{} { return strings.ReplaceAll(s[0], s[1], s[2]) }),
+	"len":        stringFunc(1, func(s []string) interface{} { return int64(len(s[0])) }),
+	"string":     stringFunc(1, func(s []string) interface{} { return s[0] }),
+	"matches": {minArgs: 2, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		re, err := cachedRegexp(toString(args[1]))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(toString(args[0])), nil
+	}},
+	"int": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		if s, ok := args[0].(string); ok {
+			return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		}
+		i, f, isInt, ok := toNumber(args[0])
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("cannot convert %T", args[0])
+		case isInt:
+			return i, nil
+		default:
+			return int64(math.Trunc(f)), nil
+		}
+	}},
+	"float": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		if s, ok := args[0].(string); ok {
+			return strconv.ParseFloat(strings.TrimSpace(s), 64)
+		}
+		_, f, _, ok := toNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("cannot convert %T", args[0])
+		}
+		return f, nil
+	}},
+	"coalesce": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		for _, arg := range args {
+			if arg != nil && arg != "" {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	}},
+}
+
+// regexps caches the patterns of matches calls, which are usually literals
+var regexps sync.Map
+
+func cachedRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+	return re, nil
+}
+
+// truthy reports whether v counts as true in conditions: null, false, empty strings and zero don't
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	if _, f, _, ok := toNumber(v); ok {
+		return f != 0
+	}
+	return true
+}
+
+// toNumber converts the numeric types params are captured or decoded as, reporting whether
+// v is a number and whether it is an integer
+func toNumber(v interface{}) (i int64, f float64, isInt, ok bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), float64(n), true, true
+	case int32:
+		return int64(n), float64(n), true, true
+	case int64:
+		return n, float64(n), true, true
+	case float32:
+		return int64(n), float64(n), false, true
+	case float64:
+		return int64(n), n, false, true
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, float64(i), true, true
+		}
+		if f, err := n.Float64(); err == nil {
+			return int64(f), f, false, true
+		}
+	}
+	return 0, 0, false, false
+}
+
+// toString formats v for string functions and concatenation, null being the empty string
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// exprEqual compares numbers by value and other values by type and value
+func exprEqual(a, b interface{}) bool {
+	if _, fa, _, ok := toNumber(a); ok {
+		_, fb, _, ok := toNumber(b)
+		return ok && fa == fb
+	}
+	switch a.(type) {
+	case nil, bool, string:
+		return a == b
+	}
+	return false
+}
+
+func compareValues(op string, a, b interface{}) (bool, error) {
+	var cmp int
+	_, fa, _, aNum := toNumber(a)
+	_, fb, _, bNum := toNumber(b)
+	sa, aStr := a.(string)
+	sb, bStr := b.(string)
+	switch {
+	case aNum && bNum:
+		cmp = compareOrdered(fa, fb)
+	case aStr && bStr:
+		cmp = strings.Compare(sa, sb)
+	case a == nil || b == nil:
+		// Comparisons with missing params are false
+		return false, nil
+	default:
+		return false, fmt.Errorf("cannot compare %T and %T", a, b)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// arithmetic applies +, -, *, / or %. Integers stay integers except when divided, and + joins
+// strings.
+func arithmetic(op string, a, b interface{}) (interface{}, error) {
+	_, aStr := a.(string)
+	_, bStr := b.(string)
+	if op == "+" && (aStr || bStr) {
+		return toString(a) + toString(b), nil
+	}
+
+	ia, fa, aInt, aNum := toNumber(a)
+	ib, fb, bInt, bNum := toNumber(b)
+	if !aNum || !bNum {
+		return nil, fmt.Errorf("cannot apply %s to %T and %T", op, a, b)
+	}
+	if (op == "/" || op == "%") && fb == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+
+	if aInt && bInt {
+		switch op {
+		case "+":
+			return ia + ib, nil
+		case "-":
+			return ia - ib, nil
+		case "*":
+			return ia * ib, nil
+		case "%":
+			return ia % ib, nil
+		}
+	}
+	switch op {
+	case "+":
+		return fa + fb, nil
+	case "-":
+		return fa - fb, nil
+	case "*":
+		return fa * fb, nil
+	case "%":
+		return math.Mod(fa, fb), nil
+	default:
+		return fa / fb, nil
+	}
+}
@@ -0,0 +1,99 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExprEval(t *testing.T) {
+	env := map[string]interface{}{
+		"path":             "/v1/decide",
+		"method":           "POST",
+		"status_code":      503,
+		"response_time_ms": int64(250),
+		"sample_rate":      0.5,
+		"enabled":          true,
+	}
+
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{"status_code", 503},
+		{"missing", nil},
+		{"status_code >= 500", true},
+		{"status_code >= 500 && startsWith(path, '/v1/')", true},
+		{"missing || enabled", true},
+		{"!enabled", false},
+		{"status_code >= 500 ? 'error' : 'ok'", "error"},
+		{"lower(method) + ' ' + path", "post /v1/decide"},
+		{"response_time_ms / 1000", 0.25},
+		{"response_time_ms * 2 + 1", int64(501)},
+		{"-(response_time_ms % 100)", int64(-50)},
+		{"1 / sample_rate", 2.0},
+		{"status_code == 503.0", true},
+		{"method != \"GET\"", true},
+		{"missing > 1", false},
+		{"matches(path, '^/v1/(decide|track)$')", true},
+		{"replace(path, '/v1/', '')", "decide"},
+		{"coalesce(missing, '', 'default')", "default"},
+		{"int('42') + int(1.9)", int64(43)},
+		{"float('1.5')", 1.5},
+		{"len(path)", int64(10)},
+		{"string(status_code) + 'x'", "503x"},
+		{"(1 + 2) * 3", int64(9)},
+		{"path.startsWith('/v1/') && method.lower() == 'post'", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			e, err := compileExpr(tt.src)
+			require.NoError(t, err)
+			got, err := e.eval(env)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExprEvalErrors(t *testing.T) {
+	env := map[string]interface{}{"path": "/v1/decide", "status_code": 200}
+	for _, src := range []string{"path * 2", "status_code / 0", "path < 1", "int(path)", "matches(path, '(')"} {
+		e, err := compileExpr(src)
+		require.NoError(t, err, src)
+		_, err = e.eval(env)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestExprIdents(t *testing.T) {
+	e, err := compileExpr("request.path.startsWith('/v1/') ? coalesce(tenant, -count) : !enabled")
+	require.NoError(t, err)
+	var names []string
+	e.idents(func(name string) { names = append(names, name) })
+	assert.Equal(t, []string{"request.path", "tenant", "count", "enabled"}, names)
+}
+
+func TestCompileExprErrors(t *testing.T) {
+	for _, src := range []string{"", "1 +", "(1", "a ? b", "'open", "unknown(1)", "path.unknown()", "lower()", "path.lower(1)", "1.2.3", "a # b", "1 2"} {
+		_, err := compileExpr(src)
+		assert.Error(t, err, src)
+	}
+}
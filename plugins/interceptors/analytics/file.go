@@ -0,0 +1,366 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const fileRotationTimeFormat = "20060102T150405.000"
+
+// FileBackend appends events to a local file as newline delimited JSON, for collection by a
+// log shipper. The file is rotated by size and age; rotated files are renamed with their
+// rotation time, e.g. events-20250601T120000.000.ndjson, and optionally gzipped.
+type FileBackend struct {
+	Path       string         `json:"path"`       // File events are appended to
+	MaxBytes   int64          `json:"maxBytes"`   // Rotates the file before it grows past this size (0 never rotates by size)
+	MaxAge     utils.Duration `json:"maxAge"`     // Rotates the file once it has been written to for this long, e.g. 1h (0 never rotates by age)
+	Compress   bool           `json:"compress"`   // Gzips rotated files
+	MaxBackups int            `json:"maxBackups"` // Rotated files kept, the oldest are removed first (0 keeps all)
+
+	mu     sync.Mutex
+	closed bool
+	writer *fileWriter
+}
+
+// Send appends the events to the file, rotating it first when due
+func (f *FileBackend) Send(ctx context.Context, events []Event) error {
+	if f.Path == "" {
+		return errors.New("file analytics backend requires a path")
+	}
+
+	var buf bytes.Buffer
+	target := dryRunFrom(ctx)
+	for _, e := range events {
+		line, err := json.Marshal(canonicalEvent(e))
+		if err != nil {
+			return err
+		}
+		if target != nil {
+			if err := target.record("file://"+f.Path, "application/json", line); err != nil {
+				return err
+			}
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if target != nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return errors.New("file analytics backend is closed")
+	}
+	if f.writer == nil {
+		w, err := attachFileWriter(f)
+		if err != nil {
+			return err
+		}
+		f.writer = w
+	}
+	return f.writer.write(buf.Bytes())
+}
+
+// Close releases the file, closing it and waiting for rotated files to be compressed unless
+// another backend still writes to it
+func (f *FileBackend) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	w := f.writer
+	f.writer = nil
+	f.mu.Unlock()
+
+	if w == nil {
+		return nil
+	}
+	return w.detach()
+}
+
+// backups lists the rotated files, oldest first
+func (f *FileBackend) backups() []string {
+	return fileBackups(f.Path)
+}
+
+var (
+	fileWriters   = map[string]*fileWriter{}
+	fileWritersMu sync.Mutex
+)
+
+// fileWriter appends to and rotates a file. File backends writing to the same path, e.g. those
+// of the interceptors of each server, or of a replaced and a reloaded instance, share a writer,
+// so that a single one tracks the size of the file and rotates it.
+type fileWriter struct {
+	path string
+	refs int // guarded by fileWritersMu
+
+	mu          sync.Mutex
+	maxBytes    int64
+	maxAge      time.Duration
+	compress    bool
+	maxBackups  int
+	file        *os.File
+	size        int64
+	openedAt    time.Time
+	compressing sync.WaitGroup
+	background  sync.Mutex // serializes compression and the removal of old backups
+}
+
+// attachFileWriter returns the writer of the path of f, with the rotation settings of f, which
+// is the most recently configured backend of the path
+func attachFileWriter(f *FileBackend) (*fileWriter, error) {
+	path, err := filepath.Abs(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileWritersMu.Lock()
+	w, ok := fileWriters[path]
+	if !ok {
+		w = &fileWriter{path: path}
+		fileWriters[path] = w
+	}
+	w.refs++
+	fileWritersMu.Unlock()
+
+	w.mu.Lock()
+	w.maxBytes, w.maxAge, w.compress, w.maxBackups = f.MaxBytes, f.MaxAge.Duration, f.Compress, f.MaxBackups
+	w.mu.Unlock()
+	return w, nil
+}
+
+// detach releases a backend's reference, closing the file when it was the last one
+func (w *fileWriter) detach() error {
+	fileWritersMu.Lock()
+	w.refs--
+	last := w.refs == 0
+	if last && fileWriters[w.path] == w {
+		delete(fileWriters, w.path)
+	}
+	fileWritersMu.Unlock()
+	if !last {
+		return nil
+	}
+
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.compressing.Wait()
+	return err
+}
+
+// write appends p to the file, rotating it first when due
+func (w *fileWriter) write(p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil && w.rotationDue(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return err
+}
+
+// open opens the file for appending, creating it and its directory as needed
+func (w *fileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o750); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = time.Now()
+	return nil
+}
+
+// rotationDue reports whether the file must be rotated before writing n more bytes.
+// An empty file is never rotated, so lines larger than maxBytes are still written.
+func (w *fileWriter) rotationDue(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.maxBytes > 0 && w.size+n > w.maxBytes {
+		return true
+	}
+	return w.maxAge > 0 && time.Since(w.openedAt) >= w.maxAge
+}
+
+// rotate renames the current file with its rotation time; the next write opens a new one.
+// Compression and the removal of old backups happen in the background.
+func (w *fileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		log.Warn().Err(err).Str("path", w.path).Msg("Failed to close analytics event file")
+	}
+	w.file = nil
+
+	rotated := rotatedPath(w.path, time.Now().UTC())
+	if err := os.Rename(w.path, rotated); err != nil {
+		return err
+	}
+
+	compress, maxBackups := w.compress, w.maxBackups
+	w.compressing.Add(1)
+	go func() {
+		defer w.compressing.Done()
+		w.background.Lock()
+		defer w.background.Unlock()
+		if compress {
+			if err := gzipFile(rotated); err != nil {
+				log.Warn().Err(err).Str("path", rotated).Msg("Failed to compress analytics event file")
+			}
+		}
+		removeOldBackups(w.path, maxBackups)
+	}()
+	return nil
+}
+
+// rotatedPath returns an unused name for the file at path rotated at t
+func rotatedPath(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+	for {
+		rotated := strings.TrimSuffix(path, ext) + "-" + t.Format(fileRotationTimeFormat) + ext
+		if !fileExists(rotated) && !fileExists(rotated+".gz") {
+			return rotated
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// removeOldBackups removes the oldest rotated files of path beyond maxBackups
+func removeOldBackups(path string, maxBackups int) {
+	if maxBackups <= 0 {
+		return
+	}
+
+	backups := fileBackups(path)
+	if len(backups) <= maxBackups {
+		return
+	}
+	for _, backup := range backups[:len(backups)-maxBackups] {
+		if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+			log.Warn().Err(err).Str("path", backup).Msg("Failed to remove analytics event file")
+		}
+	}
+}
+
+// fileBackups lists the rotated files of path, oldest first
+func fileBackups(path string) []string {
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".gz")
+		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		if _, err := time.Parse(fileRotationTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(path), entry.Name()))
+	}
+	// The rotation time format sorts chronologically
+	sort.Strings(backups)
+	return backups
+}
+
+// gzipFile replaces path with a gzipped copy, path.gz
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return err
+	}
+	return os.Remove(path)
+}
+
+func init() {
+	AddBackend("file", func() Backend {
+		return &FileBackend{}
+	})
+}
@@ -0,0 +1,194 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func readFileRecords(t *testing.T, r io.Reader) []CanonicalEvent {
+	var records []CanonicalEvent
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var rec CanonicalEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestFileBackendSend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analytics", "events.ndjson")
+	backend := &FileBackend{Path: path}
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", UserID: "user-1", Params: map[string]interface{}{"path": "/v1/decide"}},
+	}))
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "b"}}))
+	require.NoError(t, backend.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	records := readFileRecords(t, file)
+	require.Len(t, records, 2)
+	assert.Equal(t, "api_request", records[0].Name)
+	assert.Equal(t, "a", records[0].Client.ID)
+	assert.Equal(t, "user-1", records[0].Client.UserID)
+	assert.Equal(t, "/v1/decide", records[0].Request.Path)
+	assert.False(t, records[0].Timestamp.IsZero())
+	assert.Equal(t, "b", records[1].Client.ID)
+
+	// Closed backends don't reopen the file
+	assert.Error(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+}
+
+func TestFileBackendRequiresPath(t *testing.T) {
+	assert.Error(t, (&FileBackend{}).Send(context.Background(), []Event{{Name: "api_request"}}))
+}
+
+func TestFileBackendRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.ndjson")
+	backend := &FileBackend{Path: path, MaxBytes: 1, Compress: true, MaxBackups: 2}
+	for _, clientID := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: clientID}}))
+	}
+	require.NoError(t, backend.Close())
+
+	// The current file holds the last event and the two newest backups the ones before it
+	backups := backend.backups()
+	require.Len(t, backups, 2)
+	var clientIDs []string
+	for _, backup := range backups {
+		assert.True(t, strings.HasSuffix(backup, ".ndjson.gz"), backup)
+		file, err := os.Open(backup)
+		require.NoError(t, err)
+		zr, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		for _, rec := range readFileRecords(t, zr) {
+			clientIDs = append(clientIDs, rec.Client.ID)
+		}
+		file.Close()
+	}
+	assert.Equal(t, []string{"b", "c"}, clientIDs)
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	records := readFileRecords(t, file)
+	require.Len(t, records, 1)
+	assert.Equal(t, "d", records[0].Client.ID)
+}
+
+func TestFileBackendRotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	backend := &FileBackend{Path: path, MaxAge: utils.Duration{Duration: time.Millisecond}}
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a"}}))
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "b"}}))
+	time.Sleep(2 * time.Millisecond)
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "c"}}))
+	require.NoError(t, backend.Close())
+
+	backups := backend.backups()
+	require.Len(t, backups, 1)
+	assert.True(t, strings.HasSuffix(backups[0], ".ndjson"), backups[0])
+}
+
+func TestFileBackendsShareFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.ndjson")
+	first := &FileBackend{Path: path, MaxBytes: 1}
+	second := &FileBackend{Path: filepath.Join(dir, ".", "events.ndjson"), MaxBytes: 1}
+	for _, clientID := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, first.Send(context.Background(), []Event{{Name: "api_request", ClientID: clientID}}))
+		require.NoError(t, second.Send(context.Background(), []Event{{Name: "api_request", ClientID: clientID + "2"}}))
+	}
+	assert.Same(t, first.writer, second.writer)
+
+	// The file stays open for the backends still writing to it
+	require.NoError(t, first.Close())
+	require.NoError(t, second.Send(context.Background(), []Event{{Name: "api_request", ClientID: "e2"}}))
+	require.NoError(t, second.Close())
+
+	// Every event is in exactly one of the files, each rotated once
+	var clientIDs []string
+	for _, name := range append(first.backups(), path) {
+		file, err := os.Open(name)
+		require.NoError(t, err)
+		for _, rec := range readFileRecords(t, file) {
+			clientIDs = append(clientIDs, rec.Client.ID)
+		}
+		file.Close()
+	}
+	assert.Equal(t, []string{"a", "a2", "b", "b2", "c", "c2", "d", "d2", "e2"}, clientIDs)
+}
+
+func TestFileBackendBackupsIgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"events-other.ndjson", "events-20250601T120000.000.json", "events-20250601T120000.000.ndjson"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+	backend := &FileBackend{Path: filepath.Join(dir, "events.ndjson")}
+	assert.Equal(t, []string{filepath.Join(dir, "events-20250601T120000.000.ndjson")}, backend.backups())
+}
+
+func TestFileDestinationConfig(t *testing.T) {
+	dest, err := newDestination(BackendConfig{"type": "file", "path": "/var/log/agent/events.ndjson", "maxAge": "1h", "compress": true})
+	require.NoError(t, err)
+	backend, ok := dest.backend.(*FileBackend)
+	require.True(t, ok)
+	assert.Equal(t, time.Hour, backend.MaxAge.Duration)
+	assert.True(t, backend.Compress)
+}
+
+func TestFileBackendDryRun(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := newDryRunSink(filepath.Join(dir, "dryrun.ndjson"))
+	require.NoError(t, err)
+
+	backend := &dryRunBackend{
+		Backend: &FileBackend{Path: filepath.Join(dir, "events.ndjson")},
+		target:  &dryRunTarget{sink: sink, destination: "file"},
+	}
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a"},
+		{Name: "api_request", ClientID: "b"},
+	}))
+	require.NoError(t, backend.Close())
+	sink.close()
+
+	assert.NoFileExists(t, filepath.Join(dir, "events.ndjson"))
+	records := readDryRunRecords(t, filepath.Join(dir, "dryrun.ndjson"))
+	require.Len(t, records, 2)
+	var line CanonicalEvent
+	require.NoError(t, json.Unmarshal(records[1].Body, &line))
+	assert.Equal(t, "b", line.Client.ID)
+}
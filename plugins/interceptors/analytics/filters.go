@@ -0,0 +1,142 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// matchGlob reports whether p matches pattern using path.Match syntax, where a trailing
+// "/**" additionally matches the prefix and everything below it
+func matchGlob(pattern, p string) bool {
+	if prefix := strings.TrimSuffix(pattern, "/**"); prefix != pattern {
+		if matched, _ := path.Match(prefix, p); matched {
+			return true
+		}
+		for dir := path.Dir(p); dir != "/" && dir != "."; dir = path.Dir(dir) {
+			if matched, _ := path.Match(prefix, dir); matched {
+				return true
+			}
+		}
+		return false
+	}
+	matched, _ := path.Match(pattern, p)
+	return matched
+}
+
+// validateGlob returns an error for malformed glob patterns
+func validateGlob(pattern string) error {
+	if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), "/"); err != nil {
+		return fmt.Errorf("invalid analytics path pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// pathFilter decides which paths are tracked at all. Excluded paths are never tracked and,
+// when include patterns are configured, only matching paths are.
+type pathFilter struct {
+	include []string
+	exclude []string
+}
+
+// newPathFilter returns a filter for the valid patterns along with any errors for the invalid ones
+func newPathFilter(include, exclude []string) (pathFilter, []error) {
+	var f pathFilter
+	var errs []error
+	for _, pattern := range include {
+		if err := validateGlob(pattern); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f.include = append(f.include, pattern)
+	}
+	for _, pattern := range exclude {
+		if err := validateGlob(pattern); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f.exclude = append(f.exclude, pattern)
+	}
+	return f, errs
+}
+
+func (f pathFilter) allows(p string) bool {
+	for _, pattern := range f.exclude {
+		if matchGlob(pattern, p) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if matchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusPattern matches an exact status code ("404") or a status class ("4xx")
+var statusPattern = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
+// validateStatusCodes returns the valid status patterns, normalized to lower case, along with
+// errors for the invalid ones
+func validateStatusCodes(patterns []string) ([]string, []error) {
+	var valid []string
+	var errs []error
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if !statusPattern.MatchString(pattern) {
+			errs = append(errs, fmt.Errorf("invalid analytics status code pattern %q", pattern))
+			continue
+		}
+		valid = append(valid, pattern)
+	}
+	return valid, errs
+}
+
+// matchesStatus reports whether status matches any of the patterns. No patterns match every status.
+func matchesStatus(patterns []string, status int) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	code := strconv.Itoa(status)
+	for _, pattern := range patterns {
+		if pattern == code || (strings.HasSuffix(pattern, "xx") && len(code) == 3 && pattern[0] == code[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMethod reports whether method is one of methods. No methods match every method.
+func matchesMethod(methods []string, method string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGlob(t *testing.T) {
+	assert.True(t, matchGlob("/health", "/health"))
+	assert.True(t, matchGlob("/v1/*", "/v1/decide"))
+	assert.False(t, matchGlob("/v1/*", "/v1/decide/extra"))
+	assert.True(t, matchGlob("/admin/**", "/admin"))
+	assert.True(t, matchGlob("/admin/**", "/admin/config"))
+	assert.True(t, matchGlob("/admin/**", "/admin/webhooks/abc"))
+	assert.False(t, matchGlob("/admin/**", "/administrator"))
+	assert.False(t, matchGlob("/v1/*", "/v2/decide"))
+}
+
+func TestPathFilter(t *testing.T) {
+	f, errs := newPathFilter([]string{"/v1/**", "["}, []string{"/v1/config", "/health"})
+	assert.Len(t, errs, 1)
+
+	assert.True(t, f.allows("/v1/decide"))
+	assert.False(t, f.allows("/v1/config"))
+	assert.False(t, f.allows("/health"))
+	assert.False(t, f.allows("/metrics"))
+
+	// Without include patterns every path that isn't excluded is tracked
+	f, errs = newPathFilter(nil, []string{"/metrics"})
+	assert.Empty(t, errs)
+	assert.True(t, f.allows("/v1/decide"))
+	assert.False(t, f.allows("/metrics"))
+}
+
+func TestAnalyticsExcludePaths(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, ExcludePaths: []string{"/health", "/admin/**"}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	for _, p := range []string{"/health", "/admin/config", "/v1/decide"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+	}
+
+	assert.Equal(t, "/v1/decide", backend.next(t).Params["path"])
+}
+
+func TestValidateStatusCodes(t *testing.T) {
+	valid, errs := validateStatusCodes([]string{"2xx", "4XX", "404", "6xx", "20", "abc"})
+	assert.Equal(t, []string{"2xx", "4xx", "404"}, valid)
+	assert.Len(t, errs, 3)
+}
+
+func TestMatchesStatus(t *testing.T) {
+	assert.True(t, matchesStatus(nil, http.StatusInternalServerError))
+	assert.True(t, matchesStatus([]string{"2xx", "404"}, http.StatusCreated))
+	assert.True(t, matchesStatus([]string{"2xx", "404"}, http.StatusNotFound))
+	assert.False(t, matchesStatus([]string{"2xx", "404"}, http.StatusBadRequest))
+}
+
+func TestMatchesMethod(t *testing.T) {
+	assert.True(t, matchesMethod(nil, http.MethodGet))
+	assert.True(t, matchesMethod([]string{"post"}, http.MethodPost))
+	assert.False(t, matchesMethod([]string{"POST"}, http.MethodGet))
+}
+
+func TestAnalyticsMethodAndStatusFilters(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{
+		Enabled:     true,
+		Methods:     []string{"GET", "POST"},
+		StatusCodes: []string{"2xx"},
+		Rules: []RouteRule{
+			{Path: "/v1/decide", Methods: []string{"POST"}, StatusCodes: []string{"2xx", "4xx"}},
+		},
+	}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("DELETE", "/v1/config", nil),
+		httptest.NewRequest("GET", "/v1/config?fail=1", nil),
+		httptest.NewRequest("GET", "/v1/decide", nil),
+		httptest.NewRequest("POST", "/v1/decide?fail=1", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	event := backend.next(t)
+	assert.Equal(t, "/v1/decide", event.Params["path"])
+	assert.Equal(t, http.StatusBadRequest, event.Params["status_code"])
+}
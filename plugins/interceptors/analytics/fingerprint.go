@@ -0,0 +1,81 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+// FingerprintConfig configures the anonymous client IDs derived from the client's IP address
+// and user agent when no other client ID is available
+type FingerprintConfig struct {
+	Salt     string         `json:"salt"`     // Secret HMAC key (defaults to a random key per agent process)
+	Rotation utils.Duration `json:"rotation"` // Rotates the derived IDs every period, e.g. 24h (0 never rotates)
+}
+
+var (
+	processSalt     []byte
+	processSaltOnce sync.Once
+)
+
+// defaultSalt returns a random salt shared by the interceptors of this process
+func defaultSalt() []byte {
+	processSaltOnce.Do(func() {
+		processSalt = make([]byte, 32)
+		if _, err := rand.Read(processSalt); err != nil {
+			panic(err)
+		}
+	})
+	return processSalt
+}
+
+// fingerprinter derives stable anonymous client IDs as name-based UUIDs of an HMAC-SHA256 of
+// the IP address and user agent, so that raw addresses are never sent
+type fingerprinter struct {
+	salt     []byte
+	rotation time.Duration
+	now      func() time.Time
+}
+
+func newFingerprinter(conf FingerprintConfig) *fingerprinter {
+	salt := []byte(conf.Salt)
+	if len(salt) == 0 {
+		salt = defaultSalt()
+	}
+	return &fingerprinter{salt: salt, rotation: conf.Rotation.Duration, now: time.Now}
+}
+
+// ClientID implements ClientIDStrategy
+func (f *fingerprinter) ClientID(r *http.Request, body []byte) string {
+	key := f.salt
+	if f.rotation > 0 {
+		// Mixing the rotation period into the key changes every ID once per period
+		period := f.now().UnixNano() / int64(f.rotation)
+		key = append(append([]byte{}, f.salt...), strconv.FormatInt(period, 10)...)
+	}
+	return uuid.NewHash(hmac.New(sha256.New, key), uuid.Nil, []byte(getIPAddress(r)+"|"+r.UserAgent()), 5).String()
+}
@@ -0,0 +1,82 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func fingerprintRequest(ua string) *http.Request {
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("User-Agent", ua)
+	return req
+}
+
+func TestFingerprinterClientID(t *testing.T) {
+	f := newFingerprinter(FingerprintConfig{Salt: "salt"})
+	id := f.ClientID(fingerprintRequest("curl/8.0"), nil)
+
+	parsed, err := uuid.Parse(id)
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.Version(5), parsed.Version())
+	assert.NotContains(t, id, "203.0.113.7")
+
+	assert.Equal(t, id, f.ClientID(fingerprintRequest("curl/8.0"), nil))
+	assert.NotEqual(t, id, f.ClientID(fingerprintRequest("curl/8.1"), nil))
+	assert.NotEqual(t, id, newFingerprinter(FingerprintConfig{Salt: "other"}).ClientID(fingerprintRequest("curl/8.0"), nil))
+}
+
+func TestFingerprinterDefaultSalt(t *testing.T) {
+	first := newFingerprinter(FingerprintConfig{})
+	second := newFingerprinter(FingerprintConfig{})
+	assert.Len(t, first.salt, 32)
+	// Interceptors of the same process derive the same IDs
+	assert.Equal(t, first.ClientID(fingerprintRequest("curl/8.0"), nil), second.ClientID(fingerprintRequest("curl/8.0"), nil))
+}
+
+func TestFingerprinterRotation(t *testing.T) {
+	f := newFingerprinter(FingerprintConfig{Salt: "salt", Rotation: utils.Duration{Duration: 24 * time.Hour}})
+	now := time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	id := f.ClientID(fingerprintRequest("curl/8.0"), nil)
+	now = now.Add(time.Hour)
+	assert.Equal(t, id, f.ClientID(fingerprintRequest("curl/8.0"), nil))
+	now = now.Add(24 * time.Hour)
+	assert.NotEqual(t, id, f.ClientID(fingerprintRequest("curl/8.0"), nil))
+}
+
+func TestAnalyticsFingerprintFallback(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Fingerprint: FingerprintConfig{Salt: "salt"}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), fingerprintRequest("curl/8.0"))
+
+	expected := newFingerprinter(FingerprintConfig{Salt: "salt"}).ClientID(fingerprintRequest("curl/8.0"), nil)
+	assert.Equal(t, expected, backend.next(t).ClientID)
+}
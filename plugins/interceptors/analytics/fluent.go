@@ -0,0 +1,227 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultFluentTag = "optimizely.agent.analytics"
+
+// FluentBackend sends events to a Fluentd or Fluent Bit forward input using the Forward
+// protocol, as a single Forward mode message per batch with nanosecond event times
+type FluentBackend struct {
+	Network string `json:"network"` // "tcp" (default), "tls" or "unix"
+	Address string `json:"address"` // host:port of the forward input, e.g. localhost:24224, or the socket path
+	Tag     string `json:"tag"`     // Tag of the events (defaults to optimizely.agent.analytics)
+
+	once      sync.Once
+	writer    connWriter
+	tlsConfig *tls.Config
+}
+
+func (f *FluentBackend) init() {
+	if f.Tag == "" {
+		f.Tag = defaultFluentTag
+	}
+	network := f.Network
+	if network == "" {
+		network = "tcp"
+	}
+	f.writer = connWriter{network: network, address: f.Address, tlsConfig: f.tlsConfig}
+}
+
+// Send writes the events as [tag, [[time, record], ...], {"size": n}]
+func (f *FluentBackend) Send(ctx context.Context, events []Event) error {
+	f.once.Do(f.init)
+
+	target := dryRunFrom(ctx)
+	var entries bytes.Buffer
+	for _, e := range events {
+		event := canonicalEvent(e)
+		record, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if target != nil {
+			if err := target.record(f.writer.network+"://"+f.Address+"/"+f.Tag, "application/json", record); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Records are encoded from their JSON form, so params of any type map onto msgpack types
+		var fields interface{}
+		decoder := json.NewDecoder(bytes.NewReader(record))
+		decoder.UseNumber()
+		if err := decoder.Decode(&fields); err != nil {
+			return err
+		}
+		msgpackArrayHeader(&entries, 2)
+		msgpackEventTime(&entries, event.Timestamp)
+		if err := msgpackValue(&entries, fields); err != nil {
+			return err
+		}
+	}
+	if target != nil {
+		return nil
+	}
+
+	var msg bytes.Buffer
+	msgpackArrayHeader(&msg, 3)
+	msgpackString(&msg, f.Tag)
+	msgpackArrayHeader(&msg, len(events))
+	msg.Write(entries.Bytes())
+	msgpackMapHeader(&msg, 1)
+	msgpackString(&msg, "size")
+	msgpackInt(&msg, int64(len(events)))
+	return f.writer.write(ctx, msg.Bytes())
+}
+
+// Close closes the connection to the forward input
+func (f *FluentBackend) Close() error {
+	f.once.Do(f.init)
+	return f.writer.close()
+}
+
+// msgpackValue encodes the values produced by decoding JSON with UseNumber
+func msgpackValue(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case string:
+		msgpackString(buf, v)
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			msgpackInt(buf, i)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(0xcb)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	case []interface{}:
+		msgpackArrayHeader(buf, len(v))
+		for _, item := range v {
+			if err := msgpackValue(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		msgpackMapHeader(buf, len(v))
+		for _, k := range keys {
+			msgpackString(buf, k)
+			if err := msgpackValue(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported msgpack type %T", v)
+	}
+	return nil
+}
+
+func msgpackString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+func msgpackInt(buf *bytes.Buffer, i int64) {
+	if i >= 0 && i < 128 {
+		buf.WriteByte(byte(i))
+		return
+	}
+	buf.WriteByte(0xd3)
+	_ = binary.Write(buf, binary.BigEndian, i)
+}
+
+func msgpackArrayHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xdc)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdd)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+func msgpackMapHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xde)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdf)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+// msgpackEventTime encodes t as the Forward protocol EventTime extension (type 0)
+func msgpackEventTime(buf *bytes.Buffer, t time.Time) {
+	buf.Write([]byte{0xd7, 0x00})
+	_ = binary.Write(buf, binary.BigEndian, uint32(t.Unix()))
+	_ = binary.Write(buf, binary.BigEndian, uint32(t.Nanosecond()))
+}
+
+func (f *FluentBackend) setTLSConfig(conf *tls.Config) {
+	f.tlsConfig = conf
+}
+
+func init() {
+	AddBackend("fluent", func() Backend {
+		return &FluentBackend{}
+	})
+}
@@ -0,0 +1,98 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsgpackValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{false, []byte{0xc2}},
+		{"ab", []byte{0xa2, 'a', 'b'}},
+		{json.Number("7"), []byte{0x07}},
+		{json.Number("-1"), []byte{0xd3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{json.Number("1.5"), []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{[]interface{}{"a", json.Number("1")}, []byte{0x92, 0xa1, 'a', 0x01}},
+		{map[string]interface{}{"b": true, "a": nil}, []byte{0x82, 0xa1, 'a', 0xc0, 0xa1, 'b', 0xc3}},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		require.NoError(t, msgpackValue(&buf, tc.value))
+		assert.Equal(t, tc.expected, buf.Bytes(), "%v", tc.value)
+	}
+
+	var buf bytes.Buffer
+	msgpackString(&buf, string(make([]byte, 40)))
+	assert.Equal(t, []byte{0xd9, 40}, buf.Bytes()[:2])
+
+	assert.Error(t, msgpackValue(&buf, struct{}{}))
+}
+
+func TestMsgpackEventTime(t *testing.T) {
+	var buf bytes.Buffer
+	msgpackEventTime(&buf, time.Unix(1, 2))
+	assert.Equal(t, []byte{0xd7, 0x00, 0, 0, 0, 1, 0, 0, 0, 2}, buf.Bytes())
+}
+
+func TestFluentBackendSend(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	backend := &FluentBackend{Address: listener.Addr().String(), Tag: "agent"}
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{"status_code": 200}},
+		{Name: "api_request", ClientID: "b"},
+	}))
+	require.NoError(t, backend.Close())
+
+	data := <-received
+	// [tag, [entries...], {"size": 2}]
+	assert.Equal(t, []byte{0x93, 0xa5, 'a', 'g', 'e', 'n', 't', 0x92, 0x92, 0xd7, 0x00}, data[:11])
+	assert.True(t, bytes.HasSuffix(data, []byte{0x81, 0xa4, 's', 'i', 'z', 'e', 0x02}))
+	assert.Contains(t, string(data), "client")
+	assert.Contains(t, string(data), "statusCode")
+
+	// Closed backends don't reconnect
+	assert.Error(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+}
@@ -0,0 +1,293 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultGA4EndpointURL      = "https://www.google-analytics.com/mp/collect"
+	defaultGA4DebugEndpointURL = "https://www.google-analytics.com/debug/mp/collect"
+
+	// ga4MaxEventAge is how far GA4 lets events be backdated; older events are sent without
+	// their timestamp, as GA4 would drop them otherwise
+	ga4MaxEventAge = 72 * time.Hour
+)
+
+// GA4Backend sends events to the Google Analytics 4 Measurement Protocol, or with the ua protocol
+// as Universal Analytics hits to Measurement Protocol v1
+type GA4Backend struct {
+	MeasurementID  string  `json:"measurementID"`
+	APISecret      string  `json:"apiSecret"`
+	EndpointURL    string  `json:"endpointURL"`
+	Debug          bool    `json:"debug"`          // Send to the validation endpoint, which reports problems but records nothing
+	ValidateEvents float64 `json:"validateEvents"` // Fraction of payloads also sent to the validation endpoint, 0.0–1.0
+
+	// ExperimentEvents adds an experience_impression event for each experiment decision of an
+	// event, see experienceImpressions
+	ExperimentEvents bool `json:"experimentEvents"`
+
+	Protocol string   `json:"protocol"` // ga4 (default) or ua
+	UA       UAConfig `json:"ua"`       // Hit settings of the ua protocol
+
+	// Properties of the projects of SDK keys, by SDK key. Events of other SDK keys are sent to
+	// MeasurementID, or dropped if it is not set.
+	Properties map[string]GA4Property `json:"properties"`
+
+	hashedProperties map[string]GA4Property
+	client           *http.Client
+	metrics          *analyticsMetrics
+}
+
+// Send posts the events to the GA4 property of their SDK key, one request per client ID as
+// required by the Measurement Protocol
+func (g *GA4Backend) Send(ctx context.Context, events []Event) error {
+	for _, group := range g.groupByProperty(events) {
+		if group.property.MeasurementID == "" && len(g.Properties) > 0 {
+			log.Debug().Int("events", len(group.events)).Msg("Dropping GA4 events of an SDK key without a property")
+			continue
+		}
+		if err := g.send(ctx, group.property, group.events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send posts the events to a property
+func (g *GA4Backend) send(ctx context.Context, property GA4Property, events []Event) error {
+	if g.Protocol == uaProtocol {
+		return g.sendUA(ctx, property, events)
+	}
+
+	endpoint := g.EndpointURL
+	if endpoint == "" {
+		endpoint = defaultGA4EndpointURL
+		if g.Debug {
+			endpoint = defaultGA4DebugEndpointURL
+		}
+	}
+
+	now := time.Now()
+	for _, clientEvents := range groupByClient(events) {
+		for _, timedEvents := range groupByTimestampMicros(clientEvents, now) {
+			if err := g.sendClientEvents(ctx, property, endpoint, timedEvents, now); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sendClientEvents posts the events of a client with the same timestamp in a single payload.
+// Its timestamp_micros dates the events when they were captured rather than when GA4 receives
+// them, so queued and retried events are reported in the right period.
+func (g *GA4Backend) sendClientEvents(ctx context.Context, property GA4Property, endpoint string, clientEvents []Event, now time.Time) error {
+	payloadEvents := make([]map[string]interface{}, 0, len(clientEvents))
+	for _, e := range clientEvents {
+		payloadEvents = append(payloadEvents, map[string]interface{}{
+			"name":   e.Name,
+			"params": e.Params,
+		})
+		if g.ExperimentEvents {
+			for _, impression := range experienceImpressions(e) {
+				payloadEvents = append(payloadEvents, map[string]interface{}{
+					"name":   experienceImpressionEvent,
+					"params": impression,
+				})
+			}
+		}
+	}
+
+	payload := map[string]interface{}{
+		"client_id": clientEvents[0].ClientID,
+		"events":    payloadEvents,
+	}
+	if micros := ga4TimestampMicros(clientEvents[0], now); micros != 0 {
+		payload["timestamp_micros"] = micros
+	}
+	if userID := clientEvents[0].UserID; userID != "" {
+		payload["user_id"] = userID
+	}
+	if props := mergeUserProperties(clientEvents); props != nil {
+		userProperties := make(map[string]interface{}, len(props))
+		for name, v := range props {
+			userProperties[name] = map[string]interface{}{"value": v}
+		}
+		payload["user_properties"] = userProperties
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if g.Debug {
+		resp, err := postResponse(ctx, g.client, property.withCredentials(endpoint), "application/json", jsonData, nil)
+		if err != nil {
+			return err
+		}
+		g.reportValidation(resp, clientEvents)
+		return nil
+	}
+
+	if err := post(ctx, g.client, property.withCredentials(endpoint), "application/json", jsonData, nil); err != nil {
+		return err
+	}
+	if g.sampleValidation(ctx) {
+		g.validate(ctx, property, jsonData, clientEvents)
+	}
+	return nil
+}
+
+// ga4TimestampMicros returns the timestamp_micros of the event, or 0 when it is too old to be
+// backdated
+func ga4TimestampMicros(e Event, now time.Time) int64 {
+	t := eventTime(e, now)
+	if now.Sub(t) > ga4MaxEventAge {
+		return 0
+	}
+	return t.UnixMicro()
+}
+
+// groupByTimestampMicros groups the events by their timestamp_micros, which GA4 only accepts
+// per payload, in the order of their first event
+func groupByTimestampMicros(events []Event, now time.Time) [][]Event {
+	index := map[int64]int{}
+	var groups [][]Event
+	for _, e := range events {
+		micros := ga4TimestampMicros(e, now)
+		i, ok := index[micros]
+		if !ok {
+			i = len(groups)
+			index[micros] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], e)
+	}
+	return groups
+}
+
+// withCredentials adds the measurement ID and API secret of the property to endpoint
+func (p GA4Property) withCredentials(endpoint string) string {
+	query := url.Values{}
+	query.Set("measurement_id", p.MeasurementID)
+	query.Set("api_secret", p.APISecret)
+	return endpoint + "?" + query.Encode()
+}
+
+// validationURL returns the validation endpoint matching the configured endpoint
+func (g *GA4Backend) validationURL() string {
+	if strings.HasSuffix(g.EndpointURL, "/mp/collect") && !strings.HasSuffix(g.EndpointURL, "/debug/mp/collect") {
+		return strings.TrimSuffix(g.EndpointURL, "/mp/collect") + "/debug/mp/collect"
+	}
+	return defaultGA4DebugEndpointURL
+}
+
+// sampleValidation reports whether a delivered payload should also be validated. Dry runs
+// are never validated, as nothing was sent.
+func (g *GA4Backend) sampleValidation(ctx context.Context) bool {
+	if g.ValidateEvents <= 0 || dryRunFrom(ctx) != nil {
+		return false
+	}
+	return g.ValidateEvents >= 1 || rand.Float64() < g.ValidateEvents
+}
+
+// validate sends a delivered payload to the validation endpoint and reports the problems found.
+// Failures are logged only, as the events have already been delivered.
+func (g *GA4Backend) validate(ctx context.Context, property GA4Property, payload []byte, events []Event) {
+	resp, err := postResponse(ctx, g.client, property.withCredentials(g.validationURL()), "application/json", payload, nil)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to validate GA4 payload")
+		return
+	}
+	g.reportValidation(resp, events)
+}
+
+// ga4ValidationMessage is a problem reported by the GA4 validation endpoint
+type ga4ValidationMessage struct {
+	FieldPath      string `json:"fieldPath"`
+	Description    string `json:"description"`
+	ValidationCode string `json:"validationCode"`
+}
+
+// reportValidation logs and counts the problems the GA4 validation endpoint found with a payload of events
+func (g *GA4Backend) reportValidation(resp []byte, events []Event) {
+	if len(resp) == 0 {
+		return
+	}
+
+	var result struct {
+		ValidationMessages []ga4ValidationMessage `json:"validationMessages"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		log.Warn().Err(err).Msg("Failed to parse GA4 validation response")
+		return
+	}
+
+	if g.metrics != nil {
+		g.metrics.ga4Validations.Add(1)
+	}
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+	for _, msg := range result.ValidationMessages {
+		log.Warn().Str("validationCode", msg.ValidationCode).Str("fieldPath", msg.FieldPath).
+			Strs("events", names).Msg("GA4 validation: " + msg.Description)
+		if g.metrics != nil {
+			g.metrics.ga4ValidationMessages.Add(1)
+			g.metrics.ga4ValidationCode(msg.ValidationCode).Add(1)
+		}
+	}
+}
+
+// groupByClient splits events into per-client (and per-user) batches, preserving their order
+func groupByClient(events []Event) [][]Event {
+	type client struct{ clientID, userID string }
+	index := map[client]int{}
+	var groups [][]Event
+	for _, e := range events {
+		key := client{e.ClientID, e.UserID}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], e)
+	}
+	return groups
+}
+
+func (g *GA4Backend) setHTTPClient(client *http.Client) {
+	g.client = client
+}
+
+func init() {
+	AddBackend("ga4", func() Backend {
+		return &GA4Backend{}
+	})
+}
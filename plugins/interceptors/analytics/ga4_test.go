@@ -0,0 +1,208 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGA4BackendSend(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "G-TEST123", r.URL.Query().Get("measurement_id"))
+		assert.Equal(t, "secret", r.URL.Query().Get("api_secret"))
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	backend := &GA4Backend{MeasurementID: "G-TEST123", APISecret: "secret", EndpointURL: ts.URL}
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{"path": "/v1/decide"}},
+		{Name: "api_request", ClientID: "b", Params: map[string]interface{}{"path": "/v1/track"}},
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{"path": "/v1/config"}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, payloads, 2)
+	assert.Equal(t, "a", payloads[0]["client_id"])
+	assert.Len(t, payloads[0]["events"], 2)
+	assert.Equal(t, "b", payloads[1]["client_id"])
+	assert.Len(t, payloads[1]["events"], 1)
+}
+
+func TestGA4BackendSendUserID(t *testing.T) {
+	var payloads []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+	}))
+	defer ts.Close()
+
+	backend := &GA4Backend{MeasurementID: "G-TEST123", EndpointURL: ts.URL}
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", UserID: "user-1"},
+		{Name: "api_request", ClientID: "a"},
+	})
+	require.NoError(t, err)
+
+	// Events of the same client are split by user ID
+	require.Len(t, payloads, 2)
+	assert.Equal(t, "user-1", payloads[0]["user_id"])
+	assert.NotContains(t, payloads[1], "user_id")
+}
+
+func TestGA4BackendSendUserProperties(t *testing.T) {
+	var payload map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	backend := &GA4Backend{MeasurementID: "G-TEST123", EndpointURL: ts.URL}
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", UserProperties: map[string]interface{}{"plan": "free"}},
+		{Name: "api_request", ClientID: "a", UserProperties: map[string]interface{}{"plan": "pro"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{"plan": map[string]interface{}{"value": "pro"}}, payload["user_properties"])
+}
+
+func TestGA4BackendSendTimestamps(t *testing.T) {
+	var payloads []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+	}))
+	defer ts.Close()
+
+	captured := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	backend := &GA4Backend{MeasurementID: "G-TEST123", EndpointURL: ts.URL}
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", Timestamp: captured},
+		{Name: "api_request", ClientID: "a", Timestamp: captured.Add(time.Second)},
+		{Name: "api_request", ClientID: "a", Timestamp: captured},
+		{Name: "api_request", ClientID: "a", Timestamp: captured.Add(-ga4MaxEventAge)},
+	})
+	require.NoError(t, err)
+
+	// Events of a client are split by timestamp, and those too old to backdate are sent without
+	require.Len(t, payloads, 3)
+	assert.Equal(t, float64(captured.UnixMicro()), payloads[0]["timestamp_micros"])
+	assert.Len(t, payloads[0]["events"], 2)
+	assert.Equal(t, float64(captured.Add(time.Second).UnixMicro()), payloads[1]["timestamp_micros"])
+	assert.NotContains(t, payloads[2], "timestamp_micros")
+}
+
+func TestGA4BackendSendError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	backend := &GA4Backend{MeasurementID: "G-TEST123", EndpointURL: ts.URL}
+	err := backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a"}})
+	assert.Error(t, err)
+}
+
+func TestGA4BackendDebug(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(logger zerolog.Logger) { log.Logger = logger }(log.Logger)
+	log.Logger = zerolog.New(&buf)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/debug/mp/collect", r.URL.Path)
+		_, _ = w.Write([]byte(`{"validationMessages":[{"fieldPath":"events","description":"Event name is reserved.","validationCode":"NAME_RESERVED"}]}`))
+	}))
+	defer ts.Close()
+
+	backend := &GA4Backend{MeasurementID: "G-TEST123", EndpointURL: ts.URL + "/debug/mp/collect", Debug: true}
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "session_start", ClientID: "a"}}))
+	assert.Contains(t, buf.String(), "NAME_RESERVED")
+	assert.Contains(t, buf.String(), "Event name is reserved.")
+	assert.Contains(t, buf.String(), "session_start")
+}
+
+func TestGA4BackendValidateEvents(t *testing.T) {
+	var mu sync.Mutex
+	paths := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths[r.URL.Path]++
+		mu.Unlock()
+		assert.Equal(t, "secret", r.URL.Query().Get("api_secret"))
+		if r.URL.Path == "/debug/mp/collect" {
+			_, _ = w.Write([]byte(`{"validationMessages":[{"fieldPath":"events.params","description":"Param value is too long.","validationCode":"VALUE_INVALID"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	validations := expvarValue("counter.analytics.ga4.validations")
+	messages := expvarValue("counter.analytics.ga4.validationMessages.VALUE_INVALID")
+
+	backend := &GA4Backend{
+		MeasurementID:  "G-TEST123",
+		APISecret:      "secret",
+		EndpointURL:    ts.URL + "/mp/collect",
+		ValidateEvents: 1,
+		metrics:        newAnalyticsMetrics(),
+	}
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a"},
+		{Name: "api_request", ClientID: "b"},
+	}))
+
+	// Every payload is delivered and validated
+	assert.Equal(t, map[string]int{"/mp/collect": 2, "/debug/mp/collect": 2}, paths)
+	assert.Equal(t, validations+2, expvarValue("counter.analytics.ga4.validations"))
+	assert.Equal(t, messages+2, expvarValue("counter.analytics.ga4.validationMessages.VALUE_INVALID"))
+
+	// Without a fraction nothing is validated
+	backend.ValidateEvents = 0
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a"}}))
+	assert.Equal(t, 2, paths["/debug/mp/collect"])
+}
+
+func TestGA4BackendValidationURL(t *testing.T) {
+	assert.Equal(t, defaultGA4DebugEndpointURL, (&GA4Backend{}).validationURL())
+	assert.Equal(t, "https://region1.google-analytics.com/debug/mp/collect",
+		(&GA4Backend{EndpointURL: "https://region1.google-analytics.com/mp/collect"}).validationURL())
+	assert.Equal(t, defaultGA4DebugEndpointURL, (&GA4Backend{EndpointURL: "https://proxy.example.com/ga"}).validationURL())
+}
@@ -0,0 +1,69 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+const (
+	// experienceImpressionEvent is the event Google recommends for reporting the exposure of
+	// third-party experiments in GA4
+	experienceImpressionEvent = "experience_impression"
+
+	variantIDParam        = "variant_id"
+	expVariantStringParam = "exp_variant_string"
+
+	// expVariantPrefix identifies Optimizely as the experimentation tool in exp_variant_string
+	expVariantPrefix = "OPTLY"
+)
+
+// experienceImpressions returns the params of an experience_impression event for each experiment
+// decision of an event enriched with the decisions of a /v1/decide or /v1/activate response.
+// Decisions without a rule or variation, and those of "Everyone Else" rollout rules, aren't
+// experiments and are skipped. The rule key identifies the experiment and the variation key the
+// variant, as the responses carry no IDs.
+func experienceImpressions(e Event) []map[string]interface{} {
+	flagKeys, _ := e.Params[flagKeysParam].(string)
+	variationKeys, _ := e.Params[variationKeysParam].(string)
+	ruleKeys, _ := e.Params[ruleKeysParam].(string)
+	ruleTypes, _ := e.Params[ruleTypesParam].(string)
+
+	flags, variations, rules, types := splitKeys(flagKeys), splitKeys(variationKeys), splitKeys(ruleKeys), splitKeys(ruleTypes)
+	if len(variations) != len(rules) || len(types) != len(rules) {
+		return nil
+	}
+
+	var impressions []map[string]interface{}
+	for i, rule := range rules {
+		if rule == "" || variations[i] == "" || types[i] != "rule" {
+			continue
+		}
+		params := map[string]interface{}{
+			experimentIDParam:     rule,
+			variantIDParam:        variations[i],
+			expVariantStringParam: expVariantPrefix + "-" + rule + "-" + variations[i],
+		}
+		if i < len(flags) && flags[i] != "" {
+			params[flagKeyParam] = flags[i]
+		}
+		// GA4 attributes events to sessions and projects by their own params
+		for _, name := range []string{sessionIDParam, engagementTimeMsecParam, sdkKeyParam} {
+			if v, ok := e.Params[name]; ok {
+				params[name] = v
+			}
+		}
+		impressions = append(impressions, params)
+	}
+	return impressions
+}
@@ -0,0 +1,84 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperienceImpressions(t *testing.T) {
+	params := map[string]interface{}{sessionIDParam: int64(1700000000), sdkKeyParam: "sdk-key"}
+	addDecisionParams(params, []decision{
+		{FlagKey: "checkout", VariationKey: "treatment", RuleKey: "checkout_test", Enabled: true},
+		{FlagKey: "banner", VariationKey: "off", RuleKey: "default-rollout", IsEveryoneElseVariation: true},
+		{FlagKey: "search"},
+	})
+
+	assert.Equal(t, []map[string]interface{}{{
+		experimentIDParam:     "checkout_test",
+		variantIDParam:        "treatment",
+		expVariantStringParam: "OPTLY-checkout_test-treatment",
+		flagKeyParam:          "checkout",
+		sessionIDParam:        int64(1700000000),
+		sdkKeyParam:           "sdk-key",
+	}}, experienceImpressions(Event{Params: params}))
+
+	// Events without decisions have no impressions
+	assert.Empty(t, experienceImpressions(Event{Params: map[string]interface{}{}}))
+}
+
+func TestGA4BackendExperimentEvents(t *testing.T) {
+	var payload struct {
+		Events []struct {
+			Name   string                 `json:"name"`
+			Params map[string]interface{} `json:"params"`
+		} `json:"events"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	params := map[string]interface{}{}
+	addDecisionParams(params, []decision{
+		{FlagKey: "checkout", VariationKey: "treatment", RuleKey: "checkout_test"},
+		{FlagKey: "banner", VariationKey: "b", RuleKey: "banner_test"},
+	})
+	backend := &GA4Backend{MeasurementID: "G-TEST123", APISecret: "secret", EndpointURL: ts.URL, ExperimentEvents: true}
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a", Params: params}}))
+
+	require.Len(t, payload.Events, 3)
+	assert.Equal(t, "api_request", payload.Events[0].Name)
+	assert.Equal(t, experienceImpressionEvent, payload.Events[1].Name)
+	assert.Equal(t, "checkout_test", payload.Events[1].Params[experimentIDParam])
+	assert.Equal(t, "treatment", payload.Events[1].Params[variantIDParam])
+	assert.Equal(t, experienceImpressionEvent, payload.Events[2].Name)
+	assert.Equal(t, "OPTLY-banner_test-b", payload.Events[2].Params[expVariantStringParam])
+
+	// Without the option only the event itself is sent
+	backend.ExperimentEvents = false
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a", Params: params}}))
+	assert.Len(t, payload.Events, 1)
+}
@@ -0,0 +1,74 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+// GA4Property identifies the GA4 property events are sent to
+type GA4Property struct {
+	MeasurementID string `json:"measurementID"`
+	APISecret     string `json:"apiSecret"` // Measurement Protocol API secret, or a secret reference
+}
+
+// propertyEvents are the events sent to a property
+type propertyEvents struct {
+	property GA4Property
+	events   []Event
+}
+
+// property returns the property the events of an SDK key are sent to: the one mapped to the key,
+// otherwise the default property of the backend
+func (g *GA4Backend) property(sdkKey string) GA4Property {
+	if sdkKey != "" {
+		if p, ok := g.Properties[sdkKey]; ok {
+			return p
+		}
+		if p, ok := g.hashedProperties[sdkKey]; ok {
+			return p
+		}
+	}
+	return GA4Property{MeasurementID: g.MeasurementID, APISecret: g.APISecret}
+}
+
+// groupByProperty splits events into per-property batches by their SDK key, preserving their order
+func (g *GA4Backend) groupByProperty(events []Event) []propertyEvents {
+	if len(g.Properties) == 0 {
+		return []propertyEvents{{property: g.property(""), events: events}}
+	}
+
+	index := map[GA4Property]int{}
+	var groups []propertyEvents
+	for _, e := range events {
+		sdkKey, _ := e.Params[sdkKeyParam].(string)
+		p := g.property(sdkKey)
+		i, ok := index[p]
+		if !ok {
+			i = len(groups)
+			index[p] = i
+			groups = append(groups, propertyEvents{property: p})
+		}
+		groups[i].events = append(groups[i].events, e)
+	}
+	return groups
+}
+
+// setHashedSDKKeys maps the digests of the SDK keys of Properties too, for events sent with
+// hashSDKKey
+func (g *GA4Backend) setHashedSDKKeys() {
+	g.hashedProperties = make(map[string]GA4Property, len(g.Properties))
+	for sdkKey, p := range g.Properties {
+		g.hashedProperties[hashSDKKey(sdkKey)] = p
+	}
+}
@@ -0,0 +1,144 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+// ga4Request is a request received by a GA4 test server
+type ga4Request struct {
+	measurementID, apiSecret string
+	events                   int
+}
+
+func newGA4Server(t *testing.T) (*httptest.Server, func() []ga4Request) {
+	var mu sync.Mutex
+	var requests []ga4Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Events []interface{} `json:"events"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		mu.Lock()
+		requests = append(requests, ga4Request{
+			measurementID: r.URL.Query().Get("measurement_id"),
+			apiSecret:     r.URL.Query().Get("api_secret"),
+			events:        len(payload.Events),
+		})
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() []ga4Request {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]ga4Request(nil), requests...)
+	}
+}
+
+func sdkKeyEvent(sdkKey string) Event {
+	return Event{Name: "api_request", ClientID: "a", Params: map[string]interface{}{sdkKeyParam: sdkKey}}
+}
+
+func TestGA4BackendProperties(t *testing.T) {
+	ts, requests := newGA4Server(t)
+	backend := &GA4Backend{
+		MeasurementID: "G-DEFAULT",
+		APISecret:     "default-secret",
+		EndpointURL:   ts.URL,
+		Properties: map[string]GA4Property{
+			"project-a": {MeasurementID: "G-AAA", APISecret: "a-secret"},
+			"project-b": {MeasurementID: "G-BBB", APISecret: "b-secret"},
+		},
+	}
+
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		sdkKeyEvent("project-a"),
+		sdkKeyEvent("project-b"),
+		sdkKeyEvent("project-a"),
+		sdkKeyEvent("project-c"),
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{}},
+	}))
+
+	assert.Equal(t, []ga4Request{
+		{measurementID: "G-AAA", apiSecret: "a-secret", events: 2},
+		{measurementID: "G-BBB", apiSecret: "b-secret", events: 1},
+		{measurementID: "G-DEFAULT", apiSecret: "default-secret", events: 2},
+	}, requests())
+}
+
+func TestGA4BackendPropertiesWithoutDefault(t *testing.T) {
+	ts, requests := newGA4Server(t)
+	backend := &GA4Backend{
+		EndpointURL: ts.URL,
+		Properties:  map[string]GA4Property{"project-a": {MeasurementID: "G-AAA", APISecret: "a-secret"}},
+	}
+
+	// Events of unmapped SDK keys are dropped
+	require.NoError(t, backend.Send(context.Background(), []Event{sdkKeyEvent("project-c"), sdkKeyEvent("project-a")}))
+	assert.Equal(t, []ga4Request{{measurementID: "G-AAA", apiSecret: "a-secret", events: 1}}, requests())
+}
+
+func TestGA4BackendHashedSDKKeys(t *testing.T) {
+	backend := &GA4Backend{
+		MeasurementID: "G-DEFAULT",
+		Properties:    map[string]GA4Property{"project-a": {MeasurementID: "G-AAA"}},
+	}
+	assert.Equal(t, "G-DEFAULT", backend.property(hashSDKKey("project-a")).MeasurementID)
+
+	backend.setHashedSDKKeys()
+	assert.Equal(t, "G-AAA", backend.property(hashSDKKey("project-a")).MeasurementID)
+	assert.Equal(t, "G-AAA", backend.property("project-a").MeasurementID)
+	assert.Equal(t, "G-DEFAULT", backend.property("").MeasurementID)
+}
+
+func TestAnalyticsProperties(t *testing.T) {
+	t.Setenv("ANALYTICS_TEST_SECRET", "a-secret")
+	ts, requests := newGA4Server(t)
+	a := &Analytics{
+		Enabled:     true,
+		EndpointURL: ts.URL,
+		HashSDKKey:  true,
+		Properties: map[string]GA4Property{
+			"project-a": {MeasurementID: "G-AAA", APISecret: "env://ANALYTICS_TEST_SECRET"},
+		},
+	}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	require.Len(t, a.destinations, 1)
+	assert.Equal(t, "ga4", a.destinations[0].name)
+	require.NoError(t, a.Start(context.Background()))
+	defer a.Stop(context.Background())
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set(middleware.OptlySDKHeader, "project-a")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Eventually(t, func() bool { return len(requests()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, ga4Request{measurementID: "G-AAA", apiSecret: "a-secret", events: 1}, requests()[0])
+}
@@ -0,0 +1,127 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+const ipAddressParam = "ip_address"
+
+// GeoIPConfig enables coarse geolocation of clients from a MaxMind GeoIP2/GeoLite2 database
+type GeoIPConfig struct {
+	DatabasePath string `json:"databasePath"` // Path to a local City or Country .mmdb file
+	KeepIP       bool   `json:"keepIP"`       // Keep the raw IP address param, which is removed by default
+}
+
+// geoLocation is the coarse location of an IP address
+type geoLocation struct {
+	country string // ISO 3166-1 country code
+	region  string // ISO 3166-2 subdivision code, without the country prefix
+	city    string // English city name
+}
+
+// geoLocator looks up the location of an IP address
+type geoLocator interface {
+	locate(ip net.IP) (geoLocation, error)
+}
+
+// errGeoIPClosed is returned by a geoIPReader once it is closed
+var errGeoIPClosed = errors.New("GeoIP database closed")
+
+// geoIPReader locates IP addresses using a MaxMind database. Requests still served by a
+// replaced instance may locate addresses while it is closed, so the memory-mapped database is
+// only unmapped once no lookup is running.
+type geoIPReader struct {
+	mu     sync.RWMutex
+	reader *geoip2.Reader
+}
+
+func newGeoIPReader(path string) (*geoIPReader, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &geoIPReader{reader: reader}, nil
+}
+
+// locate also works with Country databases, which leave the region and city empty
+func (g *geoIPReader) locate(ip net.IP) (geoLocation, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.reader == nil {
+		return geoLocation{}, errGeoIPClosed
+	}
+	record, err := g.reader.City(ip)
+	if err != nil {
+		return geoLocation{}, err
+	}
+
+	loc := geoLocation{
+		country: record.Country.IsoCode,
+		city:    record.City.Names["en"],
+	}
+	if len(record.Subdivisions) > 0 {
+		loc.region = record.Subdivisions[0].IsoCode
+	}
+	return loc, nil
+}
+
+// close unmaps the database, after which lookups fail
+func (g *geoIPReader) close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.reader == nil {
+		return nil
+	}
+	err := g.reader.Close()
+	g.reader = nil
+	return err
+}
+
+// addGeoParams replaces the IP address param with the client's coarse location. Locations that
+// can't be determined are omitted; the IP address is removed even without a locator, so a
+// database that failed to open never leaks raw addresses.
+func addGeoParams(params map[string]interface{}, locator geoLocator, ipAddress string, keepIP bool) {
+	if !keepIP {
+		delete(params, ipAddressParam)
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(ipAddress))
+	if locator == nil || ip == nil {
+		return
+	}
+	loc, err := locator.locate(ip)
+	if err != nil {
+		return
+	}
+
+	for param, value := range map[string]string{
+		geoCountryParam: loc.country,
+		geoRegionParam:  loc.region,
+		geoCityParam:    loc.city,
+	} {
+		if value != "" {
+			params[param] = value
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticLocator locates every IP address at the same location
+type staticLocator struct {
+	loc geoLocation
+	err error
+}
+
+func (s staticLocator) locate(ip net.IP) (geoLocation, error) {
+	return s.loc, s.err
+}
+
+func TestNewGeoIPReaderMissingDatabase(t *testing.T) {
+	_, err := newGeoIPReader(filepath.Join(t.TempDir(), "missing.mmdb"))
+	assert.Error(t, err)
+}
+
+func TestGeoIPReaderClosed(t *testing.T) {
+	g := &geoIPReader{}
+	_, err := g.locate(net.ParseIP("203.0.113.7"))
+	assert.ErrorIs(t, err, errGeoIPClosed)
+	assert.NoError(t, g.close())
+}
+
+func TestAddGeoParams(t *testing.T) {
+	locator := staticLocator{loc: geoLocation{country: "US", region: "CA", city: "San Francisco"}}
+
+	params := map[string]interface{}{ipAddressParam: "203.0.113.7"}
+	addGeoParams(params, locator, "203.0.113.7", false)
+	assert.Equal(t, map[string]interface{}{
+		"geo_country": "US",
+		"geo_region":  "CA",
+		"geo_city":    "San Francisco",
+	}, params)
+
+	params = map[string]interface{}{ipAddressParam: "203.0.113.7"}
+	addGeoParams(params, locator, "203.0.113.7", true)
+	assert.Equal(t, "203.0.113.7", params[ipAddressParam])
+}
+
+func TestAddGeoParamsOmitsUnknownLocations(t *testing.T) {
+	params := map[string]interface{}{ipAddressParam: "203.0.113.7"}
+	addGeoParams(params, staticLocator{loc: geoLocation{country: "US"}}, "203.0.113.7", false)
+	assert.Equal(t, map[string]interface{}{"geo_country": "US"}, params)
+
+	for _, locator := range []geoLocator{nil, staticLocator{err: errors.New("not found")}} {
+		params = map[string]interface{}{ipAddressParam: "203.0.113.7"}
+		addGeoParams(params, locator, "203.0.113.7", false)
+		assert.Empty(t, params)
+	}
+
+	params = map[string]interface{}{}
+	addGeoParams(params, staticLocator{loc: geoLocation{country: "US"}}, "not-an-ip", false)
+	assert.Empty(t, params)
+}
+
+func TestAnalyticsGeoIP(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, GeoIP: GeoIPConfig{DatabasePath: filepath.Join(t.TempDir(), "missing.mmdb")}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+	a.geo = staticLocator{loc: geoLocation{country: "DE"}}
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := backend.next(t)
+	assert.Equal(t, "DE", event.Params["geo_country"])
+	assert.NotContains(t, event.Params, ipAddressParam)
+}
@@ -0,0 +1,72 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// GeoSuppressionConfig limits tracking of requests from the listed countries
+type GeoSuppressionConfig struct {
+	Countries     []string `json:"countries"`     // ISO 3166-1 country codes
+	CountryHeader string   `json:"countryHeader"` // CDN header with the client country, e.g. CF-IPCountry (falls back to GeoIP)
+	Mode          string   `json:"mode"`          // "anonymize" (default) or "skip" events from these countries
+}
+
+// clientCountry returns the upper-case country code of the client from the country header,
+// falling back to the GeoIP locator. It returns an empty string when the country is unknown.
+func clientCountry(r *http.Request, header string, locator geoLocator) string {
+	if header != "" {
+		if country := strings.ToUpper(strings.TrimSpace(r.Header.Get(header))); country != "" {
+			return country
+		}
+	}
+	if locator == nil {
+		return ""
+	}
+	ip := net.ParseIP(strings.TrimSpace(getIPAddress(r)))
+	if ip == nil {
+		return ""
+	}
+	loc, err := locator.locate(ip)
+	if err != nil {
+		return ""
+	}
+	return strings.ToUpper(loc.country)
+}
+
+// suppressed reports whether requests from country are subject to suppression
+func (c GeoSuppressionConfig) suppressed(country string) bool {
+	if country == "" {
+		return false
+	}
+	for _, suppressed := range c.Countries {
+		if strings.EqualFold(suppressed, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymizeLocation anonymizes the event and coarsens its location to the country
+func anonymizeLocation(event *Event) {
+	anonymize(event)
+	delete(event.Params, geoRegionParam)
+	delete(event.Params, geoCityParam)
+}
@@ -0,0 +1,84 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCountry(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	locator := staticLocator{loc: geoLocation{country: "fr"}}
+
+	assert.Equal(t, "", clientCountry(req, "", nil))
+	assert.Equal(t, "FR", clientCountry(req, "CF-IPCountry", locator))
+
+	req.Header.Set("CF-IPCountry", "de")
+	assert.Equal(t, "DE", clientCountry(req, "CF-IPCountry", locator))
+	assert.Equal(t, "FR", clientCountry(req, "", locator))
+}
+
+func TestGeoSuppressed(t *testing.T) {
+	conf := GeoSuppressionConfig{Countries: []string{"de", "FR"}}
+	assert.True(t, conf.suppressed("DE"))
+	assert.True(t, conf.suppressed("FR"))
+	assert.False(t, conf.suppressed("US"))
+	assert.False(t, conf.suppressed(""))
+}
+
+func TestAnonymizeLocation(t *testing.T) {
+	event := Event{ClientID: "client-1", Params: map[string]interface{}{
+		"geo_country": "DE", "geo_region": "BE", "geo_city": "Berlin", ipAddressParam: "203.0.113.7",
+	}}
+	anonymizeLocation(&event)
+	assert.Equal(t, map[string]interface{}{"geo_country": "DE"}, event.Params)
+	assert.NotEqual(t, "client-1", event.ClientID)
+}
+
+func TestAnalyticsGeoSuppression(t *testing.T) {
+	for _, mode := range []string{suppressSkip, suppressAnonymize} {
+		backend := newMockBackend()
+		a := &Analytics{Enabled: true, GeoSuppression: GeoSuppressionConfig{
+			Countries: []string{"DE"}, CountryHeader: "CF-IPCountry", Mode: mode,
+		}}
+		handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+		before := expvarValue("counter.analytics.requests.geoSuppressed")
+		suppressed := httptest.NewRequest("GET", "/v1/de", nil)
+		suppressed.Header.Set("CF-IPCountry", "DE")
+		handler.ServeHTTP(httptest.NewRecorder(), suppressed)
+		tracked := httptest.NewRequest("GET", "/v1/us", nil)
+		tracked.Header.Set("CF-IPCountry", "US")
+		handler.ServeHTTP(httptest.NewRecorder(), tracked)
+
+		event := backend.next(t)
+		if mode == suppressAnonymize {
+			assert.Equal(t, "/v1/de", event.Params[pathParam])
+			assert.NotContains(t, event.Params, ipAddressParam)
+			event = backend.next(t)
+		}
+		assert.Equal(t, "/v1/us", event.Params[pathParam])
+		assert.Contains(t, event.Params, ipAddressParam)
+		assert.Equal(t, before+1, expvarValue("counter.analytics.requests.geoSuppressed"))
+	}
+}
@@ -0,0 +1,390 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed" // for exportServiceSchema
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	exportMethod         = "/com.optimizely.agent.analytics.AnalyticsExport/Export"
+	defaultExportTimeout = 10 * time.Second
+)
+
+// exportServiceSchema is the AnalyticsExport service implemented by the collectors of the grpc
+// destination, with the ApiEvent messages of eventProtobufSchema
+//
+//go:embed export.proto
+var exportServiceSchema string
+
+// GRPCBackend exports events to a collector implementing the AnalyticsExport gRPC service (see
+// export.proto), one Export call per batch with the events as typed ApiEvent messages
+type GRPCBackend struct {
+	Endpoint string            `json:"endpoint"` // host:port of the collector
+	Insecure bool              `json:"insecure"` // Use a plaintext connection
+	Headers  map[string]string `json:"headers"`  // Extra gRPC metadata, e.g. for authentication
+	Timeout  utils.Duration    `json:"timeout"`  // Deadline of each call (defaults to 10s)
+
+	connOnce  sync.Once
+	conn      *grpc.ClientConn
+	connErr   error
+	tlsConfig *tls.Config
+}
+
+func (g *GRPCBackend) checkConfig() error {
+	if g.Endpoint == "" {
+		return errors.New("endpoint is required")
+	}
+	if g.Timeout.Duration < 0 {
+		return fmt.Errorf("timeout must not be negative, got %s", g.Timeout.Duration)
+	}
+	return nil
+}
+
+// Send calls Export with the events. Status codes of failed calls are mapped onto StatusErrors,
+// so that unavailable or overloaded collectors are retried and rejected batches dead-lettered.
+func (g *GRPCBackend) Send(ctx context.Context, events []Event) error {
+	records := make([]CanonicalEvent, 0, len(events))
+	var request []byte
+	for _, e := range events {
+		event := canonicalEvent(e)
+		records = append(records, event)
+		msg, err := encodeAPIEvent(event)
+		if err != nil {
+			return err
+		}
+		request = appendMessageField(request, 1, msg)
+	}
+
+	if target := dryRunFrom(ctx); target != nil {
+		body, err := json.Marshal(records)
+		if err != nil {
+			return err
+		}
+		return target.record("grpc://"+g.Endpoint+exportMethod, "application/json", body)
+	}
+
+	g.connOnce.Do(func() {
+		conf := g.tlsConfig
+		if conf == nil {
+			conf = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		creds := credentials.NewTLS(conf)
+		if g.Insecure {
+			creds = insecure.NewCredentials()
+		}
+		g.conn, g.connErr = grpc.Dial(g.Endpoint, grpc.WithTransportCredentials(creds))
+	})
+	if g.connErr != nil {
+		return g.connErr
+	}
+
+	timeout := g.Timeout.Duration
+	if timeout == 0 {
+		timeout = defaultExportTimeout
+	}
+	ctx, cancel := context.WithTimeout(withOutgoingMetadata(ctx, g.Headers), timeout)
+	defer cancel()
+
+	var response []byte
+	if err := g.conn.Invoke(ctx, exportMethod, &request, &response, grpc.ForceCodec(rawCodec{})); err != nil {
+		return exportStatusError(err)
+	}
+	rejected, message := decodeExportResponse(response)
+	if rejected > 0 {
+		log.Warn().Int64("rejected", rejected).Str("error", message).Msg("Analytics collector rejected some events")
+	}
+	return nil
+}
+
+// Close closes the connection to the collector
+func (g *GRPCBackend) Close() error {
+	if g.conn == nil {
+		return nil
+	}
+	return g.conn.Close()
+}
+
+func (g *GRPCBackend) setTLSConfig(conf *tls.Config) {
+	g.tlsConfig = conf
+}
+
+// exportStatusCodes maps the gRPC status codes of failed calls onto the HTTP status codes that
+// drive retries and dead letters
+var exportStatusCodes = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.NotFound:           http.StatusNotFound,
+	codes.Unimplemented:      http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.Aborted:            http.StatusServiceUnavailable,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.DataLoss:           http.StatusInternalServerError,
+}
+
+// exportStatusError returns the StatusError of a failed call, or err when it carries no status
+// or the call was canceled
+func exportStatusError(err error) error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	code, ok := exportStatusCodes[s.Code()]
+	if !ok {
+		return err
+	}
+	return &StatusError{StatusCode: code, Body: s.Code().String() + ": " + s.Message()}
+}
+
+// rawCodec passes the encoded messages of calls through as is
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// decodeExportResponse returns the rejected events and error message of an ExportResponse,
+// ignoring malformed responses as the batch was accepted
+func decodeExportResponse(b []byte) (int64, string) {
+	var rejected int64
+	var message string
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, m := protowire.ConsumeVarint(b)
+			rejected, n = int64(v), m
+		case num == 2 && typ == protowire.BytesType:
+			v, m := protowire.ConsumeString(b)
+			message, n = v, m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			break
+		}
+		b = b[n:]
+	}
+	return rejected, message
+}
+
+// encodeAPIEvent encodes the canonical event as an ApiEvent message of eventProtobufSchema
+func encodeAPIEvent(c CanonicalEvent) ([]byte, error) {
+	var b []byte
+	b = appendVarintField(b, 1, int64(c.SchemaVersion))
+	timestamp, err := proto.Marshal(timestamppb.New(c.Timestamp))
+	if err != nil {
+		return nil, err
+	}
+	b = appendMessageField(b, 2, timestamp)
+	b = appendStringField(b, 3, c.Name)
+
+	var client []byte
+	client = appendStringField(client, 1, c.Client.ID)
+	client = appendStringField(client, 2, c.Client.UserID)
+	client = appendStringField(client, 3, c.Client.IPAddress)
+	client = appendStringField(client, 4, c.Client.UserAgent)
+	client = appendStringField(client, 5, c.Client.Region)
+	b = appendMessageField(b, 4, client)
+
+	var request []byte
+	request = appendStringField(request, 1, c.Request.Path)
+	request = appendStringField(request, 2, c.Request.Method)
+	request = appendStringField(request, 3, c.Request.SDKKey)
+	request = appendVarintField(request, 4, c.Request.Bytes)
+	request = appendStringField(request, 5, c.Request.ID)
+	b = appendMessageField(b, 5, request)
+
+	var response []byte
+	response = appendVarintField(response, 1, int64(c.Response.StatusCode))
+	response = appendVarintField(response, 2, c.Response.Bytes)
+	response = appendVarintField(response, 3, c.Response.DurationMS)
+	b = appendMessageField(b, 6, response)
+
+	b = appendMessageField(b, 7, encodeEnrichment(c.Enrichment))
+
+	if b, err = appendValueMap(b, 8, c.Custom); err != nil {
+		return nil, err
+	}
+	return appendValueMap(b, 9, c.UserProperties)
+}
+
+func encodeEnrichment(e Enrichment) []byte {
+	var b []byte
+	if geo := e.Geo; geo != nil {
+		var m []byte
+		m = appendStringField(m, 1, geo.Country)
+		m = appendStringField(m, 2, geo.Region)
+		m = appendStringField(m, 3, geo.City)
+		b = appendMessageField(b, 1, m)
+	}
+	if device := e.Device; device != nil {
+		var m []byte
+		m = appendStringField(m, 1, device.Category)
+		m = appendStringField(m, 2, device.Browser)
+		m = appendStringField(m, 3, device.BrowserVersion)
+		m = appendStringField(m, 4, device.OS)
+		m = appendStringField(m, 5, device.OSVersion)
+		b = appendMessageField(b, 2, m)
+	}
+	if decisions := e.Decisions; decisions != nil {
+		var m []byte
+		for i, keys := range [][]string{decisions.FlagKeys, decisions.VariationKeys, decisions.RuleKeys, decisions.RuleTypes} {
+			for _, key := range keys {
+				m = protowire.AppendTag(m, protowire.Number(i+1), protowire.BytesType)
+				m = protowire.AppendString(m, key)
+			}
+		}
+		m = appendVarintField(m, 5, int64(decisions.Count))
+		m = appendVarintField(m, 6, int64(decisions.EnabledCount))
+		b = appendMessageField(b, 3, m)
+	}
+	if session := e.Session; session != nil {
+		var m []byte
+		m = appendStringField(m, 1, session.ID)
+		m = appendVarintField(m, 2, session.Number)
+		m = appendVarintField(m, 3, session.EngagementTimeMsec)
+		b = appendMessageField(b, 4, m)
+	}
+	if e.SampleRate != 0 {
+		b = protowire.AppendTag(b, 5, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(e.SampleRate))
+	}
+	return b
+}
+
+// appendValueMap appends a map<string, google.protobuf.Value> field, in key order
+func appendValueMap(b []byte, num protowire.Number, values map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value, err := protoValue(values[k])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %q: %w", k, err)
+		}
+		encoded, err := proto.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		entry := protowire.AppendTag(nil, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendBytes(entry, encoded)
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b, nil
+}
+
+// protoValue converts a param value, going through its JSON form for types structpb doesn't handle
+func protoValue(v interface{}) (*structpb.Value, error) {
+	if value, err := structpb.NewValue(v); err == nil {
+		return value, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return structpb.NewValue(decoded)
+}
+
+// appendStringField appends a string field, omitting the empty string as proto3 does
+func appendStringField(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendVarintField appends an integer field, omitting zero as proto3 does
+func appendVarintField(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendMessageField(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func init() {
+	AddBackend("grpc", func() Backend {
+		return &GRPCBackend{}
+	})
+}
@@ -0,0 +1,189 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// testExportServer implements AnalyticsExport, replying with response or failing with err
+type testExportServer struct {
+	requests chan []byte
+	metadata chan metadata.MD
+	response []byte
+	err      error
+}
+
+func (s *testExportServer) serve(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "com.optimizely.agent.analytics.AnalyticsExport",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Export",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				var request []byte
+				if err := dec(&request); err != nil {
+					return nil, err
+				}
+				md, _ := metadata.FromIncomingContext(ctx)
+				s.requests <- request
+				s.metadata <- md
+				if s.err != nil {
+					return nil, s.err
+				}
+				return &s.response, nil
+			},
+		}},
+	}, s)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func newTestExportServer() *testExportServer {
+	return &testExportServer{requests: make(chan []byte, 1), metadata: make(chan metadata.MD, 1)}
+}
+
+// decodeFields returns the values of the length-delimited fields of a message by number
+func decodeFields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	fields := map[protowire.Number][][]byte{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.True(t, n > 0)
+		b = b[n:]
+		if typ == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(b)
+			require.True(t, m > 0)
+			fields[num] = append(fields[num], v)
+			n = m
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			require.True(t, n > 0)
+		}
+		b = b[n:]
+	}
+	return fields
+}
+
+func TestGRPCBackendSend(t *testing.T) {
+	server := newTestExportServer()
+	backend := &GRPCBackend{Endpoint: server.serve(t), Insecure: true, Headers: map[string]string{"authorization": "token"}}
+	defer backend.Close()
+
+	events := []Event{{
+		Name:      "api_request",
+		ClientID:  "client",
+		Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Params:    map[string]interface{}{"path": "/v1/decide", "status_code": 200, "plan": "pro"},
+	}}
+	require.NoError(t, backend.Send(context.Background(), events))
+	assert.Equal(t, []string{"token"}, (<-server.metadata).Get("authorization"))
+
+	request := decodeFields(t, <-server.requests)
+	require.Len(t, request[1], 1)
+	event := decodeFields(t, request[1][0])
+	assert.Equal(t, "api_request", string(event[3][0]))
+	assert.Equal(t, "client", string(decodeFields(t, event[4][0])[1][0]))
+	assert.Equal(t, "/v1/decide", string(decodeFields(t, event[5][0])[1][0]))
+
+	require.Len(t, event[8], 1)
+	entry := decodeFields(t, event[8][0])
+	assert.Equal(t, "plan", string(entry[1][0]))
+	value := &structpb.Value{}
+	require.NoError(t, proto.Unmarshal(entry[2][0], value))
+	assert.Equal(t, "pro", value.GetStringValue())
+}
+
+func TestGRPCBackendStatusErrors(t *testing.T) {
+	for code, statusCode := range map[codes.Code]int{
+		codes.Unavailable:       503,
+		codes.ResourceExhausted: 429,
+		codes.InvalidArgument:   400,
+		codes.Unauthenticated:   401,
+	} {
+		server := newTestExportServer()
+		server.err = status.Error(code, "failed")
+		backend := &GRPCBackend{Endpoint: server.serve(t), Insecure: true}
+
+		err := backend.Send(context.Background(), testOTLPEvents)
+		var statusErr *StatusError
+		require.True(t, errors.As(err, &statusErr), code.String())
+		assert.Equal(t, statusCode, statusErr.StatusCode)
+		assert.Equal(t, statusCode >= 429 && statusCode != 400, isRetryable(err), code.String())
+		backend.Close()
+	}
+}
+
+func TestGRPCBackendRejectedEvents(t *testing.T) {
+	server := newTestExportServer()
+	server.response = protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), 2)
+	server.response = appendStringField(server.response, 2, "invalid name")
+	backend := &GRPCBackend{Endpoint: server.serve(t), Insecure: true}
+	defer backend.Close()
+
+	require.NoError(t, backend.Send(context.Background(), testOTLPEvents))
+	rejected, message := decodeExportResponse(server.response)
+	assert.Equal(t, int64(2), rejected)
+	assert.Equal(t, "invalid name", message)
+}
+
+func TestGRPCBackendDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dryrun.ndjson")
+	sink, err := newDryRunSink(path)
+	require.NoError(t, err)
+
+	backend := &GRPCBackend{Endpoint: "127.0.0.1:1", Insecure: true}
+	ctx := context.WithValue(context.Background(), dryRunKey{}, &dryRunTarget{sink: sink, destination: "grpc"})
+	require.NoError(t, backend.Send(ctx, testOTLPEvents))
+	sink.close()
+
+	records := readDryRunRecords(t, path)
+	require.Len(t, records, 1)
+	assert.Equal(t, "grpc://127.0.0.1:1"+exportMethod, records[0].URL)
+	assert.Contains(t, string(records[0].Body), `"name":"api_request"`)
+}
+
+func TestGRPCBackendCheckConfig(t *testing.T) {
+	assert.Error(t, (&GRPCBackend{}).checkConfig())
+	assert.NoError(t, (&GRPCBackend{Endpoint: "collector:4317"}).checkConfig())
+}
+
+func TestExportServiceSchemaMatchesEventSchema(t *testing.T) {
+	messages := eventProtobufSchema[strings.Index(eventProtobufSchema, "message ApiEvent"):]
+	assert.Contains(t, exportServiceSchema, strings.TrimSpace(messages))
+	assert.Contains(t, exportServiceSchema, "rpc Export(ExportRequest) returns (ExportResponse);")
+}
@@ -0,0 +1,253 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+	"github.com/rs/zerolog/log"
+)
+
+// Hook filters events and derives their params from the request and response with CEL
+// expressions (https://github.com/google/cel-spec), over the variables in hookVars, e.g.
+//
+//	request.path.startsWith('/v1/') && response.status < 500
+type Hook struct {
+	Filter string            `json:"filter"` // Events for which the expression isn't true are dropped
+	Set    map[string]string `json:"set"`    // Params to set from expressions. Null results remove the param.
+}
+
+// hookCostLimit bounds the work of evaluating a hook expression, in CEL cost units
+const hookCostLimit = 10000
+
+// hookVars are the variables of hook expressions, all maps from string keys:
+// event (name), client (id, ip, userAgent), request (id, method, path, route, query, host,
+// sdkKey, bytes and the headers by lower case name), response (status, bytes, durationMs)
+// and params, the params of the event.
+var hookVars = []string{"event", "client", "request", "response", "params"}
+
+var (
+	hookEnv    *cel.Env
+	hookEnvErr error
+	nativeList = reflect.TypeOf([]interface{}{})
+	nativeMap  = reflect.TypeOf(map[string]interface{}{})
+)
+
+func init() {
+	opts := []cel.EnvOption{ext.Strings(), cel.CrossTypeNumericComparisons(true)}
+	for _, name := range hookVars {
+		opts = append(opts, cel.Variable(name, cel.MapType(cel.StringType, cel.DynType)))
+	}
+	hookEnv, hookEnvErr = cel.NewEnv(opts...)
+}
+
+// hookChain is the compiled hooks, evaluated in order
+type hookChain struct {
+	hooks []hook
+}
+
+// hook is a validated Hook
+type hook struct {
+	filter *hookExpr
+	set    []hookParam
+}
+
+// hookExpr is a compiled hook expression
+type hookExpr struct {
+	src     string
+	program cel.Program
+}
+
+// hookParam is a param computed by a hook
+type hookParam struct {
+	name string
+	expr *hookExpr
+}
+
+// compileHookExpr compiles an expression of hooks, checking that it has the given result type
+func compileHookExpr(src string, result *cel.Type) (*hookExpr, error) {
+	if hookEnvErr != nil {
+		return nil, hookEnvErr
+	}
+	ast, issues := hookEnv.Compile(src)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if out := ast.OutputType(); result != nil && !out.IsExactType(result) && !out.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression returns %s, not %s", out, result)
+	}
+	program, err := hookEnv.Program(ast, cel.CostLimit(hookCostLimit))
+	if err != nil {
+		return nil, err
+	}
+	return &hookExpr{src: src, program: program}, nil
+}
+
+// eval evaluates the expression, converting its result to a param value. Null results are nil.
+func (e *hookExpr) eval(vars map[string]interface{}) (interface{}, error) {
+	v, _, err := e.program.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch v.(type) {
+	case types.Null:
+		return nil, nil
+	case traits.Lister:
+		return v.ConvertToNative(nativeList)
+	case traits.Mapper:
+		return v.ConvertToNative(nativeMap)
+	}
+	return v.Value(), nil
+}
+
+// newHookChain compiles the hooks, returning nil when there are none
+func newHookChain(hooks []Hook) (*hookChain, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	c := &hookChain{}
+	for i, conf := range hooks {
+		if conf.Filter == "" && len(conf.Set) == 0 {
+			return nil, fmt.Errorf("analytics hook %d must set filter or set", i)
+		}
+		var h hook
+		if conf.Filter != "" {
+			filter, err := compileHookExpr(conf.Filter, cel.BoolType)
+			if err != nil {
+				return nil, fmt.Errorf("analytics hook %d filter: %w", i, err)
+			}
+			h.filter = filter
+		}
+		for name, src := range conf.Set {
+			e, err := compileHookExpr(src, nil)
+			if err != nil {
+				return nil, fmt.Errorf("analytics hook %d, param %q: %w", i, name, err)
+			}
+			h.set = append(h.set, hookParam{name: name, expr: e})
+		}
+		sort.Slice(h.set, func(i, j int) bool { return h.set[i].name < h.set[j].name })
+		c.hooks = append(c.hooks, h)
+	}
+	return c, nil
+}
+
+// hookActivation returns the variables of hook expressions for the event of the request.
+// The request and response variables don't change as the hooks set params, so they are read
+// once, when first referenced.
+func hookActivation(event *Event, r *http.Request) map[string]interface{} {
+	once := func(f func() map[string]interface{}) func() interface{} {
+		var v map[string]interface{}
+		return func() interface{} {
+			if v == nil {
+				v = f()
+			}
+			return v
+		}
+	}
+	return map[string]interface{}{
+		"event": once(func() map[string]interface{} {
+			return map[string]interface{}{"name": event.Name}
+		}),
+		"client": once(func() map[string]interface{} {
+			return map[string]interface{}{"id": event.ClientID, "ip": getIPAddress(r), "userAgent": r.UserAgent()}
+		}),
+		"request": once(func() map[string]interface{} {
+			headers := make(map[string]string, len(r.Header))
+			for name, values := range r.Header {
+				if len(values) > 0 {
+					headers[strings.ToLower(name)] = values[0]
+				}
+			}
+			return map[string]interface{}{
+				"id":      event.RequestID,
+				"method":  r.Method,
+				"path":    r.URL.Path,
+				"route":   event.Params[pathParam],
+				"query":   r.URL.RawQuery,
+				"host":    hostWithoutPort(r.Host),
+				"sdkKey":  event.Params[sdkKeyParam],
+				"bytes":   event.Params[requestBytesParam],
+				"headers": headers,
+			}
+		}),
+		"response": once(func() map[string]interface{} {
+			return map[string]interface{}{
+				"status":     event.Params[statusCodeParam],
+				"bytes":      event.Params[responseBytesParam],
+				"durationMs": event.Params[responseTimeParam],
+			}
+		}),
+		"params": func() interface{} { return event.Params },
+	}
+}
+
+// apply runs the hooks on the event of the request, reporting whether the event is kept.
+// Filters that fail to evaluate drop the event; set expressions that fail remove their param.
+func (c *hookChain) apply(event *Event, r *http.Request) bool {
+	if c == nil {
+		return true
+	}
+
+	vars := hookActivation(event, r)
+	for _, h := range c.hooks {
+		if h.filter != nil {
+			v, err := h.filter.eval(vars)
+			if err != nil {
+				log.Debug().Err(err).Str("event", event.Name).Str("filter", h.filter.src).Msg("Failed to evaluate analytics hook")
+			}
+			if err != nil || v != true {
+				return false
+			}
+		}
+		// The params set by a hook are only seen by the following hooks
+		values := make([]interface{}, len(h.set))
+		for i, p := range h.set {
+			v, err := p.expr.eval(vars)
+			if err != nil {
+				log.Debug().Err(err).Str("param", p.name).Str("expr", p.expr.src).Msg("Failed to evaluate analytics hook")
+				v = nil
+			}
+			values[i] = v
+		}
+		for i, p := range h.set {
+			if values[i] == nil {
+				delete(event.Params, p.name)
+			} else {
+				event.Params[p.name] = values[i]
+			}
+		}
+	}
+	return true
+}
+
+// hostWithoutPort returns the lower case host of a Host header
+func hostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
@@ -0,0 +1,145 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookChainApply(t *testing.T) {
+	chain, err := newHookChain([]Hook{
+		{Filter: "request.path.startsWith('/v1/') && response.status < 500"},
+		{Set: map[string]string{
+			"tenant":      "request.headers['x-tenant-id']",
+			"api_version": "request.path.startsWith('/v2/') ? dyn('v2') : null",
+			"host":        "request.host",
+			"slow":        "response.durationMs > 100",
+			"method":      "null",
+			"failed":      "request.path * 2",
+		}},
+		{Set: map[string]string{
+			"tenant_route": "params.tenant + ' ' + request.route",
+			"agent":        "'user-agent' in request.headers ? dyn(request.headers['user-agent']) : null",
+			"tags":         "[params.tenant, event.name]",
+			"status":       "response.status",
+		}},
+	})
+	require.NoError(t, err)
+
+	newRequest := func(path string, status int) (*http.Request, *Event) {
+		r := httptest.NewRequest(http.MethodPost, "http://Agent.Example.com:8080"+path, nil)
+		r.Header.Set("X-Tenant-ID", "acme")
+		event := newEvent("api_request", time.Now(), ClientInfo{}, RequestInfo{Path: "/v1/{key}", Method: r.Method},
+			ResponseInfo{StatusCode: status, DurationMS: 150})
+		event.Params["api_version"] = "v1"
+		return r, &event
+	}
+
+	r, event := newRequest("/v1/decide", http.StatusOK)
+	require.True(t, chain.apply(event, r))
+	assert.Equal(t, "acme", event.Params["tenant"])
+	assert.Equal(t, "agent.example.com", event.Params["host"])
+	assert.Equal(t, true, event.Params["slow"])
+	assert.Equal(t, "acme /v1/{key}", event.Params["tenant_route"])
+	assert.Equal(t, []interface{}{"acme", "api_request"}, event.Params["tags"])
+	assert.Equal(t, int64(http.StatusOK), event.Params["status"])
+	assert.NotContains(t, event.Params, "agent")
+	assert.NotContains(t, event.Params, "api_version", "null results remove the param")
+	assert.NotContains(t, event.Params, methodParam)
+	assert.NotContains(t, event.Params, "failed", "failed expressions remove the param")
+
+	r, event = newRequest("/v1/decide", http.StatusBadGateway)
+	assert.False(t, chain.apply(event, r))
+	r, event = newRequest("/health", http.StatusOK)
+	assert.False(t, chain.apply(event, r))
+	assert.NotContains(t, event.Params, "tenant", "hooks after a failed filter don't run")
+
+	var none *hookChain
+	assert.True(t, none.apply(event, r))
+}
+
+func TestHookChainCostLimit(t *testing.T) {
+	chain, err := newHookChain([]Hook{{Filter: "params.ids.all(x, params.ids.all(y, x + y >= 0))"}})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/config", nil)
+	event := newEvent("api_request", time.Now(), ClientInfo{}, RequestInfo{}, ResponseInfo{})
+	ids := make([]interface{}, 10)
+	for i := range ids {
+		ids[i] = i
+	}
+	event.Params["ids"] = ids
+	assert.True(t, chain.apply(&event, r))
+
+	ids = make([]interface{}, 1000)
+	for i := range ids {
+		ids[i] = i
+	}
+	event.Params["ids"] = ids
+	assert.False(t, chain.apply(&event, r), "filters over the cost limit drop the event")
+}
+
+func TestNewHookChainErrors(t *testing.T) {
+	chain, err := newHookChain(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, chain)
+
+	for _, hooks := range [][]Hook{
+		{{}},
+		{{Filter: "request.path.unknown()"}},
+		{{Set: map[string]string{"p": "1 +"}}},
+		{{Filter: "request.path + '/'"}},
+		{{Filter: "unknown == 1"}},
+	} {
+		_, err := newHookChain(hooks)
+		assert.Error(t, err)
+	}
+}
+
+func TestAnalyticsHooks(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Hooks: []Hook{
+		{Filter: "request.path != '/v1/config'", Set: map[string]string{"tenant": "request.headers['x-tenant']"}},
+	}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	for _, path := range []string{"/v1/config", "/v1/datafile"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("X-Tenant", "acme")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	// The event of the filtered request isn't delivered
+	event := backend.next(t)
+	assert.Equal(t, "/v1/datafile", event.Params[pathParam])
+	assert.Equal(t, "acme", event.Params["tenant"])
+	assert.Empty(t, backend.events)
+}
+
+func TestValidateHooks(t *testing.T) {
+	a := &Analytics{Hooks: []Hook{{Filter: "request.path.startsWith("}}}
+	err := a.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "hooks: analytics hook 0 filter")
+}
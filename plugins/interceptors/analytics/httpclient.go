@@ -0,0 +1,205 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	defaultHTTPTimeout             = 5 * time.Second
+	defaultHTTPConnectTimeout      = 5 * time.Second
+	defaultHTTPMaxIdleConns        = 100
+	defaultHTTPMaxIdleConnsPerHost = 10
+	defaultHTTPIdleConnTimeout     = 90 * time.Second
+)
+
+// HTTPClientConfig configures the client shared by the HTTP destinations
+type HTTPClientConfig struct {
+	Timeout             utils.Duration `json:"timeout"`             // Overall time allowed for a request (defaults to 5s)
+	ConnectTimeout      utils.Duration `json:"connectTimeout"`      // Time allowed for connecting, including the TLS handshake (defaults to 5s)
+	ReadTimeout         utils.Duration `json:"readTimeout"`         // Time allowed for the response headers once the request is sent (defaults to none)
+	MaxIdleConns        int            `json:"maxIdleConns"`        // Idle connections kept across all hosts (defaults to 100)
+	MaxIdleConnsPerHost int            `json:"maxIdleConnsPerHost"` // Idle connections kept per host (defaults to 10)
+	IdleConnTimeout     utils.Duration `json:"idleConnTimeout"`     // Time an idle connection is kept (defaults to 90s)
+	ProxyURL            string         `json:"proxyURL"`            // Proxy for all requests (defaults to HTTP_PROXY, HTTPS_PROXY and NO_PROXY)
+	TLS                 TLSConfig      `json:"tls"`                 // CAs and client certificate for TLS connections
+}
+
+// TLSConfig holds the PEM files used for TLS connections to destinations
+type TLSConfig struct {
+	CAFile     string `json:"caFile"`     // CA bundle trusted in addition to the system roots
+	CertFile   string `json:"certFile"`   // Client certificate presented to destinations
+	KeyFile    string `json:"keyFile"`    // Private key of the client certificate
+	ServerName string `json:"serverName"` // Name verified against the server certificate (defaults to the host dialed)
+}
+
+// httpBackend is implemented by backends that send events over HTTP, so they can be given
+// the configured client
+type httpBackend interface {
+	setHTTPClient(client *http.Client)
+}
+
+// newHTTPClient creates a client with its own connection pool from the config
+func newHTTPClient(conf HTTPClientConfig) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if conf.ProxyURL != "" {
+		proxyURL, err := url.Parse(conf.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		if proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q: scheme and host are required", conf.ProxyURL)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := conf.TLS.config()
+	if err != nil {
+		return nil, err
+	}
+
+	connectTimeout := durationOr(conf.ConnectTimeout, defaultHTTPConnectTimeout)
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: conf.ReadTimeout.Duration,
+		MaxIdleConns:          intOr(conf.MaxIdleConns, defaultHTTPMaxIdleConns),
+		MaxIdleConnsPerHost:   intOr(conf.MaxIdleConnsPerHost, defaultHTTPMaxIdleConnsPerHost),
+		IdleConnTimeout:       durationOr(conf.IdleConnTimeout, defaultHTTPIdleConnTimeout),
+		ForceAttemptHTTP2:     true,
+	}
+	return &http.Client{
+		Timeout:   durationOr(conf.Timeout, defaultHTTPTimeout),
+		Transport: transport,
+	}, nil
+}
+
+// config loads the configured files, returning nil when there are none so the defaults apply
+func (c TLSConfig) config() (*tls.Config, error) {
+	if c == (TLSConfig{}) {
+		return nil, nil
+	}
+
+	conf := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: c.ServerName}
+	if c.CAFile != "" {
+		bundle, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in CA file %q", c.CAFile)
+		}
+		conf.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, errors.New("client certificate requires both certFile and keyFile")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+// tlsBackend is implemented by backends with connections of their own that use TLS, e.g. gRPC
+// or syslog over TLS, so they can be given the TLS settings of their destination
+type tlsBackend interface {
+	setTLSConfig(conf *tls.Config)
+}
+
+// connectDestination gives the backend of dest the HTTP client or TLS settings it connects with.
+// Destinations with TLS settings or compression of their own get a client of their own, with the
+// shared settings otherwise.
+func (a *Analytics) connectDestination(dest *destination) {
+	client := a.httpClient
+	if dest.tlsConfig != nil {
+		transport := client.Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig = dest.tlsConfig
+		dest.client = &http.Client{Timeout: client.Timeout, Transport: transport}
+		client = dest.client
+	}
+
+	httpBackend, isHTTP := dest.backend.(httpBackend)
+	if isHTTP && dest.compression != "" {
+		transport := &compressingTransport{next: client.Transport, encoding: dest.compression, destination: dest.name}
+		dest.client = &http.Client{Timeout: client.Timeout, Transport: transport}
+		client = dest.client
+	} else if dest.compression != "" {
+		log.Warn().Str("destination", dest.name).Msg("Analytics destination makes no HTTP requests, ignoring its compression")
+	}
+	if isHTTP {
+		httpBackend.setHTTPClient(client)
+	}
+	if tlsBackend, ok := dest.backend.(tlsBackend); ok && dest.tlsConfig != nil {
+		tlsBackend.setTLSConfig(dest.tlsConfig)
+	} else if !isHTTP && dest.tlsConfig != nil {
+		log.Warn().Str("destination", dest.name).Msg("Analytics destination makes no TLS connections, ignoring its tls settings")
+	}
+}
+
+// initHTTPClient creates the client shared by the HTTP destinations, falling back to the
+// defaults when the config is invalid
+func (a *Analytics) initHTTPClient() {
+	conf := a.HTTPClient
+	var err error
+	if conf.ProxyURL, err = a.secrets.resolve(conf.ProxyURL); err == nil {
+		a.httpClient, err = newHTTPClient(conf)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid analytics HTTP client settings, using the defaults")
+		a.httpClient, _ = newHTTPClient(HTTPClientConfig{})
+	}
+}
+
+// durationOr returns d, or fallback when d is unset
+func durationOr(d utils.Duration, fallback time.Duration) time.Duration {
+	if d.Duration > 0 {
+		return d.Duration
+	}
+	return fallback
+}
+
+// intOr returns n, or fallback when n is unset
+func intOr(n, fallback int) int {
+	if n > 0 {
+		return n
+	}
+	return fallback
+}
@@ -0,0 +1,209 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+// writePEM writes a PEM block of the given type to a file in dir and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+// newClientCertificate creates a self-signed client certificate, returning it with the paths
+// of its certificate and key files
+func newClientCertificate(t *testing.T) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "agent"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	return cert, writePEM(t, dir, "client.pem", "CERTIFICATE", der), writePEM(t, dir, "client-key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+// newMutualTLSServer starts a TLS server that requires client certificates signed by clientCA,
+// returning it with the path of a CA file trusting its certificate
+func newMutualTLSServer(t *testing.T, clientCA *x509.Certificate) (*httptest.Server, string) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	pool := x509.NewCertPool()
+	pool.AddCert(clientCA)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server, writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+}
+
+func TestNewHTTPClientDefaults(t *testing.T) {
+	client, err := newHTTPClient(HTTPClientConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultHTTPTimeout, client.Timeout)
+
+	transport := client.Transport.(*http.Transport)
+	assert.Equal(t, defaultHTTPMaxIdleConns, transport.MaxIdleConns)
+	assert.Equal(t, defaultHTTPMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, defaultHTTPIdleConnTimeout, transport.IdleConnTimeout)
+	assert.Equal(t, defaultHTTPConnectTimeout, transport.TLSHandshakeTimeout)
+	assert.Zero(t, transport.ResponseHeaderTimeout)
+	assert.Nil(t, transport.TLSClientConfig)
+}
+
+func TestNewHTTPClientSettings(t *testing.T) {
+	client, err := newHTTPClient(HTTPClientConfig{
+		Timeout:             utils.Duration{Duration: 10 * time.Second},
+		ConnectTimeout:      utils.Duration{Duration: time.Second},
+		ReadTimeout:         utils.Duration{Duration: 2 * time.Second},
+		MaxIdleConns:        20,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     utils.Duration{Duration: time.Minute},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, client.Timeout)
+
+	transport := client.Transport.(*http.Transport)
+	assert.Equal(t, 20, transport.MaxIdleConns)
+	assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Equal(t, time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 2*time.Second, transport.ResponseHeaderTimeout)
+}
+
+func TestNewHTTPClientInvalid(t *testing.T) {
+	for name, conf := range map[string]HTTPClientConfig{
+		"proxy without host": {ProxyURL: "proxy:3128"},
+		"unparsable proxy":   {ProxyURL: "http://[::1"},
+		"missing CA file":    {TLS: TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		"CA without certs":   {TLS: TLSConfig{CAFile: writePEM(t, t.TempDir(), "empty.pem", "EMPTY", nil)}},
+		"cert without key":   {TLS: TLSConfig{CertFile: "client.pem"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newHTTPClient(conf)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	requested := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested <- r.URL.String()
+	}))
+	defer proxy.Close()
+
+	client, err := newHTTPClient(HTTPClientConfig{ProxyURL: proxy.URL})
+	require.NoError(t, err)
+	_, err = send(context.Background(), client, http.MethodPost, "http://collector.invalid/events", "application/json", []byte("{}"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://collector.invalid/events", <-requested)
+}
+
+func TestHTTPClientMutualTLS(t *testing.T) {
+	clientCert, certFile, keyFile := newClientCertificate(t)
+	server, caFile := newMutualTLSServer(t, clientCert)
+
+	client, err := newHTTPClient(HTTPClientConfig{TLS: TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}})
+	require.NoError(t, err)
+	_, err = send(context.Background(), client, http.MethodPost, server.URL, "application/json", []byte("{}"), nil)
+	assert.NoError(t, err)
+
+	// Without the client certificate the handshake is rejected
+	client, err = newHTTPClient(HTTPClientConfig{TLS: TLSConfig{CAFile: caFile}})
+	require.NoError(t, err)
+	_, err = send(context.Background(), client, http.MethodPost, server.URL, "application/json", []byte("{}"), nil)
+	assert.Error(t, err)
+}
+
+func TestDestinationTLS(t *testing.T) {
+	clientCert, certFile, keyFile := newClientCertificate(t)
+	server, caFile := newMutualTLSServer(t, clientCert)
+
+	a := &Analytics{Destinations: []BackendConfig{
+		{"type": "webhook", "name": "shared", "url": server.URL},
+		{"type": "webhook", "name": "mtls", "url": server.URL, "tls": map[string]interface{}{
+			"caFile": caFile, "certFile": certFile, "keyFile": keyFile,
+		}},
+		{"type": "syslog", "name": "syslog", "network": "tls", "tls": map[string]interface{}{"caFile": caFile}},
+		{"type": "file", "name": "file", "path": filepath.Join(t.TempDir(), "events.ndjson"), "tls": map[string]interface{}{}},
+	}}
+	a.init()
+	defer a.closeDestinations()
+	require.Len(t, a.destinations, 4)
+
+	shared, mtls := a.destinations[0], a.destinations[1]
+	assert.Same(t, a.httpClient, shared.backend.(*WebhookBackend).client)
+	assert.Nil(t, shared.client)
+	require.NotNil(t, mtls.client)
+	assert.Same(t, mtls.client, mtls.backend.(*WebhookBackend).client)
+	assert.Equal(t, a.httpClient.Timeout, mtls.client.Timeout)
+	assert.Same(t, a.destinations[2].tlsConfig, a.destinations[2].backend.(*SyslogBackend).tlsConfig)
+
+	events := []Event{{Name: "api_request", ClientID: "client"}}
+	assert.Error(t, shared.backend.Send(context.Background(), events))
+	assert.NoError(t, mtls.backend.Send(context.Background(), events))
+}
+
+func TestInitHTTPClient(t *testing.T) {
+	a := &Analytics{
+		TrackingID:   "G-TEST",
+		Destinations: []BackendConfig{{"type": "snowplow", "collectorURL": "http://collector"}},
+		HTTPClient:   HTTPClientConfig{Timeout: utils.Duration{Duration: time.Second}},
+	}
+	a.init()
+	require.NotNil(t, a.httpClient)
+	assert.Equal(t, time.Second, a.httpClient.Timeout)
+	assert.Same(t, a.httpClient, a.destinations[0].backend.(*GA4Backend).client)
+	assert.Same(t, a.httpClient, a.destinations[1].backend.(*SnowplowBackend).client)
+
+	// Invalid settings fall back to the defaults
+	a = &Analytics{HTTPClient: HTTPClientConfig{ProxyURL: "proxy:3128"}}
+	a.init()
+	require.NotNil(t, a.httpClient)
+	assert.Equal(t, defaultHTTPTimeout, a.httpClient.Timeout)
+}
@@ -0,0 +1,47 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"github.com/google/uuid"
+)
+
+// idempotencyKey returns the key deliveries of the event to the destination are identified by,
+// a UUID derived from its request ID, name and the destination, so every retry and spill replay
+// of a delivery carries the same key while other destinations get keys of their own. Mirrored
+// events are identified by the UUID of the Optimizely event instead; other events have no key.
+func idempotencyKey(destination string, e Event) string {
+	id := e.RequestID
+	if id == "" {
+		id, _ = e.Params[eventUUIDParam].(string)
+	}
+	if id == "" {
+		return ""
+	}
+	return uuid.NewSHA1(uuid.Nil, []byte(destination+"\x00"+id+"\x00"+e.Name)).String()
+}
+
+// withIdempotencyKeys returns a copy of the events with the idempotency keys of their
+// deliveries to the destination
+func withIdempotencyKeys(events []Event, destination string) []Event {
+	keyed := make([]Event, len(events))
+	for i, e := range events {
+		e.IdempotencyKey = idempotencyKey(destination, e)
+		keyed[i] = e
+	}
+	return keyed
+}
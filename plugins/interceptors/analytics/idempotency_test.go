@@ -0,0 +1,59 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	event := Event{Name: "api_request", RequestID: "request"}
+	key := idempotencyKey("segment", event)
+	assert.Len(t, key, 36)
+	assert.Equal(t, key, idempotencyKey("segment", event))
+
+	// Other destinations and events of other requests get keys of their own
+	assert.NotEqual(t, key, idempotencyKey("posthog", event))
+	assert.NotEqual(t, key, idempotencyKey("segment", Event{Name: "api_request", RequestID: "other"}))
+
+	// Mirrored events are identified by the UUID of the Optimizely event
+	mirrored := Event{Name: impressionEvent, Params: map[string]interface{}{eventUUIDParam: "uuid"}}
+	assert.NotEmpty(t, idempotencyKey("segment", mirrored))
+	assert.Empty(t, idempotencyKey("segment", Event{Name: "decision"}))
+}
+
+func TestSendWithRetryIdempotencyKeys(t *testing.T) {
+	backend := newMockBackend()
+	backend.err = &StatusError{StatusCode: 503}
+	dest := destination{name: "segment", backend: backend}
+	events := []Event{{Name: "api_request", RequestID: "request"}}
+
+	err := sendWithRetry(context.Background(), dest, events, RetryConfig{MaxAttempts: 2}, func(error) {})
+	var statusErr *StatusError
+	require.True(t, errors.As(err, &statusErr))
+
+	// Every attempt carries the same key, and the events passed in are left as they are
+	first, second := backend.next(t), backend.next(t)
+	assert.Equal(t, idempotencyKey("segment", events[0]), first.IdempotencyKey)
+	assert.Equal(t, first.IdempotencyKey, second.IdempotencyKey)
+	assert.Empty(t, events[0].IdempotencyKey)
+}
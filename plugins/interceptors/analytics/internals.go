@@ -0,0 +1,78 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+const (
+	clientCacheParam  = "client_cache"
+	decisionTimeParam = "decision_time_us"
+	handlerTimeParam  = "handler_time_us"
+)
+
+// annotate returns r recording the annotations of the handlers when the event of the request
+// uses them
+func (a *Analytics) annotate(r *http.Request) (*http.Request, *middleware.Annotations) {
+	if !a.AgentInternals && (!a.DatafileEvents || datafileEventName(r.URL.Path) == "") {
+		return r, nil
+	}
+	return middleware.WithAnnotations(r)
+}
+
+// addAnnotationParams adds what the handlers recorded about serving a request to its event: the
+// SDK client cache status, datafile revision and decision time of API requests, and the project,
+// environment, revision and SDK keys of datafile webhooks
+func addAnnotationParams(event *Event, annotations *middleware.Annotations, hashKeys bool) {
+	if annotations == nil {
+		return
+	}
+	for key, param := range map[string]string{
+		middleware.AnnotationRevision:    revisionParam,
+		middleware.AnnotationClientCache: clientCacheParam,
+		middleware.AnnotationProjectID:   projectIDParam,
+		middleware.AnnotationEnvironment: environmentParam,
+	} {
+		if v, ok := annotations.Get(key); ok {
+			event.Params[param] = v
+		}
+	}
+	if v, ok := annotations.Get(middleware.AnnotationDecisionTime); ok {
+		if d, ok := v.(time.Duration); ok {
+			event.Params[decisionTimeParam] = d.Microseconds()
+		}
+	}
+
+	// Webhooks update the datafiles of every SDK key of their project
+	if v, ok := annotations.Get(middleware.AnnotationSDKKeys); ok {
+		sdkKeys, _ := v.([]string)
+		keys := make([]string, 0, len(sdkKeys))
+		for _, sdkKey := range sdkKeys {
+			sdkKey, _, _ = strings.Cut(sdkKey, ":")
+			if hashKeys {
+				sdkKey = hashSDKKey(sdkKey)
+			}
+			keys = append(keys, sdkKey)
+		}
+		event.Params[sdkKeyParam] = strings.Join(keys, ",")
+	}
+}
@@ -0,0 +1,93 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+func TestAddWebhookAnnotationParams(t *testing.T) {
+	req, annotations := middleware.WithAnnotations(httptest.NewRequest("POST", webhookPath, nil))
+	middleware.Annotate(req, middleware.AnnotationProjectID, int64(123))
+	middleware.Annotate(req, middleware.AnnotationRevision, int32(7))
+	middleware.Annotate(req, middleware.AnnotationEnvironment, "Production")
+	middleware.Annotate(req, middleware.AnnotationSDKKeys, []string{"key-a", "key-b:token"})
+
+	event := Event{Params: map[string]interface{}{}}
+	addAnnotationParams(&event, annotations, false)
+	assert.Equal(t, map[string]interface{}{
+		projectIDParam:   int64(123),
+		revisionParam:    int32(7),
+		environmentParam: "Production",
+		sdkKeyParam:      "key-a,key-b",
+	}, event.Params)
+
+	addAnnotationParams(&event, annotations, true)
+	assert.Equal(t, hashSDKKey("key-a")+","+hashSDKKey("key-b"), event.Params[sdkKeyParam])
+
+	// Requests without annotations are left alone
+	event = Event{Params: map[string]interface{}{}}
+	addAnnotationParams(&event, nil, false)
+	assert.Empty(t, event.Params)
+}
+
+func TestAddDecisionTimeParam(t *testing.T) {
+	req, annotations := middleware.WithAnnotations(httptest.NewRequest("POST", decidePath, nil))
+	middleware.Annotate(req, middleware.AnnotationDecisionTime, 1500*time.Microsecond)
+	middleware.Annotate(req, middleware.AnnotationClientCache, "miss")
+
+	event := Event{Params: map[string]interface{}{}}
+	addAnnotationParams(&event, annotations, false)
+	assert.Equal(t, map[string]interface{}{
+		decisionTimeParam: int64(1500),
+		clientCacheParam:  "miss",
+	}, event.Params)
+}
+
+func TestAnalyticsAgentInternals(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, AgentInternals: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.Annotate(r, middleware.AnnotationClientCache, "hit")
+		middleware.Annotate(r, middleware.AnnotationRevision, "42")
+		middleware.Annotate(r, middleware.AnnotationDecisionTime, 2*time.Millisecond)
+		time.Sleep(3 * time.Millisecond)
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", decidePath, nil))
+	e := backend.next(t)
+	assert.Equal(t, defaultEventName, e.Name)
+	assert.Equal(t, "hit", e.Params[clientCacheParam])
+	assert.Equal(t, "42", e.Params[revisionParam])
+	assert.Equal(t, int64(2000), e.Params[decisionTimeParam])
+	assert.GreaterOrEqual(t, e.Params[handlerTimeParam], int64(3000))
+
+	// Without the option nothing is recorded
+	a.AgentInternals = false
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", decidePath, nil))
+	e = backend.next(t)
+	assert.NotContains(t, e.Params, clientCacheParam)
+	assert.NotContains(t, e.Params, handlerTimeParam)
+}
@@ -0,0 +1,174 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	kafkaFormatJSON     = "json"
+	kafkaFormatAvro     = "avro"
+	kafkaFormatProtobuf = "protobuf"
+
+	kafkaKeyClientID       = "clientId"
+	kafkaKeyIdempotencyKey = "idempotencyKey"
+
+	kafkaRESTAvroMediaType     = "application/vnd.kafka.avro.v2+json"
+	kafkaRESTProtobufMediaType = "application/vnd.kafka.protobuf.v2+json"
+)
+
+// KafkaBackend produces events to a Kafka topic through the Kafka REST Proxy, keyed by client ID
+// or, for compacted topics that deduplicate retried deliveries, by idempotency key.
+// With the avro and protobuf formats the REST Proxy registers the canonical event schema with
+// the Schema Registry and produces typed records.
+type KafkaBackend struct {
+	RESTProxyURL string            `json:"restProxyURL"` // Base URL of the Kafka REST Proxy
+	Topic        string            `json:"topic"`
+	Format       string            `json:"format"`  // "json" (default), "avro" or "protobuf"
+	KeyBy        string            `json:"keyBy"`   // "clientId" (default) or "idempotencyKey"
+	Headers      map[string]string `json:"headers"` // Extra request headers, e.g. authorization
+
+	mu            sync.Mutex
+	keySchemaID   int // schema IDs returned by the REST Proxy, sent instead of the schemas
+	valueSchemaID int
+	client        *http.Client
+}
+
+// kafkaRESTResponse is the response of the REST Proxy to a produce request
+type kafkaRESTResponse struct {
+	KeySchemaID   int `json:"key_schema_id"`
+	ValueSchemaID int `json:"value_schema_id"`
+	Offsets       []struct {
+		ErrorCode int    `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (k *KafkaBackend) checkConfig() error {
+	switch k.KeyBy {
+	case "", kafkaKeyClientID:
+	case kafkaKeyIdempotencyKey:
+		if k.Format == kafkaFormatProtobuf {
+			return errors.New("keyBy idempotencyKey is not supported with the protobuf format, whose keys are ApiEventKey messages")
+		}
+	default:
+		return fmt.Errorf("unknown keyBy: %q", k.KeyBy)
+	}
+	return nil
+}
+
+// Send produces the events in a single request
+func (k *KafkaBackend) Send(ctx context.Context, events []Event) error {
+	if k.RESTProxyURL == "" || k.Topic == "" {
+		return errors.New("kafka analytics backend requires restProxyURL and topic")
+	}
+
+	records := make([]map[string]interface{}, 0, len(events))
+	for _, e := range events {
+		event := canonicalEvent(e)
+		key := event.Client.ID
+		if k.KeyBy == kafkaKeyIdempotencyKey && e.IdempotencyKey != "" {
+			key = e.IdempotencyKey
+		}
+		switch k.Format {
+		case kafkaFormatJSON, "":
+			records = append(records, map[string]interface{}{"key": key, "value": event})
+		case kafkaFormatProtobuf:
+			// The JSON field names of canonical events match those of the Protobuf schema
+			records = append(records, map[string]interface{}{"key": map[string]interface{}{"clientId": event.Client.ID}, "value": event})
+		case kafkaFormatAvro:
+			records = append(records, map[string]interface{}{"key": key, "value": avroEvent(event)})
+		default:
+			return fmt.Errorf("unknown kafka format: %q", k.Format)
+		}
+	}
+
+	request := map[string]interface{}{"records": records}
+	contentType := kafkaRESTJSONMediaType
+	switch k.Format {
+	case kafkaFormatAvro:
+		contentType = kafkaRESTAvroMediaType
+		k.addSchemas(request, `"string"`, eventAvroSchema)
+	case kafkaFormatProtobuf:
+		contentType = kafkaRESTProtobufMediaType
+		k.addSchemas(request, eventProtobufKeySchema, eventProtobufSchema)
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(k.RESTProxyURL, "/") + "/topics/" + k.Topic
+	resp, err := postResponse(ctx, k.client, url, contentType, body, k.Headers)
+	if err != nil || len(resp) == 0 {
+		return err
+	}
+
+	var result kafkaRESTResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("invalid Kafka REST Proxy response: %w", err)
+	}
+	k.mu.Lock()
+	if result.KeySchemaID != 0 {
+		k.keySchemaID = result.KeySchemaID
+	}
+	if result.ValueSchemaID != 0 {
+		k.valueSchemaID = result.ValueSchemaID
+	}
+	k.mu.Unlock()
+
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != 0 || offset.Error != "" {
+			return fmt.Errorf("kafka produce failed with error %d: %s", offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
+
+// addSchemas adds the schemas to the produce request, or their IDs once registered
+func (k *KafkaBackend) addSchemas(request map[string]interface{}, keySchema, valueSchema string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keySchemaID != 0 {
+		request["key_schema_id"] = k.keySchemaID
+	} else {
+		request["key_schema"] = keySchema
+	}
+	if k.valueSchemaID != 0 {
+		request["value_schema_id"] = k.valueSchemaID
+	} else {
+		request["value_schema"] = valueSchema
+	}
+}
+
+func (k *KafkaBackend) setHTTPClient(client *http.Client) {
+	k.client = client
+}
+
+func init() {
+	AddBackend("kafka", func() Backend {
+		return &KafkaBackend{}
+	})
+}
@@ -0,0 +1,144 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kafkaProduceRequest struct {
+	ContentType   string
+	KeySchema     string                   `json:"key_schema"`
+	KeySchemaID   int                      `json:"key_schema_id"`
+	ValueSchema   string                   `json:"value_schema"`
+	ValueSchemaID int                      `json:"value_schema_id"`
+	Records       []map[string]interface{} `json:"records"`
+}
+
+func newKafkaRESTProxy(t *testing.T, response string) (*httptest.Server, func() []kafkaProduceRequest) {
+	var mu sync.Mutex
+	var requests []kafkaProduceRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/analytics", r.URL.Path)
+		var req kafkaProduceRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		req.ContentType = r.Header.Get("Content-Type")
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() []kafkaProduceRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestKafkaBackendJSON(t *testing.T) {
+	ts, requests := newKafkaRESTProxy(t, `{"offsets":[{"partition":0,"offset":1}]}`)
+	backend := &KafkaBackend{RESTProxyURL: ts.URL + "/", Topic: "analytics"}
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{"path": "/v1/decide"}},
+	}))
+
+	require.Len(t, requests(), 1)
+	req := requests()[0]
+	assert.Equal(t, kafkaRESTJSONMediaType, req.ContentType)
+	assert.Empty(t, req.ValueSchema)
+	require.Len(t, req.Records, 1)
+	assert.Equal(t, "a", req.Records[0]["key"])
+	assert.Equal(t, "api_request", req.Records[0]["value"].(map[string]interface{})["name"])
+}
+
+func TestKafkaBackendKeyByIdempotencyKey(t *testing.T) {
+	ts, requests := newKafkaRESTProxy(t, `{"offsets":[{"partition":0,"offset":1},{"partition":0,"offset":2}]}`)
+	backend := &KafkaBackend{RESTProxyURL: ts.URL, Topic: "analytics", KeyBy: "idempotencyKey"}
+	require.NoError(t, backend.checkConfig())
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", IdempotencyKey: "key"},
+		{Name: "decision", ClientID: "b"},
+	}))
+
+	// Events without a key fall back to the client ID
+	records := requests()[0].Records
+	assert.Equal(t, "key", records[0]["key"])
+	assert.Equal(t, "b", records[1]["key"])
+
+	assert.Error(t, (&KafkaBackend{Format: "protobuf", KeyBy: "idempotencyKey"}).checkConfig())
+	assert.Error(t, (&KafkaBackend{KeyBy: "userId"}).checkConfig())
+}
+
+func TestKafkaBackendAvro(t *testing.T) {
+	ts, requests := newKafkaRESTProxy(t, `{"key_schema_id":1,"value_schema_id":2,"offsets":[{"partition":0,"offset":1}]}`)
+	backend := &KafkaBackend{RESTProxyURL: ts.URL, Topic: "analytics", Format: "avro"}
+	events := []Event{{Name: "api_request", ClientID: "a", UserID: "user-1", Params: map[string]interface{}{"status_code": 200, "plan": "pro"}}}
+	require.NoError(t, backend.Send(context.Background(), events))
+	require.NoError(t, backend.Send(context.Background(), events))
+
+	require.Len(t, requests(), 2)
+	first, second := requests()[0], requests()[1]
+	assert.Equal(t, kafkaRESTAvroMediaType, first.ContentType)
+	assert.Equal(t, `"string"`, first.KeySchema)
+	assert.Equal(t, eventAvroSchema, first.ValueSchema)
+
+	value := first.Records[0]["value"].(map[string]interface{})
+	assert.Equal(t, float64(eventSchemaVersion), value["schemaVersion"])
+	assert.Equal(t, map[string]interface{}{"string": "user-1"}, value["client"].(map[string]interface{})["userId"])
+	assert.Equal(t, float64(200), value["response"].(map[string]interface{})["statusCode"])
+	assert.Equal(t, map[string]interface{}{"plan": map[string]interface{}{"string": "pro"}}, value["custom"])
+
+	// Registered schemas are referenced by ID
+	assert.Empty(t, second.ValueSchema)
+	assert.Equal(t, 1, second.KeySchemaID)
+	assert.Equal(t, 2, second.ValueSchemaID)
+}
+
+func TestKafkaBackendProtobuf(t *testing.T) {
+	ts, requests := newKafkaRESTProxy(t, `{"key_schema_id":3,"value_schema_id":4,"offsets":[{"partition":0,"offset":1}]}`)
+	backend := &KafkaBackend{RESTProxyURL: ts.URL, Topic: "analytics", Format: "protobuf"}
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a"}}))
+
+	req := requests()[0]
+	assert.Equal(t, kafkaRESTProtobufMediaType, req.ContentType)
+	assert.Equal(t, eventProtobufKeySchema, req.KeySchema)
+	assert.Equal(t, eventProtobufSchema, req.ValueSchema)
+	assert.Equal(t, map[string]interface{}{"clientId": "a"}, req.Records[0]["key"])
+	assert.Equal(t, "a", req.Records[0]["value"].(map[string]interface{})["client"].(map[string]interface{})["id"])
+}
+
+func TestKafkaBackendRecordErrors(t *testing.T) {
+	ts, _ := newKafkaRESTProxy(t, `{"offsets":[{"error_code":40403,"error":"Schema not found"}]}`)
+	backend := &KafkaBackend{RESTProxyURL: ts.URL, Topic: "analytics", Format: "avro"}
+	assert.EqualError(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}),
+		"kafka produce failed with error 40403: Schema not found")
+}
+
+func TestKafkaBackendInvalidConfig(t *testing.T) {
+	assert.Error(t, (&KafkaBackend{Topic: "analytics"}).Send(context.Background(), []Event{{Name: "api_request"}}))
+	assert.Error(t, (&KafkaBackend{RESTProxyURL: "http://127.0.0.1:1", Topic: "analytics", Format: "thrift"}).
+		Send(context.Background(), []Event{{Name: "api_request"}}))
+}
@@ -0,0 +1,115 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	latencyBucketParam = "latency_bucket"
+	apdexParam         = "apdex"
+
+	apdexSatisfied  = "satisfied"
+	apdexTolerating = "tolerating"
+	apdexFrustrated = "frustrated"
+)
+
+// LatencyConfig classifies the response times of requests, so reports can show latency
+// distributions without post-processing the raw numbers
+type LatencyConfig struct {
+	Buckets []utils.Duration `json:"buckets"` // Upper bounds of the latency buckets, e.g. [100ms, 250ms, 1s]
+	ApdexT  utils.Duration   `json:"apdexT"`  // Apdex target time: within T satisfied, within 4T tolerating
+}
+
+// latencyClasses are the validated latency buckets and Apdex target
+type latencyClasses struct {
+	bounds []time.Duration
+	labels []string // one more than bounds, for the latencies beyond the last bound
+	apdexT time.Duration
+}
+
+// newLatencyClasses returns nil when neither buckets nor an Apdex target are configured.
+// Bounds are sorted and those that are not positive or repeated are ignored.
+func newLatencyClasses(conf LatencyConfig) *latencyClasses {
+	var bounds []time.Duration
+	for _, b := range conf.Buckets {
+		if b.Duration > 0 {
+			bounds = append(bounds, b.Duration)
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+	unique := bounds[:0]
+	for i, b := range bounds {
+		if i == 0 || b != bounds[i-1] {
+			unique = append(unique, b)
+		}
+	}
+	bounds = unique
+
+	if len(bounds) == 0 && conf.ApdexT.Duration <= 0 {
+		return nil
+	}
+
+	l := &latencyClasses{bounds: bounds, apdexT: conf.ApdexT.Duration}
+	for i, b := range bounds {
+		if i == 0 {
+			l.labels = append(l.labels, "<"+b.String())
+		} else {
+			l.labels = append(l.labels, bounds[i-1].String()+"-"+b.String())
+		}
+	}
+	if len(bounds) > 0 {
+		l.labels = append(l.labels, ">="+bounds[len(bounds)-1].String())
+	}
+	return l
+}
+
+// bucket returns the label of the bucket of a latency: the first bound it is below
+func (l *latencyClasses) bucket(elapsed time.Duration) string {
+	i := sort.Search(len(l.bounds), func(i int) bool { return elapsed < l.bounds[i] })
+	return l.labels[i]
+}
+
+// apdex classifies a request by the Apdex method, under which server errors always frustrate
+func (l *latencyClasses) apdex(elapsed time.Duration, status int) string {
+	switch {
+	case status >= http.StatusInternalServerError || elapsed > 4*l.apdexT:
+		return apdexFrustrated
+	case elapsed > l.apdexT:
+		return apdexTolerating
+	default:
+		return apdexSatisfied
+	}
+}
+
+// addParams adds the latency bucket and Apdex class of a request to params
+func (l *latencyClasses) addParams(params map[string]interface{}, elapsed time.Duration, status int) {
+	if l == nil {
+		return
+	}
+	if len(l.bounds) > 0 {
+		params[latencyBucketParam] = l.bucket(elapsed)
+	}
+	if l.apdexT > 0 {
+		params[apdexParam] = l.apdex(elapsed, status)
+	}
+}
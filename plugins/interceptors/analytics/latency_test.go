@@ -0,0 +1,88 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func durations(ds ...time.Duration) []utils.Duration {
+	out := make([]utils.Duration, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, utils.Duration{Duration: d})
+	}
+	return out
+}
+
+func TestNewLatencyClasses(t *testing.T) {
+	assert.Nil(t, newLatencyClasses(LatencyConfig{}))
+
+	l := newLatencyClasses(LatencyConfig{Buckets: durations(time.Second, 100*time.Millisecond, 0, 250*time.Millisecond, time.Second)})
+	require.NotNil(t, l)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 250 * time.Millisecond, time.Second}, l.bounds)
+	assert.Equal(t, []string{"<100ms", "100ms-250ms", "250ms-1s", ">=1s"}, l.labels)
+}
+
+func TestLatencyBucket(t *testing.T) {
+	l := newLatencyClasses(LatencyConfig{Buckets: durations(100*time.Millisecond, time.Second)})
+	assert.Equal(t, "<100ms", l.bucket(0))
+	assert.Equal(t, "<100ms", l.bucket(99*time.Millisecond))
+	assert.Equal(t, "100ms-1s", l.bucket(100*time.Millisecond))
+	assert.Equal(t, ">=1s", l.bucket(time.Second))
+	assert.Equal(t, ">=1s", l.bucket(time.Minute))
+}
+
+func TestApdex(t *testing.T) {
+	l := newLatencyClasses(LatencyConfig{ApdexT: utils.Duration{Duration: 100 * time.Millisecond}})
+	assert.Equal(t, apdexSatisfied, l.apdex(100*time.Millisecond, http.StatusOK))
+	assert.Equal(t, apdexTolerating, l.apdex(101*time.Millisecond, http.StatusOK))
+	assert.Equal(t, apdexTolerating, l.apdex(400*time.Millisecond, http.StatusNotFound))
+	assert.Equal(t, apdexFrustrated, l.apdex(401*time.Millisecond, http.StatusOK))
+	assert.Equal(t, apdexFrustrated, l.apdex(time.Millisecond, http.StatusServiceUnavailable))
+
+	// Without buckets only the Apdex class is added
+	params := map[string]interface{}{}
+	l.addParams(params, time.Millisecond, http.StatusOK)
+	assert.Equal(t, map[string]interface{}{apdexParam: apdexSatisfied}, params)
+
+	// Nil classes add nothing
+	(*latencyClasses)(nil).addParams(params, time.Second, http.StatusOK)
+	assert.Len(t, params, 1)
+}
+
+func TestAnalyticsLatencyParams(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Latency: LatencyConfig{
+		Buckets: durations(time.Minute),
+		ApdexT:  utils.Duration{Duration: time.Minute},
+	}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	e := backend.next(t)
+	assert.Equal(t, "<1m0s", e.Params[latencyBucketParam])
+	assert.Equal(t, apdexSatisfied, e.Params[apdexParam])
+}
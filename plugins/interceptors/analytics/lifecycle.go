@@ -0,0 +1,254 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// defaultDrainTimeout is the time allowed for delivering queued events on shutdown by default
+const defaultDrainTimeout = 5 * time.Second
+
+// Start starts the dispatch worker pool. Events of requests served before Start are only
+// recorded in the aggregate metrics.
+func (a *Analytics) Start(ctx context.Context) error {
+	a.lifecycleMu.Lock()
+	defer a.lifecycleMu.Unlock()
+	a.started = true
+	cur := a.current()
+	cur.startDispatcher()
+	a.startRefreshers(cur, cur)
+	return nil
+}
+
+// startRefreshers starts the secret and bot list refreshers cur is configured with, restarting
+// those whose settings differ from prev's and stopping those cur no longer has
+func (a *Analytics) startRefreshers(prev, cur *Analytics) {
+	interval := cur.Secrets.RefreshInterval.Duration
+	if interval != prev.Secrets.RefreshInterval.Duration || interval <= 0 {
+		stopRefresher(&a.stopSecrets)
+	}
+	if interval > 0 && a.stopSecrets == nil {
+		var refreshCtx context.Context
+		refreshCtx, a.stopSecrets = context.WithCancel(context.Background())
+		go a.refreshSecrets(refreshCtx, interval)
+	}
+
+	listURL, botsInterval := cur.bots.refresh()
+	if prevURL, prevInterval := prev.bots.refresh(); listURL != prevURL || botsInterval != prevInterval || listURL == "" {
+		stopRefresher(&a.stopBots)
+	}
+	if listURL != "" && a.stopBots == nil {
+		var refreshCtx context.Context
+		refreshCtx, a.stopBots = context.WithCancel(context.Background())
+		go a.refreshBots(refreshCtx, botsInterval)
+	}
+}
+
+// stopRefresher cancels the refresher of stop, if running
+func stopRefresher(stop *context.CancelFunc) {
+	if *stop != nil {
+		(*stop)()
+		*stop = nil
+	}
+}
+
+// startDispatcher creates the dispatcher if there are destinations to deliver to
+func (a *Analytics) startDispatcher() {
+	if a.dispatcher != nil || len(a.destinations) == 0 {
+		return
+	}
+
+	a.dispatcher = newDispatcher(a.destinations, dispatcherOptions{
+		queueSize:  a.QueueSize,
+		workers:    a.Workers,
+		batch:      a.Batch,
+		retry:      a.Retry,
+		breaker:    a.CircuitBreaker,
+		spill:      a.Spill,
+		deadLetter: a.DeadLetter,
+		httpClient: a.httpClient,
+		rateLimit:  a.RateLimit,
+		dedup:      a.Dedup,
+		priority:   a.Priority,
+		residency:  a.Residency,
+		routing:    a.Routing,
+
+		sampleByClientID: a.SampleByClientID,
+	}, a.metrics)
+	a.auditor = newAuditor(a.Audit)
+	a.dispatcher.audit = a.auditor
+	// Events over quota may be rolled up without aggregation mode
+	if a.Aggregation.Enabled || (a.quotas != nil && a.quotas.overflow == quotaAggregate) {
+		a.aggregator = newAggregator(a.Aggregation, a.dispatcher.enqueue)
+	}
+}
+
+// Health reports whether events are being accepted for dispatch. Failing destinations don't
+// affect health, they are handled by retries and circuit breakers.
+func (a *Analytics) Health() error {
+	cur := a.current()
+	if !cur.Enabled || len(cur.destinations) == 0 {
+		return nil
+	}
+	if cur.dispatcher == nil {
+		return errors.New("analytics dispatcher not started")
+	}
+	if cur.dispatcher.isClosed() {
+		return errors.New("analytics dispatcher stopped")
+	}
+	return nil
+}
+
+// Stop stops accepting events and delivers the queued ones within the drain timeout. Deliveries
+// still outstanding when it expires are cancelled, and their events spilled to disk if enabled.
+func (a *Analytics) Stop(ctx context.Context) error {
+	a.lifecycleMu.Lock()
+	defer a.lifecycleMu.Unlock()
+	unregisterInstance(a)
+	stopRefresher(&a.stopSecrets)
+	stopRefresher(&a.stopBots)
+	return a.current().drain(ctx)
+}
+
+// Reload replaces the configuration of the running interceptor with conf, e.g. sample rates,
+// filters, destinations or the enabled flag. Requests are served with the previous
+// configuration until the new one is ready; the previous dispatcher is then drained.
+func (a *Analytics) Reload(ctx context.Context, conf []byte) error {
+	next := &Analytics{name: a.name}
+	if err := json.Unmarshal(conf, next); err != nil {
+		return fmt.Errorf("invalid analytics config: %w", err)
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	next.init()
+	return a.replace(ctx, next, "Analytics config reloaded")
+}
+
+// Config returns the instance holding the configuration in effect, which changes on reloads
+func (a *Analytics) Config() interface{} {
+	return a.current()
+}
+
+// replace makes the initialized next instance serve requests and drains the previous one.
+// Nothing is replaced once ctx is done.
+func (a *Analytics) replace(ctx context.Context, next *Analytics, msg string) error {
+	a.lifecycleMu.Lock()
+	defer a.lifecycleMu.Unlock()
+	if err := ctx.Err(); err != nil {
+		next.closeDestinations()
+		return err
+	}
+	if a.started {
+		next.startDispatcher()
+	}
+	prev := a.current()
+	next.bots.inherit(prev.bots)
+	next.anomalies.inherit(prev.anomalies)
+	a.active.Store(next)
+	// Refreshers read the active config, so they are restarted once next is active
+	if a.started {
+		a.startRefreshers(prev, next)
+	}
+	log.Info().Bool("enabled", next.Enabled).Int("destinations", len(next.destinations)).Msg(msg)
+
+	return prev.drain(ctx)
+}
+
+// cloneConfig returns a new instance with the configuration of a, to be initialized
+func (a *Analytics) cloneConfig() *Analytics {
+	next := &Analytics{}
+	src, dst := reflect.ValueOf(a).Elem(), reflect.ValueOf(next).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		if src.Type().Field(i).IsExported() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	next.name = a.name
+	return next
+}
+
+// drain closes the dispatcher, delivering the queued events within the drain timeout, and then
+// the destinations
+func (a *Analytics) drain(ctx context.Context) error {
+	defer a.closeDestinations()
+	a.notifications.close()
+	if a.dispatcher == nil {
+		return nil
+	}
+	// The record of the partial period includes the events dropped while draining
+	defer a.auditor.close()
+
+	timeout := a.DrainTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultDrainTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The rollups of the partial window are queued before the queue is drained
+	if a.aggregator != nil {
+		a.aggregator.close()
+	}
+	queued := a.dispatcher.queued()
+	if err := a.dispatcher.close(ctx); err != nil {
+		log.Warn().Err(err).Int("queued", queued).Msg("Analytics drain timed out, cancelled outstanding deliveries")
+		return err
+	}
+	log.Info().Int("queued", queued).Msg("Analytics events drained")
+	return nil
+}
+
+// closeDestinations releases the files held by the destinations, the dry-run sink and the GeoIP
+// database, the StatsD socket, and the idle connections of the HTTP client
+func (a *Analytics) closeDestinations() {
+	for _, dest := range a.destinations {
+		if closer, ok := dest.backend.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Warn().Err(err).Str("destination", dest.name).Msg("Failed to close analytics destination")
+			}
+		}
+		if dest.client != nil {
+			dest.client.CloseIdleConnections()
+		}
+	}
+	if a.dryRun != nil {
+		a.dryRun.close()
+	}
+	if reader, ok := a.geo.(*geoIPReader); ok {
+		if err := reader.close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close the GeoIP database")
+		}
+	}
+	if a.statsd != nil {
+		if err := a.statsd.close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close the StatsD emitter")
+		}
+	}
+	if a.httpClient != nil {
+		a.httpClient.CloseIdleConnections()
+	}
+}
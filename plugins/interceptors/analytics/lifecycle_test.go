@@ -0,0 +1,198 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/interceptors"
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+// blockingBackend blocks every delivery until its context is cancelled
+type blockingBackend struct {
+	started chan struct{}
+}
+
+func (b *blockingBackend) Send(ctx context.Context, events []Event) error {
+	b.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestAnalyticsImplementsLifecycle(t *testing.T) {
+	assert.Implements(t, (*interceptors.Lifecycle)(nil), &Analytics{})
+}
+
+func TestAnalyticsStartAndHealth(t *testing.T) {
+	a := &Analytics{Enabled: true, Destinations: []BackendConfig{{"type": "posthog"}}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	assert.EqualError(t, a.Health(), "analytics dispatcher not started")
+
+	// Requests before Start are served without dispatching
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+
+	require.NoError(t, a.Start(context.Background()))
+	require.NotNil(t, a.dispatcher)
+	assert.NoError(t, a.Health())
+
+	// Starting again keeps the running dispatcher
+	d := a.dispatcher
+	require.NoError(t, a.Start(context.Background()))
+	assert.Same(t, d, a.dispatcher)
+
+	require.NoError(t, a.Stop(context.Background()))
+	assert.EqualError(t, a.Health(), "analytics dispatcher stopped")
+}
+
+func TestAnalyticsHealthWithoutDestinations(t *testing.T) {
+	a := &Analytics{Enabled: true}
+	a.Handler()
+	require.NoError(t, a.Start(context.Background()))
+	assert.Nil(t, a.dispatcher)
+	assert.NoError(t, a.Health())
+}
+
+func TestAnalyticsStopDrainsQueue(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{workers: 1}, a.metrics)
+
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	}
+	require.NoError(t, a.Stop(context.Background()))
+	assert.Len(t, backend.events, 3)
+
+	// Events are no longer accepted
+	before := expvarValue("counter.analytics.dispatch.dropped")
+	assert.False(t, a.dispatcher.enqueue(Event{Name: "api_request"}))
+	assert.Equal(t, before+1, expvarValue("counter.analytics.dispatch.dropped"))
+
+	// Stopping again is a no-op
+	assert.NoError(t, a.Stop(context.Background()))
+}
+
+func TestAnalyticsStopCancelsOutstandingDeliveries(t *testing.T) {
+	backend := &blockingBackend{started: make(chan struct{}, 10)}
+	a := &Analytics{DrainTimeout: utils.Duration{Duration: 50 * time.Millisecond}}
+	a.metrics = newAnalyticsMetrics()
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{
+		workers: 1,
+		spill:   SpillConfig{Directory: t.TempDir()},
+	}, a.metrics)
+	q := a.dispatcher.spill
+
+	a.dispatcher.enqueue(Event{Name: "in_flight"})
+	<-backend.started
+	a.dispatcher.enqueue(Event{Name: "queued"})
+
+	assert.ErrorIs(t, a.Stop(context.Background()), context.DeadlineExceeded)
+
+	// Both the cancelled and the queued event are kept for a later replay
+	segments, err := q.segments()
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	records, err := readSpillSegment(segments[0])
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "in_flight", records[0].Event.Name)
+	assert.Equal(t, "mock", records[0].Destination)
+	assert.Equal(t, "queued", records[1].Event.Name)
+	assert.Empty(t, records[1].Destination)
+}
+
+func TestAnalyticsReload(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	a := &Analytics{Enabled: true, Destinations: []BackendConfig{{"type": "posthog", "host": ts.URL}}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	require.NoError(t, a.Start(context.Background()))
+	prev := a.dispatcher
+
+	// Invalid config keeps the running configuration
+	assert.Error(t, a.Reload(context.Background(), []byte(`{"enabled": "yes"}`)))
+	assert.Same(t, a, a.current())
+	assert.Error(t, a.Reload(context.Background(), []byte(`{"enabled": true, "sampleRate": 2}`)))
+	assert.Same(t, a, a.current())
+
+	require.NoError(t, a.Reload(context.Background(), []byte(`{
+		"enabled": true,
+		"excludePaths": ["/v1/config"],
+		"destinations": [{"type": "posthog", "host": "`+ts.URL+`"}]
+	}`)))
+	next := a.current()
+	require.NotSame(t, a, next)
+	assert.True(t, prev.isClosed())
+	require.NotNil(t, next.dispatcher)
+	assert.NotSame(t, prev, next.dispatcher)
+	assert.NoError(t, a.Health())
+
+	// Requests are tracked with the reloaded filters
+	backend := newMockBackend()
+	next.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, next.metrics)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/datafile", nil))
+	assert.Equal(t, "/v1/datafile", backend.next(t).Params["path"])
+
+	// Disabling stops dispatching
+	require.NoError(t, a.Reload(context.Background(), []byte(`{"enabled": false}`)))
+	assert.False(t, a.current().Enabled)
+	assert.NoError(t, a.Health())
+	require.NoError(t, a.Stop(context.Background()))
+}
+
+func TestReloadClosesReplacedConnections(t *testing.T) {
+	conn := listenStatsD(t)
+	a := &Analytics{Enabled: true, StatsD: StatsDConfig{Address: conn.LocalAddr().String()}}
+	a.Handler()
+	defer unregisterInstance(a)
+	replaced := a.current().statsd
+	require.NotNil(t, replaced)
+
+	conf := fmt.Sprintf(`{"enabled": true, "statsD": {"address": %q}}`, conn.LocalAddr().String())
+	require.NoError(t, a.Reload(context.Background(), []byte(conf)))
+	require.NotNil(t, a.current().statsd)
+	assert.NotSame(t, replaced, a.current().statsd)
+	_, err := replaced.conn.Write([]byte("requests:1|c"))
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestNamedInstanceReload(t *testing.T) {
+	a := &Analytics{}
+	a.SetName("analytics-eu")
+	a.Handler()
+	defer unregisterInstance(a)
+	assert.Equal(t, "analytics_eu", a.metrics.prefix)
+
+	require.NoError(t, a.Reload(context.Background(), []byte(`{"sampleRate": 0.5}`)))
+	assert.Equal(t, "analytics-eu", a.current().instanceName())
+	assert.Equal(t, "analytics_eu", a.current().metrics.prefix)
+	assert.Equal(t, "analytics-eu", a.cloneConfig().name)
+	assert.Equal(t, defaultInstanceName, (&Analytics{}).instanceName())
+}
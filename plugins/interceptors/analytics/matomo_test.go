@@ -0,0 +1,95 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatomoBackendSend(t *testing.T) {
+	var payload matomoBulkRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/matomo.php", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	backend := &MatomoBackend{
+		URL:        ts.URL + "/",
+		SiteID:     3,
+		TokenAuth:  "token",
+		SiteURL:    "https://agent.example.com",
+		Dimensions: map[string]int{sdkKeyParam: 2},
+	}
+	timestamp := time.Unix(1700000000, 0)
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "client-1", UserID: "user-1", Timestamp: timestamp,
+			Params: map[string]interface{}{pathParam: "/v1/decide", responseTimeParam: int64(12), sdkKeyParam: "sdk-key"}},
+		{Name: "api_request", ClientID: "client-2", Params: map[string]interface{}{}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "token", payload.TokenAuth)
+	require.Len(t, payload.Requests, 2)
+	require.True(t, strings.HasPrefix(payload.Requests[0], "?"))
+	q, err := url.ParseQuery(strings.TrimPrefix(payload.Requests[0], "?"))
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"idsite":     {"3"},
+		"rec":        {"1"},
+		"apiv":       {"1"},
+		"send_image": {"0"},
+		"_id":        {matomoVisitorID("client-1")},
+		"uid":        {"user-1"},
+		"e_c":        {defaultMatomoEventCategory},
+		"e_a":        {"api_request"},
+		"e_n":        {"/v1/decide"},
+		"url":        {"https://agent.example.com/v1/decide"},
+		"pf_srv":     {"12"},
+		"cdt":        {"1700000000"},
+		"dimension2": {"sdk-key"},
+	}, q)
+	assert.Len(t, matomoVisitorID("client-1"), 16)
+}
+
+func TestMatomoBackendWithoutToken(t *testing.T) {
+	backend := &MatomoBackend{URL: "https://matomo.example.com", SiteID: 1}
+	q := backend.trackingParams(Event{Name: "api_request", ClientID: "client", Timestamp: time.Now(), Params: map[string]interface{}{}})
+	assert.Empty(t, q.Get("cdt"))
+	assert.Empty(t, q.Get("url"))
+}
+
+func TestMatomoBackendCheckConfig(t *testing.T) {
+	assert.NoError(t, (&MatomoBackend{URL: "https://matomo.example.com", SiteID: 1}).checkConfig())
+	assert.Error(t, (&MatomoBackend{URL: "https://matomo.example.com"}).checkConfig())
+	assert.Error(t, (&MatomoBackend{SiteID: 1}).checkConfig())
+	assert.Error(t, (&MatomoBackend{URL: "https://matomo.example.com", SiteID: 1, Dimensions: map[string]int{"path": 0}}).checkConfig())
+
+	dest, err := newDestination(BackendConfig{"type": "matomo", "url": "https://matomo.example.com", "siteID": float64(4)})
+	require.NoError(t, err)
+	assert.Equal(t, 4, dest.backend.(*MatomoBackend).SiteID)
+}
@@ -0,0 +1,134 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"regexp"
+	"sync"
+
+	go_kit_metrics "github.com/go-kit/kit/metrics"
+
+	"github.com/optimizely/agent/pkg/metrics"
+	"github.com/optimizely/agent/plugins/interceptors"
+)
+
+var (
+	fallbackRegistry     *metrics.Registry
+	fallbackRegistryOnce sync.Once
+
+	invalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+// analyticsMetrics holds the metrics for tracked API traffic and the dispatch pipeline
+type analyticsMetrics struct {
+	registry *metrics.Registry
+	prefix   string // name of the interceptor instance, see SetName
+
+	requests              go_kit_metrics.Counter
+	requestDuration       go_kit_metrics.Histogram
+	responseSize          go_kit_metrics.Histogram
+	dispatchFailures      go_kit_metrics.Counter
+	dispatchRetries       go_kit_metrics.Counter
+	dispatchDropped       go_kit_metrics.Counter
+	shortCircuited        go_kit_metrics.Counter
+	deadLetters           go_kit_metrics.Counter
+	rateLimited           go_kit_metrics.Counter
+	duplicates            go_kit_metrics.Counter
+	shed                  go_kit_metrics.Counter
+	sampledOut            go_kit_metrics.Counter
+	hookFiltered          go_kit_metrics.Counter
+	botExcluded           go_kit_metrics.Counter
+	anomalous             go_kit_metrics.Counter
+	collectAccepted       go_kit_metrics.Counter
+	collectRejected       go_kit_metrics.Counter
+	consentSuppressed     go_kit_metrics.Counter
+	dntSuppressed         go_kit_metrics.Counter
+	geoSuppressed         go_kit_metrics.Counter
+	quotaExceeded         go_kit_metrics.Counter
+	spillWritten          go_kit_metrics.Counter
+	spillReplayed         go_kit_metrics.Counter
+	spillDropped          go_kit_metrics.Counter
+	ga4Validations        go_kit_metrics.Counter
+	ga4ValidationMessages go_kit_metrics.Counter
+	queueDepth            go_kit_metrics.Gauge
+}
+
+// newAnalyticsMetrics registers the metrics of the analytics instance under the agent metrics
+// registry, falling back to a package level expvar registry when none has been provided
+func newAnalyticsMetrics() *analyticsMetrics {
+	return newInstanceMetrics(defaultInstanceName)
+}
+
+// newInstanceMetrics registers the metrics of the named interceptor instance, prefixed by its name
+func newInstanceMetrics(name string) *analyticsMetrics {
+	prefix := metricSegment(name)
+	registry := interceptors.MetricsRegistry
+	if registry == nil {
+		fallbackRegistryOnce.Do(func() {
+			fallbackRegistry = metrics.NewRegistry("expvar")
+		})
+		registry = fallbackRegistry
+	}
+
+	return &analyticsMetrics{
+		registry:              registry,
+		prefix:                prefix,
+		requests:              registry.GetCounter(prefix + ".requests"),
+		requestDuration:       registry.GetHistogram(prefix + ".request.duration"),
+		responseSize:          registry.GetHistogram(prefix + ".response.size"),
+		dispatchFailures:      registry.GetCounter(prefix + ".dispatch.failures"),
+		dispatchRetries:       registry.GetCounter(prefix + ".dispatch.retries"),
+		dispatchDropped:       registry.GetCounter(prefix + ".dispatch.dropped"),
+		shortCircuited:        registry.GetCounter(prefix + ".dispatch.shortCircuited"),
+		deadLetters:           registry.GetCounter(prefix + ".dispatch.deadLetters"),
+		rateLimited:           registry.GetCounter(prefix + ".dispatch.rateLimited"),
+		duplicates:            registry.GetCounter(prefix + ".dispatch.duplicates"),
+		shed:                  registry.GetCounter(prefix + ".dispatch.shed"),
+		sampledOut:            registry.GetCounter(prefix + ".requests.sampledOut"),
+		hookFiltered:          registry.GetCounter(prefix + ".requests.hookFiltered"),
+		botExcluded:           registry.GetCounter(prefix + ".requests.botExcluded"),
+		anomalous:             registry.GetCounter(prefix + ".requests.anomalous"),
+		collectAccepted:       registry.GetCounter(prefix + ".collect.accepted"),
+		collectRejected:       registry.GetCounter(prefix + ".collect.rejected"),
+		consentSuppressed:     registry.GetCounter(prefix + ".requests.consentSuppressed"),
+		dntSuppressed:         registry.GetCounter(prefix + ".requests.dntSuppressed"),
+		geoSuppressed:         registry.GetCounter(prefix + ".requests.geoSuppressed"),
+		quotaExceeded:         registry.GetCounter(prefix + ".requests.quotaExceeded"),
+		spillWritten:          registry.GetCounter(prefix + ".spill.written"),
+		spillReplayed:         registry.GetCounter(prefix + ".spill.replayed"),
+		spillDropped:          registry.GetCounter(prefix + ".spill.dropped"),
+		ga4Validations:        registry.GetCounter(prefix + ".ga4.validations"),
+		ga4ValidationMessages: registry.GetCounter(prefix + ".ga4.validationMessages"),
+		queueDepth:            registry.GetGauge(prefix + ".queue.depth"),
+	}
+}
+
+// breakerState returns the gauge holding the circuit breaker state of a destination
+// (0 closed, 1 open, 2 half-open)
+func (m *analyticsMetrics) breakerState(destinationName string) go_kit_metrics.Gauge {
+	return m.registry.GetGauge(m.prefix + ".breaker." + metricSegment(destinationName))
+}
+
+// ga4ValidationCode returns the counter of GA4 validation messages with the given code
+func (m *analyticsMetrics) ga4ValidationCode(code string) go_kit_metrics.Counter {
+	return m.registry.GetCounter(m.prefix + ".ga4.validationMessages." + metricSegment(code))
+}
+
+// metricSegment makes an operator supplied name safe to use in a metric name
+func metricSegment(name string) string {
+	return invalidMetricChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,67 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"testing"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/pkg/metrics"
+	"github.com/optimizely/agent/plugins/interceptors"
+)
+
+func TestAnalyticsMetricsUseAgentRegistry(t *testing.T) {
+	interceptors.MetricsRegistry = metrics.NewRegistry("prometheus")
+	defer func() { interceptors.MetricsRegistry = nil }()
+
+	// Every interceptor instance shares the same registered metrics
+	first := newAnalyticsMetrics()
+	second := newAnalyticsMetrics()
+	assert.Equal(t, first.requests, second.requests)
+
+	first.requests.Add(1)
+	second.dispatchFailures.Add(2)
+	second.queueDepth.Set(5)
+
+	// Named instances have their own metrics
+	named := newInstanceMetrics("analytics-eu")
+	assert.NotEqual(t, first.requests, named.requests)
+	named.requests.Add(3)
+	named.breakerState("ga4").Set(1)
+
+	families, err := stdprometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			switch {
+			case m.GetCounter() != nil:
+				values[family.GetName()] = m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				values[family.GetName()] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(1), values["counter_analytics_requests"])
+	assert.Equal(t, float64(2), values["counter_analytics_dispatch_failures"])
+	assert.Equal(t, float64(5), values["gauge_analytics_queue_depth"])
+	assert.Equal(t, float64(3), values["counter_analytics_eu_requests"])
+	assert.Equal(t, float64(1), values["gauge_analytics_eu_breaker_ga4"])
+}
@@ -0,0 +1,104 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"time"
+
+	"github.com/optimizely/go-sdk/v2/pkg/event"
+)
+
+const (
+	impressionEvent = "optimizely_impression"
+	conversionEvent = "optimizely_conversion"
+
+	// impressionKey is the key of the snapshot events of impressions
+	impressionKey = "campaign_activated"
+
+	flagKeyParam      = "flag_key"
+	ruleKeyParam      = "rule_key"
+	ruleTypeParam     = "rule_type"
+	variationKeyParam = "variation_key"
+	enabledParam      = "enabled"
+	experimentIDParam = "experiment_id"
+	variationIDParam  = "variation_id"
+	campaignIDParam   = "campaign_id"
+	eventIDParam      = "event_id"
+	eventUUIDParam    = "event_uuid"
+	projectIDParam    = "project_id"
+	revisionParam     = "revision"
+)
+
+// mirroredEvents returns an event for every impression and conversion of a log event notification,
+// the batch the SDK's event processor sends to Optimizely
+func mirroredEvents(n interface{}) []Event {
+	var l event.LogEvent
+	switch v := n.(type) {
+	case event.LogEvent:
+		l = v
+	case *event.LogEvent:
+		l = *v
+	default:
+		return nil
+	}
+
+	var events []Event
+	for _, visitor := range l.Event.Visitors {
+		for _, snapshot := range visitor.Snapshots {
+			for _, se := range snapshot.Events {
+				e := Event{
+					ClientID:  visitor.VisitorID,
+					Timestamp: time.UnixMilli(se.Timestamp),
+					Params: map[string]interface{}{
+						schemaVersionParam: eventSchemaVersion,
+						eventUUIDParam:     se.UUID,
+						projectIDParam:     l.Event.ProjectID,
+						revisionParam:      l.Event.Revision,
+					},
+				}
+				if se.Key == impressionKey && len(snapshot.Decisions) > 0 {
+					e.Name = impressionEvent
+					addImpressionParams(e.Params, snapshot.Decisions[0])
+				} else {
+					e.Name = conversionEvent
+					e.Params[eventKeyParam] = se.Key
+					e.Params[eventIDParam] = se.EntityID
+					if se.Revenue != nil {
+						e.Params[revenueParam] = *se.Revenue
+					}
+					if se.Value != nil {
+						e.Params[valueParam] = *se.Value
+					}
+				}
+				events = append(events, e)
+			}
+		}
+	}
+	return events
+}
+
+// addImpressionParams adds the flag, experiment and variation of an impression's decision to params
+func addImpressionParams(params map[string]interface{}, d event.Decision) {
+	params[flagKeyParam] = d.Metadata.FlagKey
+	params[ruleKeyParam] = d.Metadata.RuleKey
+	params[ruleTypeParam] = d.Metadata.RuleType
+	params[variationKeyParam] = d.Metadata.VariationKey
+	params[enabledParam] = d.Metadata.Enabled
+	params[experimentIDParam] = d.ExperimentID
+	params[variationIDParam] = d.VariationID
+	params[campaignIDParam] = d.CampaignID
+}
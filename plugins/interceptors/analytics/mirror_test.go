@@ -0,0 +1,162 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/optimizely/go-sdk/v2/pkg/event"
+	"github.com/optimizely/go-sdk/v2/pkg/notification"
+	"github.com/optimizely/go-sdk/v2/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+// testLogEvent returns a batch with an impression and a conversion of visitor-1
+func testLogEvent() event.LogEvent {
+	revenue := int64(1200)
+	value := 3.5
+	return event.LogEvent{
+		EndPoint: "https://logx.optimizely.com/v1/events",
+		Event: event.Batch{
+			ProjectID: "project-1",
+			Revision:  "42",
+			Visitors: []event.Visitor{{
+				VisitorID: "visitor-1",
+				Snapshots: []event.Snapshot{
+					{
+						Decisions: []event.Decision{{
+							CampaignID:   "campaign-1",
+							ExperimentID: "experiment-1",
+							VariationID:  "variation-1",
+							Metadata: event.DecisionMetadata{
+								FlagKey:      "checkout",
+								RuleKey:      "checkout_test",
+								RuleType:     "experiment",
+								VariationKey: "treatment",
+								Enabled:      true,
+							},
+						}},
+						Events: []event.SnapshotEvent{{
+							EntityID:  "campaign-1",
+							Key:       impressionKey,
+							Timestamp: 1700000000000,
+							UUID:      "uuid-1",
+						}},
+					},
+					{
+						Events: []event.SnapshotEvent{{
+							EntityID:  "event-1",
+							Key:       "purchase",
+							Timestamp: 1700000001000,
+							UUID:      "uuid-2",
+							Revenue:   &revenue,
+							Value:     &value,
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestMirroredEvents(t *testing.T) {
+	l := testLogEvent()
+	events := mirroredEvents(l)
+	require.Len(t, events, 2)
+
+	impression := events[0]
+	assert.Equal(t, impressionEvent, impression.Name)
+	assert.Equal(t, "visitor-1", impression.ClientID)
+	assert.Equal(t, time.UnixMilli(1700000000000), impression.Timestamp)
+	assert.Equal(t, map[string]interface{}{
+		schemaVersionParam: eventSchemaVersion,
+		eventUUIDParam:     "uuid-1",
+		projectIDParam:     "project-1",
+		revisionParam:      "42",
+		flagKeyParam:       "checkout",
+		ruleKeyParam:       "checkout_test",
+		ruleTypeParam:      "experiment",
+		variationKeyParam:  "treatment",
+		enabledParam:       true,
+		experimentIDParam:  "experiment-1",
+		variationIDParam:   "variation-1",
+		campaignIDParam:    "campaign-1",
+	}, impression.Params)
+
+	conversion := events[1]
+	assert.Equal(t, conversionEvent, conversion.Name)
+	assert.Equal(t, "visitor-1", conversion.ClientID)
+	assert.Equal(t, time.UnixMilli(1700000001000), conversion.Timestamp)
+	assert.Equal(t, "purchase", conversion.Params[eventKeyParam])
+	assert.Equal(t, "event-1", conversion.Params[eventIDParam])
+	assert.Equal(t, int64(1200), conversion.Params[revenueParam])
+	assert.Equal(t, 3.5, conversion.Params[valueParam])
+	assert.Equal(t, "uuid-2", conversion.Params[eventUUIDParam])
+
+	// Pointers are accepted, other notifications ignored
+	assert.Len(t, mirroredEvents(&l), 2)
+	assert.Nil(t, mirroredEvents(notification.TrackNotification{}))
+}
+
+func TestMirroredConversionWithoutValues(t *testing.T) {
+	l := testLogEvent()
+	l.Event.Visitors[0].Snapshots = l.Event.Visitors[0].Snapshots[1:]
+	l.Event.Visitors[0].Snapshots[0].Events[0].Revenue = nil
+	l.Event.Visitors[0].Snapshots[0].Events[0].Value = nil
+
+	events := mirroredEvents(l)
+	require.Len(t, events, 1)
+	assert.NotContains(t, events[0].Params, revenueParam)
+	assert.NotContains(t, events[0].Params, valueParam)
+}
+
+func TestAnalyticsMirrorsEvents(t *testing.T) {
+	const sdkKey = "mirror-sdk-key"
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, MirrorEvents: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, registry.GetNotificationCenter(sdkKey).Send(notification.LogEvent, testLogEvent()))
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+	a.Methods = []string{"POST"} // requests are not tracked themselves
+
+	req := httptest.NewRequest("GET", "/v1/decide", nil)
+	req.Header.Set(middleware.OptlySDKHeader, sdkKey)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	impression := backend.next(t)
+	assert.Equal(t, impressionEvent, impression.Name)
+	assert.Equal(t, sdkKey, impression.Params[sdkKeyParam])
+	assert.Equal(t, "treatment", impression.Params[variationKeyParam])
+	conversion := backend.next(t)
+	assert.Equal(t, conversionEvent, conversion.Name)
+	assert.Equal(t, sdkKey, conversion.Params[sdkKeyParam])
+
+	// The log event itself is not forwarded unless configured
+	select {
+	case e := <-backend.events:
+		t.Fatalf("unexpected event: %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+	a.notifications.close()
+}
@@ -0,0 +1,285 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultMQTTTopic   = "optimizely/agent/analytics"
+	defaultMQTTPort    = "1883"
+	defaultMQTTTLSPort = "8883"
+
+	// mqttKeepAlive is the interval of the pings keeping idle connections open
+	mqttKeepAlive = 30 * time.Second
+)
+
+// mqttConnackErrors describes the return codes of refused MQTT 3.1.1 connections
+var mqttConnackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// MQTTBackend publishes events to an MQTT broker (MQTT 3.1.1) as canonical events in JSON, one
+// message per event on the topic rendered from its template. With QoS 1, the default, a batch is
+// delivered once the broker has acknowledged all its messages.
+type MQTTBackend struct {
+	Broker   string `json:"broker"`   // tcp://host:port, or ssl://host:port for TLS connections
+	Topic    string `json:"topic"`    // Go template rendered with the event (defaults to optimizely/agent/analytics)
+	QoS      *int   `json:"qos"`      // 0 or 1 (default)
+	Retain   bool   `json:"retain"`   // Publish retained messages
+	ClientID string `json:"clientID"` // Optional: defaults to a random ID
+	Username string `json:"username"` // Optional
+	Password string `json:"password"` // Optional
+
+	topic     *template.Template
+	mu        sync.Mutex
+	client    mqtt.Client
+	closed    bool
+	tlsConfig *tls.Config
+}
+
+func (m *MQTTBackend) checkConfig() error {
+	u, err := url.Parse(m.Broker)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid broker: %q", m.Broker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+	default:
+		return fmt.Errorf("broker scheme must be tcp, mqtt, ssl, tls or mqtts, got %q", u.Scheme)
+	}
+	if m.QoS != nil && *m.QoS != 0 && *m.QoS != 1 {
+		return fmt.Errorf("qos must be 0 or 1, got %d", *m.QoS)
+	}
+	topic := m.Topic
+	if topic == "" {
+		topic = defaultMQTTTopic
+	}
+	m.topic, err = template.New("topic").Option("missingkey=zero").Parse(topic)
+	if err != nil {
+		return fmt.Errorf("invalid topic: %w", err)
+	}
+	return nil
+}
+
+// Send publishes the events, waiting for their acknowledgements with QoS 1
+func (m *MQTTBackend) Send(ctx context.Context, events []Event) error {
+	if m.topic == nil {
+		if err := m.checkConfig(); err != nil {
+			return err
+		}
+	}
+
+	msgs := make([]mqttMsg, 0, len(events))
+	for _, e := range events {
+		event := canonicalEvent(e)
+		var topic strings.Builder
+		if err := m.topic.Execute(&topic, event); err != nil {
+			return err
+		}
+		if topic.Len() == 0 || strings.ContainsAny(topic.String(), "+#\x00") {
+			return fmt.Errorf("invalid MQTT topic: %q", topic.String())
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, mqttMsg{topic: topic.String(), payload: payload})
+	}
+
+	if target := dryRunFrom(ctx); target != nil {
+		for _, msg := range msgs {
+			if err := target.record(strings.TrimSuffix(m.Broker, "/")+"/"+msg.topic, "application/json", msg.payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return errors.New("connection is closed")
+	}
+	if m.client != nil && !m.client.IsConnectionOpen() {
+		// The connection was lost, e.g. after missed pings
+		m.client.Disconnect(0)
+		m.client = nil
+	}
+	if m.client == nil {
+		client, err := m.connect(ctx)
+		if err != nil {
+			return err
+		}
+		m.client = client
+	}
+	qos := byte(1)
+	if m.QoS != nil {
+		qos = byte(*m.QoS)
+	}
+	if err := m.publish(ctx, msgs, qos); err != nil {
+		m.client.Disconnect(0)
+		m.client = nil
+		return err
+	}
+	return nil
+}
+
+// Close disconnects from the broker; later sends fail
+func (m *MQTTBackend) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	if m.client == nil {
+		return nil
+	}
+	m.client.Disconnect(uint(defaultWriteTimeout / time.Millisecond))
+	m.client = nil
+	return nil
+}
+
+func (m *MQTTBackend) setTLSConfig(conf *tls.Config) {
+	m.tlsConfig = conf
+}
+
+// mqttMsg is a message to publish
+type mqttMsg struct {
+	topic   string
+	payload []byte
+}
+
+// connect connects to the broker with a clean session. Connections are not reconnected by the
+// client: a send that finds its connection lost connects again.
+func (m *MQTTBackend) connect(ctx context.Context) (mqtt.Client, error) {
+	clientID := m.ClientID
+	if clientID == "" {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		clientID = "optimizely-agent-" + hex.EncodeToString(id)
+	}
+
+	timeout := defaultWriteTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	broker, err := mqttBrokerURL(m.Broker)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientID).
+		SetUsername(m.Username).
+		SetPassword(m.Password).
+		SetProtocolVersion(4).
+		SetCleanSession(true).
+		SetKeepAlive(mqttKeepAlive).
+		SetConnectTimeout(timeout).
+		SetWriteTimeout(defaultWriteTimeout).
+		SetAutoReconnect(false).
+		SetDialer(&net.Dialer{Timeout: timeout})
+	opts.SetTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12})
+	if m.tlsConfig != nil {
+		opts.SetTLSConfig(m.tlsConfig)
+	}
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if err := waitMQTT(ctx, token); err != nil {
+		client.Disconnect(0)
+		// Return codes above those of CONNACK packets stand for errors of the client, e.g. network ones
+		if reason, ok := mqttConnackErrors[token.(*mqtt.ConnectToken).ReturnCode()]; ok {
+			return nil, fmt.Errorf("MQTT broker refused the connection: %s", reason)
+		}
+		return nil, err
+	}
+	return client, nil
+}
+
+// mqttBrokerURL returns the URL of the broker with the default port of its scheme when it has none
+func mqttBrokerURL(broker string) (string, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() == "" {
+		port := defaultMQTTPort
+		if u.Scheme == "ssl" || u.Scheme == "tls" || u.Scheme == "mqtts" {
+			port = defaultMQTTTLSPort
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u.String(), nil
+}
+
+// publish sends the messages and, with QoS 1, waits for the acknowledgements of all of them
+func (m *MQTTBackend) publish(ctx context.Context, msgs []mqttMsg, qos byte) error {
+	tokens := make([]mqtt.Token, 0, len(msgs))
+	for _, msg := range msgs {
+		tokens = append(tokens, m.client.Publish(msg.topic, qos, m.Retain, msg.payload))
+	}
+	for _, token := range tokens {
+		if err := waitMQTT(ctx, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitMQTT waits for the token to complete, for at most the default timeout when ctx has no
+// deadline, and returns its error
+func waitMQTT(ctx context.Context, token mqtt.Token) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultWriteTimeout)
+		defer cancel()
+	}
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func init() {
+	AddBackend("mqtt", func() Backend {
+		return &MQTTBackend{}
+	})
+}
@@ -0,0 +1,314 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MQTT 3.1.1 control packet types
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttPingreq    = 12
+	mqttPingresp   = 13
+	mqttDisconnect = 14
+)
+
+// mqttTestMsg is a message received by mqttTestBroker
+type mqttTestMsg struct {
+	topic   string
+	qos     byte
+	retain  bool
+	payload []byte
+}
+
+// mqttTestBroker speaks enough of MQTT 3.1.1 to test the backend, refusing connections with
+// connackCode when set
+type mqttTestBroker struct {
+	listener    net.Listener
+	connackCode byte
+
+	mu       sync.Mutex
+	connects [][]byte // bodies of the CONNECT packets
+	msgs     []mqttTestMsg
+}
+
+func newMQTTTestBroker(t *testing.T) *mqttTestBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b := &mqttTestBroker{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return b
+}
+
+func (b *mqttTestBroker) serve(netConn net.Conn) {
+	defer netConn.Close()
+	reader := bufio.NewReader(netConn)
+	for {
+		typ, body, err := readMQTTPacket(reader)
+		if err != nil {
+			return
+		}
+		var reply bytes.Buffer
+		switch typ >> 4 {
+		case mqttConnect:
+			b.mu.Lock()
+			b.connects = append(b.connects, body)
+			code := b.connackCode
+			b.mu.Unlock()
+			writeMQTTPacket(&reply, mqttConnack<<4, []byte{0, code})
+		case mqttPublish:
+			msg := mqttTestMsg{qos: typ >> 1 & 3, retain: typ&1 == 1}
+			n := binary.BigEndian.Uint16(body)
+			msg.topic, body = string(body[2:2+n]), body[2+n:]
+			if msg.qos > 0 {
+				writeMQTTPacket(&reply, mqttPuback<<4, body[:2])
+				body = body[2:]
+			}
+			msg.payload = body
+			b.mu.Lock()
+			b.msgs = append(b.msgs, msg)
+			b.mu.Unlock()
+		case mqttPingreq:
+			writeMQTTPacket(&reply, mqttPingresp<<4, nil)
+		case mqttDisconnect:
+			return
+		}
+		if _, err := netConn.Write(reply.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+// readMQTTPacket reads a packet, returning the first byte of its fixed header and its remaining
+// bytes
+func readMQTTPacket(reader *bufio.Reader) (byte, []byte, error) {
+	typ, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var size, shift uint
+	for i := 0; ; i++ {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if i == 4 {
+			return 0, nil, errors.New("invalid MQTT remaining length")
+		}
+		size |= uint(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, nil, err
+	}
+	return typ, payload, nil
+}
+
+// writeMQTTPacket writes a packet with its fixed header, encoding the remaining length
+func writeMQTTPacket(buf *bytes.Buffer, header byte, body []byte) {
+	buf.WriteByte(header)
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf.WriteByte(b)
+		if n == 0 {
+			break
+		}
+	}
+	buf.Write(body)
+}
+
+func (b *mqttTestBroker) received() []mqttTestMsg {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]mqttTestMsg(nil), b.msgs...)
+}
+
+func TestMQTTBackendSend(t *testing.T) {
+	broker := newMQTTTestBroker(t)
+	backend := &MQTTBackend{
+		Broker:   "tcp://" + broker.listener.Addr().String(),
+		Topic:    "edge/site-1/{{ .Name }}",
+		ClientID: "agent-1",
+		Username: "agent",
+		Password: "secret",
+	}
+	require.NoError(t, backend.checkConfig())
+	defer backend.Close()
+
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{pathParam: "/v1/decide"}},
+		{Name: "optimizely_impression", ClientID: "b"},
+	}))
+
+	msgs := broker.received()
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "edge/site-1/api_request", msgs[0].topic)
+	assert.Equal(t, byte(1), msgs[0].qos)
+	assert.False(t, msgs[0].retain)
+	var event CanonicalEvent
+	require.NoError(t, json.Unmarshal(msgs[0].payload, &event))
+	assert.Equal(t, "/v1/decide", event.Request.Path)
+	assert.Equal(t, "edge/site-1/optimizely_impression", msgs[1].topic)
+
+	broker.mu.Lock()
+	require.Len(t, broker.connects, 1)
+	connect := broker.connects[0]
+	broker.mu.Unlock()
+	assert.Equal(t, []byte{0, 4, 'M', 'Q', 'T', 'T', 4, 0xc2, 0, 30}, connect[:10])
+	assert.Contains(t, string(connect), "agent-1")
+	assert.Contains(t, string(connect), "secret")
+
+	// The connection is reused
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+	assert.Len(t, broker.received(), 3)
+	broker.mu.Lock()
+	assert.Len(t, broker.connects, 1)
+	broker.mu.Unlock()
+
+	require.NoError(t, backend.Close())
+	assert.Error(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+}
+
+func TestMQTTBackendQoS0(t *testing.T) {
+	broker := newMQTTTestBroker(t)
+	qos := 0
+	backend := &MQTTBackend{Broker: "mqtt://" + broker.listener.Addr().String(), QoS: &qos, Retain: true}
+	require.NoError(t, backend.checkConfig())
+	defer backend.Close()
+
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+	require.NoError(t, backend.Close())
+	require.Eventually(t, func() bool { return len(broker.received()) == 1 }, time.Second, 10*time.Millisecond)
+	msg := broker.received()[0]
+	assert.Equal(t, defaultMQTTTopic, msg.topic)
+	assert.Equal(t, byte(0), msg.qos)
+	assert.True(t, msg.retain)
+}
+
+func TestMQTTBackendRefused(t *testing.T) {
+	broker := newMQTTTestBroker(t)
+	broker.connackCode = 5
+	backend := &MQTTBackend{Broker: "tcp://" + broker.listener.Addr().String()}
+	defer backend.Close()
+
+	err := backend.Send(context.Background(), []Event{{Name: "api_request"}})
+	assert.EqualError(t, err, "MQTT broker refused the connection: not authorized")
+}
+
+func TestMQTTBackendInvalidTopic(t *testing.T) {
+	backend := &MQTTBackend{Broker: "tcp://localhost", Topic: "events/{{ .Request.Path }}"}
+	require.NoError(t, backend.checkConfig())
+	err := backend.Send(context.Background(), []Event{{Name: "api_request", Params: map[string]interface{}{pathParam: "/v1/#"}}})
+	assert.EqualError(t, err, `invalid MQTT topic: "events//v1/#"`)
+}
+
+func TestMQTTBackendCheckConfig(t *testing.T) {
+	assert.EqualError(t, (&MQTTBackend{}).checkConfig(), `invalid broker: ""`)
+	assert.EqualError(t, (&MQTTBackend{Broker: "http://broker"}).checkConfig(),
+		`broker scheme must be tcp, mqtt, ssl, tls or mqtts, got "http"`)
+	qos := 2
+	assert.EqualError(t, (&MQTTBackend{Broker: "tcp://broker", QoS: &qos}).checkConfig(), "qos must be 0 or 1, got 2")
+	assert.Error(t, (&MQTTBackend{Broker: "tcp://broker", Topic: "{{ .Name"}).checkConfig())
+}
+
+func TestMQTTBrokerURL(t *testing.T) {
+	for broker, expected := range map[string]string{
+		"tcp://broker":       "tcp://broker:1883",
+		"mqtts://broker":     "mqtts://broker:8883",
+		"ssl://broker:18883": "ssl://broker:18883",
+	} {
+		actual, err := mqttBrokerURL(broker)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+}
+
+// TestMQTTBackendMosquitto publishes to the broker of MQTT_TEST_BROKER, e.g.
+// tcp://localhost:1883, and is skipped without one
+func TestMQTTBackendMosquitto(t *testing.T) {
+	broker := os.Getenv("MQTT_TEST_BROKER")
+	if broker == "" {
+		t.Skip("MQTT_TEST_BROKER is not set")
+	}
+
+	received := make(chan mqtt.Message, 10)
+	subscriber := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(broker).SetClientID("optimizely-agent-test"))
+	token := subscriber.Connect()
+	require.NoError(t, waitMQTT(context.Background(), token))
+	defer subscriber.Disconnect(0)
+	token = subscriber.Subscribe("optimizely-agent-test/#", 1, func(_ mqtt.Client, msg mqtt.Message) {
+		received <- msg
+	})
+	require.NoError(t, waitMQTT(context.Background(), token))
+
+	backend := &MQTTBackend{Broker: broker, Topic: "optimizely-agent-test/{{ .Name }}"}
+	require.NoError(t, backend.checkConfig())
+	defer backend.Close()
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", Params: map[string]interface{}{pathParam: "/v1/decide"}},
+		{Name: "optimizely_impression"},
+	}))
+
+	for _, name := range []string{"api_request", "optimizely_impression"} {
+		select {
+		case msg := <-received:
+			assert.Equal(t, "optimizely-agent-test/"+name, msg.Topic())
+			var event CanonicalEvent
+			require.NoError(t, json.Unmarshal(msg.Payload(), &event))
+			assert.Equal(t, name, event.Name)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", name)
+		}
+	}
+}
@@ -0,0 +1,528 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNATSSubject = "optimizely.agent.analytics"
+	defaultNATSPort    = "4222"
+
+	// natsMsgIDHeader identifies messages to the duplicate window of JetStream streams
+	natsMsgIDHeader = "Nats-Msg-Id"
+
+	natsPrefixByteSeed = 18 << 3 // 'S'
+	natsPrefixByteUser = 20 << 3 // 'U'
+)
+
+// NATSBackend publishes events to a NATS subject as canonical events in JSON. With JetStream the
+// acknowledgement of the stream is awaited for every message, and messages carry an ID derived
+// from their request ID so the stream drops those published again by a retried batch.
+type NATSBackend struct {
+	URL       string `json:"url"`       // nats://host:port, or tls://host:port for TLS connections
+	Subject   string `json:"subject"`   // Subject of the messages (defaults to optimizely.agent.analytics)
+	JetStream bool   `json:"jetStream"` // Wait for the acknowledgement of the stream bound to the subject
+	Token     string `json:"token"`     // Optional: token authentication
+	User      string `json:"user"`      // Optional: user and password authentication
+	Password  string `json:"password"`
+	CredsFile string `json:"credsFile"` // Optional: path of a .creds file with the user JWT and NKey seed
+
+	mu        sync.Mutex
+	conn      *natsConn
+	closed    bool
+	tlsConfig *tls.Config
+}
+
+func (n *NATSBackend) checkConfig() error {
+	u, err := url.Parse(n.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid url: %q", n.URL)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return fmt.Errorf("url scheme must be nats or tls, got %q", u.Scheme)
+	}
+	if strings.ContainsAny(n.Subject, " \t\r\n*>") {
+		return fmt.Errorf("invalid subject: %q", n.Subject)
+	}
+	return nil
+}
+
+// Send publishes the events, one message each
+func (n *NATSBackend) Send(ctx context.Context, events []Event) error {
+	subject := n.Subject
+	if subject == "" {
+		subject = defaultNATSSubject
+	}
+
+	msgs := make([]natsMsg, 0, len(events))
+	ids := eventMessageIDs(events)
+	for i, e := range events {
+		data, err := json.Marshal(canonicalEvent(e))
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, natsMsg{subject: subject, id: ids[i], data: data})
+	}
+
+	if target := dryRunFrom(ctx); target != nil {
+		for _, msg := range msgs {
+			if err := target.record(strings.TrimSuffix(n.URL, "/")+"/"+msg.subject, "application/json", msg.data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return errors.New("connection is closed")
+	}
+	if n.conn == nil {
+		conn, err := n.connect(ctx)
+		if err != nil {
+			return err
+		}
+		n.conn = conn
+	}
+	if err := n.conn.publish(ctx, msgs); err != nil {
+		_ = n.conn.close()
+		n.conn = nil
+		return err
+	}
+	return nil
+}
+
+// eventMessageIDs returns the message IDs of the events, their request ID with their name and,
+// for the events of a request with the same name, their position. Events without a request ID
+// have no message ID.
+func eventMessageIDs(events []Event) []string {
+	ids := make([]string, len(events))
+	seen := map[string]int{}
+	for i, e := range events {
+		if e.RequestID == "" {
+			continue
+		}
+		key := e.RequestID + "." + e.Name
+		ids[i] = key + "." + strconv.Itoa(seen[key])
+		seen[key]++
+	}
+	return ids
+}
+
+// Close closes the connection to the server; later sends fail
+func (n *NATSBackend) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closed = true
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.close()
+	n.conn = nil
+	return err
+}
+
+func (n *NATSBackend) setTLSConfig(conf *tls.Config) {
+	n.tlsConfig = conf
+}
+
+// natsMsg is a message to publish
+type natsMsg struct {
+	subject string
+	id      string
+	data    []byte
+}
+
+// natsInfo is the part of the INFO the server sends on connection used by the backend
+type natsInfo struct {
+	TLSRequired bool   `json:"tls_required"`
+	Headers     bool   `json:"headers"`
+	Nonce       string `json:"nonce"`
+}
+
+// natsConnect is the CONNECT sent to the server
+type natsConnect struct {
+	Verbose      bool   `json:"verbose"`
+	Pedantic     bool   `json:"pedantic"`
+	TLSRequired  bool   `json:"tls_required"`
+	Name         string `json:"name"`
+	Lang         string `json:"lang"`
+	Version      string `json:"version"`
+	Protocol     int    `json:"protocol"`
+	Headers      bool   `json:"headers"`
+	NoResponders bool   `json:"no_responders"`
+	AuthToken    string `json:"auth_token,omitempty"`
+	User         string `json:"user,omitempty"`
+	Pass         string `json:"pass,omitempty"`
+	JWT          string `json:"jwt,omitempty"`
+	Sig          string `json:"sig,omitempty"`
+}
+
+// natsConn is a connection speaking the NATS client protocol, used by one sender at a time
+type natsConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	headers bool   // whether the server supports message headers
+	inbox   string // prefix of the reply subjects of JetStream acknowledgements
+}
+
+// connect dials the server, upgrading to TLS when required, and authenticates
+func (n *NATSBackend) connect(ctx context.Context) (*natsConn, error) {
+	u, err := url.Parse(n.URL)
+	if err != nil {
+		return nil, err
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), defaultNATSPort)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	c := &natsConn{conn: conn, reader: bufio.NewReader(conn)}
+	if err := c.handshake(ctx, n, u); err != nil {
+		_ = c.close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *natsConn) handshake(ctx context.Context, n *NATSBackend, u *url.URL) error {
+	c.setDeadline(ctx)
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting: %q", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("invalid NATS INFO: %w", err)
+	}
+	c.headers = info.Headers
+
+	useTLS := u.Scheme == "tls" || info.TLSRequired
+	if useTLS {
+		conf := &tls.Config{MinVersion: tls.VersionTLS12}
+		if n.tlsConfig != nil {
+			conf = n.tlsConfig.Clone()
+		}
+		if conf.ServerName == "" {
+			conf.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(c.conn, conf)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return err
+		}
+		c.conn = tlsConn
+		c.reader = bufio.NewReader(tlsConn)
+	}
+
+	connect := natsConnect{
+		TLSRequired:  useTLS,
+		Name:         "optimizely-agent",
+		Lang:         "go",
+		Version:      "1.0.0",
+		Protocol:     1,
+		Headers:      info.Headers,
+		NoResponders: info.Headers,
+		AuthToken:    n.Token,
+		User:         n.User,
+		Pass:         n.Password,
+	}
+	if n.CredsFile != "" {
+		if connect.JWT, connect.Sig, err = signNonce(n.CredsFile, info.Nonce); err != nil {
+			return err
+		}
+	}
+	payload, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.conn, "CONNECT %s\r\nPING\r\n", payload); err != nil {
+		return err
+	}
+	if err := c.awaitPong(); err != nil {
+		return err
+	}
+
+	if n.JetStream {
+		if !c.headers {
+			return errors.New("NATS server does not support headers, required by JetStream")
+		}
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		c.inbox = "_INBOX." + hex.EncodeToString(id)
+		if _, err := fmt.Fprintf(c.conn, "SUB %s.* 1\r\n", c.inbox); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publish sends the messages. With JetStream it waits for the acknowledgement of each, otherwise
+// for the server to have processed them.
+func (c *natsConn) publish(ctx context.Context, msgs []natsMsg) error {
+	c.setDeadline(ctx)
+
+	var buf bytes.Buffer
+	for i, msg := range msgs {
+		if c.inbox == "" {
+			fmt.Fprintf(&buf, "PUB %s %d\r\n", msg.subject, len(msg.data))
+		} else {
+			header := "NATS/1.0\r\n"
+			if msg.id != "" {
+				header += natsMsgIDHeader + ": " + msg.id + "\r\n"
+			}
+			header += "\r\n"
+			fmt.Fprintf(&buf, "HPUB %s %s.%d %d %d\r\n%s", msg.subject, c.inbox, i, len(header), len(header)+len(msg.data), header)
+		}
+		buf.Write(msg.data)
+		buf.WriteString("\r\n")
+	}
+	if c.inbox == "" {
+		buf.WriteString("PING\r\n")
+	}
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	if c.inbox == "" {
+		return c.awaitPong()
+	}
+	return c.awaitAcks(len(msgs))
+}
+
+// natsPubAck is the acknowledgement of a message published to a JetStream stream
+type natsPubAck struct {
+	Stream    string `json:"stream"`
+	Duplicate bool   `json:"duplicate"`
+	Error     *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+// awaitAcks reads the acknowledgements of n messages, returning the first error reported
+func (c *natsConn) awaitAcks(n int) error {
+	acked := make(map[string]bool, n)
+	var failed error
+	for len(acked) < n {
+		line, err := c.next()
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(line)
+		var reply string
+		var headerSize, size int
+		switch {
+		case fields[0] == "MSG" && len(fields) == 4:
+			reply = fields[1]
+			size, err = strconv.Atoi(fields[3])
+		case fields[0] == "HMSG" && len(fields) == 5:
+			reply = fields[1]
+			if headerSize, err = strconv.Atoi(fields[3]); err == nil {
+				size, err = strconv.Atoi(fields[4])
+			}
+		default:
+			return fmt.Errorf("unexpected NATS message: %q", line)
+		}
+		if err != nil || headerSize > size {
+			return fmt.Errorf("invalid NATS message: %q", line)
+		}
+
+		payload := make([]byte, size+2)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return err
+		}
+		acked[reply] = true
+		if failed != nil {
+			continue
+		}
+		header, body := payload[:headerSize], payload[headerSize:size]
+		if bytes.HasPrefix(header, []byte("NATS/1.0 503")) {
+			failed = errors.New("no JetStream stream is bound to the subject")
+			continue
+		}
+		var ack natsPubAck
+		if err := json.Unmarshal(body, &ack); err != nil {
+			failed = fmt.Errorf("invalid JetStream acknowledgement: %w", err)
+		} else if ack.Error != nil {
+			failed = fmt.Errorf("JetStream publish failed with error %d: %s", ack.Error.Code, ack.Error.Description)
+		}
+	}
+	return failed
+}
+
+// awaitPong reads until the reply to a PING
+func (c *natsConn) awaitPong() error {
+	line, err := c.next()
+	if err != nil {
+		return err
+	}
+	if line != "PONG" {
+		return fmt.Errorf("unexpected NATS message: %q", line)
+	}
+	return nil
+}
+
+// next returns the next protocol line that needs handling, answering the PINGs of the server and
+// returning its errors
+func (c *natsConn) next() (string, error) {
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case line == "PING":
+			if _, err := c.conn.Write([]byte("PONG\r\n")); err != nil {
+				return "", err
+			}
+		case line == "+OK", strings.HasPrefix(line, "INFO "):
+		case strings.HasPrefix(line, "-ERR"):
+			return "", fmt.Errorf("NATS error: %s", strings.Trim(strings.TrimPrefix(line, "-ERR"), " '"))
+		default:
+			return line, nil
+		}
+	}
+}
+
+func (c *natsConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *natsConn) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultWriteTimeout)
+	}
+	_ = c.conn.SetDeadline(deadline)
+}
+
+func (c *natsConn) close() error {
+	return c.conn.Close()
+}
+
+// signNonce reads the user JWT and NKey seed of a .creds file and signs the nonce of the server
+// with the seed, returning the JWT and the signature
+func signNonce(credsFile, nonce string) (string, string, error) {
+	creds, err := os.ReadFile(credsFile)
+	if err != nil {
+		return "", "", err
+	}
+	userJWT := credsSection(string(creds), "NATS USER JWT")
+	seed := credsSection(string(creds), "USER NKEY SEED")
+	if userJWT == "" || seed == "" {
+		return "", "", fmt.Errorf("no user JWT and NKey seed in %s", credsFile)
+	}
+	key, err := decodeNKeySeed(seed)
+	if err != nil {
+		return "", "", err
+	}
+	sig := ed25519.Sign(key, []byte(nonce))
+	return userJWT, base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// credsSection returns the first line following the BEGIN line of a section of a .creds file
+func credsSection(creds, name string) string {
+	lines := strings.Split(creds, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "BEGIN "+name) {
+			for _, next := range lines[i+1:] {
+				if next = strings.TrimSpace(next); next != "" {
+					return next
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// decodeNKeySeed decodes the seed of a user NKey into its ed25519 private key
+func decodeNKeySeed(seed string) (ed25519.PrivateKey, error) {
+	raw, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(seed)
+	if err != nil || len(raw) != 2+ed25519.SeedSize+2 {
+		return nil, errors.New("invalid NKey seed")
+	}
+	data, checksum := raw[:len(raw)-2], binary.LittleEndian.Uint16(raw[len(raw)-2:])
+	if crc16(data) != checksum {
+		return nil, errors.New("invalid NKey seed checksum")
+	}
+	if raw[0]&0xf8 != natsPrefixByteSeed {
+		return nil, errors.New("invalid NKey seed prefix")
+	}
+	if keyType := (raw[0]&0x07)<<5 | (raw[1]&0xf8)>>3; keyType != natsPrefixByteUser {
+		return nil, errors.New("NKey seed is not a user seed")
+	}
+	return ed25519.NewKeyFromSeed(raw[2 : 2+ed25519.SeedSize]), nil
+}
+
+// crc16 is the CRC-16/XMODEM checksum of NKeys
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func init() {
+	AddBackend("nats", func() Backend {
+		return &NATSBackend{}
+	})
+}
@@ -0,0 +1,374 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// natsTestMsg is a message received by natsTestServer
+type natsTestMsg struct {
+	subject string
+	header  string
+	data    string
+}
+
+// natsTestServer speaks enough of the NATS protocol to test the backend, acknowledging HPUBs
+// with a reply subject as a JetStream stream would, or with ackError when set
+type natsTestServer struct {
+	listener net.Listener
+	ackError string
+
+	mu       sync.Mutex
+	connects []natsConnect
+	msgs     []natsTestMsg
+}
+
+func newNATSTestServer(t *testing.T) *natsTestServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &natsTestServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return s
+}
+
+func (s *natsTestServer) url() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *natsTestServer) serve(conn net.Conn) {
+	defer conn.Close()
+	_, _ = io.WriteString(conn, `INFO {"server_id":"test","headers":true,"nonce":"test-nonce"}`+"\r\n")
+	reader := bufio.NewReader(conn)
+	seq := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "CONNECT":
+			var connect natsConnect
+			_ = json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &connect)
+			s.mu.Lock()
+			s.connects = append(s.connects, connect)
+			s.mu.Unlock()
+		case "PING":
+			_, _ = io.WriteString(conn, "PONG\r\n")
+		case "PUB", "HPUB":
+			headerSize, reply := 0, ""
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			if fields[0] == "HPUB" {
+				headerSize, _ = strconv.Atoi(fields[len(fields)-2])
+			}
+			if len(fields) == 4 && fields[0] == "PUB" || len(fields) == 5 && fields[0] == "HPUB" {
+				reply = fields[2]
+			}
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.msgs = append(s.msgs, natsTestMsg{subject: fields[1], header: string(payload[:headerSize]), data: string(payload[headerSize:size])})
+			ackError := s.ackError
+			s.mu.Unlock()
+			if reply != "" {
+				seq++
+				ack := fmt.Sprintf(`{"stream":"EVENTS","seq":%d}`, seq)
+				if ackError != "" {
+					ack = ackError
+				}
+				_, _ = fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", reply, len(ack), ack)
+			}
+		}
+	}
+}
+
+func (s *natsTestServer) received() []natsTestMsg {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]natsTestMsg(nil), s.msgs...)
+}
+
+func TestNATSBackendSend(t *testing.T) {
+	server := newNATSTestServer(t)
+	backend := &NATSBackend{URL: server.url(), Subject: "agent.events", Token: "secret"}
+	require.NoError(t, backend.checkConfig())
+	defer backend.Close()
+
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{pathParam: "/v1/decide"}},
+		{Name: "api_request", ClientID: "b"},
+	}))
+
+	msgs := server.received()
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "agent.events", msgs[0].subject)
+	assert.Empty(t, msgs[0].header)
+	var event CanonicalEvent
+	require.NoError(t, json.Unmarshal([]byte(msgs[0].data), &event))
+	assert.Equal(t, "a", event.Client.ID)
+	assert.Equal(t, "/v1/decide", event.Request.Path)
+
+	server.mu.Lock()
+	require.Len(t, server.connects, 1)
+	assert.Equal(t, "secret", server.connects[0].AuthToken)
+	server.mu.Unlock()
+
+	// The connection is reused
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "c"}}))
+	assert.Len(t, server.received(), 3)
+	server.mu.Lock()
+	assert.Len(t, server.connects, 1)
+	server.mu.Unlock()
+
+	require.NoError(t, backend.Close())
+	assert.Error(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+}
+
+func TestNATSBackendJetStream(t *testing.T) {
+	server := newNATSTestServer(t)
+	backend := &NATSBackend{URL: server.url(), JetStream: true}
+	defer backend.Close()
+
+	events := []Event{
+		{Name: "api_request", ClientID: "a", RequestID: "req-1"},
+		{Name: "optimizely_impression", ClientID: "a", RequestID: "req-1"},
+		{Name: "optimizely_impression", ClientID: "a", RequestID: "req-1"},
+		{Name: "api_request", ClientID: "b"},
+	}
+	require.NoError(t, backend.Send(context.Background(), events))
+
+	msgs := server.received()
+	require.Len(t, msgs, 4)
+	assert.Equal(t, defaultNATSSubject, msgs[0].subject)
+	assert.Equal(t, "NATS/1.0\r\nNats-Msg-Id: req-1.api_request.0\r\n\r\n", msgs[0].header)
+	assert.Contains(t, msgs[1].header, "Nats-Msg-Id: req-1.optimizely_impression.0\r\n")
+	assert.Contains(t, msgs[2].header, "Nats-Msg-Id: req-1.optimizely_impression.1\r\n")
+	assert.Equal(t, "NATS/1.0\r\n\r\n", msgs[3].header)
+
+	server.mu.Lock()
+	server.ackError = `{"error":{"code":503,"description":"insufficient resources"}}`
+	server.mu.Unlock()
+	err := backend.Send(context.Background(), events[:1])
+	assert.EqualError(t, err, "JetStream publish failed with error 503: insufficient resources")
+
+	// Failed connections are dialed again
+	server.mu.Lock()
+	server.ackError = ""
+	server.mu.Unlock()
+	assert.NoError(t, backend.Send(context.Background(), events[:1]))
+}
+
+func TestNATSBackendCheckConfig(t *testing.T) {
+	assert.EqualError(t, (&NATSBackend{}).checkConfig(), `invalid url: ""`)
+	assert.EqualError(t, (&NATSBackend{URL: "http://nats:4222"}).checkConfig(), `url scheme must be nats or tls, got "http"`)
+	assert.EqualError(t, (&NATSBackend{URL: "nats://nats:4222", Subject: "events.>"}).checkConfig(), `invalid subject: "events.>"`)
+	assert.NoError(t, (&NATSBackend{URL: "tls://nats:4222", Subject: "events.api"}).checkConfig())
+}
+
+// encodeNKeySeed encodes an ed25519 seed as a user NKey seed
+func encodeNKeySeed(seed []byte) string {
+	raw := []byte{natsPrefixByteSeed | natsPrefixByteUser>>5, (natsPrefixByteUser & 31) << 3}
+	raw = append(raw, seed...)
+	raw = binary.LittleEndian.AppendUint16(raw, crc16(raw))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+}
+
+func TestNATSBackendCredsFile(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	encoded := encodeNKeySeed(seed)
+	assert.True(t, strings.HasPrefix(encoded, "SU"))
+
+	credsFile := filepath.Join(t.TempDir(), "user.creds")
+	creds := "-----BEGIN NATS USER JWT-----\nuser.jwt.token\n------END NATS USER JWT------\n\n" +
+		"-----BEGIN USER NKEY SEED-----\n" + encoded + "\n------END USER NKEY SEED------\n"
+	require.NoError(t, os.WriteFile(credsFile, []byte(creds), 0o600))
+
+	server := newNATSTestServer(t)
+	backend := &NATSBackend{URL: server.url(), CredsFile: credsFile}
+	defer backend.Close()
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	require.Len(t, server.connects, 1)
+	assert.Equal(t, "user.jwt.token", server.connects[0].JWT)
+	sig, err := base64.RawURLEncoding.DecodeString(server.connects[0].Sig)
+	require.NoError(t, err)
+	public := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	assert.True(t, ed25519.Verify(public, []byte("test-nonce"), sig))
+}
+
+func TestDecodeNKeySeed(t *testing.T) {
+	encoded := encodeNKeySeed(make([]byte, ed25519.SeedSize))
+	_, err := decodeNKeySeed(encoded)
+	assert.NoError(t, err)
+
+	corrupted := []byte(encoded)
+	corrupted[10] = 'A' + (corrupted[10]-'A'+1)%26
+	_, err = decodeNKeySeed(string(corrupted))
+	assert.Error(t, err)
+
+	_, err = decodeNKeySeed("not-a-seed")
+	assert.EqualError(t, err, "invalid NKey seed")
+}
+
+// natsTestClient subscribes and sends requests to a real server over a connection opened like
+// those of the backend
+type natsTestClient struct {
+	*natsConn
+	seq int
+}
+
+func newNATSTestClient(t *testing.T, serverURL string) *natsTestClient {
+	conn, err := (&NATSBackend{URL: serverURL}).connect(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.close() })
+	return &natsTestClient{natsConn: conn}
+}
+
+func (c *natsTestClient) subscribe(t *testing.T, subject string) {
+	c.seq++
+	c.setDeadline(context.Background())
+	_, err := fmt.Fprintf(c.conn, "SUB %s %d\r\nPING\r\n", subject, c.seq)
+	require.NoError(t, err)
+	require.NoError(t, c.awaitPong())
+}
+
+// read returns the subject and data of the next message received
+func (c *natsTestClient) read(t *testing.T) (string, []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.setDeadline(ctx)
+	line, err := c.next()
+	require.NoError(t, err)
+	fields := strings.Fields(line)
+	require.Contains(t, []string{"MSG", "HMSG"}, fields[0], line)
+	headerSize := 0
+	if fields[0] == "HMSG" {
+		headerSize, _ = strconv.Atoi(fields[len(fields)-2])
+	}
+	size, _ := strconv.Atoi(fields[len(fields)-1])
+	payload := make([]byte, size+2)
+	_, err = io.ReadFull(c.reader, payload)
+	require.NoError(t, err)
+	return fields[1], payload[headerSize:size]
+}
+
+// request sends body to subject and returns the reply
+func (c *natsTestClient) request(t *testing.T, subject string, body interface{}) []byte {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	inbox := fmt.Sprintf("_INBOX.optimizely-agent-test.%d", c.seq+1)
+	c.subscribe(t, inbox)
+	_, err := fmt.Fprintf(c.conn, "PUB %s %s %d\r\n%s\r\n", subject, inbox, len(data), data)
+	require.NoError(t, err)
+	_, reply := c.read(t)
+	return reply
+}
+
+// TestNATSBackendNATSServer publishes to the server of NATS_TEST_URL, e.g. nats://localhost:4222,
+// which needs JetStream enabled, and is skipped without one
+func TestNATSBackendNATSServer(t *testing.T) {
+	serverURL := os.Getenv("NATS_TEST_URL")
+	if serverURL == "" {
+		t.Skip("NATS_TEST_URL is not set")
+	}
+	client := newNATSTestClient(t, serverURL)
+
+	client.subscribe(t, "optimizely-agent-test.core")
+	backend := &NATSBackend{URL: serverURL, Subject: "optimizely-agent-test.core"}
+	defer backend.Close()
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request"}, {Name: "optimizely_impression"}}))
+	for _, name := range []string{"api_request", "optimizely_impression"} {
+		subject, data := client.read(t)
+		assert.Equal(t, "optimizely-agent-test.core", subject)
+		var event CanonicalEvent
+		require.NoError(t, json.Unmarshal(data, &event))
+		assert.Equal(t, name, event.Name)
+	}
+
+	// JetStream acknowledges the messages of the stream bound to the subject
+	type apiError struct {
+		Error *struct {
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	var created apiError
+	require.NoError(t, json.Unmarshal(client.request(t, "$JS.API.STREAM.CREATE.OPTIMIZELY_AGENT_TEST", map[string]interface{}{
+		"name":     "OPTIMIZELY_AGENT_TEST",
+		"subjects": []string{"optimizely-agent-test.js"},
+		"storage":  "memory",
+	}), &created))
+	require.Nil(t, created.Error)
+	t.Cleanup(func() { client.request(t, "$JS.API.STREAM.DELETE.OPTIMIZELY_AGENT_TEST", nil) })
+
+	jetStream := &NATSBackend{URL: serverURL, Subject: "optimizely-agent-test.js", JetStream: true}
+	defer jetStream.Close()
+	events := []Event{{Name: "api_request", RequestID: "req-1"}, {Name: "optimizely_impression", RequestID: "req-1"}}
+	require.NoError(t, jetStream.Send(context.Background(), events))
+	// The duplicate window of the stream drops the messages of batches published again
+	require.NoError(t, jetStream.Send(context.Background(), events))
+	var info struct {
+		State struct {
+			Messages int `json:"messages"`
+		} `json:"state"`
+	}
+	require.NoError(t, json.Unmarshal(client.request(t, "$JS.API.STREAM.INFO.OPTIMIZELY_AGENT_TEST", nil), &info))
+	assert.Equal(t, 2, info.State.Messages)
+
+	unbound := &NATSBackend{URL: serverURL, Subject: "optimizely-agent-test.unbound", JetStream: true}
+	defer unbound.Close()
+	assert.EqualError(t, unbound.Send(context.Background(), events), "no JetStream stream is bound to the subject")
+}
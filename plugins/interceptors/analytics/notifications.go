@@ -0,0 +1,306 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/optimizely/go-sdk/v2/pkg/event"
+	"github.com/optimizely/go-sdk/v2/pkg/notification"
+	"github.com/optimizely/go-sdk/v2/pkg/registry"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	decisionNotificationEvent = "optimizely_decision"
+	trackNotificationEvent    = "optimizely_track"
+	logEventNotificationEvent = "optimizely_log_event"
+
+	notificationDecision = "decision"
+	notificationTrack    = "track"
+	notificationLogEvent = "logEvent"
+
+	decisionTypeParam = "decision_type"
+	eventKeyParam     = "event_key"
+	revenueParam      = "revenue"
+	valueParam        = "value"
+	visitorsParam     = "visitors"
+	eventCountParam   = "event_count"
+	endpointParam     = "endpoint"
+)
+
+// decisionInfoParams maps the decision info fields of the SDK's decision notifications to event params
+var decisionInfoParams = map[string]string{
+	"flagKey":                 "flag_key",
+	"enabled":                 "enabled",
+	"variationKey":            "variation_key",
+	"ruleKey":                 "rule_key",
+	"decisionEventDispatched": "decision_event_dispatched",
+	"experimentKey":           "experiment_key",
+	"featureKey":              "feature_key",
+	"featureEnabled":          "feature_enabled",
+	"source":                  "source",
+}
+
+// NotificationConfig forwards the notifications of the Optimizely SDK clients serving the tracked
+// requests to the analytics backends, capturing experimentation outcomes rather than API traffic
+type NotificationConfig struct {
+	Enabled bool     `json:"enabled"`
+	Types   []string `json:"types"` // "decision", "track" and "logEvent" (defaults to decision and track)
+}
+
+// notificationTypes maps the configured notification types to the SDK's
+var notificationTypes = map[string]notification.Type{
+	notificationDecision: notification.Decision,
+	notificationTrack:    notification.Track,
+	notificationLogEvent: notification.LogEvent,
+}
+
+// notificationHandler is a handler added to the notification center of an SDK key
+type notificationHandler struct {
+	sdkKey string
+	id     int
+	kind   notification.Type
+}
+
+// notificationBridge subscribes to the notification centers of the SDK keys of tracked requests
+// and forwards their notifications as events
+type notificationBridge struct {
+	types     []notification.Type        // subscribed to
+	summaries map[notification.Type]bool // forwarded as one event per notification
+	mirror    bool                       // log events are mirrored as their impressions and conversions
+	forward   func(sdkKey string, events []Event)
+
+	mu       sync.Mutex
+	closed   bool
+	sdkKeys  map[string]bool
+	handlers []notificationHandler
+}
+
+// newNotificationBridge returns nil when neither forwarding notifications nor mirroring events
+// is enabled
+func newNotificationBridge(conf NotificationConfig, mirror bool, forward func(string, []Event)) (*notificationBridge, error) {
+	if !conf.Enabled && !mirror {
+		return nil, nil
+	}
+	b := &notificationBridge{
+		summaries: map[notification.Type]bool{},
+		mirror:    mirror,
+		forward:   forward,
+		sdkKeys:   map[string]bool{},
+	}
+	if conf.Enabled {
+		names := conf.Types
+		if len(names) == 0 {
+			names = []string{notificationDecision, notificationTrack}
+		}
+		for _, name := range names {
+			kind, ok := notificationTypes[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown notification type %q, expected decision, track or logEvent", name)
+			}
+			if !b.summaries[kind] {
+				b.summaries[kind] = true
+				b.types = append(b.types, kind)
+			}
+		}
+	}
+	if mirror && !b.summaries[notification.LogEvent] {
+		b.types = append(b.types, notification.LogEvent)
+	}
+	return b, nil
+}
+
+// events returns the events of a notification
+func (b *notificationBridge) events(kind notification.Type, n interface{}) []Event {
+	var events []Event
+	if b.summaries[kind] {
+		if e, ok := notificationEvent(kind, n); ok {
+			events = append(events, e)
+		}
+	}
+	if b.mirror && kind == notification.LogEvent {
+		events = append(events, mirroredEvents(n)...)
+	}
+	return events
+}
+
+// subscribe adds the handlers to the notification center of sdkKey, the first time it is seen
+func (b *notificationBridge) subscribe(sdkKey string) {
+	if b == nil || sdkKey == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed || b.sdkKeys[sdkKey] {
+		return
+	}
+	b.sdkKeys[sdkKey] = true
+
+	center := registry.GetNotificationCenter(sdkKey)
+	for _, kind := range b.types {
+		kind := kind
+		id, err := center.AddHandler(kind, func(n interface{}) {
+			if events := b.events(kind, n); len(events) > 0 {
+				b.forward(sdkKey, events)
+			}
+		})
+		if err != nil {
+			log.Error().Err(err).Str("type", string(kind)).Msg("Failed to subscribe to Optimizely notifications")
+			continue
+		}
+		b.handlers = append(b.handlers, notificationHandler{sdkKey: sdkKey, id: id, kind: kind})
+	}
+}
+
+// close removes the handlers from the notification centers
+func (b *notificationBridge) close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, h := range b.handlers {
+		if err := registry.GetNotificationCenter(h.sdkKey).RemoveHandler(h.id, h.kind); err != nil {
+			log.Warn().Err(err).Str("type", string(h.kind)).Msg("Failed to unsubscribe from Optimizely notifications")
+		}
+	}
+	b.handlers = nil
+}
+
+// notificationEvent creates the event of a notification, reporting false for unknown notifications
+func notificationEvent(kind notification.Type, n interface{}) (Event, bool) {
+	e := Event{Timestamp: time.Now(), Params: map[string]interface{}{schemaVersionParam: eventSchemaVersion}}
+	switch kind {
+	case notification.Decision:
+		var d notification.DecisionNotification
+		switch v := n.(type) {
+		case notification.DecisionNotification:
+			d = v
+		case *notification.DecisionNotification:
+			d = *v
+		default:
+			return Event{}, false
+		}
+		e.Name = decisionNotificationEvent
+		e.ClientID = d.UserContext.ID
+		e.Params[decisionTypeParam] = string(d.Type)
+		addDecisionInfoParams(e.Params, d.DecisionInfo)
+
+	case notification.Track:
+		var t notification.TrackNotification
+		switch v := n.(type) {
+		case notification.TrackNotification:
+			t = v
+		case *notification.TrackNotification:
+			t = *v
+		default:
+			return Event{}, false
+		}
+		e.Name = trackNotificationEvent
+		e.ClientID = t.UserContext.ID
+		e.Params[eventKeyParam] = t.EventKey
+		for _, tag := range []string{revenueParam, valueParam} {
+			if v, ok := t.EventTags[tag]; ok {
+				e.Params[tag] = v
+			}
+		}
+
+	case notification.LogEvent:
+		var l event.LogEvent
+		switch v := n.(type) {
+		case event.LogEvent:
+			l = v
+		case *event.LogEvent:
+			l = *v
+		default:
+			return Event{}, false
+		}
+		e.Name = logEventNotificationEvent
+		events := 0
+		for _, visitor := range l.Event.Visitors {
+			for _, snapshot := range visitor.Snapshots {
+				events += len(snapshot.Events)
+			}
+		}
+		e.Params[visitorsParam] = len(l.Event.Visitors)
+		e.Params[eventCountParam] = events
+		if u, err := url.Parse(l.EndPoint); err == nil {
+			e.Params[endpointParam] = u.Host
+		}
+		// Batches are of many visitors, the agent reports them as itself
+		e.ClientID = agentClientID()
+
+	default:
+		return Event{}, false
+	}
+	return e, true
+}
+
+// addDecisionInfoParams adds the known fields of decision info to params. Feature decisions nest
+// them under "feature" and "sourceInfo"; variables and reasons are left out.
+func addDecisionInfoParams(params map[string]interface{}, info map[string]interface{}) {
+	for key, value := range info {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			addDecisionInfoParams(params, v)
+		case map[string]string:
+			for k, s := range v {
+				if param, ok := decisionInfoParams[k]; ok {
+					params[param] = s
+				}
+			}
+		default:
+			if param, ok := decisionInfoParams[key]; ok {
+				params[param] = fmt.Sprint(v)
+				if b, ok := v.(bool); ok {
+					params[param] = b
+				}
+			}
+		}
+	}
+}
+
+// forwardNotification dispatches the events of a notification of the SDK client of sdkKey.
+// Requests settings such as consent don't apply, as notifications don't carry their request.
+func (a *Analytics) forwardNotification(sdkKey string, events []Event) {
+	if trackingPaused.Load() || !a.Enabled || a.dispatcher == nil {
+		return
+	}
+	if a.HashSDKKey {
+		sdkKey = hashSDKKey(sdkKey)
+	}
+	rate := a.sampleRate()
+	for _, event := range events {
+		event.Params[sdkKeyParam] = sdkKey
+		applyPrivacy(&event, a.privacy, "")
+
+		if !sampled(rate, event.ClientID, a.SampleByClientID) {
+			a.metrics.sampledOut.Add(1)
+			a.auditor.count(auditSampledOut)
+			continue
+		}
+		applySampleRate(&event, rate)
+		if a.withinQuota(&event) {
+			a.dispatcher.enqueue(event)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdkdecision "github.com/optimizely/go-sdk/v2/pkg/decision"
+	"github.com/optimizely/go-sdk/v2/pkg/entities"
+	"github.com/optimizely/go-sdk/v2/pkg/event"
+	"github.com/optimizely/go-sdk/v2/pkg/notification"
+	"github.com/optimizely/go-sdk/v2/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+func TestNewNotificationBridge(t *testing.T) {
+	b, err := newNotificationBridge(NotificationConfig{}, false, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, b)
+
+	b, err = newNotificationBridge(NotificationConfig{Enabled: true}, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []notification.Type{notification.Decision, notification.Track}, b.types)
+
+	// Mirroring subscribes to log events once
+	b, err = newNotificationBridge(NotificationConfig{}, true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []notification.Type{notification.LogEvent}, b.types)
+	b, err = newNotificationBridge(NotificationConfig{Enabled: true, Types: []string{"logEvent", "track"}}, true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []notification.Type{notification.LogEvent, notification.Track}, b.types)
+
+	_, err = newNotificationBridge(NotificationConfig{Enabled: true, Types: []string{"decision", "config"}}, false, nil)
+	assert.EqualError(t, err, `unknown notification type "config", expected decision, track or logEvent`)
+}
+
+func TestFlagDecisionNotificationEvent(t *testing.T) {
+	n := sdkdecision.FlagNotification("checkout", "treatment", "experiment_1", true, true,
+		entities.UserContext{ID: "user-1"}, map[string]interface{}{"color": "red"}, []string{"reason"})
+
+	e, ok := notificationEvent(notification.Decision, *n)
+	require.True(t, ok)
+	assert.Equal(t, decisionNotificationEvent, e.Name)
+	assert.Equal(t, "user-1", e.ClientID)
+	assert.Equal(t, map[string]interface{}{
+		schemaVersionParam:          eventSchemaVersion,
+		decisionTypeParam:           "flag",
+		"flag_key":                  "checkout",
+		"variation_key":             "treatment",
+		"rule_key":                  "experiment_1",
+		"enabled":                   true,
+		"decision_event_dispatched": true,
+	}, e.Params)
+
+	// Pointers are accepted too
+	_, ok = notificationEvent(notification.Decision, n)
+	assert.True(t, ok)
+}
+
+func TestFeatureDecisionNotificationEvent(t *testing.T) {
+	e, ok := notificationEvent(notification.Decision, notification.DecisionNotification{
+		Type:        notification.Feature,
+		UserContext: entities.UserContext{ID: "user-1"},
+		DecisionInfo: map[string]interface{}{
+			"feature": map[string]interface{}{
+				"featureKey":     "checkout",
+				"featureEnabled": true,
+				"source":         sdkdecision.FeatureTest,
+				"sourceInfo":     map[string]string{"experimentKey": "exp", "variationKey": "var"},
+				"color":          "red",
+			},
+		},
+	})
+	require.True(t, ok)
+	assert.Equal(t, "checkout", e.Params["feature_key"])
+	assert.Equal(t, true, e.Params["feature_enabled"])
+	assert.Equal(t, "feature-test", e.Params["source"])
+	assert.Equal(t, "exp", e.Params["experiment_key"])
+	assert.Equal(t, "var", e.Params["variation_key"])
+	assert.NotContains(t, e.Params, "color")
+}
+
+func TestTrackNotificationEvent(t *testing.T) {
+	e, ok := notificationEvent(notification.Track, notification.TrackNotification{
+		EventKey:    "purchase",
+		UserContext: entities.UserContext{ID: "user-1"},
+		EventTags:   map[string]interface{}{"revenue": 1000, "value": 9.99, "sku": "A-1"},
+	})
+	require.True(t, ok)
+	assert.Equal(t, trackNotificationEvent, e.Name)
+	assert.Equal(t, "user-1", e.ClientID)
+	assert.Equal(t, "purchase", e.Params[eventKeyParam])
+	assert.Equal(t, 1000, e.Params[revenueParam])
+	assert.Equal(t, 9.99, e.Params[valueParam])
+	assert.NotContains(t, e.Params, "sku")
+}
+
+func TestLogEventNotificationEvent(t *testing.T) {
+	e, ok := notificationEvent(notification.LogEvent, event.LogEvent{
+		EndPoint: "https://logx.optimizely.com/v1/events",
+		Event: event.Batch{Visitors: []event.Visitor{
+			{Snapshots: []event.Snapshot{{Events: make([]event.SnapshotEvent, 2)}}},
+			{Snapshots: []event.Snapshot{{Events: make([]event.SnapshotEvent, 1)}}},
+		}},
+	})
+	require.True(t, ok)
+	assert.Equal(t, logEventNotificationEvent, e.Name)
+	assert.Equal(t, agentClientID(), e.ClientID)
+	assert.Equal(t, 2, e.Params[visitorsParam])
+	assert.Equal(t, 3, e.Params[eventCountParam])
+	assert.Equal(t, "logx.optimizely.com", e.Params[endpointParam])
+}
+
+func TestUnknownNotificationEvent(t *testing.T) {
+	_, ok := notificationEvent(notification.ProjectConfigUpdate, notification.ProjectConfigUpdateNotification{})
+	assert.False(t, ok)
+	_, ok = notificationEvent(notification.Decision, "not a decision")
+	assert.False(t, ok)
+}
+
+func TestAnalyticsForwardsNotifications(t *testing.T) {
+	const sdkKey = "notifications-sdk-key"
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Notifications: NotificationConfig{Enabled: true}, Privacy: PrivacyConfig{HashClientID: true}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Notifications sent while serving the first request are forwarded
+		n := sdkdecision.FlagNotification("checkout", "on", "", true, false, entities.UserContext{ID: "user-1"}, nil, nil)
+		assert.NoError(t, registry.GetNotificationCenter(sdkKey).Send(notification.Decision, *n))
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+	a.Methods = []string{"POST"} // requests are not tracked themselves
+
+	req := httptest.NewRequest("GET", "/v1/decide", nil)
+	req.Header.Set(middleware.OptlySDKHeader, sdkKey)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	e := backend.next(t)
+	assert.Equal(t, decisionNotificationEvent, e.Name)
+	assert.Equal(t, sdkKey, e.Params[sdkKeyParam])
+	assert.Equal(t, saltedHash("", "user-1"), e.ClientID)
+
+	// Subscribing again doesn't forward notifications twice
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	backend.next(t)
+	select {
+	case e := <-backend.events:
+		t.Fatalf("unexpected duplicate event: %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Notifications are no longer forwarded once stopped
+	a.notifications.close()
+	assert.NoError(t, registry.GetNotificationCenter(sdkKey).Send(notification.Track, notification.TrackNotification{EventKey: "purchase"}))
+	select {
+	case e := <-backend.events:
+		t.Fatalf("unexpected event after close: %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
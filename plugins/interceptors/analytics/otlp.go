@@ -0,0 +1,213 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/optimizely/agent/config"
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+const (
+	otlpLogsPath          = "/v1/logs"
+	otlpScopeName         = "github.com/optimizely/agent/plugins/interceptors/analytics"
+	defaultOTLPService    = "optimizely-agent"
+	otlpProtobufMediaType = "application/x-protobuf"
+)
+
+// OTLPBackend exports events as OpenTelemetry log records to an OTLP endpoint such as
+// an OpenTelemetry Collector, over OTLP/HTTP (protobuf) or OTLP/gRPC
+type OTLPBackend struct {
+	Endpoint    string                       `json:"endpoint"`    // Base URL for http (e.g. http://collector:4318), host:port for grpc
+	Protocol    config.TracingRemoteProtocol `json:"protocol"`    // "http" (default) or "grpc"
+	Insecure    bool                         `json:"insecure"`    // Use a plaintext gRPC connection
+	Headers     map[string]string            `json:"headers"`     // Extra headers or gRPC metadata, e.g. for authentication
+	ServiceName string                       `json:"serviceName"` // service.name resource attribute
+
+	connOnce  sync.Once
+	conn      *grpc.ClientConn
+	connErr   error
+	client    *http.Client // used by the http protocol
+	tlsConfig *tls.Config  // used by the grpc protocol
+}
+
+// Send exports the events as a single ExportLogsServiceRequest
+func (o *OTLPBackend) Send(ctx context.Context, events []Event) error {
+	request := o.exportRequest(events)
+
+	switch o.Protocol {
+	case config.TracingRemoteProtocolGRPC:
+		return o.sendGRPC(ctx, request)
+	case config.TracingRemoteProtocolHTTP, "":
+		body, err := proto.Marshal(request)
+		if err != nil {
+			return err
+		}
+		return post(ctx, o.client, strings.TrimSuffix(o.Endpoint, "/")+otlpLogsPath, otlpProtobufMediaType, body, o.Headers)
+	default:
+		return fmt.Errorf("unknown OTLP protocol: %q", o.Protocol)
+	}
+}
+
+func (o *OTLPBackend) sendGRPC(ctx context.Context, request *collogspb.ExportLogsServiceRequest) error {
+	if target := dryRunFrom(ctx); target != nil {
+		body, err := protojson.Marshal(request)
+		if err != nil {
+			return err
+		}
+		return target.record(o.Endpoint, "application/grpc", body)
+	}
+
+	o.connOnce.Do(func() {
+		conf := o.tlsConfig
+		if conf == nil {
+			conf = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		creds := credentials.NewTLS(conf)
+		if o.Insecure {
+			creds = insecure.NewCredentials()
+		}
+		o.conn, o.connErr = grpc.Dial(o.Endpoint, grpc.WithTransportCredentials(creds))
+	})
+	if o.connErr != nil {
+		return o.connErr
+	}
+
+	_, err := collogspb.NewLogsServiceClient(o.conn).Export(withOutgoingMetadata(ctx, o.Headers), request)
+	return err
+}
+
+// withOutgoingMetadata adds the headers, the request ID and the trace context of the delivery to
+// the gRPC metadata of ctx
+func withOutgoingMetadata(ctx context.Context, headers map[string]string) context.Context {
+	md := metadata.New(headers)
+	if id := requestIDFrom(ctx); id != "" {
+		md.Set(strings.ToLower(middleware.OptlyRequestHeader), id)
+	}
+	carrier := propagation.MapCarrier{}
+	traceContext.Inject(ctx, carrier)
+	for k, v := range carrier {
+		md.Set(k, v)
+	}
+	if md.Len() == 0 {
+		return ctx
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func (o *OTLPBackend) exportRequest(events []Event) *collogspb.ExportLogsServiceRequest {
+	serviceName := o.ServiceName
+	if serviceName == "" {
+		serviceName = defaultOTLPService
+	}
+
+	now := uint64(time.Now().UnixNano())
+	records := make([]*logspb.LogRecord, 0, len(events))
+	for _, e := range events {
+		attrs := []*commonpb.KeyValue{
+			otlpKeyValue("event.name", e.Name),
+			otlpKeyValue("client_id", e.ClientID),
+		}
+		if e.UserID != "" {
+			attrs = append(attrs, otlpKeyValue("user_id", e.UserID))
+		}
+		for k, v := range e.Params {
+			attrs = append(attrs, otlpKeyValue(k, v))
+		}
+
+		record := &logspb.LogRecord{
+			TimeUnixNano:         now,
+			ObservedTimeUnixNano: now,
+			SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+			SeverityText:         "INFO",
+			Body:                 otlpAnyValue(e.Name),
+			Attributes:           attrs,
+		}
+		if e.spanContext.IsValid() {
+			traceID, spanID := e.spanContext.TraceID(), e.spanContext.SpanID()
+			record.TraceId = traceID[:]
+			record.SpanId = spanID[:]
+		}
+		records = append(records, record)
+	}
+
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{otlpKeyValue("service.name", serviceName)},
+			},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: otlpScopeName},
+				LogRecords: records,
+			}},
+		}},
+	}
+}
+
+func otlpKeyValue(key string, value interface{}) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: otlpAnyValue(value)}
+}
+
+func otlpAnyValue(value interface{}) *commonpb.AnyValue {
+	switch v := value.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+	}
+}
+
+func (o *OTLPBackend) setHTTPClient(client *http.Client) {
+	o.client = client
+}
+
+func (o *OTLPBackend) setTLSConfig(conf *tls.Config) {
+	o.tlsConfig = conf
+}
+
+func init() {
+	AddBackend("otlp", func() Backend {
+		return &OTLPBackend{}
+	})
+}
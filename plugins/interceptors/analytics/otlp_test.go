@@ -0,0 +1,127 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/optimizely/agent/config"
+)
+
+var testOTLPEvents = []Event{
+	{Name: "api_request", ClientID: "client", Params: map[string]interface{}{"path": "/v1/decide", "status_code": 200}},
+}
+
+func assertOTLPRequest(t *testing.T, request *collogspb.ExportLogsServiceRequest) {
+	require.Len(t, request.ResourceLogs, 1)
+	resourceLogs := request.ResourceLogs[0]
+	assert.Equal(t, "service.name", resourceLogs.Resource.Attributes[0].Key)
+	assert.Equal(t, "agent-test", resourceLogs.Resource.Attributes[0].Value.GetStringValue())
+
+	require.Len(t, resourceLogs.ScopeLogs, 1)
+	require.Len(t, resourceLogs.ScopeLogs[0].LogRecords, 1)
+	record := resourceLogs.ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, "api_request", record.Body.GetStringValue())
+
+	attrs := map[string]interface{}{}
+	for _, kv := range record.Attributes {
+		switch {
+		case kv.Value.GetStringValue() != "":
+			attrs[kv.Key] = kv.Value.GetStringValue()
+		default:
+			attrs[kv.Key] = kv.Value.GetIntValue()
+		}
+	}
+	assert.Equal(t, map[string]interface{}{
+		"event.name":  "api_request",
+		"client_id":   "client",
+		"path":        "/v1/decide",
+		"status_code": int64(200),
+	}, attrs)
+}
+
+func TestOTLPBackendSendHTTP(t *testing.T) {
+	request := &collogspb.ExportLogsServiceRequest{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, otlpLogsPath, r.URL.Path)
+		assert.Equal(t, otlpProtobufMediaType, r.Header.Get("Content-Type"))
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, proto.Unmarshal(body, request))
+	}))
+	defer ts.Close()
+
+	backend := &OTLPBackend{Endpoint: ts.URL, ServiceName: "agent-test", Headers: map[string]string{"Authorization": "token"}}
+	require.NoError(t, backend.Send(context.Background(), testOTLPEvents))
+	assertOTLPRequest(t, request)
+}
+
+type testLogsServer struct {
+	collogspb.UnimplementedLogsServiceServer
+	requests chan *collogspb.ExportLogsServiceRequest
+	metadata chan metadata.MD
+}
+
+func (s *testLogsServer) Export(ctx context.Context, request *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.metadata <- md
+	s.requests <- request
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func TestOTLPBackendSendGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	logsServer := &testLogsServer{
+		requests: make(chan *collogspb.ExportLogsServiceRequest, 1),
+		metadata: make(chan metadata.MD, 1),
+	}
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, logsServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	backend := &OTLPBackend{
+		Endpoint:    listener.Addr().String(),
+		Protocol:    config.TracingRemoteProtocolGRPC,
+		Insecure:    true,
+		ServiceName: "agent-test",
+		Headers:     map[string]string{"authorization": "token"},
+	}
+	require.NoError(t, backend.Send(context.Background(), testOTLPEvents))
+	assertOTLPRequest(t, <-logsServer.requests)
+	assert.Equal(t, []string{"token"}, (<-logsServer.metadata).Get("authorization"))
+}
+
+func TestOTLPBackendUnknownProtocol(t *testing.T) {
+	backend := &OTLPBackend{Protocol: "udp"}
+	assert.Error(t, backend.Send(context.Background(), testOTLPEvents))
+}
@@ -0,0 +1,206 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	// otherPath replaces the paths collapsed to bound their cardinality
+	otherPath = "other"
+
+	defaultTopPathsInterval = 10 * time.Minute
+)
+
+// PathLabelConfig bounds the number of distinct paths sent to the backends, as paths with
+// embedded IDs create a value per ID
+type PathLabelConfig struct {
+	Templates []string       `json:"templates"` // Templates replacing the paths they match, e.g. /v1/flags/{flagKey}
+	TopK      int            `json:"topK"`      // Most distinct paths sent, rarer ones being sent as "other" (0 sends all)
+	Interval  utils.Duration `json:"interval"`  // How often the most frequent paths are recomputed (defaults to 10m)
+}
+
+// pathTemplate is a parsed path template, where {name} segments match any single segment
+type pathTemplate struct {
+	template string
+	segments []string
+}
+
+func newPathTemplate(template string) (pathTemplate, error) {
+	if !strings.HasPrefix(template, "/") {
+		return pathTemplate{}, fmt.Errorf("invalid analytics path template %q: must start with /", template)
+	}
+	segments := strings.Split(template, "/")
+	for _, segment := range segments {
+		if strings.ContainsAny(segment, "{}") && !isTemplateParam(segment) {
+			return pathTemplate{}, fmt.Errorf("invalid analytics path template %q: malformed segment %q", template, segment)
+		}
+	}
+	return pathTemplate{template: template, segments: segments}, nil
+}
+
+func isTemplateParam(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") &&
+		!strings.ContainsAny(segment[1:len(segment)-1], "{}")
+}
+
+func (t pathTemplate) matches(p string) bool {
+	segments := strings.Split(p, "/")
+	if len(segments) != len(t.segments) {
+		return false
+	}
+	for i, segment := range t.segments {
+		if isTemplateParam(segment) {
+			if segments[i] == "" {
+				return false
+			}
+		} else if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pathLabeler maps request paths to the bounded set of values sent as the path param
+type pathLabeler struct {
+	templates []pathTemplate
+	top       *topPaths
+}
+
+// newPathLabeler returns a labeler for the valid templates along with any errors for the invalid
+// ones, or nil when paths are sent as they are
+func newPathLabeler(conf PathLabelConfig) (*pathLabeler, []error) {
+	l := &pathLabeler{}
+	var errs []error
+	for _, template := range conf.Templates {
+		t, err := newPathTemplate(template)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		l.templates = append(l.templates, t)
+	}
+	if conf.TopK > 0 {
+		l.top = newTopPaths(conf.TopK, conf.Interval.Duration)
+	}
+	if len(l.templates) == 0 && l.top == nil {
+		return nil, errs
+	}
+	return l, errs
+}
+
+// label returns the template of the first template matching p, or p itself, collapsing the
+// values outside the most frequent ones into "other"
+func (l *pathLabeler) label(p string) string {
+	if l == nil {
+		return p
+	}
+	for _, t := range l.templates {
+		if t.matches(p) {
+			p = t.template
+			break
+		}
+	}
+	if l.top != nil {
+		return l.top.label(p, time.Now())
+	}
+	return p
+}
+
+// topPaths keeps the paths sent to the K most frequent of the previous interval. Until the
+// first interval has passed, the first K paths seen are sent.
+type topPaths struct {
+	k        int
+	interval time.Duration
+
+	mu         sync.Mutex
+	counts     map[string]int64 // paths seen in the current interval, bounded to maxTracked
+	top        map[string]bool
+	ranked     bool // whether top holds the ranking of a full interval
+	nextRanked time.Time
+}
+
+// topPathsTracked is the multiple of K of paths counted per interval; rarer paths can't
+// make the top K anyway
+const topPathsTracked = 10
+
+func newTopPaths(k int, interval time.Duration) *topPaths {
+	if interval <= 0 {
+		interval = defaultTopPathsInterval
+	}
+	return &topPaths{
+		k:          k,
+		interval:   interval,
+		counts:     map[string]int64{},
+		top:        map[string]bool{},
+		nextRanked: time.Now().Add(interval),
+	}
+}
+
+func (t *topPaths) label(p string, now time.Time) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !now.Before(t.nextRanked) {
+		t.rank(now)
+	}
+	if _, ok := t.counts[p]; ok || len(t.counts) < t.k*topPathsTracked {
+		t.counts[p]++
+	}
+
+	if t.top[p] {
+		return p
+	}
+	if !t.ranked && len(t.top) < t.k {
+		t.top[p] = true
+		return p
+	}
+	return otherPath
+}
+
+// rank replaces the top paths with the K most frequent of the interval that ended and starts
+// the next one. Callers hold t.mu.
+func (t *topPaths) rank(now time.Time) {
+	paths := make([]string, 0, len(t.counts))
+	for p := range t.counts {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if t.counts[paths[i]] != t.counts[paths[j]] {
+			return t.counts[paths[i]] > t.counts[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > t.k {
+		paths = paths[:t.k]
+	}
+
+	t.top = make(map[string]bool, len(paths))
+	for _, p := range paths {
+		t.top[p] = true
+	}
+	t.ranked = true
+	t.counts = map[string]int64{}
+	t.nextRanked = now.Add(t.interval)
+}
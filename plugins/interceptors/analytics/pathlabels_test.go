@@ -0,0 +1,99 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func TestPathTemplates(t *testing.T) {
+	labeler, errs := newPathLabeler(PathLabelConfig{Templates: []string{
+		"/v1/flags/{flagKey}",
+		"/v1/datafiles/{sdkKey}",
+		"v1/invalid",
+		"/v1/{broken",
+	}})
+	assert.Len(t, errs, 2)
+
+	assert.Equal(t, "/v1/flags/{flagKey}", labeler.label("/v1/flags/checkout"))
+	assert.Equal(t, "/v1/datafiles/{sdkKey}", labeler.label("/v1/datafiles/abc123"))
+	assert.Equal(t, "/v1/flags/", labeler.label("/v1/flags/"))
+	assert.Equal(t, "/v1/flags/checkout/rules", labeler.label("/v1/flags/checkout/rules"))
+	assert.Equal(t, "/v1/decide", labeler.label("/v1/decide"))
+}
+
+func TestPathLabelerDisabled(t *testing.T) {
+	labeler, errs := newPathLabeler(PathLabelConfig{})
+	assert.Empty(t, errs)
+	assert.Nil(t, labeler)
+	assert.Equal(t, "/v1/decide", labeler.label("/v1/decide"))
+}
+
+func TestTopPaths(t *testing.T) {
+	start := time.Now()
+	top := newTopPaths(2, time.Minute)
+
+	// Before the first ranking the first paths seen are kept
+	assert.Equal(t, "/a", top.label("/a", start))
+	assert.Equal(t, "/b", top.label("/b", start))
+	assert.Equal(t, otherPath, top.label("/c", start))
+	for i := 0; i < 5; i++ {
+		top.label("/c", start)
+	}
+
+	// The ranking keeps the most frequent paths of the interval
+	later := start.Add(2 * time.Minute)
+	assert.Equal(t, "/c", top.label("/c", later))
+	assert.Equal(t, "/a", top.label("/a", later))
+	assert.Equal(t, otherPath, top.label("/b", later))
+	assert.Equal(t, otherPath, top.label("/d", later))
+}
+
+func TestTopPathsBoundsTracking(t *testing.T) {
+	top := newTopPaths(1, time.Minute)
+	for i := 0; i < 100; i++ {
+		top.label("/v1/flags/"+string(rune('a'+i%26))+string(rune('a'+i/26)), time.Now())
+	}
+	assert.Len(t, top.counts, topPathsTracked)
+}
+
+func TestAnalyticsPathLabels(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, PathLabels: PathLabelConfig{
+		Templates: []string{"/v1/flags/{flagKey}"},
+		TopK:      1,
+		Interval:  utils.Duration{Duration: time.Hour},
+	}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/flags/checkout", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/flags/banner", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+
+	require.Equal(t, "/v1/flags/{flagKey}", backend.next(t).Params[pathParam])
+	require.Equal(t, "/v1/flags/{flagKey}", backend.next(t).Params[pathParam])
+	require.Equal(t, otherPath, backend.next(t).Params[pathParam])
+}
@@ -0,0 +1,98 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultPostHogHost = "https://us.i.posthog.com"
+
+// PostHogBackend sends events to the PostHog capture API. Host may point at a
+// self-hosted instance and defaults to PostHog Cloud.
+type PostHogBackend struct {
+	APIKey string `json:"apiKey"`
+	Host   string `json:"host"`
+
+	client *http.Client
+}
+
+// postHogEvent is a single event in the PostHog capture format
+type postHogEvent struct {
+	APIKey     string                 `json:"api_key,omitempty"`
+	Event      string                 `json:"event"`
+	DistinctID string                 `json:"distinct_id"`
+	Properties map[string]interface{} `json:"properties"`
+	UUID       string                 `json:"uuid,omitempty"` // deduplicates retried deliveries
+	Timestamp  time.Time              `json:"timestamp"`      // when the event was captured rather than received
+}
+
+// Send posts a single event to /capture/ and multiple events to /batch/
+func (p *PostHogBackend) Send(ctx context.Context, events []Event) error {
+	host := p.Host
+	if host == "" {
+		host = defaultPostHogHost
+	}
+	host = strings.TrimSuffix(host, "/")
+
+	if len(events) == 1 {
+		e := p.toPostHogEvent(events[0])
+		e.APIKey = p.APIKey
+		return postJSON(ctx, p.client, host+"/capture/", e, nil)
+	}
+
+	batch := make([]postHogEvent, 0, len(events))
+	for _, e := range events {
+		batch = append(batch, p.toPostHogEvent(e))
+	}
+	payload := map[string]interface{}{
+		"api_key": p.APIKey,
+		"batch":   batch,
+	}
+	return postJSON(ctx, p.client, host+"/batch/", payload, nil)
+}
+
+func (p *PostHogBackend) toPostHogEvent(e Event) postHogEvent {
+	properties := e.Params
+	if len(e.UserProperties) > 0 {
+		properties = make(map[string]interface{}, len(e.Params)+1)
+		for k, v := range e.Params {
+			properties[k] = v
+		}
+		properties["$set"] = e.UserProperties
+	}
+	return postHogEvent{
+		Event:      e.Name,
+		DistinctID: e.ClientID,
+		Properties: properties,
+		UUID:       e.IdempotencyKey,
+		Timestamp:  eventTime(e, time.Now()).UTC(),
+	}
+}
+
+func (p *PostHogBackend) setHTTPClient(client *http.Client) {
+	p.client = client
+}
+
+func init() {
+	AddBackend("posthog", func() Backend {
+		return &PostHogBackend{}
+	})
+}
@@ -0,0 +1,97 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostHogBackendSendSingleEvent(t *testing.T) {
+	var payload map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/capture/", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	backend := &PostHogBackend{APIKey: "phc_test", Host: ts.URL + "/"}
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "client", Params: map[string]interface{}{"path": "/v1/decide"}, IdempotencyKey: "key"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "phc_test", payload["api_key"])
+	assert.Equal(t, "key", payload["uuid"])
+	assert.Equal(t, "api_request", payload["event"])
+	assert.Equal(t, "client", payload["distinct_id"])
+	assert.NotEmpty(t, payload["timestamp"])
+	assert.Equal(t, map[string]interface{}{"path": "/v1/decide"}, payload["properties"])
+}
+
+func TestPostHogBackendSendUserProperties(t *testing.T) {
+	var payload map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	params := map[string]interface{}{"path": "/v1/decide"}
+	backend := &PostHogBackend{Host: ts.URL}
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "client", Params: params, UserProperties: map[string]interface{}{"plan": "pro"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"path": "/v1/decide",
+		"$set": map[string]interface{}{"plan": "pro"},
+	}, payload["properties"])
+	// The event's params are left untouched
+	assert.NotContains(t, params, "$set")
+}
+
+func TestPostHogBackendSendBatch(t *testing.T) {
+	var payload struct {
+		APIKey string                   `json:"api_key"`
+		Batch  []map[string]interface{} `json:"batch"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/batch/", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	backend := &PostHogBackend{APIKey: "phc_test", Host: ts.URL}
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a"},
+		{Name: "api_request", ClientID: "b"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "phc_test", payload.APIKey)
+	require.Len(t, payload.Batch, 2)
+	assert.Equal(t, "a", payload.Batch[0]["distinct_id"])
+	assert.Nil(t, payload.Batch[0]["api_key"])
+	assert.Equal(t, "b", payload.Batch[1]["distinct_id"])
+}
@@ -0,0 +1,101 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+const defaultShedThreshold = 0.5
+
+// defaultLowPriorityPaths are the paths of requests that don't reflect how features are used
+var defaultLowPriorityPaths = []string{datafilePath, "/v1/config", "/webhooks/**", "/health"}
+
+// PriorityConfig splits the dispatch queue into two lanes, so that under pressure events of
+// decisions and conversions are kept while those of datafile fetches and health checks are shed
+type PriorityConfig struct {
+	Enabled bool `json:"enabled"`
+	// Glob patterns of the request paths whose events are low priority (defaults to the datafile,
+	// config, webhook and health endpoints)
+	LowPriorityPaths []string `json:"lowPriorityPaths"`
+	LowQueueSize     int      `json:"lowQueueSize"`  // Capacity of the low priority lane (defaults to a quarter of queueSize)
+	ShedThreshold    float64  `json:"shedThreshold"` // Fill of the high priority lane, 0.0–1.0, from which low priority events are shed (defaults to 0.5)
+}
+
+// priorityLanes classifies events into the lanes of the dispatch queue. A nil value puts every
+// event in the high priority lane.
+type priorityLanes struct {
+	lowPriorityPaths []string
+	shedThreshold    float64
+}
+
+// newPriorityLanes returns the lanes of the config along with the errors of invalid patterns
+func newPriorityLanes(conf PriorityConfig) (*priorityLanes, []error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+
+	p := &priorityLanes{shedThreshold: conf.ShedThreshold}
+	if p.shedThreshold <= 0 || p.shedThreshold > 1 {
+		p.shedThreshold = defaultShedThreshold
+	}
+	patterns := conf.LowPriorityPaths
+	if len(patterns) == 0 {
+		patterns = defaultLowPriorityPaths
+	}
+	var errs []error
+	for _, pattern := range patterns {
+		if err := validateGlob(pattern); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		p.lowPriorityPaths = append(p.lowPriorityPaths, pattern)
+	}
+	return p, errs
+}
+
+// lowPriority reports whether the event goes to the low priority lane. Events without a path,
+// e.g. SDK notifications, are high priority.
+func (p *priorityLanes) lowPriority(event Event) bool {
+	if p == nil {
+		return false
+	}
+	path, _ := event.Params[pathParam].(string)
+	if path == "" {
+		return false
+	}
+	for _, pattern := range p.lowPriorityPaths {
+		if matchGlob(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// shed reports whether a low priority event is shed with the high priority lane at the given
+// depth, leaving room for the events that matter
+func (p *priorityLanes) shed(depth, capacity int) bool {
+	return float64(depth) >= p.shedThreshold*float64(capacity)
+}
+
+// lowQueueSize returns the capacity of the low priority lane next to a high priority lane of
+// queueSize events
+func (conf PriorityConfig) lowQueueSize(queueSize int) int {
+	if conf.LowQueueSize > 0 {
+		return conf.LowQueueSize
+	}
+	if size := queueSize / 4; size > 0 {
+		return size
+	}
+	return 1
+}
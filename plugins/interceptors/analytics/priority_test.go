@@ -0,0 +1,100 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pathEvent(path string) Event {
+	return Event{Name: "api_request", Params: map[string]interface{}{pathParam: path}}
+}
+
+func TestPriorityLanesDisabled(t *testing.T) {
+	lanes, errs := newPriorityLanes(PriorityConfig{})
+	assert.Nil(t, lanes)
+	assert.Empty(t, errs)
+
+	// Without lanes every event is high priority
+	assert.False(t, lanes.lowPriority(pathEvent("/v1/datafile")))
+}
+
+func TestPriorityLanesLowPriority(t *testing.T) {
+	lanes, errs := newPriorityLanes(PriorityConfig{Enabled: true})
+	require.Empty(t, errs)
+	assert.True(t, lanes.lowPriority(pathEvent("/v1/datafile")))
+	assert.True(t, lanes.lowPriority(pathEvent("/webhooks/optimizely")))
+	assert.False(t, lanes.lowPriority(pathEvent("/v1/decide")))
+	assert.False(t, lanes.lowPriority(Event{Name: "decision"}))
+
+	lanes, errs = newPriorityLanes(PriorityConfig{Enabled: true, LowPriorityPaths: []string{"/v1/lookup", "["}})
+	assert.Len(t, errs, 1)
+	assert.True(t, lanes.lowPriority(pathEvent("/v1/lookup")))
+	assert.False(t, lanes.lowPriority(pathEvent("/v1/datafile")))
+}
+
+func TestPriorityConfigLowQueueSize(t *testing.T) {
+	assert.Equal(t, 250, PriorityConfig{}.lowQueueSize(1000))
+	assert.Equal(t, 1, PriorityConfig{}.lowQueueSize(2))
+	assert.Equal(t, 10, PriorityConfig{LowQueueSize: 10}.lowQueueSize(1000))
+}
+
+func TestDispatcherShedsLowPriorityEvents(t *testing.T) {
+	m := newAnalyticsMetrics()
+	lanes, _ := newPriorityLanes(PriorityConfig{Enabled: true})
+	// No workers are started so the lanes are never drained
+	d := &dispatcher{
+		queue:    make(chan Event, 4),
+		lowQueue: make(chan Event, 1),
+		lanes:    lanes,
+		metrics:  m,
+		ctx:      context.Background(),
+	}
+
+	assert.True(t, d.enqueue(pathEvent("/v1/datafile")))
+	// The low priority lane is full
+	assert.False(t, d.enqueue(pathEvent("/v1/config")))
+	<-d.lowQueue
+
+	assert.True(t, d.enqueue(pathEvent("/v1/decide")))
+	assert.True(t, d.enqueue(pathEvent("/v1/track")))
+	// The high priority lane is half full, so low priority events are shed
+	assert.False(t, d.enqueue(pathEvent("/v1/datafile")))
+	assert.True(t, d.enqueue(pathEvent("/v1/decide")))
+	assert.Equal(t, int64(2), d.stats.shed.Load())
+	assert.Equal(t, 3, d.queued())
+	assert.Equal(t, 5, d.capacity())
+}
+
+func TestDispatcherDeliversHighPriorityFirst(t *testing.T) {
+	d := &dispatcher{queue: make(chan Event, 2), lowQueue: make(chan Event, 2)}
+	d.lowQueue <- pathEvent("/v1/datafile")
+	d.queue <- pathEvent("/v1/decide")
+	d.queue <- pathEvent("/v1/track")
+	close(d.queue)
+	close(d.lowQueue)
+
+	var paths []interface{}
+	for event, ok := d.next(); ok; event, ok = d.next() {
+		paths = append(paths, event.Params[pathParam])
+	}
+	assert.Equal(t, []interface{}{"/v1/decide", "/v1/track", "/v1/datafile"}, paths)
+}
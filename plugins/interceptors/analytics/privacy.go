@@ -0,0 +1,109 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	ipKeep = "keep"
+	ipHash = "hash"
+	ipDrop = "drop"
+
+	pathParam = "path"
+)
+
+// PrivacyConfig controls how personal data is recorded before events are dispatched
+type PrivacyConfig struct {
+	IPAddress        string   `json:"ipAddress"`        // "keep" (default), "hash" or "drop"
+	HashClientID     bool     `json:"hashClientID"`     // Replace client and user IDs with a salted hash
+	Salt             string   `json:"salt"`             // Secret salt for hashed IP addresses and client IDs
+	IncludeQuery     bool     `json:"includeQuery"`     // Record the query string as part of the path
+	StripQueryParams []string `json:"stripQueryParams"` // Query params removed from the recorded path, e.g. tokens and emails
+}
+
+// validatePrivacy checks the IP address mode, falling back to dropping IP addresses when it is
+// unknown so that a typo never leaks raw addresses
+func validatePrivacy(conf PrivacyConfig) (PrivacyConfig, error) {
+	switch conf.IPAddress {
+	case "", ipKeep, ipHash, ipDrop:
+		return conf, nil
+	default:
+		mode := conf.IPAddress
+		conf.IPAddress = ipDrop
+		return conf, fmt.Errorf("unknown analytics privacy ipAddress mode %q, dropping IP addresses", mode)
+	}
+}
+
+// applyPrivacy redacts the event according to the privacy settings. rawQuery is the
+// request's query string, recorded in the path param only when IncludeQuery is set.
+func applyPrivacy(event *Event, conf PrivacyConfig, rawQuery string) {
+	switch conf.IPAddress {
+	case ipDrop:
+		delete(event.Params, ipAddressParam)
+	case ipHash:
+		if ip, ok := event.Params[ipAddressParam].(string); ok {
+			event.Params[ipAddressParam] = saltedHash(conf.Salt, ip)
+		}
+	}
+
+	if conf.HashClientID && event.ClientID != "" {
+		event.ClientID = saltedHash(conf.Salt, event.ClientID)
+	}
+	if conf.HashClientID && event.UserID != "" {
+		event.UserID = saltedHash(conf.Salt, event.UserID)
+	}
+
+	if conf.IncludeQuery && rawQuery != "" {
+		if query := stripQueryParams(rawQuery, conf.StripQueryParams); query != "" {
+			if p, ok := event.Params[pathParam].(string); ok {
+				event.Params[pathParam] = p + "?" + query
+			}
+		}
+	}
+}
+
+// saltedHash returns a keyed, non-reversible digest of value
+func saltedHash(salt, value string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// stripQueryParams removes the named params (case-insensitively) from rawQuery. An unparsable
+// query is dropped entirely rather than risk recording the params it was meant to strip.
+func stripQueryParams(rawQuery string, strip []string) string {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	for name := range query {
+		for _, s := range strip {
+			if strings.EqualFold(name, s) {
+				query.Del(name)
+				break
+			}
+		}
+	}
+	return query.Encode()
+}
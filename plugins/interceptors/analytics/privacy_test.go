@@ -0,0 +1,101 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func privacyEvent() Event {
+	return Event{
+		ClientID: "client-1",
+		Params:   map[string]interface{}{pathParam: "/v1/decide", ipAddressParam: "203.0.113.7"},
+	}
+}
+
+func TestValidatePrivacy(t *testing.T) {
+	for _, mode := range []string{"", ipKeep, ipHash, ipDrop} {
+		conf, err := validatePrivacy(PrivacyConfig{IPAddress: mode})
+		assert.NoError(t, err)
+		assert.Equal(t, mode, conf.IPAddress)
+	}
+
+	conf, err := validatePrivacy(PrivacyConfig{IPAddress: "hashed"})
+	assert.Error(t, err)
+	assert.Equal(t, ipDrop, conf.IPAddress)
+}
+
+func TestApplyPrivacyDefaultsKeepEverything(t *testing.T) {
+	event := privacyEvent()
+	applyPrivacy(&event, PrivacyConfig{}, "token=secret")
+	assert.Equal(t, privacyEvent(), event)
+}
+
+func TestApplyPrivacyIPAddress(t *testing.T) {
+	event := privacyEvent()
+	applyPrivacy(&event, PrivacyConfig{IPAddress: ipDrop}, "")
+	assert.NotContains(t, event.Params, ipAddressParam)
+
+	event = privacyEvent()
+	applyPrivacy(&event, PrivacyConfig{IPAddress: ipHash, Salt: "salt"}, "")
+	assert.Equal(t, saltedHash("salt", "203.0.113.7"), event.Params[ipAddressParam])
+	assert.NotEqual(t, saltedHash("other", "203.0.113.7"), event.Params[ipAddressParam])
+}
+
+func TestApplyPrivacyHashClientID(t *testing.T) {
+	event := privacyEvent()
+	event.UserID = "user-1"
+	applyPrivacy(&event, PrivacyConfig{HashClientID: true, Salt: "salt"}, "")
+	assert.Equal(t, saltedHash("salt", "client-1"), event.ClientID)
+	assert.Equal(t, saltedHash("salt", "user-1"), event.UserID)
+	assert.Len(t, event.ClientID, 32)
+}
+
+func TestApplyPrivacyQuery(t *testing.T) {
+	event := privacyEvent()
+	applyPrivacy(&event, PrivacyConfig{IncludeQuery: true, StripQueryParams: []string{"token", "EMAIL"}},
+		"keys=checkout&token=secret&email=a%40b.com")
+	assert.Equal(t, "/v1/decide?keys=checkout", event.Params[pathParam])
+
+	// Nothing is appended when every param is stripped
+	event = privacyEvent()
+	applyPrivacy(&event, PrivacyConfig{IncludeQuery: true, StripQueryParams: []string{"token"}}, "token=secret")
+	assert.Equal(t, "/v1/decide", event.Params[pathParam])
+}
+
+func TestStripQueryParamsDropsInvalidQuery(t *testing.T) {
+	assert.Equal(t, "", stripQueryParams("token=%zz", []string{"token"}))
+}
+
+func TestAnalyticsPrivacy(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Privacy: PrivacyConfig{IPAddress: "unknown", HashClientID: true, Salt: "salt"}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.AddCookie(&http.Cookie{Name: "_ga", Value: "client-1"})
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := backend.next(t)
+	assert.Equal(t, saltedHash("salt", "client-1"), event.ClientID)
+	assert.NotContains(t, event.Params, ipAddressParam)
+}
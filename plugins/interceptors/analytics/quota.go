@@ -0,0 +1,201 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	quotaBySDKKey   = "sdkKey"
+	quotaByClientID = "clientID"
+
+	quotaDrop      = "drop"
+	quotaSample    = "sample"
+	quotaAggregate = "aggregate"
+
+	defaultQuotaSampleRate = 0.1
+	defaultQuotaMaxTenants = 10000
+
+	// otherTenant shares a budget among the tenants beyond maxTenants
+	otherTenant = "other"
+)
+
+// QuotaConfig bounds the events each tenant may send per clock hour and per UTC day, so one noisy
+// tenant cannot consume the quota of the analytics backends shared by all tenants
+type QuotaConfig struct {
+	Key        string                `json:"key"`        // What identifies a tenant: "sdkKey" (default) or "clientID"
+	Hourly     int64                 `json:"hourly"`     // Events per tenant per hour (0 is unlimited)
+	Daily      int64                 `json:"daily"`      // Events per tenant per day (0 is unlimited)
+	Tenants    map[string]QuotaLimit `json:"tenants"`    // Budgets of individual tenants replacing hourly and daily
+	Overflow   string                `json:"overflow"`   // What happens to events over budget: "drop" (default), "sample" or "aggregate"
+	SampleRate float64               `json:"sampleRate"` // Fraction of events over budget kept with "sample" (defaults to 0.1)
+	MaxTenants int                   `json:"maxTenants"` // Most tenants tracked per day, others share one budget (defaults to 10000)
+}
+
+// QuotaLimit is the budget of a tenant
+type QuotaLimit struct {
+	Hourly int64 `json:"hourly"` // Events per hour (0 is unlimited)
+	Daily  int64 `json:"daily"`  // Events per day (0 is unlimited)
+}
+
+func (c QuotaConfig) enabled() bool {
+	if c.Hourly > 0 || c.Daily > 0 {
+		return true
+	}
+	for _, limit := range c.Tenants {
+		if limit.Hourly > 0 || limit.Daily > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantUsage counts the events of a tenant in the current hour and day
+type tenantUsage struct {
+	hour   time.Time
+	hourly int64
+	daily  int64
+}
+
+// quotas tracks the usage of every tenant against its budget
+type quotas struct {
+	key        string
+	limit      QuotaLimit
+	tenants    map[string]QuotaLimit
+	overflow   string
+	sampleRate float64
+	maxTenants int
+	now        func() time.Time
+
+	mu    sync.Mutex
+	day   time.Time
+	usage map[string]*tenantUsage
+}
+
+// newQuotas returns nil when no budget is configured. With hashSDKKey, tenants are also matched
+// by the digests of their SDK keys.
+func newQuotas(conf QuotaConfig, hashedSDKKeys bool) *quotas {
+	if !conf.enabled() {
+		return nil
+	}
+	q := &quotas{
+		key:        conf.Key,
+		limit:      QuotaLimit{Hourly: conf.Hourly, Daily: conf.Daily},
+		tenants:    make(map[string]QuotaLimit, len(conf.Tenants)),
+		overflow:   conf.Overflow,
+		sampleRate: conf.SampleRate,
+		maxTenants: conf.MaxTenants,
+		now:        time.Now,
+		usage:      map[string]*tenantUsage{},
+	}
+	if q.key == "" {
+		q.key = quotaBySDKKey
+	}
+	if q.overflow == "" {
+		q.overflow = quotaDrop
+	}
+	if q.sampleRate <= 0 {
+		q.sampleRate = defaultQuotaSampleRate
+	}
+	if q.maxTenants <= 0 {
+		q.maxTenants = defaultQuotaMaxTenants
+	}
+	for tenant, limit := range conf.Tenants {
+		q.tenants[tenant] = limit
+		if hashedSDKKeys && q.key == quotaBySDKKey {
+			q.tenants[hashSDKKey(tenant)] = limit
+		}
+	}
+	return q
+}
+
+// tenant returns the tenant an event is counted against
+func (q *quotas) tenant(event Event) string {
+	if q.key == quotaByClientID {
+		return event.ClientID
+	}
+	sdkKey, _ := event.Params[sdkKeyParam].(string)
+	return sdkKey
+}
+
+// admit counts an event against the budget of its tenant, reporting whether it is within it.
+// Events over budget are not counted, so they don't extend the overrun into the next window.
+func (q *quotas) admit(event Event) bool {
+	tenant := q.tenant(event)
+	limit, ok := q.tenants[tenant]
+	if !ok {
+		limit = q.limit
+	}
+
+	now := q.now().UTC()
+	hour, day := now.Truncate(time.Hour), now.Truncate(24*time.Hour)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// Tenants are forgotten every day, bounding the memory held for client IDs
+	if !day.Equal(q.day) {
+		q.day = day
+		q.usage = map[string]*tenantUsage{}
+	}
+	usage, ok := q.usage[tenant]
+	if !ok {
+		if len(q.usage) >= q.maxTenants {
+			tenant, limit = otherTenant, q.limit
+			usage = q.usage[tenant]
+		}
+		if usage == nil {
+			usage = &tenantUsage{}
+			q.usage[tenant] = usage
+		}
+	}
+	if !hour.Equal(usage.hour) {
+		usage.hour, usage.hourly = hour, 0
+	}
+
+	if (limit.Hourly > 0 && usage.hourly >= limit.Hourly) || (limit.Daily > 0 && usage.daily >= limit.Daily) {
+		return false
+	}
+	usage.hourly++
+	usage.daily++
+	return true
+}
+
+// withinQuota reports whether an event may be dispatched under the budget of its tenant. Events
+// over budget are sampled, rolled up or dropped according to the overflow policy.
+func (a *Analytics) withinQuota(event *Event) bool {
+	if a.quotas == nil || a.quotas.admit(*event) {
+		return true
+	}
+
+	a.metrics.quotaExceeded.Add(1)
+	switch a.quotas.overflow {
+	case quotaSample:
+		if sampled(a.quotas.sampleRate, event.ClientID, a.SampleByClientID) {
+			applySampleRate(event, a.quotas.sampleRate)
+			return true
+		}
+	case quotaAggregate:
+		if a.aggregator != nil {
+			a.aggregator.record(*event)
+			return false
+		}
+	}
+	a.auditor.count(auditOverQuota)
+	return false
+}
@@ -0,0 +1,174 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+func TestNewQuotasDisabled(t *testing.T) {
+	assert.Nil(t, newQuotas(QuotaConfig{}, false))
+	assert.Nil(t, newQuotas(QuotaConfig{Tenants: map[string]QuotaLimit{"a": {}}}, false))
+	assert.NotNil(t, newQuotas(QuotaConfig{Tenants: map[string]QuotaLimit{"a": {Daily: 1}}}, false))
+}
+
+func TestQuotasHourlyAndDaily(t *testing.T) {
+	now := time.Date(2025, 6, 2, 10, 59, 0, 0, time.UTC)
+	q := newQuotas(QuotaConfig{Hourly: 2, Daily: 3}, false)
+	q.now = func() time.Time { return now }
+
+	a, b := sdkKeyEvent("a"), sdkKeyEvent("b")
+	assert.True(t, q.admit(a))
+	assert.True(t, q.admit(a))
+	assert.False(t, q.admit(a), "hourly budget exceeded")
+	assert.True(t, q.admit(b), "tenants have their own budgets")
+
+	now = now.Add(time.Minute)
+	assert.True(t, q.admit(a), "new hour")
+	assert.False(t, q.admit(a), "daily budget exceeded")
+
+	now = now.Add(13 * time.Hour)
+	assert.True(t, q.admit(a), "new day")
+}
+
+func TestQuotasTenants(t *testing.T) {
+	q := newQuotas(QuotaConfig{
+		Key:     quotaByClientID,
+		Hourly:  1,
+		Tenants: map[string]QuotaLimit{"big": {Hourly: 3}, "unlimited": {}},
+	}, false)
+
+	small := Event{ClientID: "small"}
+	assert.True(t, q.admit(small))
+	assert.False(t, q.admit(small))
+
+	big := Event{ClientID: "big"}
+	for i := 0; i < 3; i++ {
+		assert.True(t, q.admit(big))
+	}
+	assert.False(t, q.admit(big))
+
+	unlimited := Event{ClientID: "unlimited"}
+	for i := 0; i < 10; i++ {
+		assert.True(t, q.admit(unlimited))
+	}
+}
+
+func TestQuotasHashedSDKKeys(t *testing.T) {
+	q := newQuotas(QuotaConfig{Hourly: 1, Tenants: map[string]QuotaLimit{"project-a": {Hourly: 2}}}, true)
+	hashed := sdkKeyEvent(hashSDKKey("project-a"))
+	assert.True(t, q.admit(hashed))
+	assert.True(t, q.admit(hashed))
+	assert.False(t, q.admit(hashed))
+}
+
+func TestQuotasMaxTenants(t *testing.T) {
+	q := newQuotas(QuotaConfig{Key: quotaByClientID, Hourly: 1, MaxTenants: 1}, false)
+	assert.True(t, q.admit(Event{ClientID: "a"}))
+
+	// Tenants beyond maxTenants share a budget
+	assert.True(t, q.admit(Event{ClientID: "b"}))
+	assert.False(t, q.admit(Event{ClientID: "c"}))
+	assert.Len(t, q.usage, 2)
+}
+
+// serveQuotaRequests serves requests of a client and SDK key with a quota of one event per hour
+func serveQuotaRequests(t *testing.T, a *Analytics, clientID string, requests int) {
+	a.Enabled = true
+	a.Quotas.Hourly = 1
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.destinations = []destination{{name: "mock", backend: newMockBackend()}}
+	require.NoError(t, a.Start(context.Background()))
+	t.Cleanup(func() { a.Stop(context.Background()) })
+
+	for i := 0; i < requests; i++ {
+		req := httptest.NewRequest("GET", "/v1/config", nil)
+		req.Header.Set(middleware.OptlySDKHeader, "noisy")
+		req.AddCookie(&http.Cookie{Name: "_ga", Value: clientID})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func TestAnalyticsQuotaDrop(t *testing.T) {
+	a := &Analytics{}
+	before := expvarValue("counter.analytics.requests.quotaExceeded")
+	serveQuotaRequests(t, a, "client", 3)
+
+	backend := a.destinations[0].backend.(*mockBackend)
+	backend.next(t)
+	select {
+	case e := <-backend.events:
+		t.Fatalf("unexpected event over quota: %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, before+2, expvarValue("counter.analytics.requests.quotaExceeded"))
+}
+
+func TestAnalyticsQuotaSample(t *testing.T) {
+	a := &Analytics{Quotas: QuotaConfig{Overflow: quotaSample, SampleRate: 0.5}, SampleByClientID: true}
+	// Overflow sampled by client ID is either always or never kept
+	kept, dropped := "", ""
+	for i := 0; kept == "" || dropped == ""; i++ {
+		clientID := fmt.Sprintf("client-%d", i)
+		if clientFraction(clientID) < 0.5 {
+			kept = clientID
+		} else {
+			dropped = clientID
+		}
+	}
+	serveQuotaRequests(t, a, kept, 2)
+
+	backend := a.destinations[0].backend.(*mockBackend)
+	assert.Nil(t, backend.next(t).Params[sampleRateParam])
+	assert.Equal(t, 0.5, backend.next(t).Params[sampleRateParam])
+
+	b := &Analytics{Quotas: a.Quotas, SampleByClientID: true}
+	serveQuotaRequests(t, b, dropped, 2)
+	backend = b.destinations[0].backend.(*mockBackend)
+	backend.next(t)
+	select {
+	case e := <-backend.events:
+		t.Fatalf("unexpected event over quota: %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAnalyticsQuotaAggregate(t *testing.T) {
+	a := &Analytics{Quotas: QuotaConfig{Overflow: quotaAggregate}}
+	serveQuotaRequests(t, a, "client", 3)
+
+	backend := a.destinations[0].backend.(*mockBackend)
+	assert.Equal(t, "api_request", backend.next(t).Name)
+	require.NotNil(t, a.aggregator)
+
+	// The rollups of the overflow are sent on stop
+	require.NoError(t, a.Stop(context.Background()))
+	rollup := backend.next(t)
+	assert.Equal(t, defaultAggregationEventName, rollup.Name)
+	assert.EqualValues(t, 2, rollup.Params[requestCountParam])
+	assert.Equal(t, "noisy", rollup.Params[sdkKeyParam])
+}
@@ -0,0 +1,137 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitDrop   = "drop"
+	rateLimitSample = "sample"
+	rateLimitSpill  = "spill"
+)
+
+// RateLimitConfig configures the token bucket that bounds the rate of dispatched events
+type RateLimitConfig struct {
+	MaxEventsPerSecond float64 `json:"maxEventsPerSecond"` // Sustained event rate (0 disables rate limiting)
+	Burst              int     `json:"burst"`              // Bucket size (defaults to MaxEventsPerSecond rounded up)
+	Policy             string  `json:"policy"`             // What happens to excess events: "drop" (default), "sample" or "spill"
+}
+
+// rateLimiter is a token bucket that also tracks the observed arrival rate, so that excess
+// traffic can be sampled uniformly instead of only keeping the first events of every second
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	policy string
+	now    func() time.Time
+
+	mu           sync.Mutex
+	tokens       float64
+	last         time.Time
+	windowStart  time.Time
+	windowCount  float64
+	observedRate float64
+}
+
+// newRateLimiter returns nil when rate limiting is disabled by the config
+func newRateLimiter(conf RateLimitConfig) *rateLimiter {
+	if conf.MaxEventsPerSecond <= 0 {
+		return nil
+	}
+
+	burst := float64(conf.Burst)
+	if burst <= 0 {
+		burst = math.Ceil(conf.MaxEventsPerSecond)
+	}
+	policy := conf.Policy
+	if policy == "" {
+		policy = rateLimitDrop
+	}
+
+	return &rateLimiter{
+		rate:   conf.MaxEventsPerSecond,
+		burst:  burst,
+		policy: policy,
+		now:    time.Now,
+		tokens: burst,
+	}
+}
+
+// admit decides whether an event may be dispatched. With the "sample" policy events are
+// admitted with probability rate/observed arrival rate, which keeps the admitted rate near the
+// limit while sampling uniformly across each second; the probability is returned so that
+// the event can be tagged with it. The other policies use the token bucket.
+func (l *rateLimiter) admit() (bool, float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.policy == rateLimitSample {
+		// The count of the current window is a lower bound of its rate, so that the first
+		// window and sudden bursts are sampled before the window closes
+		observed := math.Max(l.observe(now), l.windowCount)
+		if observed <= l.rate {
+			return true, 1
+		}
+		probability := l.rate / observed
+		return rand.Float64() < probability, probability
+	}
+
+	return l.take(now), 1
+}
+
+// allow takes a token from the bucket if one is available
+func (l *rateLimiter) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.take(l.now())
+}
+
+func (l *rateLimiter) take(now time.Time) bool {
+	l.refill(now)
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+func (l *rateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+}
+
+// observe counts an arrival in the current one second window and returns the arrival rate
+// estimated from the previous window
+func (l *rateLimiter) observe(now time.Time) float64 {
+	if l.windowStart.IsZero() {
+		l.windowStart = now
+	}
+	if elapsed := now.Sub(l.windowStart); elapsed >= time.Second {
+		l.observedRate = l.windowCount / elapsed.Seconds()
+		l.windowStart, l.windowCount = now, 0
+	}
+	l.windowCount++
+	return l.observedRate
+}
@@ -0,0 +1,150 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterDisabled(t *testing.T) {
+	assert.Nil(t, newRateLimiter(RateLimitConfig{}))
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	l := newRateLimiter(RateLimitConfig{MaxEventsPerSecond: 2, Burst: 3})
+	require.NotNil(t, l)
+	assert.Equal(t, rateLimitDrop, l.policy)
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.allow())
+	}
+	assert.False(t, l.allow())
+
+	// Tokens refill at the configured rate, up to the burst
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, l.allow())
+	assert.False(t, l.allow())
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.allow())
+	}
+	assert.False(t, l.allow())
+}
+
+func TestRateLimiterBurstDefaultsToRate(t *testing.T) {
+	l := newRateLimiter(RateLimitConfig{MaxEventsPerSecond: 1.5})
+	assert.Equal(t, 2.0, l.burst)
+}
+
+func TestRateLimiterSample(t *testing.T) {
+	l := newRateLimiter(RateLimitConfig{MaxEventsPerSecond: 10, Policy: rateLimitSample})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	// Nothing is sampled until the limit is reached, also in the first window
+	for i := 0; i < 10; i++ {
+		admitted, rate := l.admit()
+		assert.True(t, admitted)
+		assert.Equal(t, 1.0, rate)
+	}
+	admittedCount := 0
+	for i := 10; i < 100; i++ {
+		admitted, rate := l.admit()
+		assert.InDelta(t, 10.0/float64(i+1), rate, 1e-9)
+		if admitted {
+			admittedCount++
+		}
+	}
+	assert.InDelta(t, 23, admittedCount, 15)
+
+	// Later windows sample by the rate of the previous one
+	now = now.Add(time.Second)
+	admittedCount = 0
+	for i := 0; i < 1000; i++ {
+		admitted, rate := l.admit()
+		if i < 100 {
+			assert.Equal(t, 0.1, rate)
+		}
+		if admitted {
+			admittedCount++
+		}
+	}
+	assert.InDelta(t, 33, admittedCount, 20)
+}
+
+func TestDispatcherRateLimitDrop(t *testing.T) {
+	backend := newMockBackend()
+	d := newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{
+		rateLimit: RateLimitConfig{MaxEventsPerSecond: 0.001, Burst: 1},
+	}, newAnalyticsMetrics())
+
+	before := expvarValue("counter.analytics.dispatch.rateLimited")
+	assert.True(t, d.enqueue(Event{Name: "first"}))
+	assert.False(t, d.enqueue(Event{Name: "second"}))
+	assert.Equal(t, "first", backend.next(t).Name)
+	assert.Equal(t, before+1, expvarValue("counter.analytics.dispatch.rateLimited"))
+}
+
+func TestDispatcherRateLimitSampleTagsEvents(t *testing.T) {
+	backend := newMockBackend()
+	d := newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{
+		rateLimit: RateLimitConfig{MaxEventsPerSecond: 1, Policy: rateLimitSample},
+	}, newAnalyticsMetrics())
+
+	now := time.Now()
+	d.limiter.now = func() time.Time { return now }
+	d.limiter.observedRate = 1
+	d.limiter.windowStart = now
+	d.limiter.windowCount = 1
+
+	// An observed rate of one event per second admits everything untagged
+	now = now.Add(time.Second)
+	assert.True(t, d.enqueue(Event{Name: "api_request", Params: map[string]interface{}{}}))
+	_, tagged := backend.next(t).Params[sampleRateParam]
+	assert.False(t, tagged)
+}
+
+func TestDispatcherRateLimitSpill(t *testing.T) {
+	backend := newMockBackend()
+	d := newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{
+		rateLimit: RateLimitConfig{MaxEventsPerSecond: 0.001, Burst: 1, Policy: rateLimitSpill},
+		spill:     SpillConfig{Directory: t.TempDir()},
+	}, newAnalyticsMetrics())
+	require.NotNil(t, d.spill)
+
+	assert.True(t, d.enqueue(Event{Name: "first"}))
+	assert.True(t, d.enqueue(Event{Name: "second"}))
+	assert.Equal(t, "first", backend.next(t).Name)
+
+	segments, err := d.spill.segments()
+	require.NoError(t, err)
+	assert.NotEmpty(t, segments)
+
+	// Replay stays within the rate limit
+	assert.Zero(t, d.replay([]spillRecord{{Event: Event{Name: "second"}}}))
+	d.limiter.tokens = 1
+	assert.Equal(t, 1, d.replay([]spillRecord{{Event: Event{Name: "second"}}}))
+	assert.Equal(t, "second", backend.next(t).Name)
+}
@@ -0,0 +1,118 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const defaultRedisStream = "optimizely:agent:analytics"
+
+// RedisStreamsBackend appends events to a Redis stream with XADD, optionally trimming it to an
+// approximate maximum length. Entries keep the fields consumers commonly filter on next to the
+// canonical event in JSON, so consumer groups can route entries without decoding them.
+type RedisStreamsBackend struct {
+	Address  string `json:"host"`     // host:port of the Redis server
+	Password string `json:"password"` // Optional
+	Database int    `json:"database"` // Optional
+	Stream   string `json:"stream"`   // Key of the stream (defaults to optimizely:agent:analytics)
+	MaxLen   int64  `json:"maxLen"`   // Optional: trim the stream to about this many entries
+
+	once   sync.Once
+	client *redis.Client
+}
+
+func (r *RedisStreamsBackend) checkConfig() error {
+	if r.Address == "" {
+		return errors.New("host is required")
+	}
+	if r.MaxLen < 0 {
+		return errors.New("maxLen must not be negative")
+	}
+	return nil
+}
+
+// Send appends the events with a single pipeline of XADD commands
+func (r *RedisStreamsBackend) Send(ctx context.Context, events []Event) error {
+	stream := r.Stream
+	if stream == "" {
+		stream = defaultRedisStream
+	}
+
+	args := make([]*redis.XAddArgs, 0, len(events))
+	payloads := make([][]byte, 0, len(events))
+	for _, e := range events {
+		event := canonicalEvent(e)
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		args = append(args, &redis.XAddArgs{
+			Stream: stream,
+			MaxLen: r.MaxLen,
+			Approx: r.MaxLen > 0,
+			Values: []interface{}{
+				"name", event.Name,
+				"timestamp", event.Timestamp.Format(time.RFC3339Nano),
+				"client_id", event.Client.ID,
+				"request_id", event.Request.ID,
+				"path", event.Request.Path,
+				"event", string(data),
+			},
+		})
+		payloads = append(payloads, data)
+	}
+
+	if target := dryRunFrom(ctx); target != nil {
+		for _, payload := range payloads {
+			if err := target.record("redis://"+r.Address+"/"+stream, "application/json", payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	r.once.Do(func() {
+		r.client = redis.NewClient(&redis.Options{Addr: r.Address, Password: r.Password, DB: r.Database})
+	})
+	pipe := r.client.Pipeline()
+	for _, arg := range args {
+		pipe.XAdd(ctx, arg)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Close closes the connections to Redis
+func (r *RedisStreamsBackend) Close() error {
+	if r.client == nil {
+		return nil
+	}
+	return r.client.Close()
+}
+
+func init() {
+	AddBackend("redisstreams", func() Backend {
+		return &RedisStreamsBackend{}
+	})
+}
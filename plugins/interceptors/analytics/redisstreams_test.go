@@ -0,0 +1,164 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redisTestServer speaks enough RESP to test the backend, recording the commands it receives.
+// XADD is answered with an error when xaddError is set.
+type redisTestServer struct {
+	listener  net.Listener
+	xaddError string
+
+	mu       sync.Mutex
+	commands [][]string
+}
+
+func newRedisTestServer(t *testing.T) *redisTestServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &redisTestServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return s
+}
+
+func (s *redisTestServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, "*") {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		command := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			header, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+			arg := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, arg); err != nil {
+				return
+			}
+			command = append(command, string(arg[:size]))
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, command)
+		xaddError, id := s.xaddError, len(s.commands)%10
+		s.mu.Unlock()
+
+		reply := "+OK\r\n"
+		if strings.EqualFold(command[0], "xadd") {
+			reply = fmt.Sprintf("$3\r\n%d-0\r\n", id)
+			if xaddError != "" {
+				reply = "-" + xaddError + "\r\n"
+			}
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// xadds returns the XADD commands received
+func (s *redisTestServer) xadds() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var xadds [][]string
+	for _, command := range s.commands {
+		if strings.EqualFold(command[0], "xadd") {
+			xadds = append(xadds, command)
+		}
+	}
+	return xadds
+}
+
+func TestRedisStreamsBackendSend(t *testing.T) {
+	server := newRedisTestServer(t)
+	backend := &RedisStreamsBackend{Address: server.listener.Addr().String(), Password: "secret", Database: 2, Stream: "events", MaxLen: 1000}
+	require.NoError(t, backend.checkConfig())
+	defer backend.Close()
+
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		newEvent("api_request", time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), ClientInfo{ID: "a"}, RequestInfo{ID: "req-1", Path: "/v1/decide"}, ResponseInfo{}),
+		{Name: "optimizely_impression", ClientID: "b"},
+	}))
+
+	server.mu.Lock()
+	assert.Equal(t, []string{"auth", "secret"}, server.commands[0])
+	assert.Equal(t, []string{"select", "2"}, server.commands[1])
+	server.mu.Unlock()
+
+	xadds := server.xadds()
+	require.Len(t, xadds, 2)
+	assert.Equal(t, []string{"xadd", "events", "maxlen", "~", "1000", "*",
+		"name", "api_request", "timestamp", "2025-06-01T12:00:00Z", "client_id", "a", "request_id", "req-1", "path", "/v1/decide", "event"},
+		xadds[0][:len(xadds[0])-1])
+	var event CanonicalEvent
+	require.NoError(t, json.Unmarshal([]byte(xadds[0][len(xadds[0])-1]), &event))
+	assert.Equal(t, "/v1/decide", event.Request.Path)
+	assert.Equal(t, "optimizely_impression", xadds[1][7])
+}
+
+func TestRedisStreamsBackendDefaults(t *testing.T) {
+	server := newRedisTestServer(t)
+	backend := &RedisStreamsBackend{Address: server.listener.Addr().String()}
+	defer backend.Close()
+
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+	xadds := server.xadds()
+	require.Len(t, xadds, 1)
+	assert.Equal(t, []string{"xadd", defaultRedisStream, "*", "name", "api_request"}, xadds[0][:5])
+
+	server.mu.Lock()
+	server.xaddError = "OOM command not allowed when used memory > 'maxmemory'"
+	server.mu.Unlock()
+	err := backend.Send(context.Background(), []Event{{Name: "api_request"}})
+	assert.EqualError(t, err, "OOM command not allowed when used memory > 'maxmemory'")
+}
+
+func TestRedisStreamsBackendCheckConfig(t *testing.T) {
+	assert.EqualError(t, (&RedisStreamsBackend{}).checkConfig(), "host is required")
+	assert.EqualError(t, (&RedisStreamsBackend{Address: "redis:6379", MaxLen: -1}).checkConfig(), "maxLen must not be negative")
+}
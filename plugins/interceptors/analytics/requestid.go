@@ -0,0 +1,58 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+// requestIDParam holds the ID the agent logs the request with, so events can be joined with
+// the agent's logs and traces
+const requestIDParam = "request_id"
+
+type requestIDKey struct{}
+
+// requestID returns the ID of the request from the header the agent logs it with. Requests
+// without one are given one, which the agent then uses as well.
+func requestID(r *http.Request) string {
+	id := r.Header.Get(middleware.OptlyRequestHeader)
+	if id == "" {
+		id = uuid.NewString()
+		r.Header.Set(middleware.OptlyRequestHeader, id)
+	}
+	return id
+}
+
+// withRequestID returns a context whose outbound deliveries carry the request ID of the event
+func withRequestID(ctx context.Context, events []Event) context.Context {
+	// Batches carry the events of many requests
+	if len(events) != 1 || events[0].RequestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, events[0].RequestID)
+}
+
+// requestIDFrom returns the request ID of the delivery, if any
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
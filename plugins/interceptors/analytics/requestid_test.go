@@ -0,0 +1,85 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set(middleware.OptlyRequestHeader, "req-1")
+	assert.Equal(t, "req-1", requestID(req))
+
+	req = httptest.NewRequest("GET", "/v1/config", nil)
+	id := requestID(req)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, id, req.Header.Get(middleware.OptlyRequestHeader))
+}
+
+func TestWithRequestID(t *testing.T) {
+	ctx := withRequestID(context.Background(), []Event{{RequestID: "req-1"}})
+	assert.Equal(t, "req-1", requestIDFrom(ctx))
+
+	ctx = withRequestID(context.Background(), []Event{{RequestID: "req-1"}, {RequestID: "req-2"}})
+	assert.Empty(t, requestIDFrom(ctx))
+}
+
+func TestAnalyticsRequestID(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	var handled string
+	handler := a.Handler()(middleware.SetRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled = r.Header.Get(middleware.OptlyRequestHeader)
+	})))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set(middleware.OptlyRequestHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	event := backend.next(t)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.Equal(t, "req-1", event.Params[requestIDParam])
+
+	// Generated IDs are shared with the agent
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/config", nil))
+	event = backend.next(t)
+	assert.NotEmpty(t, event.RequestID)
+	assert.Equal(t, event.RequestID, handled)
+	assert.Equal(t, event.RequestID, rec.Header().Get(middleware.OptlyRequestHeader))
+}
+
+func TestDeliveryCarriesRequestID(t *testing.T) {
+	headers := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get(middleware.OptlyRequestHeader)
+	}))
+	defer ts.Close()
+
+	dest := destination{name: "ga4", backend: &GA4Backend{EndpointURL: ts.URL}}
+	require.NoError(t, sendWithRetry(context.Background(), dest, []Event{{Name: "api_request", RequestID: "req-1"}}, RetryConfig{}, func(error) {}))
+	assert.Equal(t, "req-1", <-headers)
+}
@@ -0,0 +1,83 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ResidencyConfig routes events to destinations by the region of the client, e.g. EU traffic
+// to an EU collector only. Region names are lower-cased by the config loader.
+type ResidencyConfig struct {
+	Regions       map[string][]string `json:"regions"`       // Region name to ISO 3166-1 country codes
+	Destinations  map[string][]string `json:"destinations"`  // Region name to the names of the destinations receiving its events
+	DefaultRegion string              `json:"defaultRegion"` // Region of clients whose country is unknown or not mapped
+	CountryHeader string              `json:"countryHeader"` // CDN header with the client country (falls back to GeoIP)
+}
+
+func (c ResidencyConfig) enabled() bool {
+	return len(c.Destinations) > 0
+}
+
+// region resolves the residency region of the client
+func (c ResidencyConfig) region(r *http.Request, locator geoLocator) string {
+	if country := clientCountry(r, c.CountryHeader, locator); country != "" {
+		for region, countries := range c.Regions {
+			for _, code := range countries {
+				if strings.EqualFold(code, country) {
+					return region
+				}
+			}
+		}
+	}
+	return c.DefaultRegion
+}
+
+// residencyRoutes decides which destinations receive the events of a region
+type residencyRoutes struct {
+	byRegion map[string]map[string]bool // region to destination names
+	assigned map[string]bool            // destinations assigned to any region
+}
+
+func newResidencyRoutes(conf ResidencyConfig) *residencyRoutes {
+	if !conf.enabled() {
+		return nil
+	}
+
+	routes := &residencyRoutes{byRegion: map[string]map[string]bool{}, assigned: map[string]bool{}}
+	for region, names := range conf.Destinations {
+		region = strings.ToLower(region)
+		if routes.byRegion[region] == nil {
+			routes.byRegion[region] = map[string]bool{}
+		}
+		for _, name := range names {
+			routes.byRegion[region][name] = true
+			routes.assigned[name] = true
+		}
+	}
+	return routes
+}
+
+// allows reports whether dest receives events of region. Destinations assigned to regions only
+// receive the events of those regions; all other destinations receive every event.
+func (r *residencyRoutes) allows(dest, region string) bool {
+	if r == nil || !r.assigned[dest] {
+		return true
+	}
+	return r.byRegion[strings.ToLower(region)][dest]
+}
@@ -0,0 +1,88 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testResidency = ResidencyConfig{
+	Regions:       map[string][]string{"eu": {"DE", "fr"}, "us": {"US"}},
+	Destinations:  map[string][]string{"EU": {"eu-collector"}, "us": {"ga4"}},
+	DefaultRegion: "us",
+	CountryHeader: "CF-IPCountry",
+}
+
+func TestResidencyRegion(t *testing.T) {
+	for country, expected := range map[string]string{"FR": "eu", "de": "eu", "US": "us", "JP": "us", "": "us"} {
+		req := httptest.NewRequest("GET", "/v1/config", nil)
+		req.Header.Set("CF-IPCountry", country)
+		assert.Equal(t, expected, testResidency.region(req, nil), country)
+	}
+
+	// The GeoIP database is used without a country header
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	assert.Equal(t, "eu", testResidency.region(req, staticLocator{loc: geoLocation{country: "DE"}}))
+}
+
+func TestResidencyRoutes(t *testing.T) {
+	assert.Nil(t, newResidencyRoutes(ResidencyConfig{}))
+	assert.True(t, (*residencyRoutes)(nil).allows("ga4", "eu"))
+
+	routes := newResidencyRoutes(testResidency)
+	assert.True(t, routes.allows("eu-collector", "eu"))
+	assert.False(t, routes.allows("eu-collector", "us"))
+	assert.False(t, routes.allows("ga4", "eu"))
+	assert.True(t, routes.allows("ga4", "US"))
+	assert.False(t, routes.allows("ga4", ""))
+
+	// Destinations not assigned to a region receive every event
+	assert.True(t, routes.allows("snowplow", "eu"))
+	assert.True(t, routes.allows("snowplow", ""))
+}
+
+func TestDispatcherRoutesByRegion(t *testing.T) {
+	eu, us := newMockBackend(), newMockBackend()
+	d := newDispatcher([]destination{
+		{name: "eu-collector", backend: eu},
+		{name: "ga4", backend: us},
+	}, dispatcherOptions{residency: testResidency}, newAnalyticsMetrics())
+
+	d.enqueue(Event{Name: "eu_event", Region: "eu"})
+	d.enqueue(Event{Name: "us_event", Region: "us"})
+
+	assert.Equal(t, "eu_event", eu.next(t).Name)
+	assert.Equal(t, "us_event", us.next(t).Name)
+}
+
+func TestAnalyticsResidency(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Residency: testResidency}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("CF-IPCountry", "FR")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "eu", backend.next(t).Region)
+}
@@ -0,0 +1,158 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// maxPooledBodyBytes is the capacity above which response body buffers are left to the garbage
+// collector rather than pooled, so a few large responses don't pin their memory
+const maxPooledBodyBytes = 64 << 10
+
+// errHijackUnsupported is returned by Hijack when the underlying writer can't be hijacked
+var errHijackUnsupported = errors.New("analytics: underlying response writer does not support hijacking")
+
+// responseWriterPool and bodyBufferPool reuse the wrappers and buffers of requests, which are
+// otherwise allocated for every request
+var (
+	responseWriterPool = sync.Pool{New: func() interface{} { return &responseWriter{} }}
+	bodyBufferPool     = sync.Pool{New: func() interface{} { return &bytes.Buffer{} }}
+)
+
+// responseWriter is a wrapper for http.ResponseWriter that captures the status code and response size.
+// The response body is only buffered when body is set, or captureErrors is set and the status is an
+// error, and up to maxBody bytes: the buffer of larger responses is dropped. It forwards http.Flusher, http.Hijacker,
+// http.Pusher and io.ReaderFrom so that streaming endpoints (server-sent events, websockets) keep
+// working behind the interceptor.
+type responseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	size       int64
+	body       *bytes.Buffer
+	maxBody    int64
+
+	captureErrors bool
+}
+
+// newResponseWriter returns a pooled wrapper of w with status 200 OK, to be released once the
+// response details were read
+func newResponseWriter(w http.ResponseWriter, maxBody int64, captureErrors bool) *responseWriter {
+	rw := responseWriterPool.Get().(*responseWriter)
+	rw.ResponseWriter = w
+	rw.statusCode = http.StatusOK
+	rw.maxBody = maxBody
+	rw.captureErrors = captureErrors
+	return rw
+}
+
+// captureBody starts buffering the response body
+func (rw *responseWriter) captureBody() {
+	if rw.body == nil {
+		rw.body = bodyBufferPool.Get().(*bytes.Buffer)
+	}
+}
+
+// dropBody stops buffering the response body, returning the buffer to the pool
+func (rw *responseWriter) dropBody() {
+	if rw.body == nil {
+		return
+	}
+	if rw.body.Cap() <= maxPooledBodyBytes {
+		rw.body.Reset()
+		bodyBufferPool.Put(rw.body)
+	}
+	rw.body = nil
+}
+
+// release returns the wrapper and its buffer to the pools. Neither may be used afterwards,
+// including the bytes of the captured body.
+func (rw *responseWriter) release() {
+	rw.dropBody()
+	*rw = responseWriter{}
+	responseWriterPool.Put(rw)
+}
+
+// WriteHeader captures the status code and calls the original WriteHeader
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	if rw.captureErrors && code >= http.StatusBadRequest {
+		rw.captureBody()
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write counts the written bytes, captures the response body if buffered and calls the original Write
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.body != nil {
+		if int64(rw.body.Len()+len(b)) > rw.maxBody {
+			rw.dropBody()
+		} else {
+			rw.body.Write(b)
+		}
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client if the original writer supports flushing
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, e.g. for websocket upgrades. The request
+// is recorded with status 101 Switching Protocols.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errHijackUnsupported
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err == nil {
+		rw.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+// Push initiates an HTTP/2 server push, returning http.ErrNotSupported when the original writer can't push
+func (rw *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := rw.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// ReadFrom copies src to the response, using the original writer's ReadFrom (e.g. sendfile) when
+// the body isn't buffered
+func (rw *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if readerFrom, ok := rw.ResponseWriter.(io.ReaderFrom); ok && rw.body == nil {
+		n, err := readerFrom.ReadFrom(src)
+		rw.size += n
+		return n, err
+	}
+	// Hide ReadFrom from io.Copy so that it writes through Write
+	return io.Copy(struct{ io.Writer }{rw}, src)
+}
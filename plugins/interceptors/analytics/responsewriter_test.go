@@ -0,0 +1,137 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hijackRecorder is a ResponseRecorder whose connection can be hijacked
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, bufio.NewReadWriter(bufio.NewReader(h.conn), bufio.NewWriter(h.conn)), nil
+}
+
+func TestResponseWriterFlush(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	rw := &responseWriter{ResponseWriter: recorder, statusCode: http.StatusOK}
+	rw.Write([]byte("data: 1\n\n"))
+	rw.Flush()
+	assert.True(t, recorder.Flushed)
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	rw := &responseWriter{ResponseWriter: httptest.NewRecorder(), statusCode: http.StatusOK}
+	_, _, err := rw.Hijack()
+	assert.Equal(t, errHijackUnsupported, err)
+
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	rw = &responseWriter{ResponseWriter: &hijackRecorder{httptest.NewRecorder(), server}, statusCode: http.StatusOK}
+	conn, _, err := rw.Hijack()
+	require.NoError(t, err)
+	assert.Equal(t, server, conn)
+	assert.Equal(t, http.StatusSwitchingProtocols, rw.statusCode)
+}
+
+func TestResponseWriterPush(t *testing.T) {
+	rw := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+	assert.Equal(t, http.ErrNotSupported, rw.Push("/style.css", nil))
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	rw := &responseWriter{ResponseWriter: recorder, body: &bytes.Buffer{}, maxBody: 100}
+	n, err := rw.ReadFrom(strings.NewReader("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n)
+	assert.Equal(t, int64(11), rw.size)
+	assert.Equal(t, "hello world", rw.body.String())
+	assert.Equal(t, "hello world", recorder.Body.String())
+}
+
+func TestAnalyticsStreamingResponse(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {}\n\n"))
+		flusher.Flush()
+	}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/v1/notifications/event-stream", nil))
+
+	assert.True(t, recorder.Flushed)
+	assert.Equal(t, http.StatusOK, backend.next(t).Params["status_code"])
+}
+
+func TestResponseWriterRelease(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	rw := newResponseWriter(recorder, 100, true)
+	assert.Equal(t, http.StatusOK, rw.statusCode)
+
+	rw.WriteHeader(http.StatusBadRequest)
+	_, err := rw.Write([]byte("invalid"))
+	require.NoError(t, err)
+	require.NotNil(t, rw.body)
+	assert.Equal(t, "invalid", rw.body.String())
+
+	rw.release()
+	assert.Equal(t, responseWriter{}, *rw)
+
+	// Pooled wrappers start out fresh
+	rw = newResponseWriter(recorder, 100, false)
+	defer rw.release()
+	assert.Equal(t, http.StatusOK, rw.statusCode)
+	assert.Zero(t, rw.size)
+	assert.Nil(t, rw.body)
+}
+
+func TestResponseWriterDropBody(t *testing.T) {
+	rw := newResponseWriter(httptest.NewRecorder(), 4, false)
+	defer rw.release()
+	rw.captureBody()
+	_, err := rw.Write([]byte("hello world"))
+	require.NoError(t, err)
+	assert.Nil(t, rw.body)
+
+	// Large buffers aren't pooled
+	rw.body = bytes.NewBuffer(make([]byte, 0, maxPooledBodyBytes+1))
+	rw.dropBody()
+	assert.Nil(t, rw.body)
+}
@@ -0,0 +1,101 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	defaultRetryBaseBackoff = 200 * time.Millisecond
+	defaultRetryMaxBackoff  = 5 * time.Second
+)
+
+// RetryConfig configures retries of failed deliveries with exponential backoff and full jitter
+type RetryConfig struct {
+	MaxAttempts int            `json:"maxAttempts"` // Total attempts per delivery including the first (0 or 1 disables retries)
+	BaseBackoff utils.Duration `json:"baseBackoff"` // Backoff before the first retry (defaults to 200ms)
+	MaxBackoff  utils.Duration `json:"maxBackoff"`  // Upper bound for any backoff (defaults to 5s)
+}
+
+// backoff returns a random delay in [0, min(MaxBackoff, BaseBackoff*2^retry)]
+func (c RetryConfig) backoff(retry int) time.Duration {
+	base, max := c.BaseBackoff.Duration, c.MaxBackoff.Duration
+	if base <= 0 {
+		base = defaultRetryBaseBackoff
+	}
+	if max <= 0 {
+		max = defaultRetryMaxBackoff
+	}
+
+	ceiling := max
+	if retry < 32 && base<<uint(retry) < max && base<<uint(retry) > 0 {
+		ceiling = base << uint(retry)
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// isRetryable reports whether a failed delivery may succeed when repeated:
+// server errors, throttling and network errors are retried, everything else is not
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// sendWithRetry sends the events to the destination after its transforms, with the idempotency
+// keys of the destination, retrying retryable failures according to the policy. onRetry is called
+// before every retry.
+func sendWithRetry(ctx context.Context, dest destination, events []Event, policy RetryConfig, onRetry func(error)) error {
+	events = withIdempotencyKeys(dest.transforms.apply(events), dest.name)
+	ctx = withRequestID(ctx, events)
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			onRetry(err)
+			select {
+			case <-time.After(policy.backoff(attempt - 1)):
+			case <-ctx.Done():
+				return err
+			}
+		}
+
+		if err = dest.backend.Send(ctx, events); err == nil || !isRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
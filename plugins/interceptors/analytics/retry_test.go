@@ -0,0 +1,111 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func TestRetryBackoffIsBounded(t *testing.T) {
+	policy := RetryConfig{
+		BaseBackoff: utils.Duration{Duration: 10 * time.Millisecond},
+		MaxBackoff:  utils.Duration{Duration: 50 * time.Millisecond},
+	}
+	for retry := 0; retry < 100; retry++ {
+		backoff := policy.backoff(retry)
+		assert.GreaterOrEqual(t, backoff, time.Duration(0))
+		assert.LessOrEqual(t, backoff, 50*time.Millisecond)
+	}
+	for i := 0; i < 100; i++ {
+		assert.LessOrEqual(t, policy.backoff(0), 10*time.Millisecond)
+		assert.LessOrEqual(t, policy.backoff(1), 20*time.Millisecond)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(&StatusError{StatusCode: http.StatusServiceUnavailable}))
+	assert.True(t, isRetryable(&StatusError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, isRetryable(&StatusError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, isRetryable(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	assert.False(t, isRetryable(context.Canceled))
+	assert.False(t, isRetryable(errors.New("invalid payload")))
+}
+
+func newStatusSequenceServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := atomic.AddInt32(&calls, 1)
+		if int(call) <= len(statuses) {
+			w.WriteHeader(statuses[call-1])
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &calls
+}
+
+func TestSendWithRetryRecovers(t *testing.T) {
+	ts, calls := newStatusSequenceServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	dest := destination{name: "ga4", backend: &GA4Backend{EndpointURL: ts.URL}}
+	policy := RetryConfig{MaxAttempts: 3, BaseBackoff: utils.Duration{Duration: time.Millisecond}}
+
+	var retries int
+	err := sendWithRetry(context.Background(), dest, []Event{{Name: "api_request"}}, policy, func(error) { retries++ })
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	assert.Equal(t, 2, retries)
+}
+
+func TestSendWithRetryGivesUp(t *testing.T) {
+	ts, calls := newStatusSequenceServer(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	dest := destination{name: "ga4", backend: &GA4Backend{EndpointURL: ts.URL}}
+	policy := RetryConfig{MaxAttempts: 2, BaseBackoff: utils.Duration{Duration: time.Millisecond}}
+
+	err := sendWithRetry(context.Background(), dest, []Event{{Name: "api_request"}}, policy, func(error) {})
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestSendWithRetrySkipsClientErrors(t *testing.T) {
+	ts, calls := newStatusSequenceServer(t, http.StatusBadRequest)
+	dest := destination{name: "ga4", backend: &GA4Backend{EndpointURL: ts.URL}}
+
+	err := sendWithRetry(context.Background(), dest, []Event{{Name: "api_request"}}, RetryConfig{MaxAttempts: 5}, func(error) {})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestSendWithRetryStopsOnCancel(t *testing.T) {
+	ts, calls := newStatusSequenceServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	dest := destination{name: "ga4", backend: &GA4Backend{EndpointURL: ts.URL}}
+	policy := RetryConfig{MaxAttempts: 3, BaseBackoff: utils.Duration{Duration: time.Hour}, MaxBackoff: utils.Duration{Duration: time.Hour}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := sendWithRetry(ctx, dest, []Event{{Name: "api_request"}}, policy, func(error) { cancel() })
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
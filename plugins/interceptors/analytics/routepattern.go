@@ -0,0 +1,47 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// withRouteContext returns the request with a chi routing context, so that the route pattern
+// matched by the router is known once the request has been served. The interceptors wrap the
+// router, which routes with a context it finds instead of creating its own.
+func withRouteContext(r *http.Request) (*http.Request, *chi.Context) {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return r, rctx
+	}
+	rctx := chi.NewRouteContext()
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)), rctx
+}
+
+// routePattern returns the pattern of the route that served the request, e.g.
+// /v1/datafiles/{sdkKey}, falling back to the request path when no route matched
+func routePattern(rctx *chi.Context, path string) string {
+	if rctx == nil {
+		return path
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+	return path
+}
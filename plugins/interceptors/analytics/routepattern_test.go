@@ -0,0 +1,73 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPatternRouter(t *testing.T, a *Analytics) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/v1", func(r chi.Router) {
+		r.Get("/datafiles/{sdkKey}", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "abc123", chi.URLParam(r, "sdkKey"))
+		})
+	})
+	return a.Handler()(r)
+}
+
+func TestAnalyticsRoutePattern(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	handler := newPatternRouter(t, a)
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/datafiles/abc123", nil))
+	assert.Equal(t, "/v1/datafiles/{sdkKey}", backend.next(t).Params[pathParam])
+
+	// Requests no route matched keep their path
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/unknown", nil))
+	assert.Equal(t, "/unknown", backend.next(t).Params[pathParam])
+}
+
+func TestAnalyticsRawPaths(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, RawPaths: true}
+	handler := newPatternRouter(t, a)
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/datafiles/abc123", nil))
+	assert.Equal(t, "/v1/datafiles/abc123", backend.next(t).Params[pathParam])
+}
+
+func TestRoutePatternInsideRouter(t *testing.T) {
+	// An interceptor mounted inside a router reuses its routing context
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	r := chi.NewRouter()
+	r.Use(a.Handler())
+	r.Get("/v1/flags/{flagKey}", func(w http.ResponseWriter, r *http.Request) {})
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/flags/checkout", nil))
+	assert.Equal(t, "/v1/flags/{flagKey}", backend.next(t).Params[pathParam])
+}
@@ -0,0 +1,147 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RoutingRule sends the events matching it to the listed destinations, e.g. decision events to
+// Kafka and errors to a webhook. Destinations named by any rule only receive the events matching
+// one of their rules, the first match applying; all other destinations receive every event.
+type RoutingRule struct {
+	Destinations []string `json:"destinations"` // Names of the destinations receiving matching events
+	Events       []string `json:"events"`       // Names of the events matched (defaults to all)
+	When         string   `json:"when"`         // Expression over the event params that must hold, see expr
+	SampleRate   *float64 `json:"sampleRate"`   // Fraction of matching events sent, 0.0–1.0 (defaults to 1, all)
+}
+
+// routingRule is a validated RoutingRule
+type routingRule struct {
+	RoutingRule
+	events map[string]bool
+	when   *expr
+}
+
+func newRoutingRule(rule RoutingRule) (routingRule, error) {
+	if len(rule.Destinations) == 0 {
+		return routingRule{}, fmt.Errorf("analytics routing rule must list destinations")
+	}
+	if rule.SampleRate != nil && (*rule.SampleRate < 0 || *rule.SampleRate > 1) {
+		return routingRule{}, fmt.Errorf("analytics routing rule sample rate must be between 0 and 1, got %v", *rule.SampleRate)
+	}
+
+	r := routingRule{RoutingRule: rule}
+	if len(rule.Events) > 0 {
+		r.events = make(map[string]bool, len(rule.Events))
+		for _, name := range rule.Events {
+			r.events[name] = true
+		}
+	}
+	if rule.When != "" {
+		when, err := compileExpr(rule.When)
+		if err != nil {
+			return routingRule{}, fmt.Errorf("invalid analytics routing rule: %w", err)
+		}
+		r.when = when
+	}
+	return r, nil
+}
+
+// matches reports whether the rule applies to the event. Expressions that fail to evaluate don't match.
+func (r routingRule) matches(event Event) bool {
+	if r.events != nil && !r.events[event.Name] {
+		return false
+	}
+	if r.when == nil {
+		return true
+	}
+	v, err := r.when.eval(event.Params)
+	if err != nil {
+		log.Debug().Err(err).Str("event", event.Name).Str("when", r.When).Msg("Failed to evaluate analytics routing rule")
+		return false
+	}
+	return truthy(v)
+}
+
+// eventRoutes decides which destinations receive an event according to the routing rules
+type eventRoutes struct {
+	byDestination map[string][]routingRule
+	byClientID    bool // sample deterministically by client ID
+}
+
+// newEventRoutes validates the rules, skipping invalid ones. The destinations of invalid rules
+// still count as routed, so they don't receive every event instead.
+func newEventRoutes(rules []RoutingRule, byClientID bool) *eventRoutes {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	routes := &eventRoutes{byDestination: map[string][]routingRule{}, byClientID: byClientID}
+	for _, conf := range rules {
+		rule, err := newRoutingRule(conf)
+		if err != nil {
+			log.Error().Err(err).Strs("destinations", conf.Destinations).Msg("Skipping analytics routing rule")
+			for _, name := range conf.Destinations {
+				if _, ok := routes.byDestination[name]; !ok {
+					routes.byDestination[name] = nil
+				}
+			}
+			continue
+		}
+		for _, name := range conf.Destinations {
+			routes.byDestination[name] = append(routes.byDestination[name], rule)
+		}
+	}
+	return routes
+}
+
+// route returns the event dest receives, reporting false when the routing rules don't send it
+// there. Sampled events are returned with their own params, carrying the compounded sample rate.
+func (r *eventRoutes) route(dest string, event Event) (Event, bool) {
+	if r == nil {
+		return event, true
+	}
+	rules, routed := r.byDestination[dest]
+	if !routed {
+		return event, true
+	}
+
+	for _, rule := range rules {
+		if !rule.matches(event) {
+			continue
+		}
+		if rule.SampleRate == nil || *rule.SampleRate >= 1 {
+			return event, true
+		}
+		// Sample each destination independently of the others and of the request sampling
+		rate := *rule.SampleRate
+		if !sampled(rate, dest+":"+event.ClientID, r.byClientID) {
+			return Event{}, false
+		}
+		params := make(map[string]interface{}, len(event.Params)+1)
+		for k, v := range event.Params {
+			params[k] = v
+		}
+		event.Params = params
+		applySampleRate(&event, rate)
+		return event, true
+	}
+	return Event{}, false
+}
@@ -0,0 +1,126 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRoutes(t *testing.T) {
+	assert.Nil(t, newEventRoutes(nil, false))
+
+	routes := newEventRoutes([]RoutingRule{
+		{Destinations: []string{"kafka"}, When: "decision_count > 0"},
+		{Destinations: []string{"webhook"}, When: "status_code >= 500"},
+		{Destinations: []string{"webhook"}, Events: []string{"session_start"}},
+	}, false)
+
+	decision := Event{Name: "api_request", Params: map[string]interface{}{"decision_count": 2, "status_code": 200}}
+	failure := Event{Name: "api_request", Params: map[string]interface{}{"status_code": 503}}
+	session := Event{Name: "session_start", Params: map[string]interface{}{}}
+
+	for _, tt := range []struct {
+		dest     string
+		event    Event
+		expected bool
+	}{
+		{"kafka", decision, true},
+		{"kafka", failure, false},
+		{"webhook", decision, false},
+		{"webhook", failure, true},
+		{"webhook", session, true},
+		{"ga4", decision, true},
+		{"ga4", session, true},
+	} {
+		_, ok := routes.route(tt.dest, tt.event)
+		assert.Equal(t, tt.expected, ok, "%s %v", tt.dest, tt.event)
+	}
+}
+
+func TestEventRoutesSampleRate(t *testing.T) {
+	half, none := 0.5, 0.0
+	routes := newEventRoutes([]RoutingRule{
+		{Destinations: []string{"ga4"}, SampleRate: &half},
+		{Destinations: []string{"kafka"}, SampleRate: &none},
+	}, true)
+
+	original := Event{Name: "api_request", Params: map[string]interface{}{sampleRateParam: 0.5}}
+	var kept int
+	for _, clientID := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		original.ClientID = clientID
+		event, ok := routes.route("ga4", original)
+		if !ok {
+			continue
+		}
+		kept++
+		assert.Equal(t, 0.25, event.Params[sampleRateParam])
+		// Deterministic by client ID
+		_, again := routes.route("ga4", original)
+		assert.True(t, again)
+	}
+	assert.Greater(t, kept, 0)
+	assert.Less(t, kept, 10)
+	// The params shared with other destinations are unchanged
+	assert.Equal(t, 0.5, original.Params[sampleRateParam])
+
+	// A rate of 0 sends no matching events
+	_, ok := routes.route("kafka", original)
+	assert.False(t, ok)
+}
+
+func TestEventRoutesInvalidRules(t *testing.T) {
+	invalid := 2.0
+	routes := newEventRoutes([]RoutingRule{
+		{Destinations: []string{"webhook"}, When: "status_code >="},
+		{Destinations: []string{"kafka"}, SampleRate: &invalid},
+		{When: "true"},
+	}, false)
+
+	// Destinations of invalid rules receive nothing rather than everything
+	_, ok := routes.route("webhook", Event{Name: "api_request"})
+	assert.False(t, ok)
+	_, ok = routes.route("kafka", Event{Name: "api_request"})
+	assert.False(t, ok)
+	_, ok = routes.route("ga4", Event{Name: "api_request"})
+	assert.True(t, ok)
+}
+
+func TestDispatcherRoutesByRule(t *testing.T) {
+	kafka, webhook := newMockBackend(), newMockBackend()
+	d := newDispatcher([]destination{
+		{name: "kafka", backend: kafka},
+		{name: "webhook", backend: webhook},
+	}, dispatcherOptions{routing: []RoutingRule{
+		{Destinations: []string{"webhook"}, When: "status_code >= 500"},
+	}}, newAnalyticsMetrics())
+
+	d.enqueue(Event{Name: "ok", Params: map[string]interface{}{"status_code": 200}})
+	d.enqueue(Event{Name: "error", Params: map[string]interface{}{"status_code": 500}})
+
+	assert.Equal(t, "ok", kafka.next(t).Name)
+	assert.Equal(t, "error", kafka.next(t).Name)
+	assert.Equal(t, "error", webhook.next(t).Name)
+	select {
+	case e := <-webhook.events:
+		require.Failf(t, "unexpected event", "%v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,142 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const defaultEventName = "api_request"
+
+// RouteRule overrides how requests to matching paths are tracked. A rule matches on a glob
+// Path (path.Match syntax, e.g. "/v1/*", with a trailing "/**" matching any depth) or a Regex; rules are evaluated in order and the
+// first match applies.
+type RouteRule struct {
+	Path       string   `json:"path"`
+	Regex      string   `json:"regex"`
+	Enabled    *bool    `json:"enabled"`    // Whether matching requests are tracked (defaults to true)
+	SampleRate *float64 `json:"sampleRate"` // Overrides SampleRate; 0 stops tracking matching requests
+	EventName  string   `json:"eventName"`  // Overrides the event name, which may be a template, see EventName of Analytics
+
+	Methods     []string `json:"methods"`     // Overrides the HTTP methods that generate events
+	StatusCodes []string `json:"statusCodes"` // Overrides the status codes ("404") or classes ("4xx") that generate events
+}
+
+// routeRule is a validated RouteRule
+type routeRule struct {
+	RouteRule
+	re *regexp.Regexp
+}
+
+// newRouteRule validates the rule and compiles its regex
+func newRouteRule(rule RouteRule) (routeRule, error) {
+	statusCodes, errs := validateStatusCodes(rule.StatusCodes)
+	if len(errs) > 0 {
+		return routeRule{}, errs[0]
+	}
+	rule.StatusCodes = statusCodes
+	if rule.SampleRate != nil && (*rule.SampleRate < 0 || *rule.SampleRate > 1) {
+		return routeRule{}, fmt.Errorf("analytics route rule sample rate must be between 0 and 1, got %v", *rule.SampleRate)
+	}
+	if _, err := compileEventName(rule.EventName); err != nil {
+		return routeRule{}, err
+	}
+
+	switch {
+	case rule.Path != "" && rule.Regex != "":
+		return routeRule{}, fmt.Errorf("analytics route rule must set only one of path or regex")
+	case rule.Path != "":
+		if err := validateGlob(rule.Path); err != nil {
+			return routeRule{}, err
+		}
+		return routeRule{RouteRule: rule}, nil
+	case rule.Regex != "":
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return routeRule{}, fmt.Errorf("invalid analytics route rule regex %q: %w", rule.Regex, err)
+		}
+		return routeRule{RouteRule: rule, re: re}, nil
+	default:
+		return routeRule{}, fmt.Errorf("analytics route rule must set a path or regex")
+	}
+}
+
+func (r routeRule) matches(p string) bool {
+	if r.re != nil {
+		return r.re.MatchString(p)
+	}
+	return matchGlob(r.Path, p)
+}
+
+// routeSettings is how a request is tracked after applying the route rules
+type routeSettings struct {
+	track       bool
+	sampleRate  float64
+	eventName   string
+	methods     []string
+	statusCodes []string
+}
+
+// generatesEvent reports whether a request with the given method and response status generates an event
+func (s routeSettings) generatesEvent(method string, status int) bool {
+	return matchesMethod(s.methods, method) && matchesStatus(s.statusCodes, status)
+}
+
+// routeSettings applies the first rule matching p to the global settings
+func (a *Analytics) routeSettings(p string) routeSettings {
+	settings := routeSettings{
+		track:       true,
+		sampleRate:  a.sampleRate(),
+		eventName:   a.eventName,
+		methods:     a.Methods,
+		statusCodes: a.statusCodes,
+	}
+	if settings.eventName == "" {
+		settings.eventName = defaultEventName
+	}
+	if a.DatafileEvents {
+		if name := datafileEventName(p); name != "" {
+			settings.eventName = name
+		}
+	}
+	for _, rule := range a.rules {
+		if !rule.matches(p) {
+			continue
+		}
+		if rule.Enabled != nil && !*rule.Enabled {
+			settings.track = false
+		}
+		if rule.SampleRate != nil {
+			settings.sampleRate = *rule.SampleRate
+			if settings.sampleRate <= 0 {
+				settings.track = false
+			}
+		}
+		if rule.EventName != "" {
+			settings.eventName = rule.EventName
+		}
+		if len(rule.Methods) > 0 {
+			settings.methods = rule.Methods
+		}
+		if len(rule.StatusCodes) > 0 {
+			settings.statusCodes = rule.StatusCodes
+		}
+		break
+	}
+	return settings
+}
@@ -0,0 +1,93 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRouteRule(t *testing.T) {
+	_, err := newRouteRule(RouteRule{Path: "/v1/*"})
+	assert.NoError(t, err)
+	_, err = newRouteRule(RouteRule{Regex: "^/v1/(decide|track)$"})
+	assert.NoError(t, err)
+
+	for _, rule := range []RouteRule{
+		{},
+		{Path: "/v1/*", Regex: "^/v1"},
+		{Path: "/v1/["},
+		{Regex: "("},
+		{Path: "/v1/*", StatusCodes: []string{"2x"}},
+		{Path: "/v1/*", EventName: "{route}"},
+	} {
+		_, err := newRouteRule(rule)
+		assert.Error(t, err, "%+v", rule)
+	}
+}
+
+func TestRouteSettings(t *testing.T) {
+	full, half, none, rare, invalid := 1.0, 0.5, 0.0, 0.01, 1.5
+	disabled := false
+	a := &Analytics{
+		SampleRate: &half,
+		Rules: []RouteRule{
+			{Path: "/v1/decide", SampleRate: &full, EventName: "decide"},
+			{Path: "/health", SampleRate: &rare},
+			{Path: "/metrics", SampleRate: &none},
+			{Path: "/v1/config", SampleRate: &invalid},
+			{Regex: "^/admin/", Enabled: &disabled},
+			{Path: "/v1/*", EventName: "v1_request"},
+			{Path: "/v1/decide", EventName: "unreachable"},
+			{Regex: "("},
+		},
+	}
+	a.initRules()
+	require.Len(t, a.rules, 6)
+
+	assert.Equal(t, routeSettings{track: true, sampleRate: 1, eventName: "decide"}, a.routeSettings("/v1/decide"))
+	assert.Equal(t, routeSettings{track: true, sampleRate: 0.01, eventName: defaultEventName}, a.routeSettings("/health"))
+	assert.False(t, a.routeSettings("/metrics").track)
+	assert.False(t, a.routeSettings("/admin/config").track)
+	assert.Equal(t, routeSettings{track: true, sampleRate: 0.5, eventName: "v1_request"}, a.routeSettings("/v1/track"))
+	assert.Equal(t, routeSettings{track: true, sampleRate: 0.5, eventName: defaultEventName}, a.routeSettings("/v2/track"))
+}
+
+func TestAnalyticsAppliesRouteRules(t *testing.T) {
+	disabled := false
+	backend := newMockBackend()
+	a := &Analytics{
+		Enabled: true,
+		Rules: []RouteRule{
+			{Path: "/health", Enabled: &disabled},
+			{Path: "/v1/decide", EventName: "decide"},
+		},
+	}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/decide", nil))
+
+	event := backend.next(t)
+	assert.Equal(t, "decide", event.Name)
+	assert.Equal(t, "/v1/decide", event.Params["path"])
+}
@@ -0,0 +1,71 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/rand"
+)
+
+const sampleRateParam = "sample_rate"
+
+// sampleRate returns the configured fraction of requests tracked, every request when unset
+func (a *Analytics) sampleRate() float64 {
+	if a.SampleRate == nil {
+		return 1
+	}
+	return *a.SampleRate
+}
+
+// sampled reports whether an event for clientID falls within the sample rate. A rate of 0
+// tracks no requests and of 1 every request. Sampling by client ID is deterministic, so a
+// client is either always or never tracked at a given rate.
+func sampled(rate float64, clientID string, byClientID bool) bool {
+	if rate <= 0 {
+		return false
+	}
+	if rate >= 1 {
+		return true
+	}
+	if byClientID {
+		return clientFraction(clientID) < rate
+	}
+	return rand.Float64() < rate
+}
+
+// clientFraction maps a client ID uniformly onto [0, 1)
+func clientFraction(clientID string) float64 {
+	sum := sha256.Sum256([]byte(clientID))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// applySampleRate records that the event was kept with the given probability. Sampling
+// stages compound, so an existing sample_rate param is multiplied rather than replaced.
+func applySampleRate(event *Event, rate float64) {
+	if rate <= 0 || rate >= 1 {
+		return
+	}
+	if event.Params == nil {
+		event.Params = map[string]interface{}{}
+	}
+	if existing, ok := event.Params[sampleRateParam].(float64); ok {
+		rate *= existing
+	}
+	event.Params[sampleRateParam] = math.Round(rate*1e6) / 1e6
+}
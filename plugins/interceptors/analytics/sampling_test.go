@@ -0,0 +1,111 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampledBounds(t *testing.T) {
+	for _, rate := range []float64{1, 1.5} {
+		assert.True(t, sampled(rate, "client", false))
+		assert.True(t, sampled(rate, "client", true))
+	}
+	for _, rate := range []float64{0, -1} {
+		assert.False(t, sampled(rate, "client", false))
+		assert.False(t, sampled(rate, "client", true))
+	}
+}
+
+func TestAnalyticsSampleRate(t *testing.T) {
+	assert.Equal(t, 1.0, (&Analytics{}).sampleRate())
+	none := 0.0
+	assert.Equal(t, 0.0, (&Analytics{SampleRate: &none}).sampleRate())
+}
+
+func TestSampledByClientIDIsDeterministic(t *testing.T) {
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		clientID := fmt.Sprintf("client-%d", i)
+		first := sampled(0.2, clientID, true)
+		assert.Equal(t, first, sampled(0.2, clientID, true))
+		if first {
+			kept++
+			// A client sampled at a rate stays sampled at any higher rate
+			assert.True(t, sampled(0.5, clientID, true))
+		}
+	}
+	assert.InDelta(t, 2000, kept, 200)
+}
+
+func TestSampledRandomRate(t *testing.T) {
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		if sampled(0.3, "client", false) {
+			kept++
+		}
+	}
+	assert.InDelta(t, 3000, kept, 300)
+}
+
+func TestApplySampleRate(t *testing.T) {
+	event := Event{}
+	applySampleRate(&event, 1)
+	assert.Nil(t, event.Params)
+
+	applySampleRate(&event, 0.5)
+	assert.Equal(t, 0.5, event.Params[sampleRateParam])
+
+	// Sampling stages compound
+	applySampleRate(&event, 0.1)
+	assert.Equal(t, 0.05, event.Params[sampleRateParam])
+}
+
+func TestAnalyticsSampling(t *testing.T) {
+	backend := newMockBackend()
+	half := 0.5
+	a := &Analytics{Enabled: true, SampleRate: &half, SampleByClientID: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	var keptID, droppedID string
+	for i := 0; keptID == "" || droppedID == ""; i++ {
+		clientID := fmt.Sprintf("client-%d", i)
+		if sampled(0.5, clientID, true) {
+			keptID = clientID
+		} else {
+			droppedID = clientID
+		}
+	}
+
+	before := expvarValue("counter.analytics.requests.sampledOut")
+	for _, clientID := range []string{droppedID, keptID} {
+		req := httptest.NewRequest("GET", "/v1/config", nil)
+		req.AddCookie(&http.Cookie{Name: "_ga", Value: clientID})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	event := backend.next(t)
+	assert.Equal(t, keptID, event.ClientID)
+	assert.Equal(t, 0.5, event.Params[sampleRateParam])
+	assert.Equal(t, before+1, expvarValue("counter.analytics.requests.sampledOut"))
+}
@@ -0,0 +1,41 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+const sdkKeyParam = "sdk_key"
+
+// getSDKKey returns the SDK key the request was made for. The header may also carry a
+// datafile access token as "<sdkKey>:<token>", which is never included.
+func getSDKKey(r *http.Request) string {
+	sdkKey, _, _ := strings.Cut(r.Header.Get(middleware.OptlySDKHeader), ":")
+	return sdkKey
+}
+
+// hashSDKKey returns a stable, non-reversible identifier for an SDK key
+func hashSDKKey(sdkKey string) string {
+	sum := sha256.Sum256([]byte(sdkKey))
+	return hex.EncodeToString(sum[:8])
+}
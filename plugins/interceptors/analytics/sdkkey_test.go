@@ -0,0 +1,64 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optimizely/agent/pkg/middleware"
+)
+
+func TestGetSDKKey(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	assert.Equal(t, "", getSDKKey(req))
+
+	req.Header.Set(middleware.OptlySDKHeader, "sdk-key")
+	assert.Equal(t, "sdk-key", getSDKKey(req))
+
+	// The datafile access token is never included
+	req.Header.Set(middleware.OptlySDKHeader, "sdk-key:secret-token")
+	assert.Equal(t, "sdk-key", getSDKKey(req))
+}
+
+func TestHashSDKKey(t *testing.T) {
+	assert.Len(t, hashSDKKey("sdk-key"), 16)
+	assert.Equal(t, hashSDKKey("sdk-key"), hashSDKKey("sdk-key"))
+	assert.NotEqual(t, hashSDKKey("sdk-key"), hashSDKKey("other-key"))
+}
+
+func TestAnalyticsSDKKeyParam(t *testing.T) {
+	for _, hash := range []bool{false, true} {
+		backend := newMockBackend()
+		a := &Analytics{Enabled: true, HashSDKKey: hash}
+		handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+		req := httptest.NewRequest("POST", "/v1/decide", nil)
+		req.Header.Set(middleware.OptlySDKHeader, "sdk-key:secret-token")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		expected := "sdk-key"
+		if hash {
+			expected = hashSDKKey("sdk-key")
+		}
+		assert.Equal(t, expected, backend.next(t).Params[sdkKeyParam])
+	}
+}
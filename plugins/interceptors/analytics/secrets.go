@@ -0,0 +1,357 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	secretEnv   = "env://"
+	secretFile  = "file://"
+	secretVault = "vault://"
+	secretAWSSM = "awssm://"
+)
+
+// SecretsConfig configures the resolution of secret references such as "env://GA_API_SECRET",
+// which may be used for the GA API secret, the proxy URL and any setting of a destination
+type SecretsConfig struct {
+	RefreshInterval utils.Duration `json:"refreshInterval"` // Resolve the references again this often, reloading on change (defaults to never)
+	VaultAddress    string         `json:"vaultAddress"`    // Base URL of Vault (defaults to VAULT_ADDR)
+	VaultTokenFile  string         `json:"vaultTokenFile"`  // File holding the Vault token, e.g. a Vault Agent sink (defaults to VAULT_TOKEN, then ~/.vault-token)
+	AWSRegion       string         `json:"awsRegion"`       // Region of Secrets Manager (defaults to the region of the ARN, then of the AWS config)
+	AWSEndpoint     string         `json:"awsEndpoint"`     // Secrets Manager endpoint override, e.g. for LocalStack
+}
+
+// isSecretRef reports whether value is a secret reference rather than a plain value
+func isSecretRef(value string) bool {
+	for _, scheme := range []string{secretEnv, secretFile, secretVault, secretAWSSM} {
+		if strings.HasPrefix(value, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretResolver resolves secret references, remembering the values so that changes can be detected
+type secretResolver struct {
+	conf   SecretsConfig
+	client *http.Client
+
+	mu     sync.Mutex
+	values map[string]string // resolved values by reference
+
+	awsMu  sync.Mutex
+	awsCfg *aws.Config // loaded on the first AWS Secrets Manager reference
+}
+
+func newSecretResolver(conf SecretsConfig) *secretResolver {
+	return &secretResolver{conf: conf, client: defaultHTTPClient, values: map[string]string{}}
+}
+
+// resolve returns the value of a secret reference, or value itself when it isn't one
+func (r *secretResolver) resolve(value string) (string, error) {
+	if !isSecretRef(value) {
+		return value, nil
+	}
+
+	r.mu.Lock()
+	resolved, ok := r.values[value]
+	r.mu.Unlock()
+	if ok {
+		return resolved, nil
+	}
+
+	// The lock isn't held while fetching, so that a slow store doesn't hold up other lookups
+	resolved, err := r.fetch(context.Background(), value)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[value] = resolved
+	return resolved, nil
+}
+
+// resolveConfig returns a copy of conf with the secret references of all its settings resolved
+func (r *secretResolver) resolveConfig(conf BackendConfig) (BackendConfig, error) {
+	resolved, err := r.resolveValue(map[string]interface{}(conf))
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]interface{}), nil
+}
+
+func (r *secretResolver) resolveValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return r.resolve(v)
+	case BackendConfig:
+		return r.resolveValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			item, err := r.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[k] = item
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			item, err := r.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = item
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// changed resolves the references again, reporting whether any value differs from the one in use.
+// References that fail to resolve keep their previous value.
+func (r *secretResolver) changed(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	refs := make(map[string]string, len(r.values))
+	for ref, value := range r.values {
+		refs[ref] = value
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for ref, value := range refs {
+		current, err := r.fetch(ctx, ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if current != value {
+			return true, nil
+		}
+	}
+	return false, errors.Join(errs...)
+}
+
+// fetch reads the value of a secret reference from its store
+func (r *secretResolver) fetch(ctx context.Context, ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, secretEnv):
+		name := strings.TrimPrefix(ref, secretEnv)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret %s: environment variable %s is not set", ref, name)
+		}
+		return value, nil
+	case strings.HasPrefix(ref, secretFile):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, secretFile))
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", ref, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(ref, secretVault):
+		value, err := r.fetchVault(ctx, strings.TrimPrefix(ref, secretVault))
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", ref, err)
+		}
+		return value, nil
+	case strings.HasPrefix(ref, secretAWSSM):
+		value, err := r.fetchAWSSM(ctx, strings.TrimPrefix(ref, secretAWSSM))
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", ref, err)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown secret reference: %q", ref)
+	}
+}
+
+// fetchVault reads a field of a Vault secret, given as "<path>#<field>" with the path of the
+// secret in the Vault API, e.g. "secret/data/analytics#apiSecret" for a KV v2 engine
+func (r *secretResolver) fetchVault(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", errors.New("vault references must be vault://<path>#<field>")
+	}
+	address := r.conf.VaultAddress
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", errors.New("vault address is not configured, set vaultAddress or VAULT_ADDR")
+	}
+
+	token, err := r.vaultToken()
+	if err != nil {
+		return "", err
+	}
+	headers := map[string]string{"X-Vault-Token": token}
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		headers["X-Vault-Namespace"] = namespace
+	}
+	body, err := send(ctx, r.client, http.MethodGet, strings.TrimSuffix(address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), "", nil, headers)
+	if err != nil {
+		return "", err
+	}
+
+	// KV v2 nests the secret in data.data, KV v1 and other engines return it in data
+	for _, p := range []string{"data.data.", "data."} {
+		if value := gjson.GetBytes(body, p+gjson.Escape(field)); value.Exists() && value.Type != gjson.JSON {
+			return value.String(), nil
+		}
+	}
+	return "", fmt.Errorf("field %q not found", field)
+}
+
+// vaultToken returns the token of the configured file, VAULT_TOKEN or the token file of the
+// Vault CLI. Files are read on every fetch, so tokens renewed by a Vault Agent are picked up.
+func (r *secretResolver) vaultToken() (string, error) {
+	path := r.conf.VaultTokenFile
+	if path == "" {
+		if token := os.Getenv("VAULT_TOKEN"); token != "" {
+			return token, nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.New("vault token is not configured, set vaultTokenFile or VAULT_TOKEN")
+		}
+		path = filepath.Join(home, ".vault-token")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("vault token is not configured, set vaultTokenFile or VAULT_TOKEN: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// fetchAWSSM reads an AWS Secrets Manager secret, given as "<secret-id>" or "<secret-id>#<key>"
+// to read a key of a JSON secret
+func (r *secretResolver) fetchAWSSM(ctx context.Context, ref string) (string, error) {
+	secretID, key, _ := strings.Cut(ref, "#")
+	if secretID == "" {
+		return "", errors.New("awssm references must be awssm://<secret-id> or awssm://<secret-id>#<key>")
+	}
+	cfg, err := r.awsConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	region := r.conf.AWSRegion
+	if arn := strings.Split(secretID, ":"); region == "" && len(arn) > 3 && arn[0] == "arn" {
+		region = arn[3]
+	}
+	if region == "" {
+		region = cfg.Region
+	}
+	if region == "" {
+		return "", errors.New("AWS region is not configured, set awsRegion or AWS_REGION")
+	}
+	client := secretsmanager.NewFromConfig(cfg, func(o *secretsmanager.Options) {
+		o.Region = region
+		if r.conf.AWSEndpoint != "" {
+			o.BaseEndpoint = aws.String(r.conf.AWSEndpoint)
+		}
+	})
+
+	out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) {
+			return "", &StatusError{StatusCode: respErr.HTTPStatusCode(), Body: respErr.Err.Error()}
+		}
+		return "", err
+	}
+	secret := aws.ToString(out.SecretString)
+	if key == "" {
+		return secret, nil
+	}
+	value := gjson.Get(secret, gjson.Escape(key))
+	if !value.Exists() {
+		return "", fmt.Errorf("key %q not found", key)
+	}
+	return value.String(), nil
+}
+
+// awsConfig loads the AWS config once. Credentials come from the default chain of the SDK: the
+// environment, shared config and credentials files, web identity tokens (e.g. IRSA) and the
+// ECS and EC2 instance roles, and are refreshed by it as they expire.
+func (r *secretResolver) awsConfig(ctx context.Context) (aws.Config, error) {
+	r.awsMu.Lock()
+	defer r.awsMu.Unlock()
+	if r.awsCfg != nil {
+		return *r.awsCfg, nil
+	}
+	// The SDK's own client, which honors AWS_CA_BUNDLE, with the timeout of the other stores
+	client := awshttp.NewBuildableClient().WithTimeout(r.client.Timeout)
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithHTTPClient(client))
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load the AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	r.awsCfg = &cfg
+	return cfg, nil
+}
+
+// refreshSecrets resolves the secret references of the active config every interval until ctx is
+// done, reloading the config with the new values when any has changed
+func (a *Analytics) refreshSecrets(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := a.current().secrets.changed(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to refresh analytics secrets, keeping the previous values")
+		}
+		if !changed {
+			continue
+		}
+		next := a.current().cloneConfig()
+		next.init()
+		if err := a.replace(ctx, next, "Analytics secrets refreshed"); err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Msg("Failed to drain analytics events after refreshing secrets")
+		}
+	}
+}
@@ -0,0 +1,298 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func TestResolvePlainValues(t *testing.T) {
+	r := newSecretResolver(SecretsConfig{})
+	for _, value := range []string{"", "secret", "https://collector.example.com"} {
+		resolved, err := r.resolve(value)
+		require.NoError(t, err)
+		assert.Equal(t, value, resolved)
+	}
+	assert.Empty(t, r.values)
+}
+
+func TestResolveEnvAndFileSecrets(t *testing.T) {
+	t.Setenv("ANALYTICS_TEST_SECRET", "from-env")
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0o600))
+
+	r := newSecretResolver(SecretsConfig{})
+	value, err := r.resolve("env://ANALYTICS_TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+	value, err = r.resolve("file://" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", value)
+
+	_, err = r.resolve("env://ANALYTICS_TEST_MISSING")
+	assert.Error(t, err)
+	_, err = r.resolve("file://" + filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestResolveVaultSecret(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "token")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/analytics":
+			_, _ = w.Write([]byte(`{"data": {"data": {"apiSecret": "kv2"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/analytics":
+			_, _ = w.Write([]byte(`{"data": {"apiSecret": "kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	r := newSecretResolver(SecretsConfig{VaultAddress: server.URL})
+	value, err := r.resolve("vault://secret/data/analytics#apiSecret")
+	require.NoError(t, err)
+	assert.Equal(t, "kv2", value)
+	value, err = r.resolve("vault://kv/analytics#apiSecret")
+	require.NoError(t, err)
+	assert.Equal(t, "kv1", value)
+
+	for _, ref := range []string{
+		"vault://secret/data/analytics#writeKey",
+		"vault://secret/data/missing#apiSecret",
+		"vault://secret/data/analytics",
+	} {
+		_, err = r.resolve(ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestResolveVaultTokenFile(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"data": {"token": %q}}`, r.Header.Get("X-Vault-Token"))
+	}))
+	defer server.Close()
+
+	// Without VAULT_TOKEN, the token file of the Vault CLI is read
+	r := newSecretResolver(SecretsConfig{VaultAddress: server.URL})
+	_, err := r.resolve("vault://kv/analytics#token")
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(os.Getenv("HOME"), ".vault-token"), []byte("cli-token\n"), 0o600))
+	value, err := r.resolve("vault://kv/analytics#token")
+	require.NoError(t, err)
+	assert.Equal(t, "cli-token", value)
+
+	// A configured token file, e.g. of a Vault Agent sink, takes precedence and is read on every fetch
+	t.Setenv("VAULT_TOKEN", "env-token")
+	path := filepath.Join(t.TempDir(), "sink")
+	require.NoError(t, os.WriteFile(path, []byte("agent-token"), 0o600))
+	r = newSecretResolver(SecretsConfig{VaultAddress: server.URL, VaultTokenFile: path})
+	value, err = r.fetch(context.Background(), "vault://kv/analytics#token")
+	require.NoError(t, err)
+	assert.Equal(t, "agent-token", value)
+	require.NoError(t, os.WriteFile(path, []byte("renewed-token"), 0o600))
+	value, err = r.fetch(context.Background(), "vault://kv/analytics#token")
+	require.NoError(t, err)
+	assert.Equal(t, "renewed-token", value)
+}
+
+func TestResolveDoesNotWaitForSlowStores(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "token")
+	t.Setenv("ANALYTICS_TEST_SECRET", "env")
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`{"data": {"apiSecret": "slow"}}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	r := newSecretResolver(SecretsConfig{VaultAddress: server.URL})
+	go func() { _, _ = r.resolve("vault://kv/analytics#apiSecret") }()
+	time.Sleep(20 * time.Millisecond)
+	value, err := r.resolve("env://ANALYTICS_TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "env", value)
+}
+
+func TestResolveAWSSMSecret(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		// Signed with the credentials of the environment, for the region of the ARN
+		assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/\d{8}/eu-west-1/secretsmanager/aws4_request, `,
+			r.Header.Get("Authorization"))
+
+		var body struct{ SecretId string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.SecretId != "arn:aws:secretsmanager:eu-west-1:123456789012:secret:analytics" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"SecretString": "{\"apiSecret\": \"from-aws\"}"}`))
+	}))
+	defer server.Close()
+
+	r := newSecretResolver(SecretsConfig{AWSEndpoint: server.URL})
+	value, err := r.resolve("awssm://arn:aws:secretsmanager:eu-west-1:123456789012:secret:analytics#apiSecret")
+	require.NoError(t, err)
+	assert.Equal(t, "from-aws", value)
+	value, err = r.resolve("awssm://arn:aws:secretsmanager:eu-west-1:123456789012:secret:analytics")
+	require.NoError(t, err)
+	assert.Equal(t, `{"apiSecret": "from-aws"}`, value)
+
+	_, err = r.resolve("awssm://arn:aws:secretsmanager:eu-west-1:123456789012:secret:analytics#writeKey")
+	assert.Error(t, err)
+	_, err = r.resolve("awssm://arn:aws:secretsmanager:eu-west-1:123456789012:secret:missing")
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+}
+
+func TestResolveConfig(t *testing.T) {
+	t.Setenv("ANALYTICS_TEST_SECRET", "token")
+	conf := BackendConfig{
+		"type":    "webhook",
+		"batch":   true,
+		"headers": map[string]interface{}{"Authorization": "env://ANALYTICS_TEST_SECRET"},
+		"list":    []interface{}{"env://ANALYTICS_TEST_SECRET", 1},
+	}
+
+	resolved, err := newSecretResolver(SecretsConfig{}).resolveConfig(conf)
+	require.NoError(t, err)
+	assert.Equal(t, BackendConfig{
+		"type":    "webhook",
+		"batch":   true,
+		"headers": map[string]interface{}{"Authorization": "token"},
+		"list":    []interface{}{"token", 1},
+	}, resolved)
+	// The configuration keeps the references
+	assert.Equal(t, "env://ANALYTICS_TEST_SECRET", conf["headers"].(map[string]interface{})["Authorization"])
+
+	_, err = newSecretResolver(SecretsConfig{}).resolveConfig(BackendConfig{"apiKey": "env://ANALYTICS_TEST_MISSING"})
+	assert.Error(t, err)
+}
+
+func TestSecretsChanged(t *testing.T) {
+	t.Setenv("ANALYTICS_TEST_SECRET", "one")
+	r := newSecretResolver(SecretsConfig{})
+	_, err := r.resolve("env://ANALYTICS_TEST_SECRET")
+	require.NoError(t, err)
+
+	changed, err := r.changed(context.Background())
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	t.Setenv("ANALYTICS_TEST_SECRET", "two")
+	changed, err = r.changed(context.Background())
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	require.NoError(t, os.Unsetenv("ANALYTICS_TEST_SECRET"))
+	changed, err = r.changed(context.Background())
+	assert.Error(t, err)
+	assert.False(t, changed)
+}
+
+func TestDestinationSecrets(t *testing.T) {
+	t.Setenv("ANALYTICS_TEST_SECRET", "secret")
+	a := &Analytics{
+		TrackingID: "G-TEST",
+		APISecret:  "env://ANALYTICS_TEST_SECRET",
+		Destinations: []BackendConfig{
+			{"type": "webhook", "url": "http://collector", "headers": map[string]interface{}{"Authorization": "env://ANALYTICS_TEST_SECRET"}},
+			{"type": "webhook", "url": "http://collector", "headers": map[string]interface{}{"Authorization": "env://ANALYTICS_TEST_MISSING"}},
+		},
+	}
+	a.init()
+
+	// The destination whose secret can't be resolved is skipped
+	require.Len(t, a.destinations, 2)
+	assert.Equal(t, "secret", a.destinations[0].backend.(*GA4Backend).APISecret)
+	assert.Equal(t, "secret", a.destinations[1].backend.(*WebhookBackend).Headers["Authorization"])
+}
+
+func TestSecretsRefresh(t *testing.T) {
+	t.Setenv("ANALYTICS_TEST_SECRET", "one")
+	a := &Analytics{
+		Enabled:      true,
+		Destinations: []BackendConfig{{"type": "webhook", "url": "http://collector", "headers": map[string]interface{}{"Authorization": "env://ANALYTICS_TEST_SECRET"}}},
+		Secrets:      SecretsConfig{RefreshInterval: utils.Duration{Duration: 10 * time.Millisecond}},
+	}
+	a.Handler()
+	require.NoError(t, a.Start(context.Background()))
+	defer a.Stop(context.Background())
+
+	authorization := func() string {
+		return a.current().destinations[0].backend.(*WebhookBackend).Headers["Authorization"]
+	}
+	assert.Equal(t, "one", authorization())
+
+	t.Setenv("ANALYTICS_TEST_SECRET", "two")
+	assert.Eventually(t, func() bool { return authorization() == "two" }, time.Second, 10*time.Millisecond)
+	assert.NotNil(t, a.current().dispatcher)
+}
+
+func TestSecretsRefreshReloaded(t *testing.T) {
+	t.Setenv("ANALYTICS_TEST_SECRET", "one")
+	a := &Analytics{Enabled: true}
+	a.Handler()
+	require.NoError(t, a.Start(context.Background()))
+	defer a.Stop(context.Background())
+	assert.Nil(t, a.stopSecrets)
+
+	// A refresh interval set by a reload starts the refresher
+	require.NoError(t, a.Reload(context.Background(), []byte(`{
+		"enabled": true,
+		"destinations": [{"type": "webhook", "url": "http://collector", "headers": {"Authorization": "env://ANALYTICS_TEST_SECRET"}}],
+		"secrets": {"refreshInterval": "10ms"}
+	}`)))
+	authorization := func() string {
+		return a.current().destinations[0].backend.(*WebhookBackend).Headers["Authorization"]
+	}
+	assert.Equal(t, "one", authorization())
+	t.Setenv("ANALYTICS_TEST_SECRET", "two")
+	assert.Eventually(t, func() bool { return authorization() == "two" }, time.Second, 10*time.Millisecond)
+
+	// and removing it stops the refresher
+	require.NoError(t, a.Reload(context.Background(), []byte(`{"enabled": true}`)))
+	a.lifecycleMu.Lock()
+	assert.Nil(t, a.stopSecrets)
+	a.lifecycleMu.Unlock()
+}
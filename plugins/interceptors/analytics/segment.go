@@ -0,0 +1,148 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSegmentDataPlaneURL = "https://api.segment.io"
+	defaultSegmentBatchPath    = "/v1/batch"
+	segmentLibraryName         = "optimizely-agent"
+
+	// segmentMaxBatchBytes is the largest batch the Segment spec accepts
+	segmentMaxBatchBytes = 500 * 1024
+)
+
+// SegmentBackend sends events as track calls to the batch endpoint of the Segment HTTP API, or of
+// any data plane implementing the Segment spec such as RudderStack or Jitsu
+type SegmentBackend struct {
+	WriteKey     string `json:"writeKey"`
+	DataPlaneURL string `json:"dataPlaneURL"` // Base URL of the data plane (defaults to Segment's)
+	BatchPath    string `json:"batchPath"`    // Path of the batch endpoint (defaults to /v1/batch)
+
+	client *http.Client
+}
+
+// segmentMessage is a track call of the Segment spec
+type segmentMessage struct {
+	Type        string                 `json:"type"`
+	Event       string                 `json:"event"`
+	MessageID   string                 `json:"messageId"`
+	AnonymousID string                 `json:"anonymousId"`
+	UserID      string                 `json:"userId,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Properties  map[string]interface{} `json:"properties"`
+	Context     segmentContext         `json:"context"`
+}
+
+// segmentContext is the context of a track call
+type segmentContext struct {
+	Library struct {
+		Name string `json:"name"`
+	} `json:"library"`
+	Traits map[string]interface{} `json:"traits,omitempty"`
+}
+
+func (s *SegmentBackend) checkConfig() error {
+	if s.WriteKey == "" {
+		return errors.New("writeKey is required")
+	}
+	if s.BatchPath != "" && !strings.HasPrefix(s.BatchPath, "/") {
+		return errors.New("batchPath must start with /")
+	}
+	return nil
+}
+
+// Send posts the events as batches of track calls, splitting batches larger than the spec allows
+func (s *SegmentBackend) Send(ctx context.Context, events []Event) error {
+	dataPlaneURL := s.DataPlaneURL
+	if dataPlaneURL == "" {
+		dataPlaneURL = defaultSegmentDataPlaneURL
+	}
+	batchPath := s.BatchPath
+	if batchPath == "" {
+		batchPath = defaultSegmentBatchPath
+	}
+	endpoint := strings.TrimSuffix(dataPlaneURL, "/") + batchPath
+	headers := map[string]string{
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(s.WriteKey+":")),
+	}
+
+	var batch []json.RawMessage
+	size := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		payload := map[string]interface{}{"batch": batch, "sentAt": time.Now().UTC()}
+		batch, size = nil, 0
+		return postJSON(ctx, s.client, endpoint, payload, headers)
+	}
+	for _, e := range events {
+		msg, err := json.Marshal(s.toSegmentMessage(e))
+		if err != nil {
+			return err
+		}
+		if size+len(msg) > segmentMaxBatchBytes {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		batch = append(batch, msg)
+		size += len(msg) + 1
+	}
+	return flush()
+}
+
+func (s *SegmentBackend) toSegmentMessage(e Event) segmentMessage {
+	timestamp := eventTime(e, time.Now())
+	msg := segmentMessage{
+		Type:        "track",
+		Event:       e.Name,
+		MessageID:   e.IdempotencyKey,
+		AnonymousID: e.ClientID,
+		UserID:      e.UserID,
+		Timestamp:   timestamp.UTC(),
+		Properties:  e.Params,
+	}
+	if msg.MessageID == "" {
+		msg.MessageID = uuid.NewString()
+	}
+	msg.Context.Library.Name = segmentLibraryName
+	msg.Context.Traits = e.UserProperties
+	return msg
+}
+
+func (s *SegmentBackend) setHTTPClient(client *http.Client) {
+	s.client = client
+}
+
+func init() {
+	AddBackend("segment", func() Backend {
+		return &SegmentBackend{}
+	})
+}
@@ -0,0 +1,103 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// segmentPayload is a batch as received by a Segment-spec data plane
+type segmentPayload struct {
+	Batch  []segmentMessage `json:"batch"`
+	SentAt time.Time        `json:"sentAt"`
+}
+
+func TestSegmentBackendSend(t *testing.T) {
+	var payloads []segmentPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/batch", r.URL.Path)
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "write-key", user)
+		assert.Empty(t, password)
+
+		var payload segmentPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+	}))
+	defer ts.Close()
+
+	backend := &SegmentBackend{WriteKey: "write-key", DataPlaneURL: ts.URL + "/"}
+	timestamp := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "client-1", UserID: "user-1", Timestamp: timestamp,
+			Params: map[string]interface{}{pathParam: "/v1/decide"}, UserProperties: map[string]interface{}{"plan": "pro"}},
+		{Name: "api_request", ClientID: "client-2", Params: map[string]interface{}{}, IdempotencyKey: "key"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, payloads, 1)
+	require.Len(t, payloads[0].Batch, 2)
+	msg := payloads[0].Batch[0]
+	assert.Equal(t, "track", msg.Type)
+	assert.Equal(t, "api_request", msg.Event)
+	assert.Equal(t, "client-1", msg.AnonymousID)
+	assert.Equal(t, "user-1", msg.UserID)
+	assert.Equal(t, timestamp, msg.Timestamp)
+	assert.Equal(t, "/v1/decide", msg.Properties[pathParam])
+	assert.Equal(t, segmentLibraryName, msg.Context.Library.Name)
+	assert.Equal(t, map[string]interface{}{"plan": "pro"}, msg.Context.Traits)
+	assert.NotEmpty(t, msg.MessageID)
+	assert.Equal(t, "key", payloads[0].Batch[1].MessageID)
+	assert.False(t, payloads[0].SentAt.IsZero())
+}
+
+func TestSegmentBackendCheckConfig(t *testing.T) {
+	assert.EqualError(t, (&SegmentBackend{}).checkConfig(), "writeKey is required")
+	assert.EqualError(t, (&SegmentBackend{WriteKey: "key", BatchPath: "v1/batch"}).checkConfig(), "batchPath must start with /")
+	assert.NoError(t, (&SegmentBackend{WriteKey: "key", DataPlaneURL: "https://rudder.example.com"}).checkConfig())
+}
+
+func TestSegmentBackendSplitsBatches(t *testing.T) {
+	var batches []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/s/s2s/batch", r.URL.Path)
+		var payload segmentPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		batches = append(batches, len(payload.Batch))
+	}))
+	defer ts.Close()
+
+	// Three events of about 200KB exceed a single 500KB batch
+	large := strings.Repeat("x", 200*1024)
+	events := make([]Event, 3)
+	for i := range events {
+		events[i] = Event{Name: "api_request", ClientID: "client", Params: map[string]interface{}{"large": large}}
+	}
+	backend := &SegmentBackend{WriteKey: "write-key", DataPlaneURL: ts.URL, BatchPath: "/api/s/s2s/batch"}
+	require.NoError(t, backend.Send(context.Background(), events))
+	assert.Equal(t, []int{2, 1}, batches)
+}
@@ -0,0 +1,124 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	defaultSessionTimeout    = 30 * time.Minute
+	defaultSessionMaxClients = 100000
+)
+
+// SessionConfig enables server-side sessions, which GA4 needs to show events in its realtime
+// and session reports
+type SessionConfig struct {
+	Enabled    bool           `json:"enabled"`
+	Timeout    utils.Duration `json:"timeout"`    // Inactivity that ends a session (defaults to 30m)
+	MaxClients int            `json:"maxClients"` // Clients tracked in memory; the least recently seen are evicted (defaults to 100000)
+}
+
+// session is the state of a client's current session
+type session struct {
+	clientID string
+	id       int64 // start of the session in unix seconds, as GA4 session IDs
+	number   int   // sessions of the client so far, including this one
+	lastSeen time.Time
+}
+
+// sessionStore assigns events to sessions. It is an LRU of clients with a TTL per session.
+type sessionStore struct {
+	timeout    time.Duration
+	maxClients int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*list.Element
+	order   *list.List // most recently seen first
+}
+
+func newSessionStore(conf SessionConfig) *sessionStore {
+	if !conf.Enabled {
+		return nil
+	}
+
+	s := &sessionStore{
+		timeout:    conf.Timeout.Duration,
+		maxClients: conf.MaxClients,
+		now:        time.Now,
+		clients:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultSessionTimeout
+	}
+	if s.maxClients <= 0 {
+		s.maxClients = defaultSessionMaxClients
+	}
+	return s
+}
+
+// track records activity of the client and returns its session along with the engagement
+// time since the previous event of the session
+func (s *sessionStore) track(clientID string) (session, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if el, ok := s.clients[clientID]; ok {
+		sess := el.Value.(*session)
+		s.order.MoveToFront(el)
+
+		if elapsed := now.Sub(sess.lastSeen); elapsed < s.timeout {
+			sess.lastSeen = now
+			return *sess, elapsed
+		}
+
+		// The previous session timed out
+		sess.id = now.Unix()
+		sess.number++
+		sess.lastSeen = now
+		return *sess, 0
+	}
+
+	sess := &session{clientID: clientID, id: now.Unix(), number: 1, lastSeen: now}
+	s.clients[clientID] = s.order.PushFront(sess)
+	if s.order.Len() > s.maxClients {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.clients, oldest.Value.(*session).clientID)
+	}
+	return *sess, 0
+}
+
+// addSessionParams assigns the event to the client's session. GA4 only counts engaged events,
+// so the engagement time is at least 1ms.
+func addSessionParams(event *Event, store *sessionStore) {
+	sess, engagement := store.track(event.ClientID)
+	msec := engagement.Milliseconds()
+	if msec < 1 {
+		msec = 1
+	}
+	event.Params[sessionIDParam] = sess.id
+	event.Params[sessionNumberParam] = sess.number
+	event.Params[engagementTimeMsecParam] = msec
+}
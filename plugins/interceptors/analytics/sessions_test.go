@@ -0,0 +1,105 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func TestSessionStoreDisabled(t *testing.T) {
+	assert.Nil(t, newSessionStore(SessionConfig{}))
+}
+
+func TestSessionStoreTrack(t *testing.T) {
+	s := newSessionStore(SessionConfig{Enabled: true, Timeout: utils.Duration{Duration: 10 * time.Minute}})
+	require.NotNil(t, s)
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+
+	sess, engagement := s.track("client")
+	assert.Equal(t, int64(1700000000), sess.id)
+	assert.Equal(t, 1, sess.number)
+	assert.Zero(t, engagement)
+
+	now = now.Add(5 * time.Minute)
+	sess, engagement = s.track("client")
+	assert.Equal(t, int64(1700000000), sess.id)
+	assert.Equal(t, 5*time.Minute, engagement)
+
+	// Inactivity past the timeout starts a new session
+	now = now.Add(10 * time.Minute)
+	sess, engagement = s.track("client")
+	assert.Equal(t, now.Unix(), sess.id)
+	assert.Equal(t, 2, sess.number)
+	assert.Zero(t, engagement)
+}
+
+func TestSessionStoreEvictsLeastRecentlySeen(t *testing.T) {
+	s := newSessionStore(SessionConfig{Enabled: true, MaxClients: 2})
+	s.track("a")
+	s.track("b")
+	s.track("a")
+	s.track("c")
+
+	assert.Len(t, s.clients, 2)
+	assert.Contains(t, s.clients, "a")
+	assert.NotContains(t, s.clients, "b")
+}
+
+func TestAddSessionParams(t *testing.T) {
+	s := newSessionStore(SessionConfig{Enabled: true})
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+
+	event := Event{ClientID: "client", Params: map[string]interface{}{}}
+	addSessionParams(&event, s)
+	assert.Equal(t, map[string]interface{}{
+		"session_id":           int64(1700000000),
+		"session_number":       1,
+		"engagement_time_msec": int64(1),
+	}, event.Params)
+
+	now = now.Add(1500 * time.Millisecond)
+	addSessionParams(&event, s)
+	assert.Equal(t, int64(1500), event.Params["engagement_time_msec"])
+}
+
+func TestAnalyticsSessions(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, Sessions: SessionConfig{Enabled: true}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/v1/config", nil)
+		req.AddCookie(&http.Cookie{Name: "_ga", Value: "client"})
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	first, second := backend.next(t), backend.next(t)
+	assert.Equal(t, first.Params["session_id"], second.Params["session_id"])
+	assert.Equal(t, 1, second.Params["session_number"])
+	assert.Contains(t, second.Params, "engagement_time_msec")
+}
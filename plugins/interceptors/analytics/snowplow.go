@@ -0,0 +1,113 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	snowplowPath                = "/com.snowplowanalytics.snowplow/tp2"
+	snowplowPayloadDataSchema   = "iglu:com.snowplowanalytics.snowplow/payload_data/jsonschema/1-0-4"
+	snowplowUnstructEventSchema = "iglu:com.snowplowanalytics.snowplow/unstruct_event/jsonschema/1-0-0"
+	defaultSnowplowEventSchema  = "iglu:com.optimizely.agent/api_request/jsonschema/1-0-0"
+	snowplowTrackerVersion      = "optimizely-agent-1.0.0"
+)
+
+// SnowplowBackend sends events to a Snowplow collector using the tracker protocol (tp2)
+// as self-describing (unstructured) events
+type SnowplowBackend struct {
+	CollectorURL string `json:"collectorURL"`
+	AppID        string `json:"appID"`
+	Namespace    string `json:"namespace"`
+	EventSchema  string `json:"eventSchema"` // Iglu URI of the self-describing event schema
+
+	client *http.Client
+}
+
+// selfDescribingJSON is the Snowplow envelope that pairs data with its Iglu schema
+type selfDescribingJSON struct {
+	Schema string      `json:"schema"`
+	Data   interface{} `json:"data"`
+}
+
+// Send posts the events as a single tp2 payload_data batch
+func (s *SnowplowBackend) Send(ctx context.Context, events []Event) error {
+	eventSchema := s.EventSchema
+	if eventSchema == "" {
+		eventSchema = defaultSnowplowEventSchema
+	}
+
+	now := time.Now()
+	sentAt := strconv.FormatInt(now.UnixMilli(), 10)
+	data := make([]map[string]string, 0, len(events))
+	for _, e := range events {
+		unstructEvent, err := json.Marshal(selfDescribingJSON{
+			Schema: snowplowUnstructEventSchema,
+			Data: selfDescribingJSON{
+				Schema: eventSchema,
+				Data:   e.Params,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		fields := map[string]string{
+			"e":     "ue",
+			"p":     "srv",
+			"tv":    snowplowTrackerVersion,
+			"tna":   s.Namespace,
+			"aid":   s.AppID,
+			"eid":   e.IdempotencyKey,
+			"duid":  e.ClientID,
+			"dtm":   strconv.FormatInt(eventTime(e, now).UnixMilli(), 10),
+			"stm":   sentAt,
+			"ue_pr": string(unstructEvent),
+		}
+		if fields["eid"] == "" {
+			fields["eid"] = uuid.NewString()
+		}
+		if e.UserID != "" {
+			fields["uid"] = e.UserID
+		}
+		data = append(data, fields)
+	}
+
+	payload := selfDescribingJSON{
+		Schema: snowplowPayloadDataSchema,
+		Data:   data,
+	}
+	return postJSON(ctx, s.client, strings.TrimSuffix(s.CollectorURL, "/")+snowplowPath, payload, nil)
+}
+
+func (s *SnowplowBackend) setHTTPClient(client *http.Client) {
+	s.client = client
+}
+
+func init() {
+	AddBackend("snowplow", func() Backend {
+		return &SnowplowBackend{}
+	})
+}
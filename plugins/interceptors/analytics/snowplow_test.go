@@ -0,0 +1,72 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnowplowBackendSend(t *testing.T) {
+	var payload struct {
+		Schema string              `json:"schema"`
+		Data   []map[string]string `json:"data"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, snowplowPath, r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	backend := &SnowplowBackend{CollectorURL: ts.URL + "/", AppID: "agent", Namespace: "ns"}
+	err := backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "client", Timestamp: time.UnixMilli(1700000000000), Params: map[string]interface{}{"path": "/v1/decide"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, snowplowPayloadDataSchema, payload.Schema)
+	require.Len(t, payload.Data, 1)
+	data := payload.Data[0]
+	assert.Equal(t, "ue", data["e"])
+	assert.Equal(t, "srv", data["p"])
+	assert.Equal(t, "agent", data["aid"])
+	assert.Equal(t, "ns", data["tna"])
+	assert.Equal(t, "client", data["duid"])
+	assert.NotEmpty(t, data["eid"])
+	assert.Equal(t, "1700000000000", data["dtm"])
+	assert.NotEmpty(t, data["stm"])
+
+	var unstructEvent struct {
+		Schema string `json:"schema"`
+		Data   struct {
+			Schema string                 `json:"schema"`
+			Data   map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(data["ue_pr"]), &unstructEvent))
+	assert.Equal(t, snowplowUnstructEventSchema, unstructEvent.Schema)
+	assert.Equal(t, defaultSnowplowEventSchema, unstructEvent.Data.Schema)
+	assert.Equal(t, "/v1/decide", unstructEvent.Data.Data["path"])
+}
@@ -0,0 +1,402 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	defaultSpillMaxBytes       = 64 << 20
+	defaultSpillMaxAge         = 24 * time.Hour
+	defaultSpillReplayInterval = 30 * time.Second
+	spillSegmentBytes          = 1 << 20
+	spillSegmentSuffix         = ".ndjson"
+)
+
+// SpillConfig configures the on-disk queue for events that cannot be delivered right away
+type SpillConfig struct {
+	Directory      string         `json:"directory"`      // Directory for spill files; spilling is disabled when empty
+	MaxBytes       int64          `json:"maxBytes"`       // Maximum total size of spill files (defaults to 64MiB)
+	MaxAge         utils.Duration `json:"maxAge"`         // Spilled events older than this are discarded (defaults to 24h)
+	ReplayInterval utils.Duration `json:"replayInterval"` // How often spilled events are replayed (defaults to 30s)
+}
+
+// spillRecord is a spilled event. Events spilled because the queue was full have no
+// destination and are re-queued for every destination on replay.
+type spillRecord struct {
+	Destination string    `json:"destination,omitempty"`
+	SpilledAt   time.Time `json:"spilledAt"`
+	Event       Event     `json:"event"`
+}
+
+var (
+	spillQueues   = map[string]*spillQueue{}
+	spillQueuesMu sync.Mutex
+)
+
+// spillQueue is an append-only queue of NDJSON segment files bounded by total size and record age
+type spillQueue struct {
+	dir          string
+	maxBytes     int64
+	maxAge       time.Duration
+	interval     time.Duration
+	segmentBytes int64
+	metrics      *analyticsMetrics
+
+	mu          sync.Mutex
+	current     *os.File
+	currentName string
+	currentSize int64
+	sizes       map[string]int64 // sizes of the segments on disk, by name
+	total       int64            // sum of sizes
+	replaying   bool             // replay is rewriting and removing segments; eviction waits for it
+
+	// Replay state, guarded by spillQueuesMu
+	deliver func([]spillRecord) int
+	refs    int
+	stop    chan struct{}
+}
+
+// openSpillQueue returns the spill queue for the configured directory. Interceptor instances
+// configured with the same directory share a single queue.
+func openSpillQueue(conf SpillConfig, m *analyticsMetrics) (*spillQueue, error) {
+	dir, err := filepath.Abs(conf.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	spillQueuesMu.Lock()
+	defer spillQueuesMu.Unlock()
+	if q, ok := spillQueues[dir]; ok {
+		return q, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	q := &spillQueue{
+		dir:          dir,
+		maxBytes:     conf.MaxBytes,
+		maxAge:       conf.MaxAge.Duration,
+		interval:     conf.ReplayInterval.Duration,
+		segmentBytes: spillSegmentBytes,
+		metrics:      m,
+		sizes:        map[string]int64{},
+	}
+	if q.maxBytes <= 0 {
+		q.maxBytes = defaultSpillMaxBytes
+	}
+	if q.maxAge <= 0 {
+		q.maxAge = defaultSpillMaxAge
+	}
+	if q.interval <= 0 {
+		q.interval = defaultSpillReplayInterval
+	}
+
+	// Segments left by a previous run count towards the limit
+	segments, err := q.segments()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range segments {
+		if info, err := os.Stat(name); err == nil {
+			q.resize(name, info.Size())
+		}
+	}
+
+	spillQueues[dir] = q
+	return q, nil
+}
+
+// attach registers deliver for replaying the queue, starting the replay loop for the first
+// attached dispatcher. The most recently attached deliver is used, so that a reloaded
+// dispatcher takes over replaying from the one it replaces.
+func (q *spillQueue) attach(deliver func([]spillRecord) int) {
+	spillQueuesMu.Lock()
+	defer spillQueuesMu.Unlock()
+	spillQueues[q.dir] = q
+	q.deliver = deliver
+	q.refs++
+	if q.stop == nil {
+		q.stop = make(chan struct{})
+		go q.replayLoop(q.stop)
+	}
+}
+
+// detach releases a dispatcher's reference, stopping the replay loop and closing the queue
+// when it was the last one
+func (q *spillQueue) detach() {
+	spillQueuesMu.Lock()
+	defer spillQueuesMu.Unlock()
+	q.refs--
+	if q.refs > 0 {
+		return
+	}
+	close(q.stop)
+	q.stop = nil
+	q.mu.Lock()
+	q.seal()
+	q.mu.Unlock()
+	if spillQueues[q.dir] == q {
+		delete(spillQueues, q.dir)
+	}
+}
+
+// write appends the record to the current segment, evicting the oldest segments
+// when the queue exceeds its size limit
+func (q *spillQueue) write(rec spillRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.current == nil || q.currentSize+int64(len(line)) > q.segmentBytes {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := q.current.Write(line)
+	q.currentSize += int64(n)
+	q.resize(q.currentName, q.currentSize)
+	if err != nil {
+		return err
+	}
+
+	q.metrics.spillWritten.Add(1)
+	if !q.replaying {
+		q.enforceLimit()
+	}
+	return nil
+}
+
+// rotate closes the current segment and starts a new one. Segment names sort chronologically.
+func (q *spillQueue) rotate() error {
+	q.seal()
+
+	name := filepath.Join(q.dir, fmt.Sprintf("spill-%020d%s", time.Now().UnixNano(), spillSegmentSuffix))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	q.current, q.currentName, q.currentSize = f, name, 0
+	q.resize(name, 0)
+	return nil
+}
+
+// resize records the size of a segment. The caller holds q.mu, or owns q while opening it.
+func (q *spillQueue) resize(name string, size int64) {
+	q.total += size - q.sizes[name]
+	q.sizes[name] = size
+}
+
+// forget drops a removed segment from the total. The caller holds q.mu.
+func (q *spillQueue) forget(name string) {
+	q.total -= q.sizes[name]
+	delete(q.sizes, name)
+}
+
+// seal closes the current segment so it can be replayed
+func (q *spillQueue) seal() {
+	if q.current == nil {
+		return
+	}
+	if err := q.current.Close(); err != nil {
+		log.Warn().Err(err).Msg("Failed to close analytics spill segment")
+	}
+	q.current, q.currentName, q.currentSize = nil, "", 0
+}
+
+// enforceLimit removes the oldest sealed segments while the queue is over its size limit. The
+// caller holds q.mu and no replay is running.
+func (q *spillQueue) enforceLimit() {
+	if q.total <= q.maxBytes {
+		return
+	}
+
+	segments := make([]string, 0, len(q.sizes))
+	for name := range q.sizes {
+		segments = append(segments, name)
+	}
+	sort.Strings(segments)
+
+	for _, name := range segments {
+		if q.total <= q.maxBytes || name == q.currentName {
+			break
+		}
+		dropped := countLines(name)
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			log.Warn().Err(err).Msg("Failed to remove analytics spill segment")
+			continue
+		}
+		q.forget(name)
+		q.metrics.spillDropped.Add(float64(dropped))
+		log.Warn().Int("events", dropped).Msg("Analytics spill queue is full, dropping oldest events")
+	}
+}
+
+// segments lists the segment files, oldest first
+func (q *spillQueue) segments() ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(q.dir, "spill-*"+spillSegmentSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// replay passes the spilled records of each segment to deliver, oldest first, which returns how
+// many of them it handled. Replay stops at the first record deliver didn't handle; that record
+// and everything after it stay queued for the next replay. Segments
+// aren't evicted while replay rewrites them, the limit is enforced once it is done.
+func (q *spillQueue) replay(deliver func([]spillRecord) int) {
+	q.mu.Lock()
+	q.seal()
+	q.replaying = true
+	segments, err := q.segments()
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.replaying = false
+		q.enforceLimit()
+	}()
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to list analytics spill segments")
+		return
+	}
+
+	for _, name := range segments {
+		records, err := readSpillSegment(name)
+		if err != nil {
+			log.Warn().Err(err).Str("segment", name).Msg("Failed to read analytics spill segment")
+			continue
+		}
+
+		live := records[:0]
+		for _, rec := range records {
+			if time.Since(rec.SpilledAt) > q.maxAge {
+				q.metrics.spillDropped.Add(1)
+				continue
+			}
+			live = append(live, rec)
+		}
+		if len(live) > 0 {
+			delivered := deliver(live)
+			q.metrics.spillReplayed.Add(float64(delivered))
+			if delivered < len(live) {
+				if err := writeSpillSegment(name, live[delivered:]); err != nil {
+					log.Warn().Err(err).Str("segment", name).Msg("Failed to rewrite analytics spill segment")
+				}
+				if info, err := os.Stat(name); err == nil {
+					q.mu.Lock()
+					q.resize(name, info.Size())
+					q.mu.Unlock()
+				}
+				return
+			}
+		}
+
+		if err := os.Remove(name); err != nil {
+			log.Warn().Err(err).Str("segment", name).Msg("Failed to remove analytics spill segment")
+			continue
+		}
+		q.mu.Lock()
+		q.forget(name)
+		q.mu.Unlock()
+	}
+}
+
+// replayLoop replays the queue with the attached deliver on every interval tick until stop is closed
+func (q *spillQueue) replayLoop(stop chan struct{}) {
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			spillQueuesMu.Lock()
+			deliver := q.deliver
+			spillQueuesMu.Unlock()
+			q.replay(deliver)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func readSpillSegment(name string) ([]spillRecord, error) {
+	f, err := os.Open(filepath.Clean(name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []spillRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), spillSegmentBytes)
+	for scanner.Scan() {
+		var rec spillRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// Skip records that were only partially written, e.g. on a crash
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// writeSpillSegment atomically replaces the segment with the given records
+func writeSpillSegment(name string, records []spillRecord) error {
+	var sb strings.Builder
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		sb.Write(line)
+		sb.WriteByte('\n')
+	}
+
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+func countLines(name string) int {
+	records, _ := readSpillSegment(name)
+	return len(records)
+}
@@ -0,0 +1,311 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func newTestSpillQueue(t *testing.T, conf SpillConfig) *spillQueue {
+	conf.Directory = t.TempDir()
+	q, err := openSpillQueue(conf, newAnalyticsMetrics())
+	require.NoError(t, err)
+	return q
+}
+
+func spillEvent(name string) spillRecord {
+	return spillRecord{Destination: "ga4", SpilledAt: time.Now(), Event: Event{Name: name, ClientID: "client"}}
+}
+
+func TestSpillQueueSharedByDirectory(t *testing.T) {
+	dir := t.TempDir()
+	first, err := openSpillQueue(SpillConfig{Directory: dir}, newAnalyticsMetrics())
+	require.NoError(t, err)
+
+	second, err := openSpillQueue(SpillConfig{Directory: dir + "/"}, newAnalyticsMetrics())
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+// eachRecord adapts a deliver of single records to replay, stopping at the first it rejects
+func eachRecord(deliver func(spillRecord) bool) func([]spillRecord) int {
+	return func(recs []spillRecord) int {
+		for i, rec := range recs {
+			if !deliver(rec) {
+				return i
+			}
+		}
+		return len(recs)
+	}
+}
+
+func TestSpillQueueAttachAndDetach(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{ReplayInterval: utils.Duration{Duration: 10 * time.Millisecond}})
+	require.NoError(t, q.write(spillEvent("spilled")))
+
+	// The most recently attached deliver replays the queue
+	replayed := make(chan string, 10)
+	q.attach(eachRecord(func(spillRecord) bool { return false }))
+	q.attach(eachRecord(func(rec spillRecord) bool {
+		replayed <- rec.Event.Name
+		return true
+	}))
+	select {
+	case name := <-replayed:
+		assert.Equal(t, "spilled", name)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for replay")
+	}
+
+	// The queue stays registered until the last dispatcher detaches
+	q.detach()
+	second, err := openSpillQueue(SpillConfig{Directory: q.dir}, newAnalyticsMetrics())
+	require.NoError(t, err)
+	assert.Same(t, q, second)
+	q.detach()
+	third, err := openSpillQueue(SpillConfig{Directory: q.dir}, newAnalyticsMetrics())
+	require.NoError(t, err)
+	assert.NotSame(t, q, third)
+}
+
+func TestSpillQueueDetachSealsSegment(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{})
+	q.attach(eachRecord(func(spillRecord) bool { return false }))
+	require.NoError(t, q.write(spillEvent("spilled")))
+	current := q.current
+	require.NotNil(t, current)
+
+	// The last detach closes the segment being written
+	q.detach()
+	assert.Nil(t, q.current)
+	assert.Error(t, current.Close())
+}
+
+func TestSpillQueueReplaysInOrder(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{})
+	require.NoError(t, q.write(spillEvent("first")))
+	require.NoError(t, q.write(spillEvent("second")))
+
+	var replayed []string
+	q.replay(eachRecord(func(rec spillRecord) bool {
+		assert.Equal(t, "ga4", rec.Destination)
+		replayed = append(replayed, rec.Event.Name)
+		return true
+	}))
+	assert.Equal(t, []string{"first", "second"}, replayed)
+
+	segments, err := q.segments()
+	require.NoError(t, err)
+	assert.Empty(t, segments)
+}
+
+func TestSpillQueueKeepsRejectedRecords(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{})
+	for _, name := range []string{"first", "second", "third"} {
+		require.NoError(t, q.write(spillEvent(name)))
+	}
+
+	var replayed []string
+	q.replay(eachRecord(func(rec spillRecord) bool {
+		if rec.Event.Name == "second" {
+			return false
+		}
+		replayed = append(replayed, rec.Event.Name)
+		return true
+	}))
+	assert.Equal(t, []string{"first"}, replayed)
+
+	// Records written during an outage are replayed after the kept ones
+	require.NoError(t, q.write(spillEvent("fourth")))
+	q.replay(eachRecord(func(rec spillRecord) bool {
+		replayed = append(replayed, rec.Event.Name)
+		return true
+	}))
+	assert.Equal(t, []string{"first", "second", "third", "fourth"}, replayed)
+}
+
+func TestSpillQueueDiscardsExpiredRecords(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{MaxAge: utils.Duration{Duration: time.Hour}})
+	expired := spillEvent("expired")
+	expired.SpilledAt = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, q.write(expired))
+	require.NoError(t, q.write(spillEvent("fresh")))
+
+	var replayed []string
+	q.replay(eachRecord(func(rec spillRecord) bool {
+		replayed = append(replayed, rec.Event.Name)
+		return true
+	}))
+	assert.Equal(t, []string{"fresh"}, replayed)
+}
+
+func TestSpillQueueEvictsOldestSegments(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{MaxBytes: 300})
+	q.segmentBytes = 150
+	for i := 0; i < 10; i++ {
+		require.NoError(t, q.write(spillEvent("event")))
+	}
+
+	segments, err := q.segments()
+	require.NoError(t, err)
+	var total int64
+	for _, name := range segments {
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		total += info.Size()
+	}
+	assert.LessOrEqual(t, total, int64(300))
+	assert.Greater(t, len(segments), 0)
+	assert.Equal(t, total, q.total)
+}
+
+// spillBytesOnDisk sums the sizes of the segments of q
+func spillBytesOnDisk(t *testing.T, q *spillQueue) int64 {
+	segments, err := q.segments()
+	require.NoError(t, err)
+	var total int64
+	for _, name := range segments {
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		total += info.Size()
+	}
+	return total
+}
+
+func TestSpillQueueEvictsAfterReplay(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{MaxBytes: 300})
+	q.segmentBytes = 150
+	require.NoError(t, q.write(spillEvent("first")))
+	require.NoError(t, q.write(spillEvent("second")))
+
+	// Events spilled while replaying don't evict the segments being replayed
+	q.replay(eachRecord(func(rec spillRecord) bool {
+		for i := 0; i < 10; i++ {
+			require.NoError(t, q.write(spillEvent("during")))
+		}
+		return false
+	}))
+
+	assert.LessOrEqual(t, spillBytesOnDisk(t, q), int64(300))
+	assert.Equal(t, spillBytesOnDisk(t, q), q.total)
+}
+
+func TestSpillQueueCountsExistingSegments(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{})
+	require.NoError(t, q.write(spillEvent("first")))
+	q.mu.Lock()
+	q.seal()
+	q.mu.Unlock()
+
+	// A queue opened on the directory of a previous run counts its segments
+	spillQueuesMu.Lock()
+	delete(spillQueues, q.dir)
+	spillQueuesMu.Unlock()
+	reopened, err := openSpillQueue(SpillConfig{Directory: q.dir}, newAnalyticsMetrics())
+	require.NoError(t, err)
+	assert.NotSame(t, q, reopened)
+	assert.Equal(t, spillBytesOnDisk(t, q), reopened.total)
+	assert.Greater(t, reopened.total, int64(0))
+}
+
+func TestReadSpillSegmentSkipsPartialRecords(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "spill-1.ndjson")
+	require.NoError(t, writeSpillSegment(name, []spillRecord{spillEvent("complete")}))
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o640)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"destination":"ga4","ev`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	records, err := readSpillSegment(name)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "complete", records[0].Event.Name)
+}
+
+func TestDispatcherSpillsWhenQueueIsFull(t *testing.T) {
+	q := newTestSpillQueue(t, SpillConfig{})
+	d := &dispatcher{queue: make(chan Event, 1), spill: q, metrics: newAnalyticsMetrics(), ctx: context.Background()}
+
+	assert.True(t, d.enqueue(Event{Name: "queued"}))
+	assert.True(t, d.enqueue(Event{Name: "spilled"}))
+
+	// Replay re-queues the event once there is room again
+	<-d.queue
+	q.replay(d.replay)
+	assert.Equal(t, "spilled", (<-d.queue).Name)
+}
+
+func TestDispatcherSpillsAndReplaysFailedDeliveries(t *testing.T) {
+	backend := newMockBackend()
+	backend.err = &StatusError{StatusCode: http.StatusServiceUnavailable}
+	q := newTestSpillQueue(t, SpillConfig{})
+	d := &dispatcher{
+		destinations: []destination{{name: "mock", backend: backend}},
+		spill:        q,
+		metrics:      newAnalyticsMetrics(),
+		ctx:          context.Background(),
+	}
+
+	d.deliver([]Event{{Name: "api_request"}})
+	backend.next(t)
+
+	// Still failing, the event stays spilled
+	q.replay(d.replay)
+	backend.next(t)
+	segments, err := q.segments()
+	require.NoError(t, err)
+	assert.Len(t, segments, 1)
+
+	backend.err = nil
+	q.replay(d.replay)
+	assert.Equal(t, "api_request", backend.next(t).Name)
+	segments, err = q.segments()
+	require.NoError(t, err)
+	assert.Empty(t, segments)
+}
+
+func TestDispatcherDoesNotSpillPermanentFailures(t *testing.T) {
+	backend := newMockBackend()
+	backend.err = errors.New("invalid payload")
+	q := newTestSpillQueue(t, SpillConfig{})
+	d := &dispatcher{
+		destinations: []destination{{name: "mock", backend: backend}},
+		spill:        q,
+		metrics:      newAnalyticsMetrics(),
+		ctx:          context.Background(),
+	}
+
+	d.deliver([]Event{{Name: "api_request"}})
+	backend.next(t)
+	q.replay(eachRecord(func(spillRecord) bool {
+		t.Fatal("permanent failures must not be spilled")
+		return true
+	}))
+}
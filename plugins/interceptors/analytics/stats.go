@@ -0,0 +1,190 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// dispatchStats counts the events offered to a dispatcher, for the stats endpoint
+type dispatchStats struct {
+	accepted    atomic.Int64 // queued or spilled for dispatch
+	dropped     atomic.Int64 // dropped because the queue was full or closed
+	rateLimited atomic.Int64 // over the rate limit
+	duplicates  atomic.Int64 // repeating a recent event of the same request ID
+	shed        atomic.Int64 // low priority events shed under pressure
+}
+
+// destinationCounter identifies a delivery outcome counted by destinationStats
+type destinationCounter int
+
+const (
+	statAccepted       destinationCounter = iota // routed to the destination
+	statSent                                     // events delivered, including replays
+	statFailed                                   // events whose delivery failed after retries
+	statRetried                                  // retries of failed attempts
+	statShortCircuited                           // skipped by the open circuit breaker
+	statSpilled                                  // kept in the spill queue for a later replay
+	statDeadLettered                             // rejected and routed to the dead letter sink
+	statDropped                                  // not delivered and neither spilled nor dead-lettered
+	numDestinationCounters
+)
+
+// destinationStats counts the deliveries to a destination, for the stats endpoint. A nil
+// destinationStats counts nothing.
+type destinationStats struct {
+	counts [numDestinationCounters]atomic.Int64
+
+	mu            sync.Mutex
+	lastError     string
+	lastErrorAt   time.Time
+	lastSuccessAt time.Time
+}
+
+// add counts an outcome
+func (s *destinationStats) add(c destinationCounter) {
+	s.addN(c, 1)
+}
+
+// addN counts an outcome of n events
+func (s *destinationStats) addN(c destinationCounter, n int) {
+	if s != nil {
+		s.counts[c].Add(int64(n))
+	}
+}
+
+// recordResult counts the outcome of the delivery of a batch of events, remembering the last error
+func (s *destinationStats) recordResult(err error, events int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.counts[statSent].Add(int64(events))
+		s.lastSuccessAt = time.Now()
+		return
+	}
+	s.counts[statFailed].Add(int64(events))
+	s.lastError = err.Error()
+	s.lastErrorAt = time.Now()
+}
+
+// analyticsStats is the admin API representation of the pipeline stats of all instances
+type analyticsStats struct {
+	Instances []instanceStats `json:"instances"`
+}
+
+// instanceStats reports the dispatcher of one interceptor instance
+type instanceStats struct {
+	Name          string                  `json:"name"` // name the instance is configured under
+	Started       bool                    `json:"started"`
+	Queued        int                     `json:"queued"`
+	QueueCapacity int                     `json:"queueCapacity"`
+	Accepted      int64                   `json:"accepted"`
+	Dropped       int64                   `json:"dropped"`
+	RateLimited   int64                   `json:"rateLimited"`
+	Duplicates    int64                   `json:"duplicates"`
+	Shed          int64                   `json:"shed"`
+	Destinations  []destinationStatsState `json:"destinations"`
+}
+
+// destinationStatsState reports the deliveries and circuit breaker of a destination
+type destinationStatsState struct {
+	Name           string     `json:"name"`
+	Breaker        string     `json:"breaker"`
+	Accepted       int64      `json:"accepted"`
+	Sent           int64      `json:"sent"`
+	Failed         int64      `json:"failed"`
+	Retried        int64      `json:"retried"`
+	ShortCircuited int64      `json:"shortCircuited"`
+	Spilled        int64      `json:"spilled"`
+	DeadLettered   int64      `json:"deadLettered"`
+	Dropped        int64      `json:"dropped"`
+	LastError      string     `json:"lastError,omitempty"`
+	LastErrorAt    *time.Time `json:"lastErrorAt,omitempty"`
+	LastSuccessAt  *time.Time `json:"lastSuccessAt,omitempty"`
+}
+
+// state returns the admin API representation of the stats
+func (s *destinationStats) state(name string, breaker breakerState) destinationStatsState {
+	state := destinationStatsState{Name: name, Breaker: breaker.String()}
+	if s == nil {
+		return state
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state.Accepted = s.counts[statAccepted].Load()
+	state.Sent = s.counts[statSent].Load()
+	state.Failed = s.counts[statFailed].Load()
+	state.Retried = s.counts[statRetried].Load()
+	state.ShortCircuited = s.counts[statShortCircuited].Load()
+	state.Spilled = s.counts[statSpilled].Load()
+	state.DeadLettered = s.counts[statDeadLettered].Load()
+	state.Dropped = s.counts[statDropped].Load()
+	state.LastError = s.lastError
+	if !s.lastErrorAt.IsZero() {
+		at := s.lastErrorAt
+		state.LastErrorAt = &at
+	}
+	if !s.lastSuccessAt.IsZero() {
+		at := s.lastSuccessAt
+		state.LastSuccessAt = &at
+	}
+	return state
+}
+
+// currentStats returns the pipeline stats of all instances. The counts are reset when an
+// instance is reloaded.
+func currentStats() analyticsStats {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+
+	stats := analyticsStats{Instances: []instanceStats{}}
+	for _, instance := range instances {
+		is := instanceStats{Name: instance.instanceName(), Destinations: []destinationStatsState{}}
+		if d := instance.current().dispatcher; d != nil {
+			is.Started = !d.isClosed()
+			is.Queued = d.queued()
+			is.QueueCapacity = d.capacity()
+			is.Accepted = d.stats.accepted.Load()
+			is.Dropped = d.stats.dropped.Load()
+			is.RateLimited = d.stats.rateLimited.Load()
+			is.Duplicates = d.stats.duplicates.Load()
+			is.Shed = d.stats.shed.Load()
+			for _, dest := range d.destinations {
+				breaker := breakerClosed
+				if dest.breaker != nil {
+					breaker = dest.breaker.currentState()
+				}
+				is.Destinations = append(is.Destinations, dest.stats.state(dest.name, breaker))
+			}
+		}
+		stats.Instances = append(stats.Instances, is)
+	}
+	return stats
+}
+
+// getStats reports the queue and per-destination delivery stats of the dispatchers
+func getStats(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, currentStats())
+}
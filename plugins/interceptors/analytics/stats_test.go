@@ -0,0 +1,128 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/interceptors"
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func statsRequest(t *testing.T) analyticsStats {
+	rec := httptest.NewRecorder()
+	interceptors.AdminHandlers["analytics"].ServeHTTP(rec, httptest.NewRequest("GET", "/stats", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stats analyticsStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	return stats
+}
+
+// instanceStatsFor returns the stats of the instance with the named destination
+func instanceStatsFor(t *testing.T, stats analyticsStats, name string) instanceStats {
+	for _, instance := range stats.Instances {
+		for _, dest := range instance.Destinations {
+			if dest.Name == name {
+				return instance
+			}
+		}
+	}
+	require.Failf(t, "instance not found", "no instance with destination %q", name)
+	return instanceStats{}
+}
+
+func TestAdminStats(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "stats-mock", backend: backend}}, dispatcherOptions{queueSize: 10}, a.metrics)
+	defer unregisterInstance(a)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	backend.next(t)
+	require.Eventually(t, func() bool {
+		return instanceStatsFor(t, statsRequest(t), "stats-mock").Destinations[0].Sent == 1
+	}, time.Second, 10*time.Millisecond)
+
+	backend.err = &StatusError{StatusCode: http.StatusBadRequest, Body: "bad"}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	backend.next(t)
+
+	var instance instanceStats
+	require.Eventually(t, func() bool {
+		instance = instanceStatsFor(t, statsRequest(t), "stats-mock")
+		return instance.Destinations[0].Failed == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "analytics", instance.Name)
+	assert.True(t, instance.Started)
+	assert.Equal(t, 10, instance.QueueCapacity)
+	assert.EqualValues(t, 2, instance.Accepted)
+	dest := instance.Destinations[0]
+	assert.Equal(t, "closed", dest.Breaker)
+	assert.EqualValues(t, 2, dest.Accepted)
+	assert.EqualValues(t, 1, dest.Sent)
+	assert.EqualValues(t, 1, dest.DeadLettered)
+	assert.Equal(t, backend.err.Error(), dest.LastError)
+	assert.NotNil(t, dest.LastErrorAt)
+	assert.NotNil(t, dest.LastSuccessAt)
+}
+
+func TestDispatcherStats(t *testing.T) {
+	backend := newMockBackend()
+	backend.err = &StatusError{StatusCode: http.StatusServiceUnavailable, Body: "unavailable"}
+	d := &dispatcher{
+		queue:        make(chan Event, 1),
+		destinations: []destination{{name: "mock", backend: backend, stats: &destinationStats{}}},
+		retry:        RetryConfig{MaxAttempts: 2, BaseBackoff: utils.Duration{Duration: time.Millisecond}},
+		metrics:      newAnalyticsMetrics(),
+		ctx:          context.Background(),
+	}
+
+	// Without workers, the second event overflows the queue
+	assert.True(t, d.enqueue(Event{Name: "first"}))
+	assert.False(t, d.enqueue(Event{Name: "second"}))
+	assert.EqualValues(t, 1, d.stats.accepted.Load())
+	assert.EqualValues(t, 1, d.stats.dropped.Load())
+
+	// Retryable failures are dropped without a spill queue
+	d.deliver([]Event{<-d.queue})
+	backend.next(t)
+	backend.next(t)
+	state := d.destinations[0].stats.state("mock", breakerClosed)
+	assert.EqualValues(t, 1, state.Accepted)
+	assert.EqualValues(t, 1, state.Retried)
+	assert.EqualValues(t, 1, state.Failed)
+	assert.EqualValues(t, 1, state.Dropped)
+	assert.Zero(t, state.Sent)
+	assert.Equal(t, backend.err.Error(), state.LastError)
+}
+
+func TestDestinationStatsNil(t *testing.T) {
+	var s *destinationStats
+	s.add(statSent)
+	s.recordResult(nil, 1)
+	assert.Equal(t, destinationStatsState{Name: "mock", Breaker: "open"}, s.state("mock", breakerOpen))
+}
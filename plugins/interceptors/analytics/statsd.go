@@ -0,0 +1,114 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultStatsDPrefix = "optimizely.agent"
+
+// StatsDConfig configures the StatsD/DogStatsD emitter for aggregate request metrics
+type StatsDConfig struct {
+	Address   string   `json:"address"`   // UDP address of the StatsD agent, e.g. localhost:8125
+	Prefix    string   `json:"prefix"`    // Metric name prefix (defaults to optimizely.agent)
+	DogStatsD bool     `json:"dogStatsD"` // Emit path/method/status as DogStatsD tags instead of name segments
+	Tags      []string `json:"tags"`      // Constant DogStatsD tags, e.g. env:prod
+}
+
+// statsdEmitter writes request counters and timings to a StatsD endpoint over UDP
+type statsdEmitter struct {
+	conn      net.Conn
+	prefix    string
+	dogStatsD bool
+	tags      []string
+}
+
+func newStatsDEmitter(conf StatsDConfig) (*statsdEmitter, error) {
+	conn, err := net.Dial("udp", conf.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := conf.Prefix
+	if prefix == "" {
+		prefix = defaultStatsDPrefix
+	}
+
+	return &statsdEmitter{
+		conn:      conn,
+		prefix:    strings.TrimSuffix(prefix, "."),
+		dogStatsD: conf.DogStatsD,
+		tags:      conf.Tags,
+	}, nil
+}
+
+// close closes the UDP socket. Requests still served by a replaced instance may emit
+// afterwards, which fails silently like any other best effort write.
+func (s *statsdEmitter) close() error {
+	return s.conn.Close()
+}
+
+// emitRequest sends a request counter and a latency timing in a single packet.
+// Errors are ignored since StatsD delivery is best effort.
+func (s *statsdEmitter) emitRequest(method, path string, status int, duration time.Duration) {
+	ms := strconv.FormatInt(duration.Milliseconds(), 10)
+	statusCode := strconv.Itoa(status)
+
+	var lines []string
+	if s.dogStatsD {
+		tags := append([]string{
+			"method:" + sanitizeStatsDTag(method),
+			"path:" + sanitizeStatsDTag(path),
+			"status:" + statusCode,
+		}, s.tags...)
+		suffix := "|#" + strings.Join(tags, ",")
+		lines = []string{
+			fmt.Sprintf("%s.requests:1|c%s", s.prefix, suffix),
+			fmt.Sprintf("%s.request.duration:%s|ms%s", s.prefix, ms, suffix),
+		}
+	} else {
+		key := strings.Join([]string{sanitizeStatsDSegment(method), sanitizeStatsDSegment(path), statusCode}, ".")
+		lines = []string{
+			fmt.Sprintf("%s.requests.%s:1|c", s.prefix, key),
+			fmt.Sprintf("%s.request.duration.%s:%s|ms", s.prefix, key, ms),
+		}
+	}
+
+	_, _ = s.conn.Write([]byte(strings.Join(lines, "\n")))
+}
+
+// statsDTagReplacer replaces the characters delimiting DogStatsD datagrams, metrics and tags
+var statsDTagReplacer = strings.NewReplacer("|", "_", ",", "_", "#", "_", "\n", "_", "\r", "_")
+
+// sanitizeStatsDTag makes a value safe to embed in a DogStatsD tag, e.g. a raw request path
+func sanitizeStatsDTag(value string) string {
+	return statsDTagReplacer.Replace(value)
+}
+
+// sanitizeStatsDSegment makes a value safe to embed in a dot-delimited StatsD metric name
+func sanitizeStatsDSegment(value string) string {
+	value = strings.Trim(value, "/")
+	if value == "" {
+		return "root"
+	}
+	return strings.NewReplacer("/", "_", ".", "_", ":", "_", "|", "_", "@", "_", "#", "_", " ", "_").Replace(value)
+}
@@ -0,0 +1,82 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listenStatsD(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readStatsD(t *testing.T, conn *net.UDPConn) []string {
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return strings.Split(string(buf[:n]), "\n")
+}
+
+func TestStatsDEmitter(t *testing.T) {
+	conn := listenStatsD(t)
+	emitter, err := newStatsDEmitter(StatsDConfig{Address: conn.LocalAddr().String()})
+	require.NoError(t, err)
+
+	emitter.emitRequest("POST", "/v1/decide", 200, 42*time.Millisecond)
+	assert.Equal(t, []string{
+		"optimizely.agent.requests.POST.v1_decide.200:1|c",
+		"optimizely.agent.request.duration.POST.v1_decide.200:42|ms",
+	}, readStatsD(t, conn))
+}
+
+func TestDogStatsDEmitter(t *testing.T) {
+	conn := listenStatsD(t)
+	emitter, err := newStatsDEmitter(StatsDConfig{
+		Address:   conn.LocalAddr().String(),
+		Prefix:    "agent.",
+		DogStatsD: true,
+		Tags:      []string{"env:test"},
+	})
+	require.NoError(t, err)
+
+	emitter.emitRequest("GET", "/v1/config", 404, 7*time.Millisecond)
+	assert.Equal(t, []string{
+		"agent.requests:1|c|#method:GET,path:/v1/config,status:404,env:test",
+		"agent.request.duration:7|ms|#method:GET,path:/v1/config,status:404,env:test",
+	}, readStatsD(t, conn))
+}
+
+func TestSanitizeStatsDTag(t *testing.T) {
+	assert.Equal(t, "/v1/decide", sanitizeStatsDTag("/v1/decide"))
+	assert.Equal(t, "/v1/a_b_c_d", sanitizeStatsDTag("/v1/a|b,c#d"))
+	assert.Equal(t, "/v1/a_b", sanitizeStatsDTag("/v1/a\nb"))
+}
+
+func TestSanitizeStatsDSegment(t *testing.T) {
+	assert.Equal(t, "root", sanitizeStatsDSegment("/"))
+	assert.Equal(t, "v1_datafiles_key_json", sanitizeStatsDSegment("/v1/datafiles/key.json"))
+}
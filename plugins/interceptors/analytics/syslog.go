@@ -0,0 +1,161 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSyslogAppName  = "optimizely-agent"
+	defaultSyslogFacility = "local0"
+	syslogSeverityInfo    = 6
+)
+
+// syslogFacilities maps facility names to their RFC 5424 codes
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogBackend sends events to a syslog server as RFC 5424 messages, one per event, with
+// the event name as MSGID and the event as JSON message
+type SyslogBackend struct {
+	Network  string `json:"network"`  // "udp" (default), "tcp" or "tls"
+	Address  string `json:"address"`  // host:port of the syslog server, e.g. localhost:514
+	Facility string `json:"facility"` // Facility name, e.g. local0 (default) or user
+	AppName  string `json:"appName"`  // APP-NAME of the messages (defaults to optimizely-agent)
+	Hostname string `json:"hostname"` // HOSTNAME of the messages (defaults to the host name)
+
+	once      sync.Once
+	initErr   error
+	priority  int
+	header    string // HOSTNAME APP-NAME PROCID
+	writer    connWriter
+	tlsConfig *tls.Config
+}
+
+func (s *SyslogBackend) init() {
+	facility := s.Facility
+	if facility == "" {
+		facility = defaultSyslogFacility
+	}
+	code, ok := syslogFacilities[strings.ToLower(facility)]
+	if !ok {
+		s.initErr = fmt.Errorf("unknown syslog facility: %q", s.Facility)
+		return
+	}
+	s.priority = code*8 + syslogSeverityInfo
+
+	hostname := s.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	appName := s.AppName
+	if appName == "" {
+		appName = defaultSyslogAppName
+	}
+	s.header = strings.Join([]string{
+		syslogField(hostname, 255),
+		syslogField(appName, 48),
+		strconv.Itoa(os.Getpid()),
+	}, " ")
+
+	network := s.Network
+	if network == "" {
+		network = "udp"
+	}
+	s.writer = connWriter{network: network, address: s.Address, tlsConfig: s.tlsConfig}
+}
+
+// Send writes one message per event. Over TCP and TLS messages are framed by octet counting
+// (RFC 6587, RFC 5425).
+func (s *SyslogBackend) Send(ctx context.Context, events []Event) error {
+	s.once.Do(s.init)
+	if s.initErr != nil {
+		return s.initErr
+	}
+
+	target := dryRunFrom(ctx)
+	msgs := make([][]byte, 0, len(events))
+	for _, e := range events {
+		event := canonicalEvent(e)
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("<%d>1 %s %s %s - %s", s.priority, event.Timestamp.Format(time.RFC3339Nano), s.header, syslogField(e.Name, 32), body)
+
+		if target != nil {
+			if err := target.record(s.writer.network+"://"+s.Address, "text/plain", []byte(msg)); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.writer.network != "udp" {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+		msgs = append(msgs, []byte(msg))
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return s.writer.write(ctx, msgs...)
+}
+
+// Close closes the connection to the syslog server
+func (s *SyslogBackend) Close() error {
+	s.once.Do(s.init)
+	return s.writer.close()
+}
+
+// syslogField makes value a valid RFC 5424 header field of at most maxLen printable ASCII characters
+func syslogField(value string, maxLen int) string {
+	if value == "" {
+		return "-"
+	}
+	field := []byte(value)
+	for i, c := range field {
+		if c < 33 || c > 126 {
+			field[i] = '_'
+		}
+	}
+	if len(field) > maxLen {
+		field = field[:maxLen]
+	}
+	return string(field)
+}
+
+func (s *SyslogBackend) setTLSConfig(conf *tls.Config) {
+	s.tlsConfig = conf
+}
+
+func init() {
+	AddBackend("syslog", func() Backend {
+		return &SyslogBackend{}
+	})
+}
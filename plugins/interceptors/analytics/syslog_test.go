@@ -0,0 +1,111 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var syslogMessagePattern = regexp.MustCompile(`^<134>1 \S+ agent-host optimizely-agent \d+ api_request - (\{.*\})$`)
+
+func TestSyslogBackendUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	backend := &SyslogBackend{Address: conn.LocalAddr().String(), Hostname: "agent-host"}
+	defer backend.Close()
+	require.NoError(t, backend.Send(context.Background(), []Event{
+		{Name: "api_request", ClientID: "a", Params: map[string]interface{}{"path": "/v1/decide"}},
+		{Name: "api_request", ClientID: "b"},
+	}))
+
+	// One datagram per event
+	for _, clientID := range []string{"a", "b"} {
+		buf := make([]byte, 2048)
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		match := syslogMessagePattern.FindSubmatch(buf[:n])
+		require.NotNil(t, match, string(buf[:n]))
+
+		var record CanonicalEvent
+		require.NoError(t, json.Unmarshal(match[1], &record))
+		assert.Equal(t, clientID, record.Client.ID)
+	}
+}
+
+func TestSyslogBackendTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			// Octet counting framing: MSG-LEN SP SYSLOG-MSG
+			prefix, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(prefix))
+			msg := make([]byte, n)
+			if _, err := reader.Read(msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	backend := &SyslogBackend{Network: "tcp", Address: listener.Addr().String(), Hostname: "agent-host"}
+	defer backend.Close()
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "a"}}))
+	require.NoError(t, backend.Send(context.Background(), []Event{{Name: "api_request", ClientID: "b"}}))
+
+	assert.Regexp(t, syslogMessagePattern, <-received)
+	assert.Regexp(t, syslogMessagePattern, <-received)
+}
+
+func TestSyslogBackendFacility(t *testing.T) {
+	backend := &SyslogBackend{Facility: "user", Address: "127.0.0.1:1"}
+	backend.once.Do(backend.init)
+	assert.Equal(t, 14, backend.priority)
+
+	backend = &SyslogBackend{Facility: "unknown", Address: "127.0.0.1:1"}
+	assert.Error(t, backend.Send(context.Background(), []Event{{Name: "api_request"}}))
+}
+
+func TestSyslogField(t *testing.T) {
+	assert.Equal(t, "-", syslogField("", 32))
+	assert.Equal(t, "api_request", syslogField("api request", 32))
+	assert.Equal(t, "abc", syslogField("abcdef", 3))
+}
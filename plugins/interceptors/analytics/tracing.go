@@ -0,0 +1,113 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName        = "analytics"
+	requestSpanName   = "analytics.request"
+	dispatchSpanName  = "analytics.dispatch"
+	attributeKeySpace = "analytics."
+)
+
+// traceContext propagates the W3C trace context (traceparent and tracestate) of requests to
+// their deliveries, whether or not the agent exports traces itself
+var traceContext = propagation.TraceContext{}
+
+// startRequestSpan starts the span covering an intercepted request, continuing any trace
+// propagated by the caller. Spans are exported by the agent's configured tracer provider.
+func startRequestSpan(r *http.Request) (context.Context, trace.Span) {
+	ctx := traceContext.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return otel.Tracer(tracerName).Start(ctx, requestSpanName, trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// startDispatchSpan starts the span of an outbound delivery of a batch to a destination, a child
+// of the request span of its first event and linked to those of the others
+func startDispatchSpan(ctx context.Context, events []Event, backendName string) (context.Context, trace.Span) {
+	ctx = trace.ContextWithRemoteSpanContext(ctx, events[0].spanContext)
+	links := make([]trace.Link, 0, len(events)-1)
+	for _, event := range events[1:] {
+		if event.spanContext.IsValid() {
+			links = append(links, trace.Link{SpanContext: event.spanContext})
+		}
+	}
+	return otel.Tracer(tracerName).Start(ctx, dispatchSpanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String(attributeKeySpace+"backend", backendName),
+			attribute.String(attributeKeySpace+"event", events[0].Name),
+			attribute.Int(attributeKeySpace+"events", len(events)),
+		),
+	)
+}
+
+// injectTraceContext adds the trace context of a delivery to the headers of its outbound request,
+// so the call shows up in the trace of the originating request
+func injectTraceContext(ctx context.Context, header http.Header) {
+	traceContext.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// endDispatchSpan records the delivery outcome and ends the span
+func endDispatchSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// eventAttributes converts the event params to span attributes
+func eventAttributes(event Event) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(event.Params)+2)
+	attrs = append(attrs,
+		attribute.String(attributeKeySpace+"event", event.Name),
+		attribute.String(attributeKeySpace+"client_id", event.ClientID),
+	)
+	if event.UserID != "" {
+		attrs = append(attrs, attribute.String(attributeKeySpace+"user_id", event.UserID))
+	}
+	for k, v := range event.Params {
+		key := attributeKeySpace + k
+		switch value := v.(type) {
+		case string:
+			attrs = append(attrs, attribute.String(key, value))
+		case int:
+			attrs = append(attrs, attribute.Int(key, value))
+		case int64:
+			attrs = append(attrs, attribute.Int64(key, value))
+		case float64:
+			attrs = append(attrs, attribute.Float64(key, value))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, value))
+		default:
+			attrs = append(attrs, attribute.String(key, fmt.Sprint(value)))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,165 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func useSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestRequestAndDispatchSpans(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	backend := newMockBackend()
+	backend.err = errors.New("unavailable")
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/config", nil))
+	backend.next(t)
+
+	require.Eventually(t, func() bool { return len(recorder.Ended()) == 2 }, time.Second, 10*time.Millisecond)
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	request := spans[requestSpanName]
+	require.NotNil(t, request)
+	assert.Contains(t, request.Attributes(), attribute.String("analytics.path", "/v1/config"))
+	assert.Contains(t, request.Attributes(), attribute.Int("analytics.status_code", http.StatusOK))
+
+	dispatch := spans[dispatchSpanName]
+	require.NotNil(t, dispatch)
+	assert.Equal(t, request.SpanContext().TraceID(), dispatch.Parent().TraceID())
+	assert.Equal(t, request.SpanContext().SpanID(), dispatch.Parent().SpanID())
+	assert.Contains(t, dispatch.Attributes(), attribute.String("analytics.backend", "mock"))
+	assert.Equal(t, codes.Error, dispatch.Status().Code)
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer server.Close()
+
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	backend := &GA4Backend{EndpointURL: server.URL}
+	a.dispatcher = newDispatcher([]destination{{name: "ga4", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "vendor=value")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var outbound http.Header
+	select {
+	case outbound = <-headers:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the outbound request")
+	}
+
+	require.Eventually(t, func() bool { return len(recorder.Ended()) == 2 }, time.Second, 10*time.Millisecond)
+	var dispatch sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == dispatchSpanName {
+			dispatch = span
+		}
+	}
+	require.NotNil(t, dispatch)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", dispatch.SpanContext().TraceID().String())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+dispatch.SpanContext().SpanID().String()+"-01", outbound.Get("traceparent"))
+	assert.Equal(t, "vendor=value", outbound.Get("tracestate"))
+}
+
+func TestTraceContextPropagationWithoutTracing(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer server.Close()
+
+	a := &Analytics{Enabled: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	backend := &GA4Backend{EndpointURL: server.URL}
+	a.dispatcher = newDispatcher([]destination{{name: "ga4", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("traceparent", traceparent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case outbound := <-headers:
+		assert.Equal(t, traceparent, outbound.Get("traceparent"))
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the outbound request")
+	}
+}
+
+func TestEventAttributes(t *testing.T) {
+	attrs := eventAttributes(Event{
+		Name:     "api_request",
+		ClientID: "client",
+		Params: map[string]interface{}{
+			"path":    "/v1/decide",
+			"count":   int64(3),
+			"ratio":   0.5,
+			"sampled": true,
+			"list":    []string{"a"},
+		},
+	})
+
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("analytics.event", "api_request"),
+		attribute.String("analytics.client_id", "client"),
+		attribute.String("analytics.path", "/v1/decide"),
+		attribute.Int64("analytics.count", 3),
+		attribute.Float64("analytics.ratio", 0.5),
+		attribute.Bool("analytics.sampled", true),
+		attribute.String("analytics.list", "[a]"),
+	}, attrs)
+}
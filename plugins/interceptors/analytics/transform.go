@@ -0,0 +1,148 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Transform is a step of a destination's transform chain, which reshapes the params of events
+// before they are sent. Each step sets one of the fields; steps run in order.
+type Transform struct {
+	Rename map[string]string `json:"rename"` // Params to rename, from the current name to the new one
+	Drop   []string          `json:"drop"`   // Params to remove
+	Keep   []string          `json:"keep"`   // Params to keep, removing all others
+	Set    map[string]string `json:"set"`    // Params to set from expressions over the params, see expr. Null results remove the param.
+}
+
+// transformChain is the compiled transform chain of a destination
+type transformChain []transformStep
+
+// transformStep is a validated Transform
+type transformStep struct {
+	Transform
+	set []setParam
+}
+
+// setParam is a param computed by a set step
+type setParam struct {
+	name string
+	expr *expr
+}
+
+// newTransformChain validates the steps and compiles their expressions
+func newTransformChain(transforms []Transform) (transformChain, error) {
+	chain := make(transformChain, 0, len(transforms))
+	for i, t := range transforms {
+		fields := 0
+		for _, set := range []bool{len(t.Rename) > 0, len(t.Drop) > 0, len(t.Keep) > 0, len(t.Set) > 0} {
+			if set {
+				fields++
+			}
+		}
+		if fields != 1 {
+			return nil, fmt.Errorf("analytics transform %d must set exactly one of rename, drop, keep or set", i)
+		}
+
+		step := transformStep{Transform: t}
+		for name, src := range t.Set {
+			e, err := compileExpr(src)
+			if err != nil {
+				return nil, fmt.Errorf("analytics transform %d, param %q: %w", i, name, err)
+			}
+			step.set = append(step.set, setParam{name: name, expr: e})
+		}
+		sort.Slice(step.set, func(i, j int) bool { return step.set[i].name < step.set[j].name })
+		chain = append(chain, step)
+	}
+	return chain, nil
+}
+
+// apply returns the events with transformed copies of their params. The events passed in are
+// shared with the other destinations and are left unchanged.
+func (c transformChain) apply(events []Event) []Event {
+	if len(c) == 0 {
+		return events
+	}
+
+	transformed := make([]Event, len(events))
+	for i, e := range events {
+		params := make(map[string]interface{}, len(e.Params))
+		for k, v := range e.Params {
+			params[k] = v
+		}
+		for _, step := range c {
+			step.apply(e.Name, params)
+		}
+		e.Params = params
+		transformed[i] = e
+	}
+	return transformed
+}
+
+// apply transforms params in place
+func (s transformStep) apply(eventName string, params map[string]interface{}) {
+	switch {
+	case len(s.Rename) > 0:
+		// Read every value before writing, so swapping two params works
+		values := make(map[string]interface{}, len(s.Rename))
+		for from := range s.Rename {
+			if v, ok := params[from]; ok {
+				values[from] = v
+				delete(params, from)
+			}
+		}
+		for from, v := range values {
+			params[s.Rename[from]] = v
+		}
+	case len(s.Drop) > 0:
+		for _, name := range s.Drop {
+			delete(params, name)
+		}
+	case len(s.Keep) > 0:
+		keep := make(map[string]bool, len(s.Keep))
+		for _, name := range s.Keep {
+			keep[name] = true
+		}
+		for name := range params {
+			if !keep[name] {
+				delete(params, name)
+			}
+		}
+	case len(s.set) > 0:
+		// Every expression of the step sees the params from before the step
+		values := make([]interface{}, len(s.set))
+		for i, p := range s.set {
+			v, err := p.expr.eval(params)
+			if err != nil {
+				log.Debug().Err(err).Str("event", eventName).Str("param", p.name).Msg("Failed to compute analytics param")
+				continue
+			}
+			values[i] = v
+		}
+		for i, p := range s.set {
+			if values[i] == nil {
+				delete(params, p.name)
+			} else {
+				params[p.name] = values[i]
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformChain(t *testing.T) {
+	chain, err := newTransformChain([]Transform{
+		{Rename: map[string]string{"status_code": "http_status", "path": "page"}},
+		{Set: map[string]string{
+			"is_error":     "http_status >= 500",
+			"duration_s":   "response_time_ms / 1000",
+			"page":         "'agent:' + page",
+			"missing_calc": "missing * 2",
+		}},
+		{Drop: []string{"response_time_ms"}},
+	})
+	require.NoError(t, err)
+
+	original := map[string]interface{}{"path": "/v1/decide", "status_code": 503, "response_time_ms": int64(250)}
+	events := []Event{{Name: "api_request", Params: original}}
+	transformed := chain.apply(events)
+
+	assert.Equal(t, map[string]interface{}{
+		"page":        "agent:/v1/decide",
+		"http_status": 503,
+		"is_error":    true,
+		"duration_s":  0.25,
+	}, transformed[0].Params)
+	// Other destinations share the original params
+	assert.Equal(t, map[string]interface{}{"path": "/v1/decide", "status_code": 503, "response_time_ms": int64(250)}, events[0].Params)
+}
+
+func TestTransformChainRenameSwaps(t *testing.T) {
+	chain, err := newTransformChain([]Transform{{Rename: map[string]string{"a": "b", "b": "a"}}})
+	require.NoError(t, err)
+	events := chain.apply([]Event{{Params: map[string]interface{}{"a": 1, "b": 2}}})
+	assert.Equal(t, map[string]interface{}{"a": 2, "b": 1}, events[0].Params)
+}
+
+func TestTransformChainKeep(t *testing.T) {
+	chain, err := newTransformChain([]Transform{{Keep: []string{"path"}}})
+	require.NoError(t, err)
+	events := chain.apply([]Event{{Params: map[string]interface{}{"path": "/v1/decide", "ip_address": "192.0.2.1"}}})
+	assert.Equal(t, map[string]interface{}{"path": "/v1/decide"}, events[0].Params)
+}
+
+func TestNewTransformChainErrors(t *testing.T) {
+	_, err := newTransformChain([]Transform{{}})
+	assert.Error(t, err)
+
+	_, err = newTransformChain([]Transform{{Drop: []string{"a"}, Keep: []string{"b"}}})
+	assert.Error(t, err)
+
+	_, err = newTransformChain([]Transform{{Set: map[string]string{"a": "1 +"}}})
+	assert.Error(t, err)
+}
+
+func TestDestinationTransforms(t *testing.T) {
+	dest, err := newDestination(BackendConfig{
+		"type": "ga4",
+		"transforms": []interface{}{
+			map[string]interface{}{"drop": []interface{}{"ip_address"}},
+		},
+	})
+	require.NoError(t, err)
+
+	backend := newMockBackend()
+	dest.backend = backend
+	require.NoError(t, sendWithRetry(context.Background(), dest, []Event{
+		{Name: "api_request", Params: map[string]interface{}{"path": "/v1/decide", "ip_address": "192.0.2.1"}},
+	}, RetryConfig{}, func(error) {}))
+	assert.Equal(t, map[string]interface{}{"path": "/v1/decide"}, backend.next(t).Params)
+
+	_, err = newDestination(BackendConfig{
+		"type":       "ga4",
+		"transforms": []interface{}{map[string]interface{}{"set": map[string]interface{}{"a": "("}}},
+	})
+	assert.Error(t, err)
+}
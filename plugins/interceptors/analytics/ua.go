@@ -0,0 +1,169 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ga4Protocol = "ga4"
+	uaProtocol  = "ua"
+
+	defaultUAEndpointURL   = "https://www.google-analytics.com/batch"
+	defaultUAEventCategory = "Optimizely Agent"
+	uaEventHit             = "event"
+	uaPageviewHit          = "pageview"
+
+	// uaBatchSize is the most hits Measurement Protocol v1 accepts in a batch request
+	uaBatchSize = 20
+	// uaMaxCustomIndex is the highest custom dimension and metric index of a UA property
+	uaMaxCustomIndex = 200
+)
+
+// UAConfig formats the hits of the Universal Analytics protocol (Measurement Protocol v1)
+type UAConfig struct {
+	HitType          string         `json:"hitType"`          // event (default) or pageview
+	EventCategory    string         `json:"eventCategory"`    // Category of event hits (defaults to "Optimizely Agent")
+	CustomDimensions map[string]int `json:"customDimensions"` // Custom dimension index of params, e.g. sdk_key: 1
+	CustomMetrics    map[string]int `json:"customMetrics"`    // Custom metric index of numeric params, e.g. response_time_ms: 1
+}
+
+// checkConfig checks the protocol settings of the backend
+func (g *GA4Backend) checkConfig() error {
+	switch g.Protocol {
+	case "", ga4Protocol:
+		return nil
+	case uaProtocol:
+	default:
+		return fmt.Errorf("unknown GA protocol %q, expected ga4 or ua", g.Protocol)
+	}
+
+	if g.Debug || g.ValidateEvents > 0 {
+		return errors.New("debug and validateEvents require the ga4 protocol")
+	}
+	switch g.UA.HitType {
+	case "", uaEventHit, uaPageviewHit:
+	default:
+		return fmt.Errorf("unknown UA hit type %q, expected event or pageview", g.UA.HitType)
+	}
+	for _, custom := range []map[string]int{g.UA.CustomDimensions, g.UA.CustomMetrics} {
+		for name, index := range custom {
+			if index < 1 || index > uaMaxCustomIndex {
+				return fmt.Errorf("UA custom index of %q must be between 1 and %d, got %d", name, uaMaxCustomIndex, index)
+			}
+		}
+	}
+	return nil
+}
+
+// sendUA posts the events to a property as Measurement Protocol v1 hits. Batch endpoints receive
+// up to uaBatchSize hits per request, endpoints ending in /collect one hit per request.
+func (g *GA4Backend) sendUA(ctx context.Context, property GA4Property, events []Event) error {
+	endpoint := g.EndpointURL
+	if endpoint == "" {
+		endpoint = defaultUAEndpointURL
+	}
+	batchSize := uaBatchSize
+	if strings.HasSuffix(endpoint, "/collect") {
+		batchSize = 1
+	}
+
+	now := time.Now()
+	for start := 0; start < len(events); start += batchSize {
+		end := start + batchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		hits := make([]string, 0, end-start)
+		for _, e := range events[start:end] {
+			hits = append(hits, g.uaHit(property, e, now).Encode())
+		}
+		body := []byte(strings.Join(hits, "\n"))
+		if err := post(ctx, g.client, endpoint, "application/x-www-form-urlencoded", body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uaHit formats an event as a non-interaction hit, so server-side events don't affect the
+// bounce rate of the property
+func (g *GA4Backend) uaHit(property GA4Property, e Event, now time.Time) url.Values {
+	hit := url.Values{}
+	hit.Set("v", "1")
+	hit.Set("tid", property.MeasurementID)
+	hit.Set("cid", e.ClientID)
+	if e.UserID != "" {
+		hit.Set("uid", e.UserID)
+	}
+	hit.Set("ni", "1")
+
+	path, _ := e.Params[pathParam].(string)
+	if g.UA.HitType == uaPageviewHit {
+		hit.Set("t", uaPageviewHit)
+		hit.Set("dp", path)
+		hit.Set("dt", e.Name)
+	} else {
+		category := g.UA.EventCategory
+		if category == "" {
+			category = defaultUAEventCategory
+		}
+		hit.Set("t", uaEventHit)
+		hit.Set("ec", category)
+		hit.Set("ea", e.Name)
+		if path != "" {
+			hit.Set("el", path)
+		}
+	}
+
+	// Events are delivered after their request; the queue time lets UA date the hit correctly
+	if !e.Timestamp.IsZero() {
+		if qt := now.Sub(e.Timestamp).Milliseconds(); qt > 0 {
+			hit.Set("qt", strconv.FormatInt(qt, 10))
+		}
+	}
+
+	for _, name := range sortedKeys(g.UA.CustomDimensions) {
+		if v, ok := e.Params[name]; ok {
+			hit.Set("cd"+strconv.Itoa(g.UA.CustomDimensions[name]), fmt.Sprint(v))
+		}
+	}
+	for _, name := range sortedKeys(g.UA.CustomMetrics) {
+		if _, f, _, ok := toNumber(e.Params[name]); ok {
+			hit.Set("cm"+strconv.Itoa(g.UA.CustomMetrics[name]), strconv.FormatFloat(f, 'f', -1, 64))
+		}
+	}
+	return hit
+}
+
+// sortedKeys returns the keys of a custom index map in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
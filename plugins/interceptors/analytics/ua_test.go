@@ -0,0 +1,121 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUAHit(t *testing.T) {
+	now := time.Now()
+	e := Event{
+		Name:      "api_request",
+		ClientID:  "client-1",
+		UserID:    "user-1",
+		Timestamp: now.Add(-1500 * time.Millisecond),
+		Params:    map[string]interface{}{pathParam: "/v1/decide", sdkKeyParam: "sdk-key", responseTimeParam: int64(12)},
+	}
+	g := &GA4Backend{Protocol: uaProtocol, UA: UAConfig{
+		CustomDimensions: map[string]int{sdkKeyParam: 1, "missing": 2},
+		CustomMetrics:    map[string]int{responseTimeParam: 3},
+	}}
+
+	hit := g.uaHit(GA4Property{MeasurementID: "UA-1234-1"}, e, now)
+	assert.Equal(t, url.Values{
+		"v":   {"1"},
+		"tid": {"UA-1234-1"},
+		"cid": {"client-1"},
+		"uid": {"user-1"},
+		"ni":  {"1"},
+		"t":   {"event"},
+		"ec":  {defaultUAEventCategory},
+		"ea":  {"api_request"},
+		"el":  {"/v1/decide"},
+		"qt":  {"1500"},
+		"cd1": {"sdk-key"},
+		"cm3": {"12"},
+	}, hit)
+
+	g.UA = UAConfig{HitType: uaPageviewHit}
+	hit = g.uaHit(GA4Property{MeasurementID: "UA-1234-1"}, e, now)
+	assert.Equal(t, "pageview", hit.Get("t"))
+	assert.Equal(t, "/v1/decide", hit.Get("dp"))
+	assert.Equal(t, "api_request", hit.Get("dt"))
+	assert.Empty(t, hit.Get("ea"))
+}
+
+func TestGA4BackendSendUA(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	events := make([]Event, 25)
+	for i := range events {
+		events[i] = Event{Name: "api_request", ClientID: "client", Params: map[string]interface{}{}}
+	}
+
+	// Batch endpoints receive up to 20 hits per request
+	g := &GA4Backend{MeasurementID: "UA-1234-1", EndpointURL: ts.URL + "/batch", Protocol: uaProtocol}
+	require.NoError(t, g.Send(context.Background(), events))
+	require.Len(t, bodies, 2)
+	assert.Len(t, strings.Split(bodies[0], "\n"), 20)
+	assert.Len(t, strings.Split(bodies[1], "\n"), 5)
+	hit, err := url.ParseQuery(strings.Split(bodies[0], "\n")[0])
+	require.NoError(t, err)
+	assert.Equal(t, "UA-1234-1", hit.Get("tid"))
+
+	// Collect endpoints one hit per request
+	bodies = nil
+	g.EndpointURL = ts.URL + "/collect"
+	require.NoError(t, g.Send(context.Background(), events[:3]))
+	assert.Len(t, bodies, 3)
+}
+
+func TestGA4BackendCheckConfig(t *testing.T) {
+	assert.NoError(t, (&GA4Backend{}).checkConfig())
+	assert.NoError(t, (&GA4Backend{Protocol: uaProtocol, UA: UAConfig{HitType: uaPageviewHit}}).checkConfig())
+
+	for _, g := range []GA4Backend{
+		{Protocol: "mp2"},
+		{Protocol: uaProtocol, Debug: true},
+		{Protocol: uaProtocol, UA: UAConfig{HitType: "screenview"}},
+		{Protocol: uaProtocol, UA: UAConfig{CustomDimensions: map[string]int{sdkKeyParam: 201}}},
+	} {
+		assert.Error(t, g.checkConfig(), g.Protocol)
+	}
+
+	_, err := newDestination(BackendConfig{"type": "ga4", "protocol": "mp2"})
+	assert.ErrorContains(t, err, "unknown GA protocol")
+}
@@ -0,0 +1,73 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"strings"
+
+	"github.com/mssola/useragent"
+)
+
+const userAgentParam = "user_agent"
+
+// addUserAgentParams replaces the raw user agent param with the structured device_category,
+// browser, browser_version, os and os_version params used for device reporting. Values that
+// can't be determined are omitted.
+func addUserAgentParams(params map[string]interface{}, ua string) {
+	delete(params, userAgentParam)
+	if ua == "" {
+		return
+	}
+
+	parsed := useragent.New(ua)
+	browser, browserVersion := parsed.Browser()
+	osInfo := parsed.OSInfo()
+	osName := osInfo.Name
+	if parsed.Model() == "iPad" && osName == "OS" {
+		osName = "iPadOS"
+	}
+
+	params[deviceCategoryParam] = deviceCategory(parsed, ua)
+	for param, value := range map[string]string{
+		browserParam:        browser,
+		browserVersionParam: browserVersion,
+		osParam:             osName,
+		osVersionParam:      osInfo.Version,
+	} {
+		if value != "" {
+			params[param] = value
+		}
+	}
+}
+
+// deviceCategory classifies the client as "desktop", "mobile" or "tablet" like GA4 does, with
+// "bot" for crawlers and "other" for clients without an operating system, such as SDKs
+func deviceCategory(parsed *useragent.UserAgent, ua string) string {
+	switch {
+	case parsed.Bot():
+		return "bot"
+	case parsed.Model() == "iPad",
+		parsed.OSInfo().Name == "Android" && !strings.Contains(ua, "Mobile"):
+		return "tablet"
+	case parsed.Mobile():
+		return "mobile"
+	case parsed.OS() != "":
+		return "desktop"
+	default:
+		return "other"
+	}
+}
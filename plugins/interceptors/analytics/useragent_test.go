@@ -0,0 +1,90 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddUserAgentParams(t *testing.T) {
+	scenarios := []struct {
+		ua       string
+		expected map[string]interface{}
+	}{
+		{
+			ua: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected: map[string]interface{}{
+				"device_category": "desktop", "browser": "Chrome", "browser_version": "120.0.0.0", "os": "Windows", "os_version": "10",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			expected: map[string]interface{}{
+				"device_category": "mobile", "browser": "Safari", "browser_version": "17.0", "os": "iPhone OS", "os_version": "17.0",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			expected: map[string]interface{}{
+				"device_category": "tablet", "browser": "Safari", "browser_version": "16.6", "os": "iPadOS", "os_version": "16.6",
+			},
+		},
+		{
+			ua: "Mozilla/5.0 (Linux; Android 13; SM-X200) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected: map[string]interface{}{
+				"device_category": "tablet", "browser": "Chrome", "browser_version": "120.0.0.0", "os": "Android", "os_version": "13",
+			},
+		},
+		{
+			ua:       "Go-http-client/1.1",
+			expected: map[string]interface{}{"device_category": "other", "browser": "Go-http-client", "browser_version": "1.1"},
+		},
+		{
+			ua:       "Googlebot/2.1 (+http://www.google.com/bot.html)",
+			expected: map[string]interface{}{"device_category": "bot", "browser": "Googlebot"},
+		},
+		{
+			ua:       "",
+			expected: map[string]interface{}{},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		params := map[string]interface{}{userAgentParam: scenario.ua}
+		addUserAgentParams(params, scenario.ua)
+		assert.Equal(t, scenario.expected, params, scenario.ua)
+	}
+}
+
+func TestAnalyticsParseUserAgent(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, ParseUserAgent: true}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("User-Agent", "python-requests/2.31.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := backend.next(t)
+	assert.Equal(t, "python-requests", event.Params["browser"])
+	assert.NotContains(t, event.Params, userAgentParam)
+}
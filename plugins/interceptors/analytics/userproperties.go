@@ -0,0 +1,48 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import "net/http"
+
+// addUserProperties sets the declared user properties that are present on the request
+func addUserProperties(event *Event, props []dimension, r *http.Request) {
+	for _, p := range props {
+		v, ok := p.value(r)
+		if !ok {
+			continue
+		}
+		if event.UserProperties == nil {
+			event.UserProperties = map[string]interface{}{}
+		}
+		event.UserProperties[p.param] = v
+	}
+}
+
+// mergeUserProperties combines the user properties of a client's events, later events taking
+// precedence. It returns nil when none of the events carry user properties.
+func mergeUserProperties(events []Event) map[string]interface{} {
+	var merged map[string]interface{}
+	for _, e := range events {
+		for k, v := range e.UserProperties {
+			if merged == nil {
+				merged = map[string]interface{}{}
+			}
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,69 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddUserProperties(t *testing.T) {
+	props, errs := newDimensions(map[string]string{
+		"plan":    "jwt:plan",
+		"tier":    "header:X-Tier",
+		"channel": "static:api",
+	})
+	assert.Empty(t, errs)
+
+	req := withBearerToken(t, httptest.NewRequest("GET", "/v1/config", nil), jwt.MapClaims{"plan": "enterprise"})
+	event := Event{}
+	addUserProperties(&event, props, req)
+	assert.Equal(t, map[string]interface{}{"plan": "enterprise", "channel": "api"}, event.UserProperties)
+
+	// Events without any of the properties carry none
+	event = Event{}
+	addUserProperties(&event, props[1:2], httptest.NewRequest("GET", "/v1/config", nil))
+	assert.Nil(t, event.UserProperties)
+}
+
+func TestMergeUserProperties(t *testing.T) {
+	assert.Nil(t, mergeUserProperties([]Event{{}, {}}))
+	assert.Equal(t, map[string]interface{}{"plan": "pro", "tier": "gold"}, mergeUserProperties([]Event{
+		{UserProperties: map[string]interface{}{"plan": "free", "tier": "gold"}},
+		{},
+		{UserProperties: map[string]interface{}{"plan": "pro"}},
+	}))
+}
+
+func TestAnalyticsUserProperties(t *testing.T) {
+	backend := newMockBackend()
+	a := &Analytics{Enabled: true, UserProperties: map[string]string{"plan": "header:X-Plan"}}
+	handler := a.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	a.dispatcher = newDispatcher([]destination{{name: "mock", backend: backend}}, dispatcherOptions{}, a.metrics)
+
+	req := httptest.NewRequest("GET", "/v1/config", nil)
+	req.Header.Set("X-Plan", "enterprise")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	event := backend.next(t)
+	assert.Equal(t, map[string]interface{}{"plan": "enterprise"}, event.UserProperties)
+	assert.NotContains(t, event.Params, "plan")
+}
@@ -0,0 +1,329 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// trackingIDPattern matches GA4 measurement IDs (G-XXXXXXXXXX) and Universal Analytics property
+// IDs (UA-XXXXX-Y)
+var trackingIDPattern = regexp.MustCompile(`^(G-[A-Z0-9]+|UA-[0-9]+-[0-9]+)$`)
+
+// configErrors collects the problems found in a config, each naming the setting it concerns
+type configErrors []error
+
+func (e *configErrors) add(field string, err error) {
+	*e = append(*e, fmt.Errorf("%s: %w", field, err))
+}
+
+func (e *configErrors) addf(field, format string, args ...interface{}) {
+	e.add(field, fmt.Errorf(format, args...))
+}
+
+// Validate checks the configuration, returning every problem found with the setting it concerns.
+// The server does not start with an invalid configuration and reloads of one are rejected, where
+// Handler alone skips invalid settings.
+func (a *Analytics) Validate() error {
+	var errs configErrors
+
+	if a.TrackingID != "" {
+		if !trackingIDPattern.MatchString(a.TrackingID) {
+			errs.addf("trackingID", "%q is not a GA4 measurement ID (G-XXXXXXXXXX) or UA property ID (UA-XXXXX-Y)", a.TrackingID)
+		}
+		if a.APISecret == "" && strings.HasPrefix(a.TrackingID, "G-") && a.GAProtocol != uaProtocol {
+			errs.addf("apiSecret", "required with a GA4 trackingID")
+		}
+		if a.GAProtocol == uaProtocol && !strings.HasPrefix(a.TrackingID, "UA-") {
+			errs.addf("trackingID", "the ua protocol requires a UA property ID (UA-XXXXX-Y)")
+		}
+	}
+	ga := GA4Backend{Protocol: a.GAProtocol, UA: a.UA, Debug: a.GA4Debug, ValidateEvents: a.ValidateEvents}
+	if err := ga.checkConfig(); err != nil {
+		errs.add("gaProtocol", err)
+	}
+	sdkKeys := make([]string, 0, len(a.Properties))
+	for sdkKey := range a.Properties {
+		sdkKeys = append(sdkKeys, sdkKey)
+	}
+	sort.Strings(sdkKeys)
+	for _, sdkKey := range sdkKeys {
+		field := fmt.Sprintf("properties[%s]", sdkKey)
+		p := a.Properties[sdkKey]
+		switch {
+		case sdkKey == "":
+			errs.addf(field, "SDK key is required")
+		case !trackingIDPattern.MatchString(p.MeasurementID):
+			errs.addf(field+".measurementID", "%q is not a GA4 measurement ID (G-XXXXXXXXXX) or UA property ID (UA-XXXXX-Y)", p.MeasurementID)
+		case p.APISecret == "" && strings.HasPrefix(p.MeasurementID, "G-") && a.GAProtocol != uaProtocol:
+			errs.addf(field+".apiSecret", "required with a GA4 measurementID")
+		}
+	}
+	validateURL(&errs, "endpointURL", a.EndpointURL)
+	if a.SampleRate != nil {
+		validateFraction(&errs, "sampleRate", *a.SampleRate)
+	}
+	validateFraction(&errs, "validateEvents", a.ValidateEvents)
+	if a.GA4Debug && a.ValidateEvents > 0 {
+		errs.addf("validateEvents", "cannot be combined with ga4Debug, which validates every payload")
+	}
+	validateNotNegative(&errs, "queueSize", int64(a.QueueSize))
+	validateNotNegative(&errs, "workers", int64(a.Workers))
+	validateNotNegative(&errs, "batch.maxEvents", int64(a.Batch.MaxEvents))
+	validateNotNegative(&errs, "batch.flushInterval", int64(a.Batch.FlushInterval.Duration))
+	validateNotNegative(&errs, "maxCaptureBytes", a.MaxCaptureBytes)
+	validateNotNegative(&errs, "retry.maxAttempts", int64(a.Retry.MaxAttempts))
+	if a.DryRunFile != "" && !a.DryRun {
+		errs.addf("dryRunFile", "requires dryRun")
+	}
+	a.validateQuotas(&errs)
+	if _, err := newNotificationBridge(a.Notifications, false, nil); err != nil {
+		errs.add("notifications.types", err)
+	}
+	validateNotNegative(&errs, "audit.interval", int64(a.Audit.Interval.Duration))
+	validateNotNegative(&errs, "latency.apdexT", int64(a.Latency.ApdexT.Duration))
+	for i, b := range a.Latency.Buckets {
+		if b.Duration <= 0 {
+			errs.addf(fmt.Sprintf("latency.buckets[%d]", i), "must be positive, got %v", b.Duration)
+		} else if i > 0 && b.Duration <= a.Latency.Buckets[i-1].Duration {
+			errs.addf(fmt.Sprintf("latency.buckets[%d]", i), "must be greater than the previous bucket")
+		}
+	}
+	if a.Audit.File != "" && a.Audit.Interval.Duration <= 0 {
+		errs.addf("audit.file", "requires audit.interval")
+	}
+
+	if len(a.BodyParams) > 0 && !a.CaptureRequestBody {
+		errs.addf("bodyParams", "requires captureRequestBody")
+	}
+	if a.ClientIDSource.Type == "body" && !a.CaptureRequestBody {
+		errs.addf("clientIDSource", "body client IDs require captureRequestBody")
+	}
+	if a.EnrichDecisions && !a.CaptureResponseBody {
+		errs.addf("enrichDecisions", "requires captureResponseBody")
+	}
+	if a.ExperimentEvents && !a.EnrichDecisions {
+		errs.addf("experimentEvents", "requires enrichDecisions")
+	}
+	if source := a.ClientIDSource.Type; source != "" && source != fingerprintSource {
+		if _, err := newClientIDStrategy(a.ClientIDSource); err != nil {
+			errs.add("clientIDSource.type", err)
+		}
+	}
+
+	a.validateFilters(&errs)
+	names := a.validateDestinations(&errs)
+	for i, rule := range a.Routing {
+		field := fmt.Sprintf("routing[%d]", i)
+		if _, err := newRoutingRule(rule); err != nil {
+			errs.add(field, err)
+		}
+		for _, name := range rule.Destinations {
+			if !names[name] {
+				errs.addf(field+".destinations", "unknown destination %q", name)
+			}
+		}
+	}
+	a.validateDelivery(&errs)
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid analytics config: %w", errors.Join(errs...))
+}
+
+// validateFilters checks the settings deciding which requests are tracked and what is sent
+func (a *Analytics) validateFilters(errs *configErrors) {
+	if _, filterErrs := newPathFilter(a.IncludePaths, nil); len(filterErrs) > 0 {
+		errs.add("includePaths", errors.Join(filterErrs...))
+	}
+	if _, filterErrs := newPathFilter(nil, a.ExcludePaths); len(filterErrs) > 0 {
+		errs.add("excludePaths", errors.Join(filterErrs...))
+	}
+	if _, activationErrs := newActivation(a.Activation); len(activationErrs) > 0 {
+		errs.add("activation.hosts", errors.Join(activationErrs...))
+	}
+	if _, codeErrs := validateStatusCodes(a.StatusCodes); len(codeErrs) > 0 {
+		errs.add("statusCodes", errors.Join(codeErrs...))
+	}
+	if _, labelErrs := newPathLabeler(a.PathLabels); len(labelErrs) > 0 {
+		errs.add("pathLabels.templates", errors.Join(labelErrs...))
+	}
+	if _, paramErrs := newDimensions(a.Params); len(paramErrs) > 0 {
+		errs.add("params", errors.Join(paramErrs...))
+	}
+	if _, propErrs := newDimensions(a.UserProperties); len(propErrs) > 0 {
+		errs.add("userProperties", errors.Join(propErrs...))
+	}
+	if _, err := validatePrivacy(a.Privacy); err != nil {
+		errs.add("privacy", err)
+	}
+	if _, err := newHookChain(a.Hooks); err != nil {
+		errs.add("hooks", err)
+	}
+	if _, err := newCollector(a.Collect); err != nil {
+		errs.add("collect", err)
+	}
+	if _, err := newBotDetector(a.Bots); err != nil {
+		errs.add("bots", err)
+	}
+	if _, err := newAnomalyDetector(a.Anomalies); err != nil {
+		errs.add("anomalies", err)
+	}
+	if _, err := compileEventName(a.EventName); err != nil {
+		errs.add("eventName", err)
+	}
+	for i, rule := range a.Rules {
+		if _, err := newRouteRule(rule); err != nil {
+			errs.add(fmt.Sprintf("rules[%d]", i), err)
+		}
+	}
+}
+
+// validateDestinations checks the destinations, returning the names they are known by
+func (a *Analytics) validateDestinations(errs *configErrors) map[string]bool {
+	names := map[string]bool{}
+	if a.TrackingID != "" || len(a.Properties) > 0 {
+		names["ga4"] = true
+	}
+	for i, conf := range a.Destinations {
+		field := fmt.Sprintf("destinations[%d]", i)
+		dest, err := newDestination(conf)
+		if err != nil {
+			errs.add(field, err)
+			continue
+		}
+		if names[dest.name] {
+			errs.addf(field+".name", "duplicate destination name %q", dest.name)
+		}
+		names[dest.name] = true
+		keys := make([]string, 0, len(conf))
+		for key := range conf {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if s, ok := conf[key].(string); ok && strings.HasSuffix(strings.ToLower(key), "url") {
+				validateURL(errs, field+"."+key, s)
+			}
+		}
+	}
+	return names
+}
+
+// validateDelivery checks the settings of the dispatcher and its HTTP client
+func (a *Analytics) validateDelivery(errs *configErrors) {
+	conf := a.HTTPClient
+	if isSecretRef(conf.ProxyURL) {
+		conf.ProxyURL = ""
+	}
+	if _, err := newHTTPClient(conf); err != nil {
+		errs.add("httpClient", err)
+	}
+
+	switch a.DeadLetter.Type {
+	case "", deadLetterDiscard:
+	case deadLetterFile:
+		if a.DeadLetter.Path == "" {
+			errs.addf("deadLetter.path", "required with the file type")
+		}
+	case deadLetterKafka:
+		if a.DeadLetter.RESTProxyURL == "" || a.DeadLetter.Topic == "" {
+			errs.addf("deadLetter", "restProxyURL and topic are required with the kafka type")
+		}
+		validateURL(errs, "deadLetter.restProxyURL", a.DeadLetter.RESTProxyURL)
+	default:
+		errs.addf("deadLetter.type", "unknown type %q, expected discard, file or kafka", a.DeadLetter.Type)
+	}
+
+	switch a.RateLimit.Policy {
+	case "", rateLimitDrop, rateLimitSample:
+	case rateLimitSpill:
+		if a.Spill.Directory == "" {
+			errs.addf("rateLimit.policy", "spill requires spill.directory")
+		}
+	default:
+		errs.addf("rateLimit.policy", "unknown policy %q, expected drop, sample or spill", a.RateLimit.Policy)
+	}
+	if a.RateLimit.MaxEventsPerSecond < 0 {
+		errs.addf("rateLimit.maxEventsPerSecond", "must not be negative, got %v", a.RateLimit.MaxEventsPerSecond)
+	}
+}
+
+// validateQuotas checks the tenant budgets
+func (a *Analytics) validateQuotas(errs *configErrors) {
+	conf := a.Quotas
+	switch conf.Key {
+	case "", quotaBySDKKey, quotaByClientID:
+	default:
+		errs.addf("quotas.key", "unknown key %q, expected sdkKey or clientID", conf.Key)
+	}
+	switch conf.Overflow {
+	case "", quotaDrop, quotaSample, quotaAggregate:
+	default:
+		errs.addf("quotas.overflow", "unknown overflow %q, expected drop, sample or aggregate", conf.Overflow)
+	}
+	validateNotNegative(errs, "quotas.hourly", conf.Hourly)
+	validateNotNegative(errs, "quotas.daily", conf.Daily)
+	validateNotNegative(errs, "quotas.maxTenants", int64(conf.MaxTenants))
+	validateFraction(errs, "quotas.sampleRate", conf.SampleRate)
+
+	tenants := make([]string, 0, len(conf.Tenants))
+	for tenant := range conf.Tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		limit := conf.Tenants[tenant]
+		validateNotNegative(errs, fmt.Sprintf("quotas.tenants[%s].hourly", tenant), limit.Hourly)
+		validateNotNegative(errs, fmt.Sprintf("quotas.tenants[%s].daily", tenant), limit.Daily)
+	}
+}
+
+// validateNotNegative checks that a count or size is not negative
+func validateNotNegative(errs *configErrors, field string, value int64) {
+	if value < 0 {
+		errs.addf(field, "must not be negative, got %d", value)
+	}
+}
+
+// validateFraction checks that a rate is between 0 and 1
+func validateFraction(errs *configErrors, field string, value float64) {
+	if value < 0 || value > 1 {
+		errs.addf(field, "must be between 0 and 1, got %v", value)
+	}
+}
+
+// validateURL checks that a configured URL is absolute, unless it is unset or a secret reference
+func validateURL(errs *configErrors, field, value string) {
+	if value == "" || isSecretRef(value) {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		errs.add(field, err)
+		return
+	}
+	if u.Scheme == "" || u.Host == "" {
+		errs.addf(field, "%q is not an absolute URL", value)
+	}
+}
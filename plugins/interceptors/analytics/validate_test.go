@@ -0,0 +1,133 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+func TestValidateValidConfig(t *testing.T) {
+	assert.NoError(t, (&Analytics{}).Validate())
+	half := 0.5
+
+	a := &Analytics{
+		Enabled:             true,
+		TrackingID:          "G-ABC123XYZ",
+		APISecret:           "env://GA_API_SECRET",
+		SampleRate:          &half,
+		CaptureResponseBody: true,
+		EnrichDecisions:     true,
+		ClientIDSource:      ClientIDSource{Type: "header", Name: "X-Client-Id"},
+		Destinations: []BackendConfig{
+			{"type": "snowplow", "collectorURL": "https://collector.example.com"},
+			{"type": "webhook", "name": "warehouse", "url": "env://WAREHOUSE_URL"},
+		},
+		Routing:   []RoutingRule{{Destinations: []string{"ga4", "warehouse"}, Events: []string{"api_request"}}},
+		RateLimit: RateLimitConfig{MaxEventsPerSecond: 10, Policy: rateLimitSpill},
+		Spill:     SpillConfig{Directory: t.TempDir()},
+	}
+	assert.NoError(t, a.Validate())
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	tooHigh := 1.5
+	a := &Analytics{
+		TrackingID:     "GA-123",
+		EndpointURL:    "collect",
+		SampleRate:     &tooHigh,
+		GA4Debug:       true,
+		ValidateEvents: 0.1,
+		Workers:        -1,
+		GAProtocol:     "mp2",
+		DryRunFile:     "payloads.ndjson",
+		Properties: map[string]GA4Property{
+			"project-a": {MeasurementID: "G-AAA"},
+			"project-b": {MeasurementID: "123"},
+		},
+		Audit:           AuditConfig{File: "audit.jsonl"},
+		Latency:         LatencyConfig{Buckets: []utils.Duration{{Duration: time.Second}, {Duration: 100 * time.Millisecond}}},
+		EnrichDecisions: true,
+		StatusCodes:     []string{"6xx"},
+		ClientIDSource:  ClientIDSource{Type: "carrier-pigeon"},
+		Destinations: []BackendConfig{
+			{"type": "snowplow", "collectorURL": "collector.example.com"},
+			{"type": "unknown"},
+			{"type": "posthog", "name": "snowplow"},
+		},
+		Routing:       []RoutingRule{{Destinations: []string{"warehouse"}, SampleRate: &tooHigh}},
+		HTTPClient:    HTTPClientConfig{ProxyURL: "proxy:3128"},
+		DeadLetter:    DeadLetterConfig{Type: deadLetterKafka},
+		RateLimit:     RateLimitConfig{Policy: rateLimitSpill},
+		Notifications: NotificationConfig{Enabled: true, Types: []string{"impression"}},
+		Quotas:        QuotaConfig{Key: "tenant", Hourly: -1, Overflow: "queue"},
+	}
+
+	err := a.Validate()
+	require.Error(t, err)
+	for _, field := range []string{
+		"trackingID: ",
+		"properties[project-a].apiSecret: required with a GA4 measurementID",
+		"properties[project-b].measurementID: ",
+		"endpointURL: ",
+		"gaProtocol: unknown GA protocol \"mp2\"",
+		"sampleRate: must be between 0 and 1, got 1.5",
+		"validateEvents: cannot be combined with ga4Debug",
+		"workers: must not be negative, got -1",
+		"dryRunFile: requires dryRun",
+		"audit.file: requires audit.interval",
+		"latency.buckets[1]: must be greater than the previous bucket",
+		"enrichDecisions: requires captureResponseBody",
+		"statusCodes: ",
+		"clientIDSource.type: ",
+		"destinations[0].collectorURL: ",
+		"destinations[1]: analytics backend not found",
+		"destinations[2].name: duplicate destination name \"snowplow\"",
+		"routing[0]: ",
+		"routing[0].destinations: unknown destination \"warehouse\"",
+		"httpClient: invalid proxy URL",
+		"deadLetter: restProxyURL and topic are required",
+		"rateLimit.policy: spill requires spill.directory",
+		"quotas.key: unknown key \"tenant\"",
+		"notifications.types: unknown notification type \"impression\"",
+		"quotas.hourly: must not be negative, got -1",
+		"quotas.overflow: unknown overflow \"queue\"",
+	} {
+		assert.Contains(t, err.Error(), field)
+	}
+}
+
+func TestValidateExperimentEventsRequireDecisions(t *testing.T) {
+	a := &Analytics{TrackingID: "G-ABC123XYZ", APISecret: "secret", ExperimentEvents: true}
+	assert.ErrorContains(t, a.Validate(), "experimentEvents: requires enrichDecisions")
+
+	a.EnrichDecisions, a.CaptureResponseBody = true, true
+	assert.NoError(t, a.Validate())
+}
+
+func TestValidateGA4RequiresAPISecret(t *testing.T) {
+	err := (&Analytics{TrackingID: "G-ABC123XYZ"}).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "apiSecret: required")
+
+	assert.NoError(t, (&Analytics{TrackingID: "UA-12345-1"}).Validate())
+}
@@ -0,0 +1,198 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/tidwall/gjson"
+)
+
+const staticMappingPrefix = "static:"
+
+// WebhookBackend sends events to any HTTP endpoint, with the payload rendered from a Go template
+// or built from a JSON mapping of event fields. Without either, the event is sent as JSON in the
+// same format as the file destination.
+type WebhookBackend struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`      // Request method (defaults to POST)
+	Headers     map[string]string `json:"headers"`     // Extra request headers, e.g. authorization
+	ContentType string            `json:"contentType"` // Content type of the payload (defaults to application/json)
+	Template    string            `json:"template"`    // Go template rendered with the event, or with .Events in batch mode
+	Mapping     map[string]string `json:"mapping"`     // Payload fields as gjson paths into the event or "static:<value>"
+	Batch       bool              `json:"batch"`       // Send all events of a delivery in one request
+
+	once     sync.Once
+	initErr  error
+	template *template.Template
+	client   *http.Client
+}
+
+// webhookBatch is the template data in batch mode
+type webhookBatch struct {
+	Events []CanonicalEvent
+}
+
+var webhookTemplateFuncs = template.FuncMap{
+	// json renders a value as JSON, e.g. {{ json .Params }}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func (w *WebhookBackend) init() {
+	switch {
+	case w.URL == "":
+		w.initErr = errors.New("webhook analytics backend requires a url")
+	case w.Template != "" && len(w.Mapping) > 0:
+		w.initErr = errors.New("webhook analytics backend accepts either a template or a mapping")
+	case w.Template != "":
+		w.template, w.initErr = template.New("webhook").Funcs(webhookTemplateFuncs).Option("missingkey=zero").Parse(w.Template)
+	}
+}
+
+// Send renders and sends one request per event, or a single one in batch mode
+func (w *WebhookBackend) Send(ctx context.Context, events []Event) error {
+	w.once.Do(w.init)
+	if w.initErr != nil {
+		return w.initErr
+	}
+
+	records := make([]CanonicalEvent, 0, len(events))
+	for _, e := range events {
+		records = append(records, canonicalEvent(e))
+	}
+
+	if w.Batch {
+		payload, err := w.render(records, webhookBatch{Events: records})
+		if err != nil {
+			return err
+		}
+		return w.send(ctx, payload)
+	}
+	for _, record := range records {
+		payload, err := w.render(record, record)
+		if err != nil {
+			return err
+		}
+		if err := w.send(ctx, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render builds the payload of value, which is an event record or a slice of them, passing
+// data to the template
+func (w *WebhookBackend) render(value, data interface{}) ([]byte, error) {
+	if w.template != nil {
+		var buf bytes.Buffer
+		if err := w.template.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	if len(w.Mapping) == 0 {
+		return json.Marshal(value)
+	}
+
+	if records, ok := value.([]CanonicalEvent); ok {
+		mapped := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			fields, err := w.mapRecord(record)
+			if err != nil {
+				return nil, err
+			}
+			mapped = append(mapped, fields)
+		}
+		return json.Marshal(mapped)
+	}
+	fields, err := w.mapRecord(value.(CanonicalEvent))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// mapRecord builds the payload fields of the mapping. Dotted field names create nested objects
+// and fields whose path the event doesn't carry are omitted.
+func (w *WebhookBackend) mapRecord(record CanonicalEvent) (map[string]interface{}, error) {
+	source, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	for field, path := range w.Mapping {
+		var value interface{}
+		if strings.HasPrefix(path, staticMappingPrefix) {
+			value = strings.TrimPrefix(path, staticMappingPrefix)
+		} else {
+			result := gjson.GetBytes(source, path)
+			if !result.Exists() || result.Type == gjson.Null {
+				continue
+			}
+			value = result.Value()
+		}
+		setNestedField(fields, strings.Split(field, "."), value)
+	}
+	return fields, nil
+}
+
+// setNestedField sets the value at path, creating the intermediate objects
+func setNestedField(fields map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := fields[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			fields[key] = child
+		}
+		fields = child
+	}
+	fields[path[len(path)-1]] = value
+}
+
+func (w *WebhookBackend) send(ctx context.Context, payload []byte) error {
+	method := w.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	contentType := w.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	_, err := send(ctx, w.client, strings.ToUpper(method), w.URL, contentType, payload, w.Headers)
+	return err
+}
+
+func (w *WebhookBackend) setHTTPClient(client *http.Client) {
+	w.client = client
+}
+
+func init() {
+	AddBackend("webhook", func() Backend {
+		return &WebhookBackend{}
+	})
+}
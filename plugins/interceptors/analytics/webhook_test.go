@@ -0,0 +1,158 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookRequest struct {
+	method      string
+	contentType string
+	auth        string
+	body        string
+}
+
+func newWebhookServer(t *testing.T) (*httptest.Server, func() []webhookRequest) {
+	var mu sync.Mutex
+	var requests []webhookRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		mu.Lock()
+		requests = append(requests, webhookRequest{
+			method:      r.Method,
+			contentType: r.Header.Get("Content-Type"),
+			auth:        r.Header.Get("Authorization"),
+			body:        string(body),
+		})
+		mu.Unlock()
+	}))
+	t.Cleanup(ts.Close)
+	return ts, func() []webhookRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+var webhookEvents = []Event{
+	{Name: "api_request", ClientID: "a", Params: map[string]interface{}{"path": "/v1/decide", "status_code": 200, "plan": "pro"}},
+	{Name: "api_request", ClientID: "b", Params: map[string]interface{}{"path": "/v1/track"}},
+}
+
+func TestWebhookBackendDefaultPayload(t *testing.T) {
+	ts, requests := newWebhookServer(t)
+	backend := &WebhookBackend{URL: ts.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+	require.NoError(t, backend.Send(context.Background(), webhookEvents))
+
+	require.Len(t, requests(), 2)
+	req := requests()[0]
+	assert.Equal(t, http.MethodPost, req.method)
+	assert.Equal(t, "application/json", req.contentType)
+	assert.Equal(t, "Bearer token", req.auth)
+
+	var record CanonicalEvent
+	require.NoError(t, json.Unmarshal([]byte(req.body), &record))
+	assert.Equal(t, "a", record.Client.ID)
+	assert.Equal(t, "/v1/decide", record.Request.Path)
+}
+
+func TestWebhookBackendTemplate(t *testing.T) {
+	ts, requests := newWebhookServer(t)
+	backend := &WebhookBackend{
+		URL:         ts.URL,
+		Method:      "put",
+		ContentType: "text/plain",
+		Template:    `{{ .Name }} {{ .Client.ID }} {{ .Request.Path }} {{ .Response.StatusCode }} {{ json .Custom.plan }}`,
+	}
+	require.NoError(t, backend.Send(context.Background(), webhookEvents))
+
+	require.Len(t, requests(), 2)
+	assert.Equal(t, http.MethodPut, requests()[0].method)
+	assert.Equal(t, "text/plain", requests()[0].contentType)
+	assert.Equal(t, "api_request a /v1/decide 200 \"pro\"", requests()[0].body)
+	assert.Equal(t, "api_request b /v1/track 0 null", requests()[1].body)
+}
+
+func TestWebhookBackendBatchTemplate(t *testing.T) {
+	ts, requests := newWebhookServer(t)
+	backend := &WebhookBackend{
+		URL:      ts.URL,
+		Batch:    true,
+		Template: `{"items":[{{ range $i, $e := .Events }}{{ if $i }},{{ end }}{"id":{{ json $e.Client.ID }}}{{ end }}]}`,
+	}
+	require.NoError(t, backend.Send(context.Background(), webhookEvents))
+
+	require.Len(t, requests(), 1)
+	assert.JSONEq(t, `{"items":[{"id":"a"},{"id":"b"}]}`, requests()[0].body)
+}
+
+func TestWebhookBackendMapping(t *testing.T) {
+	ts, requests := newWebhookServer(t)
+	backend := &WebhookBackend{
+		URL:   ts.URL,
+		Batch: true,
+		Mapping: map[string]string{
+			"event":       "name",
+			"user.id":     "client.id",
+			"page.path":   "request.path",
+			"page.status": "response.statusCode",
+			"plan":        "custom.plan",
+			"source":      "static:optimizely-agent",
+		},
+	}
+	require.NoError(t, backend.Send(context.Background(), webhookEvents))
+
+	require.Len(t, requests(), 1)
+	assert.JSONEq(t, `[
+		{"event":"api_request","user":{"id":"a"},"page":{"path":"/v1/decide","status":200},"plan":"pro","source":"optimizely-agent"},
+		{"event":"api_request","user":{"id":"b"},"page":{"path":"/v1/track","status":0},"source":"optimizely-agent"}
+	]`, requests()[0].body)
+}
+
+func TestWebhookBackendInvalidConfig(t *testing.T) {
+	tests := []*WebhookBackend{
+		{},
+		{URL: "http://127.0.0.1:1", Template: "{{ .Name"},
+		{URL: "http://127.0.0.1:1", Template: "{{ .Name }}", Mapping: map[string]string{"event": "name"}},
+	}
+	for _, backend := range tests {
+		assert.Error(t, backend.Send(context.Background(), webhookEvents))
+	}
+}
+
+func TestWebhookBackendStatusError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	err := (&WebhookBackend{URL: ts.URL}).Send(context.Background(), webhookEvents)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+}
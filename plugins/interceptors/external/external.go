@@ -0,0 +1,232 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+// Package external runs interceptors implemented by external processes, which the agent calls
+// over a gRPC shim, so that custom logic doesn't require rebuilding the agent.
+package external
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/optimizely/agent/plugins/interceptors"
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+const (
+	// requestHook calls the process before the agent handles a request, which it may change or answer
+	requestHook = "request"
+	// responseHook sends the process the requests and their responses once they were served
+	responseHook = "response"
+
+	defaultTimeout   = 100 * time.Millisecond
+	defaultQueueSize = 1000
+	stopTimeout      = 5 * time.Second
+)
+
+// External calls the configured external processes for the requests of the server
+type External struct {
+	Processes []ProcessConfig `json:"processes"`
+
+	clients []*client
+}
+
+// ProcessConfig configures an external process serving the interceptor shim
+type ProcessConfig struct {
+	Name      string            `json:"name"`      // Name of the process in logs and metrics
+	Address   string            `json:"address"`   // gRPC address of the shim, e.g. localhost:9090 or unix:///run/tracker.sock
+	Command   []string          `json:"command"`   // Command starting the process with the agent, if the agent runs it
+	Env       map[string]string `json:"env"`       // Additional environment of the command
+	Hooks     []string          `json:"hooks"`     // request and/or response (the default)
+	Timeout   utils.Duration    `json:"timeout"`   // Deadline of request hook calls (defaults to 100ms)
+	QueueSize int               `json:"queueSize"` // Served requests waiting for the response hook (defaults to 1000); more are dropped
+}
+
+// Validate checks the configuration of the processes
+func (e *External) Validate() error {
+	var errs []error
+	names := map[string]bool{}
+	for i, p := range e.Processes {
+		field := fmt.Sprintf("processes[%d]", i)
+		switch {
+		case p.Name == "":
+			errs = append(errs, fmt.Errorf("%s.name: required", field))
+		case names[p.Name]:
+			errs = append(errs, fmt.Errorf("%s.name: %q is not unique", field, p.Name))
+		}
+		names[p.Name] = true
+		if p.Address == "" {
+			errs = append(errs, fmt.Errorf("%s.address: required", field))
+		}
+		for _, hook := range p.Hooks {
+			if hook != requestHook && hook != responseHook {
+				errs = append(errs, fmt.Errorf("%s.hooks: unknown hook %q, expected request or response", field, hook))
+			}
+		}
+		if p.Timeout.Duration < 0 {
+			errs = append(errs, fmt.Errorf("%s.timeout: must not be negative", field))
+		}
+		if p.QueueSize < 0 {
+			errs = append(errs, fmt.Errorf("%s.queueSize: must not be negative", field))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Handler returns a middleware calling the request hooks of the processes in order before next
+// and queuing the served requests for their response hooks. Failing hooks are skipped.
+func (e *External) Handler() func(http.Handler) http.Handler {
+	e.clients = nil
+	for _, p := range e.Processes {
+		c, err := newClient(p)
+		if err != nil {
+			log.Error().Err(err).Str("process", p.Name).Msg("Skipping external interceptor")
+			continue
+		}
+		e.clients = append(e.clients, c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			var observers []*client
+			for _, c := range e.clients {
+				if c.request {
+					if c.intercept(w, r) {
+						return
+					}
+				}
+				if c.response {
+					observers = append(observers, c)
+				}
+			}
+			if len(observers) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			exchange := newExchange(r, rw, time.Since(start))
+			for _, c := range observers {
+				c.observe(exchange)
+			}
+		})
+	}
+}
+
+// Start starts the commands of the processes the agent runs
+func (e *External) Start(ctx context.Context) error {
+	for _, c := range e.clients {
+		if err := startProcess(c.conf); err != nil {
+			return fmt.Errorf("starting external interceptor %q: %w", c.conf.Name, err)
+		}
+	}
+	return nil
+}
+
+// Stop sends the queued requests to the response hooks, closes the connections and stops the
+// commands of the processes
+func (e *External) Stop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, stopTimeout)
+	defer cancel()
+	var errs []error
+	for _, c := range e.clients {
+		c.close(ctx)
+		if err := stopProcess(ctx, c.conf); err != nil {
+			errs = append(errs, fmt.Errorf("stopping external interceptor %q: %w", c.conf.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// errHijackUnsupported is returned when hijacking a response writer that doesn't support it
+var errHijackUnsupported = errors.New("external: underlying response writer does not support hijacking")
+
+// statusWriter records the status code and size of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+// WriteHeader records the status code
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write counts the bytes written
+func (w *statusWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush flushes the original writer if it supports flushing
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, e.g. for websocket upgrades. The request is
+// observed with status 101 Switching Protocols.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errHijackUnsupported
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, buf, err
+}
+
+// Push initiates an HTTP/2 server push, returning http.ErrNotSupported when the original writer can't push
+func (w *statusWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// ReadFrom copies src to the response with the original writer's ReadFrom (e.g. sendfile) when it
+// has one, counting the bytes written
+func (w *statusWriter) ReadFrom(src io.Reader) (int64, error) {
+	if readerFrom, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err := readerFrom.ReadFrom(src)
+		w.size += n
+		return n, err
+	}
+	// Hide ReadFrom from io.Copy so that it writes through Write
+	return io.Copy(struct{ io.Writer }{w}, src)
+}
+
+func init() {
+	interceptors.Add("external", func() interceptors.Interceptor {
+		return &External{}
+	})
+}
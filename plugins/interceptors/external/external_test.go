@@ -0,0 +1,232 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package external
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/optimizely/agent/plugins/interceptors"
+	"github.com/optimizely/agent/plugins/utils"
+)
+
+// shimServer serves the interceptor shim with the given request hook, recording the requests
+// sent to the response hook
+type shimServer struct {
+	address   string
+	intercept func(request map[string]interface{}) map[string]interface{}
+	observed  chan map[string]interface{}
+}
+
+func newShimServer(t *testing.T, intercept func(map[string]interface{}) map[string]interface{}) *shimServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &shimServer{address: listener.Addr().String(), intercept: intercept, observed: make(chan map[string]interface{}, 10)}
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "com.optimizely.agent.interceptor.Interceptor",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Intercept",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &structpb.Struct{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return structpb.NewStruct(s.intercept(in.AsMap()))
+			},
+		}, {
+			MethodName: "Observe",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &structpb.Struct{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				s.observed <- in.AsMap()
+				return &emptypb.Empty{}, nil
+			},
+		}},
+	}, struct{}{})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return s
+}
+
+func TestExternalRequestHook(t *testing.T) {
+	shim := newShimServer(t, func(request map[string]interface{}) map[string]interface{} {
+		headers := request["headers"].(map[string]interface{})
+		switch {
+		case headers["X-Block"] != nil:
+			return map[string]interface{}{"respond": map[string]interface{}{
+				"status":  403,
+				"headers": map[string]interface{}{"Content-Type": "text/plain"},
+				"body":    "blocked " + request["path"].(string),
+			}}
+		case headers["Authorization"] != nil, headers["Cookie"] != nil, headers["X-Optimizely-Sdk-Key"] != nil:
+			return map[string]interface{}{"setHeaders": map[string]interface{}{"X-Leaked": "authorization"}}
+		}
+		return map[string]interface{}{"setHeaders": map[string]interface{}{"X-Tenant": "acme"}}
+	})
+	e := &External{Processes: []ProcessConfig{{Name: "tenant", Address: shim.address, Hooks: []string{"request"}, Timeout: utils.Duration{Duration: time.Second}}}}
+	require.NoError(t, e.Validate())
+	var served *http.Request
+	handler := e.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served = r }))
+	defer e.Stop(context.Background())
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/config", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	r.Header.Set("Cookie", "session=abc")
+	r.Header.Set("X-Optimizely-SDK-Key", "sdk-key")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	require.NotNil(t, served)
+	assert.Equal(t, "acme", served.Header.Get("X-Tenant"))
+	assert.Empty(t, served.Header.Get("X-Leaked"), "credentials aren't sent")
+
+	served = nil
+	r = httptest.NewRequest(http.MethodGet, "/v1/config", nil)
+	r.Header.Set("X-Block", "1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	assert.Nil(t, served)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "blocked /v1/config", rec.Body.String())
+}
+
+func TestExternalResponseHook(t *testing.T) {
+	shim := newShimServer(t, nil)
+	e := &External{Processes: []ProcessConfig{{Name: "tracker", Address: shim.address}}}
+	handler := e.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("accepted"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/track?eventKey=buy", nil))
+	require.NoError(t, e.Stop(context.Background()))
+
+	select {
+	case exchange := <-shim.observed:
+		request := exchange["request"].(map[string]interface{})
+		response := exchange["response"].(map[string]interface{})
+		assert.Equal(t, "POST", request["method"])
+		assert.Equal(t, "/v1/track", request["path"])
+		assert.Equal(t, "eventKey=buy", request["query"])
+		assert.Equal(t, float64(http.StatusAccepted), response["status"])
+		assert.Equal(t, float64(8), response["bytes"])
+		assert.Equal(t, map[string]interface{}{"X-Request-Id": "req-1"}, response["headers"], "cookies aren't sent")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the response hook")
+	}
+}
+
+func TestExternalFailsOpen(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	e := &External{Processes: []ProcessConfig{{Name: "down", Address: address, Hooks: []string{"request", "response"}, QueueSize: 1}}}
+	called := false
+	handler := e.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer e.Stop(context.Background())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/config", nil))
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestExternalValidate(t *testing.T) {
+	e := &External{Processes: []ProcessConfig{
+		{Name: "a", Address: "localhost:1"},
+		{Name: "a", Hooks: []string{"before"}, QueueSize: -1},
+		{Address: "localhost:2", Timeout: utils.Duration{Duration: -time.Second}},
+	}}
+	err := e.Validate()
+	require.Error(t, err)
+	for _, expected := range []string{
+		`processes[1].name: "a" is not unique`,
+		"processes[1].address: required",
+		`processes[1].hooks: unknown hook "before"`,
+		"processes[1].queueSize: must not be negative",
+		"processes[2].name: required",
+		"processes[2].timeout: must not be negative",
+	} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestInit(t *testing.T) {
+	creator, ok := interceptors.Interceptors["external"]
+	require.True(t, ok)
+	assert.Equal(t, &External{}, creator())
+}
+
+// hijackRecorder is a ResponseRecorder whose connection can be hijacked
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.conn, bufio.NewReadWriter(bufio.NewReader(h.conn), bufio.NewWriter(h.conn)), nil
+}
+
+func TestStatusWriterHijack(t *testing.T) {
+	w := &statusWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK}
+	_, _, err := w.Hijack()
+	assert.Equal(t, errHijackUnsupported, err)
+
+	server, client := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+	w = &statusWriter{ResponseWriter: &hijackRecorder{httptest.NewRecorder(), server}, status: http.StatusOK}
+	conn, _, err := w.Hijack()
+	require.NoError(t, err)
+	assert.Equal(t, server, conn)
+	assert.Equal(t, http.StatusSwitchingProtocols, w.status)
+}
+
+func TestStatusWriterPush(t *testing.T) {
+	w := &statusWriter{ResponseWriter: httptest.NewRecorder()}
+	assert.Equal(t, http.ErrNotSupported, w.Push("/style.css", nil))
+}
+
+func TestStatusWriterReadFrom(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := &statusWriter{ResponseWriter: recorder}
+	n, err := w.ReadFrom(strings.NewReader("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), n)
+	assert.Equal(t, int64(11), w.size)
+	assert.Equal(t, "hello world", recorder.Body.String())
+}
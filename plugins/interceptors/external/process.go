@@ -0,0 +1,116 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// addressEnv tells started processes the address to serve the shim on
+const addressEnv = "AGENT_INTERCEPTOR_ADDRESS"
+
+// processes are the running commands by process name. Each server has its own interceptor
+// instance, so commands are shared and stopped with the last instance using them.
+var processes = struct {
+	sync.Mutex
+	byName map[string]*process
+}{byName: map[string]*process{}}
+
+// process is a running command
+type process struct {
+	cmd    *exec.Cmd
+	users  int
+	exited chan struct{}
+}
+
+// startProcess starts the command of conf, unless it is already running or conf has none
+func startProcess(conf ProcessConfig) error {
+	if len(conf.Command) == 0 {
+		return nil
+	}
+
+	processes.Lock()
+	defer processes.Unlock()
+	if p, ok := processes.byName[conf.Name]; ok {
+		p.users++
+		return nil
+	}
+
+	cmd := exec.Command(conf.Command[0], conf.Command[1:]...)
+	cmd.Env = append(os.Environ(), addressEnv+"="+conf.Address)
+	for name, value := range conf.Env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	log.Info().Str("process", conf.Name).Int("pid", cmd.Process.Pid).Msg("Started external interceptor.")
+
+	p := &process{cmd: cmd, users: 1, exited: make(chan struct{})}
+	processes.byName[conf.Name] = p
+	go func() {
+		err := cmd.Wait()
+		close(p.exited)
+		log.Info().Err(err).Str("process", conf.Name).Msg("External interceptor exited.")
+	}()
+	return nil
+}
+
+// stopProcess stops the command of conf once no other interceptor instance uses it, asking it to
+// terminate and killing it when it hasn't exited by the time ctx is done
+func stopProcess(ctx context.Context, conf ProcessConfig) error {
+	if len(conf.Command) == 0 {
+		return nil
+	}
+
+	processes.Lock()
+	p, ok := processes.byName[conf.Name]
+	if ok {
+		p.users--
+		if p.users > 0 {
+			ok = false
+		} else {
+			delete(processes.byName, conf.Name)
+		}
+	}
+	processes.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		_ = p.cmd.Process.Kill()
+	}
+	select {
+	case <-p.exited:
+		return nil
+	case <-ctx.Done():
+		_ = p.cmd.Process.Kill()
+		<-p.exited
+		return fmt.Errorf("killed after %w", ctx.Err())
+	}
+}
@@ -0,0 +1,81 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package external
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessIsSharedAndStoppedWithLastUser(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "address")
+	conf := ProcessConfig{
+		Name:    "sleeper",
+		Address: "localhost:9090",
+		Command: []string{"sh", "-c", `echo "$AGENT_INTERCEPTOR_ADDRESS $TENANT" > "$OUT"; exec sleep 30`},
+		Env:     map[string]string{"TENANT": "acme", "OUT": out},
+	}
+
+	require.NoError(t, startProcess(conf))
+	require.NoError(t, startProcess(conf))
+	processes.Lock()
+	p := processes.byName["sleeper"]
+	processes.Unlock()
+	require.NotNil(t, p)
+	assert.Eventually(t, func() bool {
+		b, _ := os.ReadFile(out)
+		return string(b) == "localhost:9090 acme\n"
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, stopProcess(ctx, conf))
+	select {
+	case <-p.exited:
+		t.Fatal("the process stopped while still in use")
+	default:
+	}
+
+	require.NoError(t, stopProcess(ctx, conf))
+	select {
+	case <-p.exited:
+	case <-time.After(time.Second):
+		t.Fatal("the process was not stopped")
+	}
+}
+
+func TestProcessIsKilledAfterTimeout(t *testing.T) {
+	conf := ProcessConfig{Name: "stubborn", Command: []string{"sh", "-c", `trap "" TERM; while true; do sleep 0.1; done`}}
+	require.NoError(t, startProcess(conf))
+	time.Sleep(100 * time.Millisecond) // let the shell set its trap
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, stopProcess(ctx, conf), context.DeadlineExceeded)
+}
+
+func TestStartProcessErrors(t *testing.T) {
+	assert.Error(t, startProcess(ProcessConfig{Name: "missing", Command: []string{"/does/not/exist"}}))
+	assert.NoError(t, startProcess(ProcessConfig{Name: "remote"}), "processes without a command aren't started")
+	assert.NoError(t, stopProcess(context.Background(), ProcessConfig{Name: "remote"}))
+}
@@ -0,0 +1,231 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package external
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	go_kit_metrics "github.com/go-kit/kit/metrics"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/optimizely/agent/plugins/interceptors"
+)
+
+// Methods of the interceptor shim, see interceptor.proto
+const (
+	interceptMethod = "/com.optimizely.agent.interceptor.Interceptor/Intercept"
+	observeMethod   = "/com.optimizely.agent.interceptor.Interceptor/Observe"
+)
+
+// withheldHeaders are request headers never sent to processes, as they carry credentials
+var withheldHeaders = []string{"Authorization", "Cookie", "X-Optimizely-SDK-Key"}
+
+// withheldResponseHeaders are response headers never sent to processes, as they carry credentials
+var withheldResponseHeaders = []string{"Set-Cookie"}
+
+// client calls the shim of an external process
+type client struct {
+	conf     ProcessConfig
+	conn     *grpc.ClientConn
+	timeout  time.Duration
+	request  bool
+	response bool
+
+	exchanges chan *structpb.Struct
+	workers   sync.WaitGroup
+	closeOnce sync.Once
+
+	failures go_kit_metrics.Counter
+	dropped  go_kit_metrics.Counter
+}
+
+// newClient connects to the shim of the process, which may start later, and starts sending
+// served requests to its response hook
+func newClient(conf ProcessConfig) (*client, error) {
+	conn, err := grpc.Dial(conf.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &client{conf: conf, conn: conn, timeout: conf.Timeout.Duration}
+	if c.timeout == 0 {
+		c.timeout = defaultTimeout
+	}
+	if len(conf.Hooks) == 0 {
+		c.response = true
+	}
+	for _, hook := range conf.Hooks {
+		c.request = c.request || hook == requestHook
+		c.response = c.response || hook == responseHook
+	}
+	if registry := interceptors.MetricsRegistry; registry != nil {
+		c.failures = registry.GetCounter("external." + conf.Name + ".failures")
+		c.dropped = registry.GetCounter("external." + conf.Name + ".dropped")
+	}
+
+	if c.response {
+		queueSize := conf.QueueSize
+		if queueSize == 0 {
+			queueSize = defaultQueueSize
+		}
+		c.exchanges = make(chan *structpb.Struct, queueSize)
+		c.workers.Add(1)
+		go c.run()
+	}
+	return c, nil
+}
+
+// intercept calls the request hook, applying the headers it sets to r. It reports true when the
+// process answered the request instead of the agent.
+func (c *client) intercept(w http.ResponseWriter, r *http.Request) bool {
+	ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+	defer cancel()
+
+	reply := &structpb.Struct{}
+	if err := c.conn.Invoke(ctx, interceptMethod, newRequestMessage(r), reply); err != nil {
+		c.fail(err, "request")
+		return false
+	}
+	result := reply.AsMap()
+
+	if headers, ok := result["setHeaders"].(map[string]interface{}); ok {
+		for name, value := range headers {
+			r.Header.Set(name, fmt.Sprint(value))
+		}
+	}
+	respond, ok := result["respond"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if headers, ok := respond["headers"].(map[string]interface{}); ok {
+		for name, value := range headers {
+			w.Header().Set(name, fmt.Sprint(value))
+		}
+	}
+	status := http.StatusOK
+	if code, ok := respond["status"].(float64); ok && code >= 100 && code <= 999 {
+		status = int(code)
+	}
+	w.WriteHeader(status)
+	if body, ok := respond["body"].(string); ok {
+		_, _ = w.Write([]byte(body))
+	}
+	return true
+}
+
+// observe queues a served request for the response hook, dropping it when the queue is full
+func (c *client) observe(exchange *structpb.Struct) {
+	select {
+	case c.exchanges <- exchange:
+	default:
+		if c.dropped != nil {
+			c.dropped.Add(1)
+		}
+	}
+}
+
+// run sends the queued requests to the response hook until the queue is closed
+func (c *client) run() {
+	defer c.workers.Done()
+	for exchange := range c.exchanges {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		if err := c.conn.Invoke(ctx, observeMethod, exchange, &emptypb.Empty{}); err != nil {
+			c.fail(err, "response")
+		}
+		cancel()
+	}
+}
+
+// close sends the queued requests to the response hook within ctx and closes the connection
+func (c *client) close(ctx context.Context) {
+	c.closeOnce.Do(func() {
+		if c.exchanges != nil {
+			close(c.exchanges)
+			done := make(chan struct{})
+			go func() {
+				c.workers.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				log.Warn().Str("process", c.conf.Name).Msg("Timed out sending requests to external interceptor")
+			}
+		}
+		_ = c.conn.Close()
+	})
+}
+
+// fail records a failed call of a hook
+func (c *client) fail(err error, hook string) {
+	if c.failures != nil {
+		c.failures.Add(1)
+	}
+	log.Debug().Err(err).Str("process", c.conf.Name).Str("hook", hook).Msg("External interceptor call failed")
+}
+
+// newRequestMessage describes r for the hooks
+func newRequestMessage(r *http.Request) *structpb.Struct {
+	return &structpb.Struct{Fields: requestFields(r)}
+}
+
+// newExchange describes a served request and its response for the response hook
+func newExchange(r *http.Request, w *statusWriter, elapsed time.Duration) *structpb.Struct {
+	return &structpb.Struct{Fields: map[string]*structpb.Value{
+		"request": structpb.NewStructValue(&structpb.Struct{Fields: requestFields(r)}),
+		"response": structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"status":     structpb.NewNumberValue(float64(w.status)),
+			"bytes":      structpb.NewNumberValue(float64(w.size)),
+			"durationMs": structpb.NewNumberValue(float64(elapsed.Microseconds()) / 1000),
+			"headers":    headersValue(w.Header(), withheldResponseHeaders),
+		}}),
+	}}
+}
+
+// requestFields returns the fields describing r
+func requestFields(r *http.Request) map[string]*structpb.Value {
+	return map[string]*structpb.Value{
+		"method":     structpb.NewStringValue(r.Method),
+		"path":       structpb.NewStringValue(r.URL.Path),
+		"query":      structpb.NewStringValue(r.URL.RawQuery),
+		"host":       structpb.NewStringValue(r.Host),
+		"remoteAddr": structpb.NewStringValue(r.RemoteAddr),
+		"headers":    headersValue(r.Header, withheldHeaders),
+	}
+}
+
+// headersValue returns the headers but the withheld ones, the values of each joined by commas
+func headersValue(header http.Header, withheld []string) *structpb.Value {
+	fields := make(map[string]*structpb.Value, len(header))
+	for name, values := range header {
+		fields[name] = structpb.NewStringValue(strings.Join(values, ", "))
+	}
+	for _, name := range withheld {
+		delete(fields, name)
+		delete(fields, http.CanonicalHeaderKey(name))
+	}
+	return structpb.NewStructValue(&structpb.Struct{Fields: fields})
+}
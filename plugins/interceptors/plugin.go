@@ -0,0 +1,71 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package interceptors
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+
+	"github.com/rs/zerolog/log"
+)
+
+// errPluginsUnsupported rejects plugins in builds that can't load them
+var errPluginsUnsupported = errors.New("this build of the agent can't load Go plugins: " +
+	"they need cgo, which CGO_ENABLED=0 builds such as the static binary lack, and Linux, FreeBSD or macOS")
+
+// ValidatePlugin reports why the Go plugin at path can't be loaded, if it can't: plugins must be
+// .so files and the agent must be built with cgo on a platform supporting them.
+func ValidatePlugin(path string) error {
+	if !pluginsSupported {
+		return errPluginsUnsupported
+	}
+	if filepath.Ext(path) != ".so" {
+		return errors.New("plugin paths must be .so files built with go build -buildmode=plugin")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LoadPlugins opens the Go plugins at paths, i.e. packages built with go build -buildmode=plugin
+// against the same agent version. Like built-in interceptors, plugins register theirs with Add in
+// their init functions, after which they are configured by name. Plugins rejected by
+// ValidatePlugin or failing to load are skipped and returned as an error.
+func LoadPlugins(paths []string) error {
+	var errs []error
+	for _, path := range paths {
+		if err := ValidatePlugin(path); err != nil {
+			errs = append(errs, fmt.Errorf("interceptor plugin %q: %w", path, err))
+			continue
+		}
+		registered := len(Interceptors)
+		if _, err := plugin.Open(path); err != nil {
+			errs = append(errs, fmt.Errorf("loading interceptor plugin %q: %w", path, err))
+			continue
+		}
+		if len(Interceptors) == registered {
+			log.Warn().Str("path", path).Msg("Interceptor plugin did not register any interceptor.")
+			continue
+		}
+		log.Info().Str("path", path).Int("interceptors", len(Interceptors)-registered).Msg("Loaded interceptor plugin.")
+	}
+	return errors.Join(errs...)
+}
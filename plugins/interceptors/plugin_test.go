@@ -0,0 +1,51 @@
+/****************************************************************************
+ * Copyright 2025, Optimizely, Inc. and contributors                        *
+ *                                                                          *
+ * Licensed under the Apache License, Version 2.0 (the "License");          *
+ * you may not use this file except in compliance with the License.         *
+ * You may obtain a copy of the License at                                  *
+ *                                                                          *
+ *    http://www.apache.org/licenses/LICENSE-2.0                            *
+ *                                                                          *
+ * Unless required by applicable law or agreed to in writing, software      *
+ * distributed under the License is distributed on an "AS IS" BASIS,        *
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. *
+ * See the License for the specific language governing permissions and      *
+ * limitations under the License.                                           *
+ ***************************************************************************/
+
+package interceptors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPlugins(t *testing.T) {
+	assert.NoError(t, LoadPlugins(nil))
+
+	missing := filepath.Join(t.TempDir(), "missing.so")
+	err := LoadPlugins([]string{missing})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `interceptor plugin "`+missing+`"`)
+	}
+}
+
+func TestValidatePlugin(t *testing.T) {
+	dir := t.TempDir()
+	built := filepath.Join(dir, "tenant.so")
+	require.NoError(t, os.WriteFile(built, nil, 0o600))
+
+	if !pluginsSupported {
+		assert.ErrorIs(t, ValidatePlugin(built), errPluginsUnsupported)
+		assert.Contains(t, errPluginsUnsupported.Error(), "CGO_ENABLED=0")
+		return
+	}
+	assert.NoError(t, ValidatePlugin(built))
+	assert.EqualError(t, ValidatePlugin(filepath.Join(dir, "tenant.go")), "plugin paths must be .so files built with go build -buildmode=plugin")
+	assert.ErrorIs(t, ValidatePlugin(filepath.Join(dir, "missing.so")), os.ErrNotExist)
+}
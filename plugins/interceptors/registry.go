@@ -18,8 +18,11 @@
 package interceptors
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+
+	"github.com/optimizely/agent/pkg/metrics"
 )
 
 // Interceptor interface for defining a middleware-style plugin
@@ -27,12 +30,72 @@ type Interceptor interface {
 	Handler() func(http.Handler) http.Handler
 }
 
+// Starter is implemented by interceptors that initialize resources, e.g. worker pools, before
+// the server starts serving requests. ctx is done when the server begins shutting down.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by interceptors holding resources to release when the server shuts
+// down, e.g. queued work to flush. Stop is called after the server stopped serving requests.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// HealthChecker is implemented by interceptors that report readiness. An error fails the
+// server's health check.
+type HealthChecker interface {
+	Health() error
+}
+
+// Reloader is implemented by interceptors that can apply a new configuration at runtime. conf
+// is the interceptor's configuration encoded as JSON, as used for creating it.
+type Reloader interface {
+	Reload(ctx context.Context, conf []byte) error
+}
+
+// Validator is implemented by interceptors that check their configuration once it is decoded. An
+// error prevents the server from starting; it should name every invalid setting.
+type Validator interface {
+	Validate() error
+}
+
+// Namer is implemented by interceptors that need the name they are configured under, e.g. to
+// tell several instances of the interceptor apart. SetName is called before Handler.
+type Namer interface {
+	SetName(name string)
+}
+
+// Lifecycle is implemented by stateful interceptors. The server detects each of the optional
+// Starter, Stopper and HealthChecker interfaces separately.
+type Lifecycle interface {
+	Interceptor
+	Starter
+	Stopper
+	HealthChecker
+}
+
 // Creator type defines a function for creating an instance of a Interceptor
 type Creator func() Interceptor
 
 // Interceptors stores the mapping of  Creators
 var Interceptors = map[string]Creator{}
 
+// MetricsRegistry is the agent metrics registry made available to interceptors.
+// It is set on startup before any interceptor is created and may be nil.
+var MetricsRegistry *metrics.Registry
+
+// AdminHandlers stores admin API handlers of interceptors, mounted under /<name> on the admin listener
+var AdminHandlers = map[string]http.Handler{}
+
+// AddAdminHandler registers an admin API handler for the named interceptor
+func AddAdminHandler(name string, handler http.Handler) {
+	if _, ok := AdminHandlers[name]; ok {
+		panic(fmt.Sprintf("Admin handler for interceptor %q already exists", name))
+	}
+	AdminHandlers[name] = handler
+}
+
 // Add function registers a Middleware Creator
 func Add(name string, creator Creator) {
 	if _, ok := Interceptors[name]; ok {
@@ -56,6 +56,12 @@ func TestDuplicateKeys(t *testing.T) {
 	assert.Fail(t, "Should have panicked")
 }
 
+func TestAddAdminHandler(t *testing.T) {
+	AddAdminHandler("admin", http.NotFoundHandler())
+	assert.NotNil(t, AdminHandlers["admin"])
+	assert.Panics(t, func() { AddAdminHandler("admin", http.NotFoundHandler()) })
+}
+
 func TestDoesNotExist(t *testing.T) {
 	dne := Interceptors["DNE"]
 	assert.Nil(t, dne)
@@ -0,0 +1,115 @@
+#!/usr/bin/env bash
+#
+# Load tests the agent with the analytics interceptor disabled and enabled, and fails when the
+# interceptor adds more latency than the thresholds allow. Requires vegeta and jq, a built
+# bin/optimizely and an SDK key whose datafile the agent can fetch.
+#
+# Usage: SDK_KEY=<key> scripts/loadtest.sh
+#
+# Settings (environment variables):
+#   RATE                requests per second (default 500)
+#   DURATION            duration of each attack (default 30s)
+#   TARGET_PATH         API path attacked with GET requests (default /v1/config)
+#   MAX_ADDED_P50_MS    largest acceptable increase of the median latency (default 1)
+#   MAX_ADDED_P99_MS    largest acceptable increase of the 99th percentile latency (default 5)
+#   OUT_DIR             directory of the vegeta results and reports (default loadtest)
+
+set -euo pipefail
+
+: "${SDK_KEY:?SDK_KEY is required}"
+RATE=${RATE:-500}
+DURATION=${DURATION:-30s}
+TARGET_PATH=${TARGET_PATH:-/v1/config}
+MAX_ADDED_P50_MS=${MAX_ADDED_P50_MS:-1}
+MAX_ADDED_P99_MS=${MAX_ADDED_P99_MS:-5}
+OUT_DIR=${OUT_DIR:-loadtest}
+BINARY=bin/optimizely
+
+for tool in vegeta jq; do
+    if ! command -v "$tool" > /dev/null; then
+        echo "Error! $tool is required, see https://github.com/tsenart/vegeta and https://jqlang.github.io/jq/"
+        exit 1
+    fi
+done
+if [[ ! -x "$BINARY" ]]; then
+    echo "Error! $BINARY not found, run make build first"
+    exit 1
+fi
+
+mkdir -p "$OUT_DIR"
+
+# The enabled configuration tracks every request with the default settings, writing the
+# payloads to /dev/null so that no analytics backend is involved
+cat > "$OUT_DIR/analytics.yaml" <<CONFIG
+server:
+    interceptors:
+        analytics:
+            enabled: true
+            trackingID: "G-LOADTEST"
+            apiSecret: "loadtest"
+            dryRun: true
+            dryRunFile: /dev/null
+CONFIG
+echo "server: {}" > "$OUT_DIR/disabled.yaml"
+
+agent_pid=""
+stop_agent () {
+    if [[ -n "$agent_pid" ]]; then
+        kill "$agent_pid" 2> /dev/null || true
+        wait "$agent_pid" 2> /dev/null || true
+        agent_pid=""
+    fi
+}
+trap stop_agent EXIT
+
+# attack runs the load against the agent started with the configuration of $1
+attack () {
+    local mode=$1
+    OPTIMIZELY_CONFIG_FILENAME="$OUT_DIR/$mode.yaml" OPTIMIZELY_LOG_LEVEL=error "$BINARY" > "$OUT_DIR/$mode.log" 2>&1 &
+    agent_pid=$!
+    bash scripts/wait_for_agent_to_start.sh
+
+    # Warm up the SDK client and its datafile before measuring
+    curl --silent --fail -H "X-Optimizely-SDK-Key: $SDK_KEY" "localhost:8080$TARGET_PATH" > /dev/null
+
+    echo "Attacking with analytics $mode at $RATE req/s for $DURATION..."
+    echo "GET http://localhost:8080$TARGET_PATH" |
+        vegeta attack -header "X-Optimizely-SDK-Key: $SDK_KEY" -rate "$RATE" -duration "$DURATION" > "$OUT_DIR/$mode.bin"
+    vegeta report < "$OUT_DIR/$mode.bin"
+    vegeta report -type=json < "$OUT_DIR/$mode.bin" > "$OUT_DIR/$mode.json"
+    stop_agent
+}
+
+attack disabled
+attack analytics
+
+# latency_ms prints the latency percentile $2 of the report of mode $1 in milliseconds
+latency_ms () {
+    jq -r ".latencies[\"$2\"] / 1000000" "$OUT_DIR/$1.json"
+}
+
+failed=0
+for percentile in 50th 99th; do
+    disabled=$(latency_ms disabled "$percentile")
+    enabled=$(latency_ms analytics "$percentile")
+    added=$(jq -n "$enabled - $disabled")
+    if [[ $percentile == 50th ]]; then max=$MAX_ADDED_P50_MS; else max=$MAX_ADDED_P99_MS; fi
+    printf "%s percentile: %.3fms disabled, %.3fms enabled, %.3fms added (max %sms)\n" "$percentile" "$disabled" "$enabled" "$added" "$max"
+    if jq -e -n "$added > $max" > /dev/null; then
+        failed=1
+    fi
+done
+
+for mode in disabled analytics; do
+    success=$(jq -r '.success' "$OUT_DIR/$mode.json")
+    if jq -e -n "$success < 0.99" > /dev/null; then
+        echo "Error! Only $success of the requests with analytics $mode succeeded"
+        failed=1
+    fi
+done
+
+if (( failed )); then
+    echo "Analytics added more latency than allowed."
+    exit 1
+fi
+echo "Analytics overhead is within the thresholds."